@@ -1,16 +1,51 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"os"
 
 	"github.com/okazaki-kk/miniDB/internal/engine"
+	"github.com/okazaki-kk/miniDB/internal/format"
 	"github.com/okazaki-kk/miniDB/internal/repl"
 	"github.com/okazaki-kk/miniDB/storage"
 )
 
 func main() {
+	file := flag.String("f", "", "execute statements from `file` and exit")
+	outputFormat := flag.String("format", string(format.Aligned), "output format of query results: aligned, csv, json, ndjson or markdown")
+	sortMemory := flag.Int("sort-mem", engine.DefaultSortMemory>>10, "memory in `kB` a sort uses before it spills rows to temporary files")
+	flag.Parse()
+
+	f, err := format.Parse(*outputFormat)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
 	catalog := storage.NewCatalog()
 	engine := engine.New(*catalog)
-	r := repl.New(os.Stdin, os.Stdout, *catalog, *engine)
-	r.Start()
+	engine.SetSortMemory(*sortMemory << 10)
+
+	if *file == "" {
+		r := repl.New(os.Stdin, os.Stdout, *catalog, *engine)
+		r.SetFormat(f)
+		r.Start()
+		return
+	}
+
+	script, err := os.Open(*file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer script.Close()
+
+	r := repl.New(script, os.Stdout, *catalog, *engine)
+	r.SetFormat(f)
+	if err := r.Run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		script.Close()
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,691 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/okazaki-kk/miniDB/internal/parser/ast"
+	"github.com/okazaki-kk/miniDB/internal/parser/token"
+	"github.com/okazaki-kk/miniDB/internal/sql"
+	"github.com/okazaki-kk/miniDB/internal/sql/datatype"
+)
+
+// accumulator computes an aggregate over the arguments of the rows of a
+// group.
+type accumulator interface {
+	add(args []sql.Value) error
+	// merge adds the rows another accumulator of the aggregate was given,
+	// which follow its own rows. The other accumulator is left unchanged.
+	merge(other accumulator) error
+	result() (sql.Value, error)
+}
+
+// aggregateFunction is an aggregate function with the accumulators of its
+// groups.
+type aggregateFunction struct {
+	signatures []Signature
+	// user is set for aggregates registered with RegisterAggregate.
+	user bool
+	new  func() accumulator
+}
+
+// aggregates are the aggregate functions by their name. The built-in ones
+// skip NULLs, except for json_agg, which keeps them as JSON nulls, and,
+// except for count, return NULL for a group without values.
+var aggregates = map[string]*aggregateFunction{
+	"count": {
+		signatures: signatures(sql.Integer, anyArg),
+		new:        func() accumulator { return &countAccumulator{} },
+	},
+	"sum": {
+		signatures: []Signature{{Args: integerArg, Result: sql.Integer}, {Args: floatArg, Result: sql.Float}, {Args: decimalArg, Result: sql.Decimal}},
+		new:        func() accumulator { return &sumAccumulator{name: "sum"} },
+	},
+	"avg": {
+		signatures: []Signature{{Args: floatArg, Result: sql.Float}, {Args: decimalArg, Result: sql.Decimal}},
+		new:        func() accumulator { return &avgAccumulator{sumAccumulator{name: "avg"}} },
+	},
+	"min": {
+		signatures: signatures(sql.Null, anyArg),
+		new:        func() accumulator { return &extremumAccumulator{sign: -1} },
+	},
+	"max": {
+		signatures: signatures(sql.Null, anyArg),
+		new:        func() accumulator { return &extremumAccumulator{sign: 1} },
+	},
+	"json_agg": {
+		signatures: []Signature{{Args: anyArg, Result: sql.JSON}},
+		new:        func() accumulator { return &jsonAggAccumulator{} },
+	},
+}
+
+type countAccumulator struct {
+	n int64
+}
+
+func (a *countAccumulator) add(args []sql.Value) error {
+	if !sql.IsNull(args[0]) {
+		a.n++
+	}
+	return nil
+}
+
+func (a *countAccumulator) merge(other accumulator) error {
+	a.n += other.(*countAccumulator).n
+	return nil
+}
+
+func (a *countAccumulator) result() (sql.Value, error) {
+	return datatype.NewInteger(a.n), nil
+}
+
+// sumAccumulator sums integers as an integer, decimals exactly as a decimal
+// and any other numbers as a float.
+type sumAccumulator struct {
+	name  string
+	sum   sql.Value
+	count int64
+}
+
+func (a *sumAccumulator) add(args []sql.Value) error {
+	value := args[0]
+	if sql.IsNull(value) {
+		return nil
+	}
+
+	if _, ok := number(value); !ok {
+		return fmt.Errorf("function %s(%s) does not exist", a.name, value.DataType())
+	}
+
+	a.count++
+
+	return a.plus(value)
+}
+
+func (a *sumAccumulator) merge(other accumulator) error {
+	return a.mergeSum(other.(*sumAccumulator))
+}
+
+// mergeSum adds the sum and count of the other accumulator.
+func (a *sumAccumulator) mergeSum(other *sumAccumulator) error {
+	if other.sum == nil {
+		return nil
+	}
+
+	a.count += other.count
+
+	return a.plus(other.sum)
+}
+
+func (a *sumAccumulator) plus(value sql.Value) error {
+	if a.sum == nil {
+		a.sum = value
+		return nil
+	}
+
+	sum, err := arithmetic(token.PLUS, a.sum, value)
+	if err != nil {
+		return err
+	}
+	a.sum = sum
+
+	return nil
+}
+
+func (a *sumAccumulator) result() (sql.Value, error) {
+	if a.sum == nil {
+		return datatype.NewNull(), nil
+	}
+	return a.sum, nil
+}
+
+type avgAccumulator struct {
+	sumAccumulator
+}
+
+func (a *avgAccumulator) merge(other accumulator) error {
+	return a.mergeSum(&other.(*avgAccumulator).sumAccumulator)
+}
+
+func (a *avgAccumulator) result() (sql.Value, error) {
+	if a.sum == nil {
+		return datatype.NewNull(), nil
+	}
+
+	if sum, ok := a.sum.(datatype.Decimal); ok {
+		return sum.Quo(datatype.DecimalFromInt(a.count))
+	}
+
+	sum, _ := number(a.sum)
+	return datatype.NewFloat(sum / float64(a.count)), nil
+}
+
+// extremumAccumulator keeps the smallest value for a negative sign and the
+// largest one for a positive sign.
+type extremumAccumulator struct {
+	sign  int
+	value sql.Value
+}
+
+func (a *extremumAccumulator) add(args []sql.Value) error {
+	value := args[0]
+	if sql.IsNull(value) {
+		return nil
+	}
+
+	if a.value == nil {
+		a.value = value
+		return nil
+	}
+
+	c, err := value.Compare(a.value)
+	if err != nil {
+		return err
+	}
+
+	if c*a.sign > 0 {
+		a.value = value
+	}
+
+	return nil
+}
+
+func (a *extremumAccumulator) merge(other accumulator) error {
+	if value := other.(*extremumAccumulator).value; value != nil {
+		return a.add([]sql.Value{value})
+	}
+	return nil
+}
+
+func (a *extremumAccumulator) result() (sql.Value, error) {
+	if a.value == nil {
+		return datatype.NewNull(), nil
+	}
+	return a.value, nil
+}
+
+// jsonAggAccumulator collects the values of the group into a JSON array.
+type jsonAggAccumulator struct {
+	elements []datatype.JSON
+}
+
+func (a *jsonAggAccumulator) add(args []sql.Value) error {
+	a.elements = append(a.elements, datatype.ToJSON(args[0]))
+	return nil
+}
+
+func (a *jsonAggAccumulator) merge(other accumulator) error {
+	a.elements = append(a.elements, other.(*jsonAggAccumulator).elements...)
+	return nil
+}
+
+func (a *jsonAggAccumulator) result() (sql.Value, error) {
+	if a.elements == nil {
+		return datatype.NewNull(), nil
+	}
+	return datatype.NewJSONArray(a.elements), nil
+}
+
+// distinctAccumulator passes the arguments of every distinct row once to the
+// accumulator.
+type distinctAccumulator struct {
+	accumulator
+	seen map[uint64][]sql.Row
+	// order are the distinct arguments in the order they were added.
+	order []sql.Row
+}
+
+func (a *distinctAccumulator) add(args []sql.Value) error {
+	for _, value := range args {
+		if sql.IsNull(value) {
+			return nil
+		}
+	}
+
+	row := sql.Row(args)
+	h := row.Hash()
+	for _, r := range a.seen[h] {
+		if r.Equal(row) {
+			return nil
+		}
+	}
+
+	row = append(sql.Row(nil), args...)
+	a.seen[h] = append(a.seen[h], row)
+	a.order = append(a.order, row)
+
+	return a.accumulator.add(row)
+}
+
+func (a *distinctAccumulator) merge(other accumulator) error {
+	for _, row := range other.(*distinctAccumulator).order {
+		if err := a.add(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newAccumulator returns the accumulator of the aggregate call.
+func (r *registry) newAccumulator(call *ast.CallExpr) (accumulator, error) {
+	name := strings.ToLower(call.Name)
+
+	agg, ok := r.lookupAggregate(name)
+	if !ok {
+		return nil, fmt.Errorf("function %s is not an aggregate", name)
+	}
+
+	if !agg.user && len(call.Args) != 1 {
+		return nil, fmt.Errorf("function %s() takes exactly one argument", name)
+	}
+
+	for _, arg := range call.Args {
+		if _, ok := arg.(*ast.AsteriskExpr); ok && (name != "count" || call.Distinct) {
+			return nil, fmt.Errorf("%s(*) is not valid", name)
+		}
+	}
+
+	a := agg.new()
+	if call.Distinct {
+		a = &distinctAccumulator{accumulator: a, seen: make(map[uint64][]sql.Row)}
+	}
+
+	return a, nil
+}
+
+// aggregateArgs returns the values of the arguments of the aggregate call,
+// which count(*) has none of and counts as 1.
+func aggregateArgs(call *ast.CallExpr, value func(ast.Expression) (sql.Value, error)) ([]sql.Value, error) {
+	args := make([]sql.Value, len(call.Args))
+	for i, arg := range call.Args {
+		if _, ok := arg.(*ast.AsteriskExpr); ok {
+			args[i] = datatype.NewInteger(1)
+			continue
+		}
+
+		var err error
+		if args[i], err = value(arg); err != nil {
+			return nil, err
+		}
+	}
+
+	return args, nil
+}
+
+// collectAggregates appends the aggregate calls of the expression. Aggregates
+// can't be nested.
+func (r *registry) collectAggregates(expr ast.Expression, calls []*ast.CallExpr) ([]*ast.CallExpr, error) {
+	switch expr := expr.(type) {
+	case *ast.UnaryExpr:
+		return r.collectAggregates(expr.Operand, calls)
+	case *ast.ConditionExpr:
+		calls, err := r.collectAggregates(expr.Left, calls)
+		if err != nil {
+			return nil, err
+		}
+		return r.collectAggregates(expr.Right, calls)
+	case *ast.IsNullExpr:
+		return r.collectAggregates(expr.Expr, calls)
+	case *ast.InExpr, *ast.BetweenExpr, *ast.LikeExpr, *ast.CaseExpr, *ast.CastExpr, *ast.AtTimeZoneExpr:
+		var err error
+		for _, e := range operands(expr) {
+			if calls, err = r.collectAggregates(e, calls); err != nil {
+				return nil, err
+			}
+		}
+	case *ast.WindowExpr:
+		// The window function itself is no aggregate, even when it is an
+		// aggregate over the window.
+		var err error
+		for _, e := range windowExprs(expr) {
+			if calls, err = r.collectAggregates(e, calls); err != nil {
+				return nil, err
+			}
+		}
+	case *ast.IsDistinctExpr:
+		calls, err := r.collectAggregates(expr.Left, calls)
+		if err != nil {
+			return nil, err
+		}
+		return r.collectAggregates(expr.Right, calls)
+	case *ast.CallExpr:
+		if r.isAggregate(expr) {
+			for _, arg := range expr.Args {
+				nested, err := r.collectAggregates(arg, nil)
+				if err != nil {
+					return nil, err
+				}
+				if len(nested) > 0 {
+					return nil, fmt.Errorf("aggregate function calls cannot be nested")
+				}
+			}
+
+			return append(calls, expr), nil
+		}
+
+		var err error
+		for _, arg := range expr.Args {
+			if calls, err = r.collectAggregates(arg, calls); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return calls, nil
+}
+
+// noAggregates fails when the expression of the clause has an aggregate.
+func (r *registry) noAggregates(clause string, expr ast.Expression) error {
+	calls, err := r.collectAggregates(expr, nil)
+	if err != nil {
+		return err
+	}
+
+	if len(calls) > 0 {
+		return fmt.Errorf("aggregate functions are not allowed in %s", clause)
+	}
+
+	return nil
+}
+
+// group is a group of rows with equal keys. The first row of the group
+// represents the group.
+type group struct {
+	key          sql.Row
+	row          sql.Row
+	accumulators []accumulator
+}
+
+// hashAggregate groups rows by the values of the keys in a hash table and
+// computes the aggregates of every group.
+type hashAggregate struct {
+	keys     []ast.Expression
+	calls    []*ast.CallExpr
+	registry *registry
+
+	groups map[uint64][]*group
+	order  []*group
+}
+
+func newHashAggregate(keys []ast.Expression, calls []*ast.CallExpr, r *registry) (*hashAggregate, error) {
+	// Invalid calls fail even when there are no groups.
+	for _, call := range calls {
+		if _, err := r.newAccumulator(call); err != nil {
+			return nil, err
+		}
+	}
+
+	return &hashAggregate{keys: keys, calls: calls, registry: r, groups: make(map[uint64][]*group)}, nil
+}
+
+// add adds the row of the scope to its group.
+func (h *hashAggregate) add(s *scope) error {
+	key := make(sql.Row, len(h.keys))
+	for i, expr := range h.keys {
+		value, err := eval(expr, s)
+		if err != nil {
+			return err
+		}
+		key[i] = value
+	}
+
+	g, err := h.group(key, s.row)
+	if err != nil {
+		return err
+	}
+
+	for i, call := range h.calls {
+		args, err := aggregateArgs(call, func(expr ast.Expression) (sql.Value, error) {
+			return eval(expr, s)
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := g.accumulators[i].add(args); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// group returns the group of the key, which is created for the row when
+// there is none yet.
+func (h *hashAggregate) group(key, row sql.Row) (*group, error) {
+	hash := key.Hash()
+
+	for _, g := range h.groups[hash] {
+		if g.key.Equal(key) {
+			return g, nil
+		}
+	}
+
+	g := &group{key: key, row: row, accumulators: make([]accumulator, len(h.calls))}
+	for i, call := range h.calls {
+		a, err := h.registry.newAccumulator(call)
+		if err != nil {
+			return nil, err
+		}
+		g.accumulators[i] = a
+	}
+
+	h.groups[hash] = append(h.groups[hash], g)
+	h.order = append(h.order, g)
+
+	return g, nil
+}
+
+// rows returns a row per group in the order the groups were first seen: the
+// row representing the group followed by the results of the aggregates.
+func (h *hashAggregate) rows() ([]sql.Row, error) {
+	rows := make([]sql.Row, 0, len(h.order))
+
+	for _, g := range h.order {
+		row := make(sql.Row, 0, len(g.row)+len(g.accumulators))
+		row = append(row, g.row...)
+
+		for _, a := range g.accumulators {
+			value, err := a.result()
+			if err != nil {
+				return nil, err
+			}
+			row = append(row, value)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// aggregate groups the rows by the GROUP BY keys and filters the groups by
+// the HAVING condition. The aggregates of the scope are bound to the values
+// the returned rows have after the columns. Without GROUP BY all rows form a
+// single group, even when there are none.
+func aggregate(rows []sql.Row, s *scope, keys []ast.Expression, having *ast.HavingStatement, calls []*ast.CallExpr) ([]sql.Row, error) {
+	h, err := newHashAggregate(keys, calls, s.functions())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		s.row = row
+
+		if err := h.add(s); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(keys) == 0 && len(rows) == 0 {
+		if _, err := h.group(sql.Row{}, nullRow(len(s.columns))); err != nil {
+			return nil, err
+		}
+	}
+
+	s.aggregates = make(map[*ast.CallExpr]int, len(calls))
+	for i, call := range calls {
+		s.aggregates[call] = len(s.columns) + i
+	}
+
+	grouped, err := h.rows()
+	if err != nil {
+		return nil, err
+	}
+
+	if having == nil {
+		return grouped, nil
+	}
+
+	filtered := grouped[:0]
+	for _, row := range grouped {
+		s.row = row
+
+		ok, err := matches(having.Expr, s)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			filtered = append(filtered, row)
+		}
+	}
+
+	return filtered, nil
+}
+
+// groupKeys resolves the GROUP BY expressions, replacing the positions of
+// result columns by their expressions.
+func groupKeys(stmt *ast.SelectStatement, s *scope) ([]ast.Expression, error) {
+	keys := make([]ast.Expression, 0, len(stmt.GroupBy.Exprs))
+
+	for _, expr := range stmt.GroupBy.Exprs {
+		if scalar, ok := expr.(*ast.ScalarExpr); ok {
+			value, err := literal(scalar)
+			if err != nil {
+				return nil, err
+			}
+
+			position, ok := value.Raw().(int64)
+			if !ok {
+				return nil, fmt.Errorf("non-integer constant in GROUP BY")
+			}
+			if position < 1 || position > int64(len(stmt.Result)) {
+				return nil, fmt.Errorf("GROUP BY position %d is not in select list", position)
+			}
+
+			expr = stmt.Result[position-1].Expr
+			if _, ok := expr.(*ast.AsteriskExpr); ok {
+				return nil, fmt.Errorf("GROUP BY position %d is not in select list", position)
+			}
+		}
+
+		if err := s.functions().noAggregates("GROUP BY", expr); err != nil {
+			return nil, err
+		}
+
+		if err := noWindows("GROUP BY", expr); err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, expr)
+	}
+
+	return keys, nil
+}
+
+// checkGrouped verifies the expression only references columns of the
+// GROUP BY keys outside of aggregates, so that it has a single value for
+// every group.
+func checkGrouped(expr ast.Expression, keys []ast.Expression, s *scope) error {
+	for _, key := range keys {
+		if key.String() == expr.String() {
+			return nil
+		}
+	}
+
+	switch expr := expr.(type) {
+	case *ast.IdentExpr:
+		i, err := s.find(expr)
+		if err != nil {
+			return err
+		}
+		if i < 0 {
+			// A column of an outer query is constant within the groups.
+			_, _, err := s.resolve(expr)
+			return err
+		}
+
+		// The key may reference the column by another name, like qualified.
+		for _, key := range keys {
+			if ident, ok := key.(*ast.IdentExpr); ok {
+				if j, err := s.index(ident); err == nil && i == j {
+					return nil
+				}
+			}
+		}
+
+		name := s.columns[i].name
+		if s.columns[i].table != "" {
+			name = s.columns[i].table + "." + name
+		}
+
+		return fmt.Errorf("column %q must appear in the GROUP BY clause or be used in an aggregate function", name)
+	case *ast.AsteriskExpr:
+		indexes, err := s.expand(expr)
+		if err != nil {
+			return err
+		}
+
+		for _, i := range indexes {
+			if err := checkGrouped(&ast.IdentExpr{Table: s.columns[i].table, Name: s.columns[i].name}, keys, s); err != nil {
+				return err
+			}
+		}
+	case *ast.UnaryExpr:
+		return checkGrouped(expr.Operand, keys, s)
+	case *ast.ConditionExpr:
+		if err := checkGrouped(expr.Left, keys, s); err != nil {
+			return err
+		}
+		return checkGrouped(expr.Right, keys, s)
+	case *ast.IsNullExpr:
+		return checkGrouped(expr.Expr, keys, s)
+	case *ast.InExpr, *ast.BetweenExpr, *ast.LikeExpr, *ast.CaseExpr, *ast.CastExpr, *ast.AtTimeZoneExpr:
+		for _, e := range operands(expr) {
+			if err := checkGrouped(e, keys, s); err != nil {
+				return err
+			}
+		}
+	case *ast.WindowExpr:
+		for _, e := range windowExprs(expr) {
+			if err := checkGrouped(e, keys, s); err != nil {
+				return err
+			}
+		}
+	case *ast.IsDistinctExpr:
+		if err := checkGrouped(expr.Left, keys, s); err != nil {
+			return err
+		}
+		return checkGrouped(expr.Right, keys, s)
+	case *ast.CallExpr:
+		if s.functions().isAggregate(expr) {
+			return nil
+		}
+
+		for _, arg := range expr.Args {
+			if err := checkGrouped(arg, keys, s); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func nullRow(n int) sql.Row {
+	row := make(sql.Row, n)
+	for i := range row {
+		row[i] = datatype.NewNull()
+	}
+	return row
+}
@@ -0,0 +1,605 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/okazaki-kk/miniDB/internal/parser/ast"
+	"github.com/okazaki-kk/miniDB/internal/sql"
+	"github.com/okazaki-kk/miniDB/storage"
+)
+
+// rowRewrite upgrades a row stored with the previous scheme.
+type rowRewrite func(sql.Row) (sql.Row, error)
+
+// alteration collects the changes of an ALTER TABLE statement, so that they
+// are applied to the table at once.
+type alteration struct {
+	db          storage.Database
+	table       string
+	columns     []storage.Column
+	constraints []storage.Constraint
+	indexes     []*storage.Index
+	// definitions are the added constraints, defined once the columns are
+	// final.
+	definitions []ast.Constraint
+	rewrites    []rowRewrite
+	rename      string
+	// renamed maps the renamed columns to their new name.
+	renamed map[string]string
+	// registry has the functions of the defaults, constraints and indexes.
+	registry *registry
+}
+
+// AlterTable changes the scheme of the table in the current database and
+// rewrites its rows. Either all actions are applied or none of them.
+func (e *Engine) AlterTable(name string, actions []ast.AlterTableAction) (string, error) {
+	db, err := e.currentDatabase()
+	if err != nil {
+		return "", err
+	}
+
+	table, err := db.GetTable(name)
+	if err != nil {
+		return "", err
+	}
+
+	a := alteration{
+		db:          db,
+		table:       name,
+		columns:     table.Scheme().Columns(),
+		constraints: table.Constraints(),
+		indexes:     table.Indexes(),
+		renamed:     make(map[string]string),
+		registry:    e.registry,
+	}
+
+	for _, action := range actions {
+		if err := a.apply(action); err != nil {
+			return "", err
+		}
+	}
+
+	if a.rename != "" && a.rename != name {
+		if _, err := db.GetTable(a.rename); err == nil {
+			return "", fmt.Errorf("table %q already exist", a.rename)
+		}
+	}
+
+	scheme := make(storage.Scheme, len(a.columns))
+	for i, column := range a.columns {
+		column.Position = uint8(i)
+		a.columns[i].Position = column.Position
+		scheme[column.Name] = column
+	}
+
+	r := relation{name: name, columns: a.columns, constraints: a.renameConstraints(a.constraints), registry: a.registry}
+
+	if r.constraints, err = defineConstraints(db, r, a.definitions); err != nil {
+		return "", err
+	}
+
+	if len(a.definitions) > 0 {
+		// The added constraints are verified on the rewritten rows before
+		// any of them is stored.
+		_, rows := table.Snapshot()
+		for i := range rows {
+			if rows[i], err = a.rewrite(rows[i]); err != nil {
+				return "", err
+			}
+		}
+
+		if err := r.validate(db, rows); err != nil {
+			return "", err
+		}
+	}
+
+	indexes, err := a.renameIndexes(name)
+	if err != nil {
+		return "", err
+	}
+
+	references := make(map[*storage.Table][]storage.Constraint)
+	for _, fk := range referencing(db, name) {
+		references[fk.table] = a.followReferences(name, fk.table.Constraints())
+	}
+
+	if err := db.AlterTable(name, storage.Alteration{
+		Name:        a.rename,
+		Scheme:      scheme,
+		Rewrite:     a.rewrite,
+		Indexes:     indexes,
+		Constraints: a.renameReferences(r.constraints),
+		References:  references,
+	}); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("alter table %s\n", name), nil
+}
+
+// rewrite upgrades a row stored with the previous scheme.
+func (a *alteration) rewrite(row sql.Row) (sql.Row, error) {
+	var err error
+	for _, rewrite := range a.rewrites {
+		if row, err = rewrite(row); err != nil {
+			return nil, err
+		}
+	}
+	return row, nil
+}
+
+func (a *alteration) apply(action ast.AlterTableAction) error {
+	switch action := action.(type) {
+	case *ast.AddColumnAction:
+		return a.addColumn(action.Column)
+	case *ast.DropColumnAction:
+		return a.dropColumn(action.Column)
+	case *ast.RenameColumnAction:
+		return a.renameColumn(action.Column, action.NewName)
+	case *ast.AddConstraintAction:
+		a.definitions = append(a.definitions, action.Constraint)
+	case *ast.DropConstraintAction:
+		for _, c := range a.constraints {
+			if c.Name == action.Name {
+				a.constraints = withoutConstraint(a.constraints, action.Name)
+				return nil
+			}
+		}
+
+		return fmt.Errorf("constraint %q of relation %q does not exist", action.Name, a.table)
+	case *ast.RenameTableAction:
+		a.rename = action.NewName
+	case *ast.SetNotNullAction:
+		return a.setNotNull(action.Column, action.NotNull)
+	case *ast.AlterColumnTypeAction:
+		return a.alterColumnType(action)
+	default:
+		return fmt.Errorf("unsupported ALTER TABLE action %T", action)
+	}
+
+	return nil
+}
+
+func (a *alteration) addColumn(definition ast.Column) error {
+	if _, err := a.index(definition.Name); err == nil {
+		return fmt.Errorf("column %q of relation %q already exists", definition.Name, a.table)
+	}
+
+	if definition.PrimaryKey {
+		return fmt.Errorf("multiple primary keys are not allowed")
+	}
+
+	column, err := storage.NewColumn(uint8(len(a.columns)), definition)
+	if err != nil {
+		return err
+	}
+
+	if err := checkDefault(column, a.registry); err != nil {
+		return err
+	}
+
+	value, err := defaultValue(column, a.registry)
+	if err != nil {
+		return err
+	}
+
+	if sql.IsNull(value) && !column.Nullable {
+		a.rewrites = append(a.rewrites, func(row sql.Row) (sql.Row, error) {
+			return nil, fmt.Errorf("column %q of relation %q contains null values", column.Name, a.table)
+		})
+	}
+
+	a.columns = append(a.columns, column)
+	a.definitions = append(a.definitions, definition.Constraints...)
+	a.rewrites = append(a.rewrites, func(row sql.Row) (sql.Row, error) {
+		upgraded := make(sql.Row, len(row), len(row)+1)
+		copy(upgraded, row)
+		return append(upgraded, value), nil
+	})
+
+	return nil
+}
+
+func (a *alteration) dropColumn(name string) error {
+	i, err := a.index(name)
+	if err != nil {
+		return err
+	}
+
+	if a.columns[i].PrimaryKey {
+		return fmt.Errorf("cannot drop primary key column %q", name)
+	}
+
+	if a.depends(name) {
+		return fmt.Errorf("cannot drop column %q of table %q because other objects depend on it", name, a.table)
+	}
+
+	a.columns = append(a.columns[:i:i], a.columns[i+1:]...)
+	a.rewrites = append(a.rewrites, func(row sql.Row) (sql.Row, error) {
+		upgraded := make(sql.Row, 0, len(row)-1)
+		upgraded = append(upgraded, row[:i]...)
+		return append(upgraded, row[i+1:]...), nil
+	})
+
+	return nil
+}
+
+func (a *alteration) setNotNull(name string, notNull bool) error {
+	i, err := a.index(name)
+	if err != nil {
+		return err
+	}
+
+	if !notNull {
+		if a.columns[i].PrimaryKey {
+			return fmt.Errorf("column %q is in a primary key", name)
+		}
+
+		a.columns[i].Nullable = true
+		return nil
+	}
+
+	a.columns[i].Nullable = false
+	a.rewrites = append(a.rewrites, func(row sql.Row) (sql.Row, error) {
+		if sql.IsNull(row[i]) {
+			return nil, fmt.Errorf("column %q of relation %q contains null values", name, a.table)
+		}
+		return row, nil
+	})
+
+	return nil
+}
+
+func (a *alteration) alterColumnType(action *ast.AlterColumnTypeAction) error {
+	i, err := a.index(action.Column)
+	if err != nil {
+		return err
+	}
+
+	column, err := storage.NewColumn(a.columns[i].Position, ast.Column{
+		Name:       a.columns[i].Name,
+		Type:       action.Type,
+		Default:    a.columns[i].Default,
+		Nullable:   a.columns[i].Nullable,
+		PrimaryKey: a.columns[i].PrimaryKey,
+		Precision:  action.Precision,
+		Scale:      action.Scale,
+	})
+	if err != nil {
+		return err
+	}
+
+	if a.columns[i].TypeName() == column.TypeName() {
+		return nil
+	}
+
+	if a.columns[i].PrimaryKey {
+		return fmt.Errorf("cannot change the type of primary key column %q", action.Column)
+	}
+
+	if a.inForeignKey(action.Column) {
+		return fmt.Errorf("cannot change the type of column %q used in a foreign key constraint", action.Column)
+	}
+
+	if err := checkDefault(column, a.registry); err != nil {
+		return err
+	}
+
+	a.columns[i] = column
+	a.rewrites = append(a.rewrites, func(row sql.Row) (sql.Row, error) {
+		value, err := column.Convert(row[i])
+		if err != nil {
+			return nil, fmt.Errorf("column %q cannot be cast to type %s: %w", action.Column, column.TypeName(), err)
+		}
+
+		upgraded := make(sql.Row, len(row))
+		copy(upgraded, row)
+		upgraded[i] = value
+
+		return upgraded, nil
+	})
+
+	return nil
+}
+
+func (a *alteration) renameColumn(name, newName string) error {
+	i, err := a.index(name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := a.index(newName); err == nil {
+		return fmt.Errorf("column %q of relation %q already exists", newName, a.table)
+	}
+
+	a.columns[i].Name = newName
+
+	for original, current := range a.renamed {
+		if current == name {
+			name = original
+			break
+		}
+	}
+	a.renamed[name] = newName
+
+	return nil
+}
+
+// depends reports whether a constraint or an index of the table or a foreign
+// key of another table uses the column.
+func (a *alteration) depends(name string) bool {
+	name = a.original(name)
+
+	for _, c := range a.constraints {
+		if contains(c.Columns, name) || (c.Check != nil && usesColumn(c.Check, name)) {
+			return true
+		}
+	}
+
+	for _, index := range a.indexes {
+		if usesColumn(index.Expr, name) {
+			return true
+		}
+	}
+
+	return a.referenced(name)
+}
+
+// inForeignKey reports whether the column references or is referenced by a
+// foreign key.
+func (a *alteration) inForeignKey(name string) bool {
+	name = a.original(name)
+
+	for _, c := range a.constraints {
+		if c.Type == storage.ForeignKey && contains(c.Columns, name) {
+			return true
+		}
+	}
+
+	return a.referenced(name)
+}
+
+// referenced reports whether a foreign key references the column, given by
+// its original name.
+func (a *alteration) referenced(name string) bool {
+	for _, fk := range referencing(a.db, a.table) {
+		if contains(fk.constraint.Reference.Columns, name) {
+			return true
+		}
+	}
+
+	for _, c := range a.constraints {
+		if c.Type == storage.ForeignKey && c.Reference.Table == a.table && contains(c.Reference.Columns, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// original returns the name of the column before it was renamed.
+func (a *alteration) original(name string) string {
+	for original, current := range a.renamed {
+		if current == name {
+			return original
+		}
+	}
+
+	return name
+}
+
+// renameConstraints returns the constraints of the table with the renamed
+// columns, including the ones it references itself.
+func (a *alteration) renameConstraints(constraints []storage.Constraint) []storage.Constraint {
+	renamed := make([]storage.Constraint, 0, len(constraints))
+
+	for _, c := range constraints {
+		c.Columns = a.renameColumns(c.Columns)
+
+		if c.Check != nil {
+			c.Check = renameIdents(c.Check, a.renamed)
+		}
+
+		if c.Type == storage.ForeignKey && c.Reference.Table == a.table {
+			reference := *c.Reference
+			reference.Columns = a.renameColumns(reference.Columns)
+			c.Reference = &reference
+		}
+
+		renamed = append(renamed, c)
+	}
+
+	return renamed
+}
+
+// renameIndexes returns the indexes of the table with the renamed columns,
+// evaluated with the altered columns.
+func (a *alteration) renameIndexes(table string) ([]*storage.Index, error) {
+	indexes := make([]*storage.Index, 0, len(a.indexes))
+
+	for _, index := range a.indexes {
+		renamed, err := newIndex(table, a.columns, index.Name, renameIdents(index.Expr, a.renamed), a.registry)
+		if err != nil {
+			return nil, err
+		}
+
+		indexes = append(indexes, renamed)
+	}
+
+	return indexes, nil
+}
+
+// followReferences returns the constraints of another table with the foreign
+// keys referencing the table following the renames of its columns and itself.
+func (a *alteration) followReferences(table string, constraints []storage.Constraint) []storage.Constraint {
+	followed := make([]storage.Constraint, 0, len(constraints))
+
+	for _, c := range constraints {
+		if c.Type == storage.ForeignKey && c.Reference.Table == table {
+			reference := *c.Reference
+			reference.Columns = a.renameColumns(reference.Columns)
+			if a.rename != "" {
+				reference.Table = a.rename
+			}
+			c.Reference = &reference
+		}
+
+		followed = append(followed, c)
+	}
+
+	return followed
+}
+
+// renameReferences returns the constraints of the table with the foreign keys
+// referencing the table itself following its rename.
+func (a *alteration) renameReferences(constraints []storage.Constraint) []storage.Constraint {
+	if a.rename == "" {
+		return constraints
+	}
+
+	for i, c := range constraints {
+		if c.Type == storage.ForeignKey && c.Reference.Table == a.table {
+			reference := *c.Reference
+			reference.Table = a.rename
+			constraints[i].Reference = &reference
+		}
+	}
+
+	return constraints
+}
+
+func (a *alteration) renameColumns(columns []string) []string {
+	renamed := make([]string, len(columns))
+
+	for i, name := range columns {
+		renamed[i] = name
+		if newName, ok := a.renamed[name]; ok {
+			renamed[i] = newName
+		}
+	}
+
+	return renamed
+}
+
+// renameIdents returns a copy of the expression with the columns renamed.
+func renameIdents(expr ast.Expression, renamed map[string]string) ast.Expression {
+	return mapIdents(expr, func(ident *ast.IdentExpr) *ast.IdentExpr {
+		if newName, ok := renamed[ident.Name]; ok {
+			return &ast.IdentExpr{Table: ident.Table, Name: newName}
+		}
+		return ident
+	})
+}
+
+// mapIdents returns a copy of the expression with the columns replaced by
+// the function.
+func mapIdents(expr ast.Expression, fn func(*ast.IdentExpr) *ast.IdentExpr) ast.Expression {
+	switch expr := expr.(type) {
+	case *ast.IdentExpr:
+		return fn(expr)
+	case *ast.UnaryExpr:
+		return &ast.UnaryExpr{Operator: expr.Operator, Operand: mapIdents(expr.Operand, fn)}
+	case *ast.ConditionExpr:
+		return &ast.ConditionExpr{
+			Left:     mapIdents(expr.Left, fn),
+			Operator: expr.Operator,
+			Right:    mapIdents(expr.Right, fn),
+		}
+	case *ast.IsNullExpr:
+		return &ast.IsNullExpr{Expr: mapIdents(expr.Expr, fn), Not: expr.Not}
+	case *ast.IsDistinctExpr:
+		return &ast.IsDistinctExpr{Left: mapIdents(expr.Left, fn), Right: mapIdents(expr.Right, fn), Not: expr.Not}
+	case *ast.CallExpr:
+		args := make([]ast.Expression, len(expr.Args))
+		for i, arg := range expr.Args {
+			args[i] = mapIdents(arg, fn)
+		}
+		return &ast.CallExpr{Name: expr.Name, Args: args, Distinct: expr.Distinct}
+	case *ast.InExpr:
+		if expr.Select != nil {
+			return expr
+		}
+		list := make([]ast.Expression, len(expr.List))
+		for i, value := range expr.List {
+			list[i] = mapIdents(value, fn)
+		}
+		return &ast.InExpr{Expr: mapIdents(expr.Expr, fn), Not: expr.Not, List: list}
+	case *ast.BetweenExpr:
+		return &ast.BetweenExpr{
+			Expr: mapIdents(expr.Expr, fn),
+			Not:  expr.Not,
+			Low:  mapIdents(expr.Low, fn),
+			High: mapIdents(expr.High, fn),
+		}
+	case *ast.LikeExpr:
+		like := *expr
+		like.Expr = mapIdents(expr.Expr, fn)
+		like.Pattern = mapIdents(expr.Pattern, fn)
+		if expr.Escape != nil {
+			like.Escape = mapIdents(expr.Escape, fn)
+		}
+		return &like
+	case *ast.CaseExpr:
+		c := ast.CaseExpr{Whens: make([]ast.WhenClause, len(expr.Whens))}
+		if expr.Operand != nil {
+			c.Operand = mapIdents(expr.Operand, fn)
+		}
+		for i, when := range expr.Whens {
+			c.Whens[i] = ast.WhenClause{Cond: mapIdents(when.Cond, fn), Result: mapIdents(when.Result, fn)}
+		}
+		if expr.Else != nil {
+			c.Else = mapIdents(expr.Else, fn)
+		}
+		return &c
+	case *ast.CastExpr:
+		return &ast.CastExpr{Expr: mapIdents(expr.Expr, fn), Type: expr.Type, Precision: expr.Precision, Scale: expr.Scale}
+	case *ast.AtTimeZoneExpr:
+		return &ast.AtTimeZoneExpr{Expr: mapIdents(expr.Expr, fn), Zone: mapIdents(expr.Zone, fn)}
+	}
+
+	return expr
+}
+
+// usesColumn reports whether the expression references the column.
+func usesColumn(expr ast.Expression, name string) bool {
+	switch expr := expr.(type) {
+	case *ast.IdentExpr:
+		return expr.Name == name
+	case *ast.UnaryExpr:
+		return usesColumn(expr.Operand, name)
+	case *ast.ConditionExpr:
+		return usesColumn(expr.Left, name) || usesColumn(expr.Right, name)
+	case *ast.IsNullExpr:
+		return usesColumn(expr.Expr, name)
+	case *ast.IsDistinctExpr:
+		return usesColumn(expr.Left, name) || usesColumn(expr.Right, name)
+	case *ast.CallExpr:
+		for _, arg := range expr.Args {
+			if usesColumn(arg, name) {
+				return true
+			}
+		}
+	case *ast.InExpr, *ast.BetweenExpr, *ast.LikeExpr, *ast.CaseExpr, *ast.CastExpr, *ast.AtTimeZoneExpr:
+		for _, e := range operands(expr) {
+			if usesColumn(e, name) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (a *alteration) index(name string) (int, error) {
+	return columnIndex(a.table, a.columns, name)
+}
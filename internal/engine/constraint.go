@@ -0,0 +1,472 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/okazaki-kk/miniDB/internal/parser/ast"
+	"github.com/okazaki-kk/miniDB/internal/sql"
+	"github.com/okazaki-kk/miniDB/storage"
+)
+
+const notNullConstraint = "not-null"
+
+// ConstraintError is returned when a statement would store a row violating
+// a constraint of the table.
+type ConstraintError struct {
+	Table string
+	// Column is set for violations of a NOT NULL constraint.
+	Column string
+	// Constraint names the violated constraint, "not-null" for NOT NULL.
+	Constraint string
+
+	message string
+}
+
+func (e *ConstraintError) Error() string {
+	return e.message
+}
+
+func notNullViolation(table, column string) error {
+	return &ConstraintError{
+		Table:      table,
+		Column:     column,
+		Constraint: notNullConstraint,
+		message:    fmt.Sprintf("null value in column %q of relation %q violates not-null constraint", column, table),
+	}
+}
+
+func uniqueViolation(table, constraint string) error {
+	return &ConstraintError{
+		Table:      table,
+		Constraint: constraint,
+		message:    fmt.Sprintf("duplicate key value violates unique constraint %q", constraint),
+	}
+}
+
+func checkViolation(table, constraint string) error {
+	return &ConstraintError{
+		Table:      table,
+		Constraint: constraint,
+		message:    fmt.Sprintf("new row for relation %q violates check constraint %q", table, constraint),
+	}
+}
+
+func foreignKeyViolation(table, constraint string) error {
+	return &ConstraintError{
+		Table:      table,
+		Constraint: constraint,
+		message:    fmt.Sprintf("insert or update on table %q violates foreign key constraint %q", table, constraint),
+	}
+}
+
+func referencedViolation(table, constraint, referencing string) error {
+	return &ConstraintError{
+		Table:      table,
+		Constraint: constraint,
+		message: fmt.Sprintf(
+			"update or delete on table %q violates foreign key constraint %q on table %q",
+			table, constraint, referencing,
+		),
+	}
+}
+
+// relation is the scheme and constraints of a table, used to verify rows
+// before they are stored.
+type relation struct {
+	name        string
+	columns     []storage.Column
+	constraints []storage.Constraint
+	// registry has the functions the CHECK constraints call.
+	registry *registry
+}
+
+func relationOf(table *storage.Table, r *registry) relation {
+	return relation{
+		name:        table.Name(),
+		columns:     table.Scheme().Columns(),
+		constraints: table.Constraints(),
+		registry:    r,
+	}
+}
+
+// uniqueKeys returns the primary key and the UNIQUE constraints.
+func (r relation) uniqueKeys() []storage.Constraint {
+	var keys []storage.Constraint
+
+	if pk, ok := primaryKeyIndex(r.columns); ok {
+		keys = append(keys, storage.Constraint{
+			Name:    r.name + "_pkey",
+			Type:    storage.Unique,
+			Columns: []string{r.columns[pk].Name},
+		})
+	}
+
+	for _, c := range r.constraints {
+		if c.Type == storage.Unique {
+			keys = append(keys, c)
+		}
+	}
+
+	return keys
+}
+
+// referencedFunc reports whether a row of the table has the values in the
+// columns, which are a key of the table.
+type referencedFunc func(table string, columns []string, values []sql.Value) (bool, error)
+
+// checkRow verifies the NOT NULL, CHECK and FOREIGN KEY constraints on the
+// row. The referenced rows are looked up with referenced.
+func (r relation) checkRow(row sql.Row, referenced referencedFunc) error {
+	for i, column := range r.columns {
+		if !column.Nullable && sql.IsNull(row[i]) {
+			return notNullViolation(r.name, column.Name)
+		}
+	}
+
+	for _, c := range r.constraints {
+		switch c.Type {
+		case storage.Check:
+			ok, err := r.satisfies(c, row)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return checkViolation(r.name, c.Name)
+			}
+		case storage.ForeignKey:
+			values, ok, err := r.values(row, c.Columns)
+			if err != nil || !ok {
+				return err
+			}
+
+			found, err := referenced(c.Reference.Table, c.Reference.Columns, values)
+			if err != nil {
+				return err
+			}
+			if !found {
+				return foreignKeyViolation(r.name, c.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// satisfies evaluates the CHECK constraint on the row. Like in a WHERE
+// clause NULL is unknown, but unlike there it doesn't violate the check.
+func (r relation) satisfies(c storage.Constraint, row sql.Row) (bool, error) {
+	value, err := eval(c.Check, &scope{columns: scopeColumns(r.name, r.columns), row: row, registry: r.registry})
+	if err != nil {
+		return false, err
+	}
+
+	ok, notNull, err := truth(value)
+	return ok || !notNull, err
+}
+
+// checkUnique verifies the primary key and UNIQUE constraints on the rows.
+func (r relation) checkUnique(rows []sql.Row) error {
+	for _, c := range r.uniqueKeys() {
+		seen := make(map[string]bool, len(rows))
+
+		for _, row := range rows {
+			tuple, ok, err := r.tuple(row, c.Columns)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+
+			if seen[tuple] {
+				return uniqueViolation(r.name, c.Name)
+			}
+			seen[tuple] = true
+		}
+	}
+
+	return nil
+}
+
+// checkStored verifies the primary key and UNIQUE constraints on the rows
+// of the table stored with the keys, looking up the other rows having their
+// values. Rows deleted since they were stored are skipped.
+func (r relation) checkStored(table *storage.Table, keys map[int64]bool) error {
+	rows := make([]sql.Row, 0, len(keys))
+	for key := range keys {
+		if row, ok := table.Get(key); ok {
+			rows = append(rows, row)
+		}
+	}
+
+	for _, c := range r.uniqueKeys() {
+		for _, row := range rows {
+			values, ok, err := r.values(row, c.Columns)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+
+			found, _, err := table.LookupKey(c.Columns, values)
+			if err != nil {
+				return err
+			}
+			if len(found) > 1 {
+				return uniqueViolation(r.name, c.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// values returns the values of the columns of the row. It reports false
+// when one of them is NULL.
+func (r relation) values(row sql.Row, columns []string) ([]sql.Value, bool, error) {
+	values := make([]sql.Value, 0, len(columns))
+
+	for _, name := range columns {
+		i, err := columnIndex(r.name, r.columns, name)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if sql.IsNull(row[i]) {
+			return nil, false, nil
+		}
+
+		values = append(values, row[i])
+	}
+
+	return values, true, nil
+}
+
+// tuple encodes the values of the columns of the row for comparison. It
+// reports false when one of the values is NULL.
+func (r relation) tuple(row sql.Row, columns []string) (string, bool, error) {
+	values, ok, err := r.values(row, columns)
+	if err != nil || !ok {
+		return "", false, err
+	}
+
+	return storage.EncodeKey(values...)
+}
+
+// defineConstraints creates the constraints of the relation from their
+// definitions and returns them after the existing ones.
+func defineConstraints(db storage.Database, r relation, definitions []ast.Constraint) ([]storage.Constraint, error) {
+	existing := len(r.constraints)
+	r.constraints = append([]storage.Constraint(nil), r.constraints...)
+
+	for _, definition := range definitions {
+		c, err := storage.NewConstraint(r.name, definition)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, existing := range r.constraints {
+			if existing.Name == c.Name {
+				return nil, fmt.Errorf("constraint %q for relation %q already exists", c.Name, r.name)
+			}
+		}
+
+		for _, name := range c.Columns {
+			if _, err := columnIndex(r.name, r.columns, name); err != nil {
+				return nil, err
+			}
+		}
+
+		if c.Type == storage.Check {
+			// Evaluating the condition on a row of NULLs finds unknown columns.
+			if _, err := r.satisfies(c, nullRow(len(r.columns))); err != nil {
+				return nil, err
+			}
+		}
+
+		r.constraints = append(r.constraints, c)
+	}
+
+	// References are resolved last, as a foreign key may reference a unique
+	// key of the same table declared after it.
+	for i := existing; i < len(r.constraints); i++ {
+		if r.constraints[i].Type != storage.ForeignKey {
+			continue
+		}
+
+		if err := r.defineReference(db, &r.constraints[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return r.constraints, nil
+}
+
+// defineReference resolves the referenced columns of the foreign key, which
+// default to the primary key, and verifies they are unique.
+func (r relation) defineReference(db storage.Database, c *storage.Constraint) error {
+	parent := r
+	if c.Reference.Table != r.name {
+		table, err := db.GetTable(c.Reference.Table)
+		if err != nil {
+			return err
+		}
+
+		parent = relationOf(table, r.registry)
+	}
+
+	reference := *c.Reference
+	if len(reference.Columns) == 0 {
+		pk, ok := primaryKeyIndex(parent.columns)
+		if !ok {
+			return fmt.Errorf("there is no primary key for referenced table %q", parent.name)
+		}
+
+		reference.Columns = []string{parent.columns[pk].Name}
+	}
+	c.Reference = &reference
+
+	if len(reference.Columns) != len(c.Columns) {
+		return fmt.Errorf("number of referencing and referenced columns for foreign key disagree")
+	}
+
+	for i, name := range reference.Columns {
+		pi, err := columnIndex(parent.name, parent.columns, name)
+		if err != nil {
+			return err
+		}
+
+		ci, err := columnIndex(r.name, r.columns, c.Columns[i])
+		if err != nil {
+			return err
+		}
+
+		if parent.columns[pi].DataType != r.columns[ci].DataType {
+			return fmt.Errorf(
+				"foreign key constraint %q cannot be implemented: key columns %q and %q are of incompatible types: %s and %s",
+				c.Name, c.Columns[i], name, r.columns[ci].DataType, parent.columns[pi].DataType,
+			)
+		}
+	}
+
+	for _, key := range parent.uniqueKeys() {
+		if sameColumns(key.Columns, reference.Columns) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("there is no unique constraint matching given keys for referenced table %q", parent.name)
+}
+
+// validate verifies the constraints on the rows of the relation, which may
+// reference the relation itself.
+func (r relation) validate(db storage.Database, rows []sql.Row) error {
+	// The rows aren't stored yet, the keys they reference of their own are
+	// collected per list of referenced columns.
+	own := make(map[string]map[string]bool)
+
+	referenced := func(name string, columns []string, values []sql.Value) (bool, error) {
+		if name != r.name {
+			table, err := db.GetTable(name)
+			if err != nil {
+				return false, err
+			}
+
+			keys, _, err := table.LookupKey(columns, values)
+			return len(keys) > 0, err
+		}
+
+		list := strings.Join(columns, ",")
+
+		tuples, ok := own[list]
+		if !ok {
+			tuples = make(map[string]bool, len(rows))
+			for _, row := range rows {
+				tuple, ok, err := r.tuple(row, columns)
+				if err != nil {
+					return false, err
+				}
+				if ok {
+					tuples[tuple] = true
+				}
+			}
+			own[list] = tuples
+		}
+
+		tuple, _, err := storage.EncodeKey(values...)
+		return tuples[tuple], err
+	}
+
+	for _, row := range rows {
+		if err := r.checkRow(row, referenced); err != nil {
+			return err
+		}
+	}
+
+	return r.checkUnique(rows)
+}
+
+// foreignKey is a foreign key of a table.
+type foreignKey struct {
+	table      *storage.Table
+	constraint storage.Constraint
+}
+
+// referencing returns the foreign keys of other tables referencing the table,
+// ordered by the name of the referencing table.
+func referencing(db storage.Database, table string) []foreignKey {
+	var keys []foreignKey
+
+	for _, t := range db.ListTables() {
+		if t.Name() == table {
+			continue
+		}
+
+		for _, c := range t.Constraints() {
+			if c.Type == storage.ForeignKey && c.Reference.Table == table {
+				keys = append(keys, foreignKey{table: t, constraint: c})
+			}
+		}
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].table.Name() < keys[j].table.Name()
+	})
+
+	return keys
+}
+
+// withoutConstraint returns the constraints except the named one.
+func withoutConstraint(constraints []storage.Constraint, name string) []storage.Constraint {
+	kept := make([]storage.Constraint, 0, len(constraints))
+
+	for _, c := range constraints {
+		if c.Name != name {
+			kept = append(kept, c)
+		}
+	}
+
+	return kept
+}
+
+func sameColumns(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	set := make(map[string]bool, len(a))
+	for _, name := range a {
+		set[name] = true
+	}
+
+	for _, name := range b {
+		if !set[name] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,199 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/okazaki-kk/miniDB/internal/parser/ast"
+	"github.com/okazaki-kk/miniDB/internal/parser/token"
+)
+
+// recursionLimit is the number of times the recursive term of a recursive
+// query runs at most, so that a query which doesn't end fails instead.
+const recursionLimit = 1000
+
+// commonTable is a query named by a WITH clause, read like a table by the
+// statement following the clause.
+type commonTable struct {
+	query     *ast.CommonTableExpr
+	recursive bool
+	// scope is the scope the query runs in, which binds the queries of the
+	// WITH clause it can read.
+	scope *scope
+	// result is set once the query has run, unless it reads the columns of
+	// an outer query.
+	result *source
+
+	running bool
+	// working holds the rows the recursive term reads while it runs in the
+	// iteration scope.
+	working   *source
+	iteration *scope
+}
+
+// with binds the queries of the WITH clause in scopes between the scope of
+// the statement and its outer scope. Every query has a scope of its own, so
+// that it only reads the queries before it, unless the clause is RECURSIVE.
+func (x *executor) with(with *ast.WithStatement, s *scope) error {
+	names := make(map[string]bool, len(with.Queries))
+	for _, query := range with.Queries {
+		if names[query.Name] {
+			return fmt.Errorf("WITH query name %q specified more than once", query.Name)
+		}
+		names[query.Name] = true
+	}
+
+	if with.Recursive {
+		bound := &scope{outer: s.outer, tables: make(map[string]*commonTable, len(with.Queries))}
+		for i, query := range with.Queries {
+			bound.tables[query.Name] = &commonTable{query: &with.Queries[i], recursive: true, scope: bound}
+		}
+
+		s.outer = bound
+		return nil
+	}
+
+	for i, query := range with.Queries {
+		s.outer = &scope{
+			outer:  s.outer,
+			tables: map[string]*commonTable{query.Name: {query: &with.Queries[i], scope: s.outer}},
+		}
+	}
+
+	return nil
+}
+
+// commonTable returns the rows of the query read in the scope.
+func (x *executor) commonTable(c *commonTable, s *scope) (source, error) {
+	if c.running {
+		if c.working == nil {
+			if isRecursive(c.query.Query) {
+				return source{}, fmt.Errorf("recursive reference to query %q must not appear within its non-recursive term", c.query.Name)
+			}
+			return source{}, fmt.Errorf("recursive query %q does not have the form non-recursive-term UNION [ALL] recursive-term", c.query.Name)
+		}
+
+		// The working rows change with every iteration, like the values of
+		// the columns of an outer query.
+		s.correlate(c.iteration)
+		return *c.working, nil
+	}
+
+	if c.result != nil {
+		return *c.result, nil
+	}
+
+	q := &scope{outer: c.scope}
+
+	result, err := x.runCommonTable(c, q)
+	if err != nil {
+		return source{}, err
+	}
+
+	if q.correlated {
+		s.correlate(c.scope)
+	} else {
+		c.result = &result
+	}
+
+	return result, nil
+}
+
+// isRecursive reports whether the query has the form of a recursive query.
+func isRecursive(stmt *ast.SelectStatement) bool {
+	return stmt.Operator == token.UNION
+}
+
+// runCommonTable runs the query in the scope and renames its columns. The
+// recursive term of a recursive query runs on the rows its previous run
+// returned until it returns none. Without ALL rows already returned are
+// dropped, which ends queries following cycles.
+func (x *executor) runCommonTable(c *commonTable, q *scope) (source, error) {
+	c.running = true
+	defer func() {
+		c.running, c.working, c.iteration = false, nil, nil
+	}()
+
+	stmt := c.query.Query
+	if !c.recursive || !isRecursive(stmt) {
+		result, err := x.query(stmt, q)
+		if err != nil {
+			return source{}, err
+		}
+		return c.rename(result)
+	}
+
+	switch {
+	case stmt.OrderBy != nil:
+		return source{}, fmt.Errorf("ORDER BY in a recursive query is not implemented")
+	case stmt.Limit != nil:
+		return source{}, fmt.Errorf("LIMIT in a recursive query is not implemented")
+	case stmt.Offset != nil:
+		return source{}, fmt.Errorf("OFFSET in a recursive query is not implemented")
+	}
+
+	result, err := x.query(stmt.Left, &scope{outer: q})
+	if err != nil {
+		return source{}, err
+	}
+
+	if result, err = c.rename(result); err != nil {
+		return source{}, err
+	}
+
+	set := make(rowSet)
+	if !stmt.All {
+		result.rows = distinctRows(result.rows)
+		for _, row := range result.rows {
+			set.add(row)
+		}
+	}
+
+	working := source{columns: result.columns, rows: result.rows}
+
+	for i := 0; len(working.rows) > 0; i++ {
+		if i == recursionLimit {
+			return source{}, fmt.Errorf("recursive query %q exceeded the limit of %d iterations", c.query.Name, recursionLimit)
+		}
+
+		c.working, c.iteration = &working, &scope{outer: q}
+
+		next, err := x.query(stmt.Right, c.iteration)
+		if err != nil {
+			return source{}, err
+		}
+
+		if _, err := combinedColumns(stmt.Operator, result.columns, next.columns); err != nil {
+			return source{}, err
+		}
+
+		working = source{columns: result.columns}
+		for _, row := range next.rows {
+			if stmt.All || set.add(row) {
+				working.rows = append(working.rows, row)
+			}
+		}
+
+		result.rows = append(result.rows, working.rows...)
+	}
+
+	return result, nil
+}
+
+// rename names the columns of the result like the columns of the query.
+func (c *commonTable) rename(result source) (source, error) {
+	names := c.query.Columns
+	if len(names) > len(result.columns) {
+		return source{}, fmt.Errorf(
+			"WITH query %q has %d columns available but %d columns specified",
+			c.query.Name, len(result.columns), len(names),
+		)
+	}
+
+	columns := make([]column, len(result.columns))
+	copy(columns, result.columns)
+	for i, name := range names {
+		columns[i].name = name
+	}
+
+	return source{columns: columns, rows: result.rows}, nil
+}
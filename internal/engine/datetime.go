@@ -0,0 +1,440 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/okazaki-kk/miniDB/internal/parser/ast"
+	"github.com/okazaki-kk/miniDB/internal/parser/token"
+	"github.com/okazaki-kk/miniDB/internal/sql"
+	"github.com/okazaki-kk/miniDB/internal/sql/datatype"
+
+	// The tz database is embedded, so that AT TIME ZONE doesn't depend on the
+	// system's.
+	_ "time/tzdata"
+)
+
+const microsPerDay = int64(24 * time.Hour / time.Microsecond)
+
+// operation is an arithmetic operator applied to operands of the types.
+type operation struct {
+	operator    token.TokenType
+	left, right sql.DataType
+}
+
+// datetimeOperations are the result types of the arithmetic on dates, times,
+// timestamps and intervals.
+var datetimeOperations = map[operation]sql.DataType{
+	{token.PLUS, sql.Date, sql.Integer}:   sql.Date,
+	{token.PLUS, sql.Integer, sql.Date}:   sql.Date,
+	{token.MINUS, sql.Date, sql.Integer}:  sql.Date,
+	{token.MINUS, sql.Date, sql.Date}:     sql.Integer,
+	{token.PLUS, sql.Date, sql.Interval}:  sql.Timestamp,
+	{token.PLUS, sql.Interval, sql.Date}:  sql.Timestamp,
+	{token.MINUS, sql.Date, sql.Interval}: sql.Timestamp,
+	{token.PLUS, sql.Date, sql.Time}:      sql.Timestamp,
+	{token.PLUS, sql.Time, sql.Date}:      sql.Timestamp,
+
+	{token.PLUS, sql.Timestamp, sql.Interval}:       sql.Timestamp,
+	{token.PLUS, sql.Interval, sql.Timestamp}:       sql.Timestamp,
+	{token.MINUS, sql.Timestamp, sql.Interval}:      sql.Timestamp,
+	{token.MINUS, sql.Timestamp, sql.Timestamp}:     sql.Interval,
+	{token.PLUS, sql.TimestampTZ, sql.Interval}:     sql.TimestampTZ,
+	{token.PLUS, sql.Interval, sql.TimestampTZ}:     sql.TimestampTZ,
+	{token.MINUS, sql.TimestampTZ, sql.Interval}:    sql.TimestampTZ,
+	{token.MINUS, sql.TimestampTZ, sql.TimestampTZ}: sql.Interval,
+
+	{token.PLUS, sql.Time, sql.Interval}:  sql.Time,
+	{token.PLUS, sql.Interval, sql.Time}:  sql.Time,
+	{token.MINUS, sql.Time, sql.Interval}: sql.Time,
+	{token.MINUS, sql.Time, sql.Time}:     sql.Interval,
+
+	{token.PLUS, sql.Interval, sql.Interval}:    sql.Interval,
+	{token.MINUS, sql.Interval, sql.Interval}:   sql.Interval,
+	{token.ASTERISK, sql.Interval, sql.Integer}: sql.Interval,
+	{token.ASTERISK, sql.Interval, sql.Float}:   sql.Interval,
+	{token.ASTERISK, sql.Integer, sql.Interval}: sql.Interval,
+	{token.ASTERISK, sql.Float, sql.Interval}:   sql.Interval,
+	{token.SLASH, sql.Interval, sql.Integer}:    sql.Interval,
+	{token.SLASH, sql.Interval, sql.Float}:      sql.Interval,
+}
+
+// datetimeArithmetic applies the operator of the datetimeOperations to the
+// values.
+func datetimeArithmetic(operator token.TokenType, left, right sql.Value) (sql.Value, error) {
+	// The commutative operations are computed with the interval, integer or
+	// time on the right.
+	switch l, r := left.DataType(), right.DataType(); {
+	case operator == token.PLUS && l == sql.Interval && r != sql.Interval,
+		operator == token.PLUS && (l == sql.Integer || l == sql.Time) && r == sql.Date,
+		operator == token.ASTERISK && l != sql.Interval:
+		left, right = right, left
+	}
+
+	sign := int64(1)
+	if operator == token.MINUS {
+		sign = -1
+	}
+
+	switch l := left.(type) {
+	case datatype.Date:
+		t := l.Raw().(time.Time)
+
+		switch r := right.(type) {
+		case datatype.Integer:
+			return datatype.NewDate(t.AddDate(0, 0, int(sign*r.Raw().(int64)))), nil
+		case datatype.Date:
+			return datatype.NewInteger((t.Unix() - r.Raw().(time.Time).Unix()) / (24 * 60 * 60)), nil
+		case datatype.Interval:
+			return datatype.NewTimestamp(addInterval(t, r, sign)), nil
+		case datatype.Time:
+			return datatype.NewTimestamp(t.Add(r.Raw().(time.Duration))), nil
+		}
+	case datatype.Timestamp, datatype.TimestampTZ:
+		t := l.Raw().(time.Time)
+
+		var result time.Time
+		switch r := right.(type) {
+		case datatype.Interval:
+			result = addInterval(t, r, sign)
+		default:
+			micros := t.UnixMicro() - r.Raw().(time.Time).UnixMicro()
+			return datatype.NewInterval(0, micros/microsPerDay, micros%microsPerDay), nil
+		}
+
+		if l.DataType() == sql.TimestampTZ {
+			return datatype.NewTimestampTZ(result), nil
+		}
+		return datatype.NewTimestamp(result), nil
+	case datatype.Time:
+		d := l.Raw().(time.Duration)
+
+		switch r := right.(type) {
+		case datatype.Interval:
+			return datatype.NewTime(d + time.Duration(sign*r.Micros())*time.Microsecond), nil
+		case datatype.Time:
+			return datatype.NewInterval(0, 0, int64((d-r.Raw().(time.Duration))/time.Microsecond)), nil
+		}
+	case datatype.Interval:
+		switch operator {
+		case token.PLUS, token.MINUS:
+			r := right.(datatype.Interval)
+			return datatype.NewInterval(l.Months()+sign*r.Months(), l.Days()+sign*r.Days(), l.Micros()+sign*r.Micros()), nil
+		case token.ASTERISK:
+			f, _ := number(right)
+			return scaleInterval(l, f), nil
+		default:
+			f, _ := number(right)
+			if f == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			return scaleInterval(l, 1/f), nil
+		}
+	}
+
+	return nil, fmt.Errorf("operator does not exist: %s %s %s", left.DataType(), operator, right.DataType())
+}
+
+// addInterval adds the interval times the sign to the time. Adding months
+// keeps the day of the month unless the month is shorter, like 2024-01-31 +
+// 1 month is 2024-02-29.
+func addInterval(t time.Time, i datatype.Interval, sign int64) time.Time {
+	if months := sign * i.Months(); months != 0 {
+		y, m, d := t.Date()
+
+		first := time.Date(y, m+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+		if last := first.AddDate(0, 1, -1).Day(); d > last {
+			d = last
+		}
+
+		t = first.AddDate(0, 0, d-1)
+	}
+
+	t = t.AddDate(0, 0, int(sign*i.Days()))
+
+	return t.Add(time.Duration(sign*i.Micros()) * time.Microsecond)
+}
+
+// scaleInterval multiplies the interval by the factor, spilling fractions of
+// months into days and fractions of days into microseconds.
+func scaleInterval(i datatype.Interval, f float64) datatype.Interval {
+	months := float64(i.Months()) * f
+	wholeMonths := math.Trunc(months)
+
+	days := float64(i.Days())*f + (months-wholeMonths)*30
+	wholeDays := math.Trunc(days)
+
+	micros := float64(i.Micros())*f + (days-wholeDays)*float64(microsPerDay)
+
+	return datatype.NewInterval(int64(wholeMonths), int64(wholeDays), int64(math.Round(micros)))
+}
+
+// isDatetime reports whether the type is a date, time, timestamp or interval.
+func isDatetime(t sql.DataType) bool {
+	switch t {
+	case sql.Date, sql.Time, sql.Timestamp, sql.TimestampTZ, sql.Interval:
+		return true
+	}
+	return false
+}
+
+// compareValues compares the values like sql.Value.Compare. Text compared
+// with a date, time, timestamp, interval or JSON document is converted to
+// its type first, so that columns compare with string literals like
+// '2024-01-01' or '{"a": 1}'.
+func compareValues(a, b sql.Value) (int, error) {
+	var err error
+
+	switch {
+	case a.DataType() == sql.Text && castsFromText(b.DataType()):
+		a, err = datatype.Cast(a, b.DataType())
+	case b.DataType() == sql.Text && castsFromText(a.DataType()):
+		b, err = datatype.Cast(b, a.DataType())
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return a.Compare(b)
+}
+
+// castsFromText reports whether text compared with values of the type is
+// converted to it.
+func castsFromText(t sql.DataType) bool {
+	return isDatetime(t) || t == sql.JSON
+}
+
+// evalAtTimeZone converts a timestamp, which is in the time zone, to a
+// timestamp with time zone, and a timestamp with time zone to the timestamp
+// in the time zone.
+func evalAtTimeZone(expr *ast.AtTimeZoneExpr, s *scope) (sql.Value, error) {
+	value, err := eval(expr.Expr, s)
+	if err != nil {
+		return nil, err
+	}
+
+	zone, err := eval(expr.Zone, s)
+	if err != nil {
+		return nil, err
+	}
+
+	if sql.IsNull(value) || sql.IsNull(zone) {
+		return datatype.NewNull(), nil
+	}
+
+	name, ok := zone.Raw().(string)
+	if !ok {
+		return nil, fmt.Errorf("time zone must be text, not %s", zone.DataType())
+	}
+
+	loc, err := datatype.LoadZone(name)
+	if err != nil {
+		return nil, err
+	}
+
+	switch value.DataType() {
+	case sql.Timestamp:
+		t := value.Raw().(time.Time)
+		y, m, d := t.Date()
+		return datatype.NewTimestampTZ(time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)), nil
+	case sql.TimestampTZ:
+		return datatype.NewTimestamp(value.Raw().(time.Time).In(loc)), nil
+	default:
+		return nil, fmt.Errorf("operator does not exist: %s AT TIME ZONE %s", value.DataType(), zone.DataType())
+	}
+}
+
+// atTimeZoneType returns the type of the AT TIME ZONE conversion of the type.
+func atTimeZoneType(t sql.DataType) sql.DataType {
+	switch t {
+	case sql.Timestamp:
+		return sql.TimestampTZ
+	case sql.TimestampTZ:
+		return sql.Timestamp
+	default:
+		return sql.Null
+	}
+}
+
+// datetimeField returns the name of the field of a date, time or interval,
+// like day for days.
+func datetimeField(name string) string {
+	if unit, ok := datatype.IntervalUnit(name); ok {
+		return unit
+	}
+	return strings.ToLower(name)
+}
+
+func now([]sql.Value) (sql.Value, error) {
+	return datatype.NewTimestampTZ(time.Now()), nil
+}
+
+// dateTrunc truncates the timestamp to the precision of the field.
+func dateTrunc(args []sql.Value) (sql.Value, error) {
+	field := datetimeField(args[0].Raw().(string))
+	t := args[1].Raw().(time.Time)
+
+	y, m, d := t.Date()
+
+	switch field {
+	case "microsecond":
+	case "millisecond", "second", "minute", "hour":
+		t = t.Truncate(map[string]time.Duration{
+			"millisecond": time.Millisecond,
+			"second":      time.Second,
+			"minute":      time.Minute,
+			"hour":        time.Hour,
+		}[field])
+	case "day":
+		t = time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	case "week":
+		t = time.Date(y, m, d-(int(t.Weekday())+6)%7, 0, 0, 0, 0, time.UTC)
+	case "month":
+		t = time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	case "quarter":
+		t = time.Date(y, m-(m-1)%3, 1, 0, 0, 0, 0, time.UTC)
+	case "year":
+		t = time.Date(y, 1, 1, 0, 0, 0, 0, time.UTC)
+	case "decade":
+		t = time.Date(y-y%10, 1, 1, 0, 0, 0, 0, time.UTC)
+	case "century":
+		t = time.Date((y-1)/100*100+1, 1, 1, 0, 0, 0, 0, time.UTC)
+	case "millennium":
+		t = time.Date((y-1)/1000*1000+1, 1, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return nil, fmt.Errorf("unit %q not recognized for type %s", args[0].Raw(), args[1].DataType())
+	}
+
+	if args[1].DataType() == sql.TimestampTZ {
+		return datatype.NewTimestampTZ(t), nil
+	}
+	return datatype.NewTimestamp(t), nil
+}
+
+// extract returns the field of the date, time, timestamp or interval.
+func extract(args []sql.Value) (sql.Value, error) {
+	field := datetimeField(args[0].Raw().(string))
+
+	var (
+		f  float64
+		ok bool
+	)
+
+	switch value := args[1].(type) {
+	case datatype.Date:
+		if f, ok = extractDate(field, value.Raw().(time.Time)); !ok && field == "epoch" {
+			f, ok = float64(value.Raw().(time.Time).Unix()), true
+		}
+	case datatype.Time:
+		f, ok = extractTime(field, int64(value.Raw().(time.Duration)/time.Microsecond))
+	case datatype.Timestamp, datatype.TimestampTZ:
+		t := value.Raw().(time.Time)
+
+		switch f, ok = extractDate(field, t); {
+		case ok:
+		case field == "epoch":
+			f, ok = float64(t.UnixMicro())/1e6, true
+		case field == "timezone" && value.DataType() == sql.TimestampTZ:
+			f, ok = 0, true
+		default:
+			f, ok = extractTime(field, t.Sub(t.Truncate(24*time.Hour)).Microseconds())
+		}
+	case datatype.Interval:
+		f, ok = extractInterval(field, value)
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("unit %q not supported for type %s", args[0].Raw(), args[1].DataType())
+	}
+
+	return datatype.NewFloat(f), nil
+}
+
+// extractDate returns the field of the date of the time.
+func extractDate(field string, t time.Time) (float64, bool) {
+	year := t.Year()
+
+	switch field {
+	case "year":
+		return float64(year), true
+	case "month":
+		return float64(t.Month()), true
+	case "day":
+		return float64(t.Day()), true
+	case "quarter":
+		return float64((t.Month()-1)/3 + 1), true
+	case "decade":
+		return float64(year / 10), true
+	case "century":
+		return float64((year + 99) / 100), true
+	case "millennium":
+		return float64((year + 999) / 1000), true
+	case "dow":
+		return float64(t.Weekday()), true
+	case "isodow":
+		return float64((int(t.Weekday())+6)%7 + 1), true
+	case "doy":
+		return float64(t.YearDay()), true
+	case "week":
+		_, week := t.ISOWeek()
+		return float64(week), true
+	case "isoyear":
+		year, _ := t.ISOWeek()
+		return float64(year), true
+	default:
+		return 0, false
+	}
+}
+
+// extractTime returns the field of the time of day of the microseconds since
+// midnight.
+func extractTime(field string, micros int64) (float64, bool) {
+	seconds := micros % (60 * 1e6)
+
+	switch field {
+	case "hour":
+		return float64(micros / (60 * 60 * 1e6)), true
+	case "minute":
+		return float64(micros / (60 * 1e6) % 60), true
+	case "second":
+		return float64(seconds) / 1e6, true
+	case "millisecond":
+		return float64(seconds) / 1e3, true
+	case "microsecond":
+		return float64(seconds), true
+	case "epoch":
+		return float64(micros) / 1e6, true
+	default:
+		return 0, false
+	}
+}
+
+// extractInterval returns the field of the interval. Its epoch counts years
+// of 365.25 days and months of 30 days.
+func extractInterval(field string, i datatype.Interval) (float64, bool) {
+	months := i.Months()
+
+	switch field {
+	case "year":
+		return float64(months / 12), true
+	case "month":
+		return float64(months % 12), true
+	case "quarter":
+		return float64(months%12/3 + 1), true
+	case "decade":
+		return float64(months / 120), true
+	case "century":
+		return float64(months / 1200), true
+	case "millennium":
+		return float64(months / 12000), true
+	case "day":
+		return float64(i.Days()), true
+	case "epoch":
+		days := float64(months/12)*365.25 + float64(months%12*30+i.Days())
+		return days*float64(microsPerDay/1e6) + float64(i.Micros())/1e6, true
+	default:
+		return extractTime(field, i.Micros())
+	}
+}
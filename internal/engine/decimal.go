@@ -0,0 +1,93 @@
+package engine
+
+import (
+	"math"
+
+	"github.com/okazaki-kk/miniDB/internal/parser/token"
+	"github.com/okazaki-kk/miniDB/internal/sql"
+	"github.com/okazaki-kk/miniDB/internal/sql/datatype"
+)
+
+// decimals returns both operands as decimals when one is a decimal and the
+// other a decimal or an integer. A float makes the operation a float one.
+func decimals(left, right sql.Value) (datatype.Decimal, datatype.Decimal, bool) {
+	l, lok := decimal(left)
+	r, rok := decimal(right)
+
+	if !lok || !rok || (left.DataType() != sql.Decimal && right.DataType() != sql.Decimal) {
+		return datatype.Decimal{}, datatype.Decimal{}, false
+	}
+
+	return l, r, true
+}
+
+func decimal(value sql.Value) (datatype.Decimal, bool) {
+	switch v := value.Raw().(type) {
+	case datatype.Decimal:
+		return v, true
+	case int64:
+		return datatype.DecimalFromInt(v), true
+	default:
+		return datatype.Decimal{}, false
+	}
+}
+
+// decimalArithmetic computes exactly, except for divisions whose quotient is
+// rounded like the one of Decimal.Quo.
+func decimalArithmetic(operator token.TokenType, l, r datatype.Decimal) (sql.Value, error) {
+	switch operator {
+	case token.PLUS:
+		return l.Add(r), nil
+	case token.MINUS:
+		return l.Sub(r), nil
+	case token.ASTERISK:
+		return l.Mul(r), nil
+	default:
+		return l.Quo(r)
+	}
+}
+
+// places returns the number of decimal places of the optional second
+// argument of round and trunc, 0 without it.
+func places(args []sql.Value) int32 {
+	if len(args) < 2 {
+		return 0
+	}
+
+	n := args[1].Raw().(int64)
+	switch {
+	case n > datatype.MaxDecimalPrecision:
+		return datatype.MaxDecimalPrecision
+	case n < -datatype.MaxDecimalPrecision:
+		return -datatype.MaxDecimalPrecision
+	default:
+		return int32(n)
+	}
+}
+
+// trunc truncates toward zero to the given number of decimal places,
+// negative ones truncating to tens, hundreds and so on.
+func trunc(args []sql.Value) (sql.Value, error) {
+	if d, ok := args[0].Raw().(datatype.Decimal); ok {
+		return d.Truncate(places(args)), nil
+	}
+
+	v := args[0].Raw().(float64)
+	if len(args) == 1 {
+		return datatype.NewFloat(math.Trunc(v)), nil
+	}
+
+	scale := math.Pow(10, float64(args[1].Raw().(int64)))
+	return datatype.NewFloat(math.Trunc(v*scale) / scale), nil
+}
+
+// rounding returns a function rounding a float with the float function and
+// a decimal with the decimal one.
+func rounding(float func(float64) float64, decimal func(datatype.Decimal) datatype.Decimal) func(args []sql.Value) (sql.Value, error) {
+	return func(args []sql.Value) (sql.Value, error) {
+		if d, ok := args[0].Raw().(datatype.Decimal); ok {
+			return decimal(d), nil
+		}
+		return datatype.NewFloat(float(args[0].Raw().(float64))), nil
+	}
+}
@@ -0,0 +1,497 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/okazaki-kk/miniDB/internal/parser/ast"
+	"github.com/okazaki-kk/miniDB/internal/sql"
+	"github.com/okazaki-kk/miniDB/internal/sql/datatype"
+	"github.com/okazaki-kk/miniDB/storage"
+)
+
+// Insert adds the rows of values to the table of the current database.
+// Omitted columns get their default value, an omitted integer primary key
+// the next free key. Either all rows are inserted or none of them.
+func (e *Engine) Insert(stmt *ast.InsertStatement) (string, error) {
+	db, err := e.currentDatabase()
+	if err != nil {
+		return "", err
+	}
+
+	table, err := db.GetTable(stmt.Table)
+	if err != nil {
+		return "", err
+	}
+
+	columns := table.Scheme().Columns()
+
+	values := stmt.Values
+	if stmt.DefaultValues {
+		values = [][]ast.Expression{nil}
+	}
+
+	// The scope has the functions of the engine for the defaults also with
+	// DEFAULT VALUES.
+	s, err := e.statementScope(stmt.With, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var targets []int
+
+	if !stmt.DefaultValues {
+		if targets, err = insertTargets(table.Name(), columns, stmt); err != nil {
+			return "", err
+		}
+
+		for _, exprs := range values {
+			if err := checkTypes(s, exprs...); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	w := newWriter(db, e.registry)
+
+	for _, exprs := range values {
+		row, err := insertedRow(columns, targets, exprs, s)
+		if err != nil {
+			w.rollback()
+			return "", err
+		}
+
+		key, err := prepareRow(table, columns, row)
+		if err != nil {
+			w.rollback()
+			return "", err
+		}
+
+		if err := w.insertRow(table, key, row); err != nil {
+			w.rollback()
+			return "", err
+		}
+	}
+
+	if err := w.commit(); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("insert %d\n", len(values)), nil
+}
+
+// insertedRow evaluates the values inserted to the target columns, the
+// other columns get their default value.
+func insertedRow(columns []storage.Column, targets []int, exprs []ast.Expression, s *scope) (sql.Row, error) {
+	row := make(sql.Row, len(columns))
+	assigned := make([]bool, len(columns))
+
+	for i, expr := range exprs {
+		if _, ok := expr.(*ast.DefaultExpr); ok {
+			continue
+		}
+
+		var err error
+		if row[targets[i]], err = eval(expr, s); err != nil {
+			return nil, err
+		}
+
+		assigned[targets[i]] = true
+	}
+
+	for i, column := range columns {
+		if !assigned[i] {
+			var err error
+			if row[i], err = defaultValue(column, s.functions()); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return row, nil
+}
+
+// insertTargets returns the position of the column each value is inserted
+// to. All rows of values have the same length.
+func insertTargets(table string, columns []storage.Column, stmt *ast.InsertStatement) ([]int, error) {
+	n := len(stmt.Values[0])
+	for _, values := range stmt.Values[1:] {
+		if len(values) != n {
+			return nil, fmt.Errorf("VALUES lists must all be the same length")
+		}
+	}
+
+	if stmt.Columns == nil {
+		if n > len(columns) {
+			return nil, fmt.Errorf("INSERT has more expressions than target columns")
+		}
+
+		targets := make([]int, n)
+		for i := range targets {
+			targets[i] = i
+		}
+		return targets, nil
+	}
+
+	if n > len(stmt.Columns) {
+		return nil, fmt.Errorf("INSERT has more expressions than target columns")
+	}
+
+	if n < len(stmt.Columns) {
+		return nil, fmt.Errorf("INSERT has more target columns than expressions")
+	}
+
+	targets := make([]int, 0, len(stmt.Columns))
+	for _, name := range stmt.Columns {
+		i, err := columnIndex(table, columns, name)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, target := range targets {
+			if target == i {
+				return nil, fmt.Errorf("column %q specified more than once", name)
+			}
+		}
+
+		targets = append(targets, i)
+	}
+
+	return targets, nil
+}
+
+// Update changes the rows of the table in the current database matching the
+// WHERE clause. Nothing is changed when one of the rows can't be updated.
+func (e *Engine) Update(stmt *ast.UpdateStatement) (string, error) {
+	db, err := e.currentDatabase()
+	if err != nil {
+		return "", err
+	}
+
+	table, err := db.GetTable(stmt.Table)
+	if err != nil {
+		return "", err
+	}
+
+	columns := table.Scheme().Columns()
+
+	targets := make([]int, 0, len(stmt.Set))
+	for _, set := range stmt.Set {
+		i, err := columnIndex(table.Name(), columns, set.Column)
+		if err != nil {
+			return "", err
+		}
+
+		for _, target := range targets {
+			if target == i {
+				return "", fmt.Errorf("multiple assignments to same column %q", set.Column)
+			}
+		}
+
+		targets = append(targets, i)
+	}
+
+	var changes []rowChange
+
+	s, err := e.statementScope(stmt.With, scopeColumns(table.Name(), columns))
+	if err != nil {
+		return "", err
+	}
+
+	exprs := make([]ast.Expression, 0, len(stmt.Set)+1)
+	for _, set := range stmt.Set {
+		exprs = append(exprs, set.Value)
+	}
+	if stmt.Where != nil {
+		exprs = append(exprs, stmt.Where.Expr)
+	}
+
+	if err := checkTypes(s, exprs...); err != nil {
+		return "", err
+	}
+
+	keys, rows, err := candidateRows(table, table.Name(), whereExpr(stmt.Where), e.registry)
+	if err != nil {
+		return "", err
+	}
+
+	for n, row := range rows {
+		s.row = row
+
+		if stmt.Where != nil {
+			ok, err := matches(stmt.Where.Expr, s)
+			if err != nil {
+				return "", err
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		updated := make(sql.Row, len(row))
+		copy(updated, row)
+
+		for j, set := range stmt.Set {
+			value, err := assignedValue(columns[targets[j]], set.Value, s)
+			if err != nil {
+				return "", err
+			}
+
+			updated[targets[j]] = value
+		}
+
+		if err := convertRow(columns, updated); err != nil {
+			return "", err
+		}
+
+		newKey := keys[n]
+		if pk, ok := primaryKeyIndex(columns); ok && columns[pk].DataType == sql.Integer {
+			if sql.IsNull(updated[pk]) {
+				return "", notNullViolation(table.Name(), columns[pk].Name)
+			}
+			newKey = updated[pk].Raw().(int64)
+		}
+
+		changes = append(changes, rowChange{key: keys[n], newKey: newKey, old: row, row: updated})
+	}
+
+	w := newWriter(db, e.registry)
+	if err := w.updateRows(table, changes); err != nil {
+		w.rollback()
+		return "", err
+	}
+
+	if err := w.commit(); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("update %d\n", len(changes)), nil
+}
+
+// Delete removes the rows of the table in the current database matching the
+// WHERE clause, applying the ON DELETE actions of the referencing foreign keys.
+func (e *Engine) Delete(stmt *ast.DeleteStatement) (string, error) {
+	db, err := e.currentDatabase()
+	if err != nil {
+		return "", err
+	}
+
+	table, err := db.GetTable(stmt.Table)
+	if err != nil {
+		return "", err
+	}
+
+	s, err := e.statementScope(stmt.With, scopeColumns(table.Name(), table.Scheme().Columns()))
+	if err != nil {
+		return "", err
+	}
+
+	if stmt.Where != nil {
+		if err := checkTypes(s, stmt.Where.Expr); err != nil {
+			return "", err
+		}
+	}
+
+	keys, rows, err := candidateRows(table, table.Name(), whereExpr(stmt.Where), e.registry)
+	if err != nil {
+		return "", err
+	}
+
+	var deleted []int64
+	for i, row := range rows {
+		if stmt.Where != nil {
+			s.row = row
+
+			ok, err := matches(stmt.Where.Expr, s)
+			if err != nil {
+				return "", err
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		deleted = append(deleted, keys[i])
+	}
+
+	w := newWriter(db, e.registry)
+	for _, key := range deleted {
+		if err := w.deleteRow(table, key); err != nil {
+			w.rollback()
+			return "", err
+		}
+	}
+
+	if err := w.commit(); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("delete %d\n", len(deleted)), nil
+}
+
+// assignedValue evaluates the value assigned to the column, which may be
+// DEFAULT.
+func assignedValue(column storage.Column, expr ast.Expression, s *scope) (sql.Value, error) {
+	if _, ok := expr.(*ast.DefaultExpr); ok {
+		return defaultValue(column, s.functions())
+	}
+
+	return eval(expr, s)
+}
+
+// defaultValue evaluates the default of the column, NULL when it has none.
+func defaultValue(column storage.Column, r *registry) (sql.Value, error) {
+	if column.Default == nil {
+		return datatype.NewNull(), nil
+	}
+
+	value, err := eval(column.Default, &scope{registry: r})
+	if err != nil {
+		return nil, err
+	}
+
+	return column.Convert(value)
+}
+
+// checkDefault verifies the DEFAULT expression of the column when it is
+// defined: it references no columns, runs no subquery and its value converts
+// to the type of the column.
+func checkDefault(column storage.Column, r *registry) error {
+	if column.Default == nil {
+		return nil
+	}
+
+	if err := constantDefault(column.Default); err != nil {
+		return err
+	}
+
+	if _, err := defaultValue(column, r); err != nil {
+		return fmt.Errorf("column %q is of type %s: %w", column.Name, column.TypeName(), err)
+	}
+
+	return nil
+}
+
+// constantDefault fails when the DEFAULT expression references a column or
+// runs a subquery.
+func constantDefault(expr ast.Expression) error {
+	var exprs []ast.Expression
+
+	switch expr := expr.(type) {
+	case *ast.IdentExpr:
+		return fmt.Errorf("cannot use column reference in DEFAULT expression")
+	case *ast.SubqueryExpr, *ast.ExistsExpr:
+		return fmt.Errorf("cannot use subquery in DEFAULT expression")
+	case *ast.InExpr:
+		if expr.Select != nil {
+			return fmt.Errorf("cannot use subquery in DEFAULT expression")
+		}
+		exprs = operands(expr)
+	case *ast.UnaryExpr:
+		exprs = []ast.Expression{expr.Operand}
+	case *ast.ConditionExpr:
+		exprs = []ast.Expression{expr.Left, expr.Right}
+	case *ast.IsNullExpr:
+		exprs = []ast.Expression{expr.Expr}
+	case *ast.IsDistinctExpr:
+		exprs = []ast.Expression{expr.Left, expr.Right}
+	case *ast.CallExpr:
+		exprs = expr.Args
+	default:
+		exprs = operands(expr)
+	}
+
+	for _, e := range exprs {
+		if err := constantDefault(e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// prepareRow converts the values of the row to the column types, assigns a
+// missing integer primary key and returns the key to store the row with.
+func prepareRow(table *storage.Table, columns []storage.Column, row sql.Row) (int64, error) {
+	if err := convertRow(columns, row); err != nil {
+		return 0, err
+	}
+
+	key := table.NextKey()
+
+	if pk, ok := primaryKeyIndex(columns); ok && columns[pk].DataType == sql.Integer {
+		if sql.IsNull(row[pk]) {
+			row[pk] = datatype.NewInteger(key)
+		}
+		key = row[pk].Raw().(int64)
+	}
+
+	return key, nil
+}
+
+// convertRow converts the values of the row to the types of the columns.
+func convertRow(columns []storage.Column, row sql.Row) error {
+	for i := range row {
+		value, err := columns[i].Convert(row[i])
+		if err != nil {
+			return fmt.Errorf("column %q is of type %s: %w", columns[i].Name, columns[i].TypeName(), err)
+		}
+
+		row[i] = value
+	}
+
+	return nil
+}
+
+// matches reports whether the condition holds for the row of the scope. NULL
+// doesn't match.
+func matches(expr ast.Expression, s *scope) (bool, error) {
+	value, err := eval(expr, s)
+	if err != nil {
+		return false, err
+	}
+
+	ok, _, err := truth(value)
+	return ok, err
+}
+
+func scopeColumns(table string, columns []storage.Column) []column {
+	scoped := make([]column, 0, len(columns))
+	for _, c := range columns {
+		scoped = append(scoped, column{table: table, name: c.Name, dataType: c.DataType})
+	}
+	return scoped
+}
+
+func primaryKeyIndex(columns []storage.Column) (int, bool) {
+	for i := range columns {
+		if columns[i].PrimaryKey {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func columnIndex(table string, columns []storage.Column, name string) (int, error) {
+	for i := range columns {
+		if columns[i].Name == name {
+			return i, nil
+		}
+	}
+
+	return 0, fmt.Errorf("column %q of relation %q does not exist", name, table)
+}
+
+// statementScope returns the scope of the rows of the table a statement
+// changes, with the queries of its WITH clause bound.
+func (e *Engine) statementScope(with *ast.WithStatement, columns []column) (*scope, error) {
+	x := newExecutor(e)
+	s := &scope{columns: columns, executor: x}
+
+	if with != nil {
+		if err := x.with(with, s); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
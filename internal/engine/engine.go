@@ -2,39 +2,130 @@ package engine
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/okazaki-kk/miniDB/internal/parser"
 	"github.com/okazaki-kk/miniDB/internal/parser/ast"
 	"github.com/okazaki-kk/miniDB/internal/parser/lexer"
+	"github.com/okazaki-kk/miniDB/internal/sql"
 	"github.com/okazaki-kk/miniDB/storage"
 )
 
 type Engine struct {
 	parser  parser.Parser
 	catalog storage.Catalog
+	session session
+	// sortMemory is the memory in bytes a sort uses before it spills its rows
+	// to temporary files.
+	sortMemory int
+	// registry holds the functions registered with the engine.
+	registry *registry
+}
+
+// session holds the state of the client using the engine.
+type session struct {
+	database string
+}
+
+// Result is the outcome of an executed statement. Statements returning rows
+// set Columns and Rows, the others only report a Message.
+type Result struct {
+	Message string
+	Columns []string
+	Rows    sql.RowIter
 }
 
 func New(catalog storage.Catalog) *Engine {
-	return &Engine{catalog: catalog}
+	return &Engine{catalog: catalog, sortMemory: DefaultSortMemory, registry: newRegistry()}
+}
+
+// SetSortMemory sets the memory in bytes a sort uses before it spills its
+// rows to temporary files.
+func (e *Engine) SetSortMemory(bytes int) {
+	e.sortMemory = bytes
+}
+
+// Database returns the name of the current database of the session.
+func (e *Engine) Database() string {
+	return e.session.database
 }
 
-func (e *Engine) Exec(database, input string) (string, error) {
+func (e *Engine) Exec(input string) (*Result, error) {
 	e.parser = *parser.New(lexer.New(input))
 	stmt, err := e.parser.Parse()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	switch stmt := stmt.(type) {
 	case *ast.CreateDatabaseStatement:
-		return e.CreateDatabase(stmt.Database)
+		if _, err := e.catalog.GetDatabase(stmt.Database); err == nil && stmt.IfNotExists {
+			return message(fmt.Sprintf("database %q already exists, skipping\n", stmt.Database), nil)
+		}
+		return message(e.CreateDatabase(stmt.Database))
 	case *ast.CreateTableStatement:
-		return e.CreateTable(database, stmt.Table, stmt.Columns)
+		if db, err := e.currentDatabase(); err == nil && stmt.IfNotExists {
+			if _, err := db.GetTable(stmt.Table); err == nil {
+				return message(fmt.Sprintf("table %q already exists, skipping\n", stmt.Table), nil)
+			}
+		}
+		return message(e.CreateTable(e.session.database, stmt.Table, stmt.Columns, stmt.Constraints...))
+	case *ast.CreateIndexStatement:
+		return message(e.CreateIndex(stmt))
+	case *ast.SelectStatement:
+		return e.Select(stmt)
+	case *ast.InsertStatement:
+		return message(e.Insert(stmt))
+	case *ast.UpdateStatement:
+		return message(e.Update(stmt))
+	case *ast.DeleteStatement:
+		return message(e.Delete(stmt))
+	case *ast.AlterTableStatement:
+		return message(e.AlterTable(stmt.Table, stmt.Actions))
+	case *ast.DropDatabaseStatement:
+		return message(e.DropDatabase(stmt.Database, stmt.IfExists, stmt.Force))
+	case *ast.DropTableStatement:
+		return message(e.DropTables(stmt.Tables, stmt.IfExists, stmt.Cascade))
+	case *ast.DropIndexStatement:
+		return message(e.DropIndex(stmt.Index, stmt.IfExists))
+	case *ast.TruncateStatement:
+		return message(e.Truncate(stmt.Tables))
+	case *ast.UseStatement:
+		return message(e.Use(stmt.Database))
+	case *ast.ShowDatabasesStatement:
+		return e.ShowDatabases()
+	case *ast.ShowTablesStatement:
+		return e.ShowTables()
+	case *ast.ShowFunctionsStatement:
+		return e.ShowFunctions()
+	case *ast.ShowColumnsStatement:
+		return e.ShowColumns(stmt.Table)
+	case *ast.ShowCreateTableStatement:
+		return e.ShowCreateTable(stmt.Table)
 	default:
-		return "", nil
+		return &Result{}, nil
 	}
 }
 
+func message(msg string, err error) (*Result, error) {
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Message: msg}, nil
+}
+
+// Use switches the current database of the session.
+func (e *Engine) Use(name string) (string, error) {
+	db, err := e.catalog.GetDatabase(name)
+	if err != nil {
+		return "", err
+	}
+
+	e.session.database = db.Name()
+
+	return "database changed\n", nil
+}
+
 func (e *Engine) CreateDatabase(name string) (string, error) {
 	db, err := e.catalog.CreateDatabase(name)
 	if err != nil {
@@ -43,7 +134,9 @@ func (e *Engine) CreateDatabase(name string) (string, error) {
 	return fmt.Sprintf("create database %s\n", db.Name()), err
 }
 
-func (e *Engine) CreateTable(database string, tableName string, columns []ast.Column) (string, error) {
+// CreateTable creates the table with the constraints declared with the
+// columns and the table.
+func (e *Engine) CreateTable(database string, tableName string, columns []ast.Column, constraints ...ast.Constraint) (string, error) {
 	db, err := e.catalog.GetDatabase(database)
 	if err != nil {
 		return "", err
@@ -54,10 +147,153 @@ func (e *Engine) CreateTable(database string, tableName string, columns []ast.Co
 		return "", err
 	}
 
-	_, err = db.CreateTable(tableName, scheme)
+	for _, column := range scheme.Columns() {
+		if err := checkDefault(column, e.registry); err != nil {
+			return "", err
+		}
+	}
+
+	definitions := make([]ast.Constraint, 0, len(constraints))
+	for _, column := range columns {
+		definitions = append(definitions, column.Constraints...)
+	}
+	definitions = append(definitions, constraints...)
+
+	r := relation{name: tableName, columns: scheme.Columns(), registry: e.registry}
+
+	defined, err := defineConstraints(db, r, definitions)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = db.CreateTable(tableName, scheme, defined...)
 	if err != nil {
 		return "", err
 	}
 
 	return fmt.Sprintf("create table %s\n", tableName), err
 }
+
+// DropDatabase drops the database. The database the session is using can only
+// be dropped when forced, after which the session has no current database.
+func (e *Engine) DropDatabase(name string, ifExists, force bool) (string, error) {
+	if _, err := e.catalog.GetDatabase(name); err != nil {
+		if ifExists {
+			return fmt.Sprintf("database %q does not exist, skipping\n", name), nil
+		}
+		return "", err
+	}
+
+	if name == e.session.database && !force {
+		return "", fmt.Errorf("cannot drop the currently open database %q", name)
+	}
+
+	if err := e.catalog.DropDatabase(name); err != nil {
+		return "", err
+	}
+
+	if name == e.session.database {
+		e.session.database = ""
+	}
+
+	return fmt.Sprintf("drop database %s\n", name), nil
+}
+
+// DropTables drops the tables of the current database. Either all tables are
+// dropped or, when one of them doesn't exist, none of them. A table referenced
+// by foreign keys of other tables is only dropped with CASCADE, which drops
+// those foreign keys too.
+func (e *Engine) DropTables(names []string, ifExists, cascade bool) (string, error) {
+	db, err := e.currentDatabase()
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+
+	tables := make([]string, 0, len(names))
+	for _, name := range names {
+		if _, err := db.GetTable(name); err != nil {
+			if !ifExists {
+				return "", err
+			}
+
+			b.WriteString(fmt.Sprintf("table %q does not exist, skipping\n", name))
+			continue
+		}
+
+		tables = append(tables, name)
+	}
+
+	dropped := make(map[string]bool, len(tables))
+	for _, name := range tables {
+		dropped[name] = true
+	}
+
+	for _, name := range tables {
+		for _, fk := range referencing(db, name) {
+			if !dropped[fk.table.Name()] && !cascade {
+				return "", fmt.Errorf("cannot drop table %q because other objects depend on it", name)
+			}
+		}
+	}
+
+	for _, name := range tables {
+		for _, fk := range referencing(db, name) {
+			if dropped[fk.table.Name()] {
+				continue
+			}
+
+			fk.table.SetConstraints(withoutConstraint(fk.table.Constraints(), fk.constraint.Name))
+			b.WriteString(fmt.Sprintf("drop cascades to constraint %s on table %s\n", fk.constraint.Name, fk.table.Name()))
+		}
+
+		if err := db.DropTable(name); err != nil {
+			return "", err
+		}
+
+		b.WriteString(fmt.Sprintf("drop table %s\n", name))
+	}
+
+	return b.String(), nil
+}
+
+// Truncate removes all rows from the tables of the current database.
+func (e *Engine) Truncate(names []string) (string, error) {
+	db, err := e.currentDatabase()
+	if err != nil {
+		return "", err
+	}
+
+	tables := make([]*storage.Table, 0, len(names))
+	for _, name := range names {
+		table, err := db.GetTable(name)
+		if err != nil {
+			return "", err
+		}
+
+		tables = append(tables, table)
+	}
+
+	truncated := make(map[string]bool, len(names))
+	for _, name := range names {
+		truncated[name] = true
+	}
+
+	for _, name := range names {
+		for _, fk := range referencing(db, name) {
+			if !truncated[fk.table.Name()] {
+				return "", fmt.Errorf(
+					"cannot truncate a table referenced in a foreign key constraint: table %q references %q",
+					fk.table.Name(), name,
+				)
+			}
+		}
+	}
+
+	for _, table := range tables {
+		table.Truncate()
+	}
+
+	return "truncate table\n", nil
+}
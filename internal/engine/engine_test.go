@@ -1,9 +1,20 @@
 package engine
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/okazaki-kk/miniDB/internal/parser/ast"
+	"github.com/okazaki-kk/miniDB/internal/parser/token"
+	"github.com/okazaki-kk/miniDB/internal/sql"
+	"github.com/okazaki-kk/miniDB/internal/sql/datatype"
 	"github.com/okazaki-kk/miniDB/storage"
 	"github.com/stretchr/testify/assert"
 )
@@ -55,4 +66,2895 @@ func TestCreateTable(t *testing.T) {
 	})
 	assert.NoError(t, err)
 	assert.Equal(t, "create table test3\n", message)
+
+	_, err = engine.Use("test")
+	assert.NoError(t, err)
+
+	for input, expected := range map[string]string{
+		"CREATE TABLE t (id INT PRIMARY KEY, n INT DEFAULT nosuch)":            "cannot use column reference in DEFAULT expression",
+		"CREATE TABLE t (id INT PRIMARY KEY, n INT DEFAULT abs(id) + 1)":       "cannot use column reference in DEFAULT expression",
+		"CREATE TABLE t (id INT PRIMARY KEY, n INT DEFAULT (SELECT 1))":        "cannot use subquery in DEFAULT expression",
+		"CREATE TABLE t (id INT PRIMARY KEY, n INT DEFAULT (1 IN (SELECT 1)))": "cannot use subquery in DEFAULT expression",
+		"CREATE TABLE t (id INT PRIMARY KEY, n INTEGER DEFAULT 'abc')":         `column "n" is of type integer: invalid input syntax for type integer: "abc"`,
+	} {
+		_, err := engine.Exec(input)
+		assert.EqualError(t, err, expected, input)
+	}
+
+	_, err = engine.Exec("CREATE TABLE t (id INT PRIMARY KEY, n INTEGER DEFAULT '42', f FLOAT DEFAULT abs(-2) + 0.5)")
+	assert.NoError(t, err)
+}
+
+// collect reads all rows of the result.
+func collect(t *testing.T, result *Result) []sql.Row {
+	t.Helper()
+
+	var rows []sql.Row
+	for {
+		row, err := result.Rows.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		assert.NoError(t, err)
+
+		rows = append(rows, row)
+	}
+
+	assert.NoError(t, result.Rows.Close())
+
+	return rows
+}
+
+func TestUse(t *testing.T) {
+	catalog := storage.NewCatalog()
+	engine := New(*catalog)
+
+	_, err := engine.Exec("CREATE DATABASE demo")
+	assert.NoError(t, err)
+	assert.Equal(t, "", engine.Database())
+
+	_, err = engine.Exec("USE unknown")
+	assert.EqualError(t, err, `database "unknown" not found`)
+	assert.Equal(t, "", engine.Database())
+
+	result, err := engine.Exec("USE demo;")
+	assert.NoError(t, err)
+	assert.Equal(t, "database changed\n", result.Message)
+	assert.Equal(t, "demo", engine.Database())
+
+	_, err = engine.Exec("CREATE TABLE users (id INT, name TEXT)")
+	assert.Error(t, err)
+
+	_, err = engine.CreateTable("demo", "users", []ast.Column{
+		{Name: "id", Type: "INT", PrimaryKey: true},
+		{Name: "name", Type: "TEXT", Nullable: true},
+	})
+	assert.NoError(t, err)
+
+	db, err := catalog.GetDatabase("demo")
+	assert.NoError(t, err)
+	assert.Len(t, db.ListTables(), 1)
+}
+
+func TestShow(t *testing.T) {
+	catalog := storage.NewCatalog()
+	engine := New(*catalog)
+
+	_, err := engine.Exec("SHOW TABLES")
+	assert.EqualError(t, err, "no database selected")
+
+	for _, name := range []string{"demo", "abc"} {
+		_, err := engine.CreateDatabase(name)
+		assert.NoError(t, err)
+	}
+
+	_, err = engine.Use("demo")
+	assert.NoError(t, err)
+
+	for _, name := range []string{"users", "aircrafts"} {
+		_, err = engine.CreateTable("demo", name, []ast.Column{
+			{Name: "id", Type: "INT", PrimaryKey: true},
+			{Name: "name", Type: "TEXT", Nullable: true},
+			{Name: "code", Type: "TEXT"},
+		})
+		assert.NoError(t, err)
+	}
+
+	tests := []struct {
+		input   string
+		columns []string
+		rows    []sql.Row
+		err     string
+	}{
+		{
+			input:   "SHOW DATABASES",
+			columns: []string{"Database"},
+			rows: []sql.Row{
+				{datatype.NewText("abc")},
+				{datatype.NewText("demo")},
+			},
+		},
+		{
+			input:   "SHOW TABLES",
+			columns: []string{"Tables_in_demo"},
+			rows: []sql.Row{
+				{datatype.NewText("aircrafts")},
+				{datatype.NewText("users")},
+			},
+		},
+		{
+			input:   "SHOW COLUMNS FROM users",
+			columns: []string{"Field", "Type", "Null", "Key", "Default"},
+			rows: []sql.Row{
+				{datatype.NewText("id"), datatype.NewText("integer"), datatype.NewText("NO"), datatype.NewText("PRI"), datatype.NewNull()},
+				{datatype.NewText("name"), datatype.NewText("text"), datatype.NewText("YES"), datatype.NewText(""), datatype.NewNull()},
+				{datatype.NewText("code"), datatype.NewText("text"), datatype.NewText("NO"), datatype.NewText(""), datatype.NewNull()},
+			},
+		},
+		{
+			input:   "DESCRIBE aircrafts",
+			columns: []string{"Field", "Type", "Null", "Key", "Default"},
+			rows: []sql.Row{
+				{datatype.NewText("id"), datatype.NewText("integer"), datatype.NewText("NO"), datatype.NewText("PRI"), datatype.NewNull()},
+				{datatype.NewText("name"), datatype.NewText("text"), datatype.NewText("YES"), datatype.NewText(""), datatype.NewNull()},
+				{datatype.NewText("code"), datatype.NewText("text"), datatype.NewText("NO"), datatype.NewText(""), datatype.NewNull()},
+			},
+		},
+		{
+			input:   "SHOW CREATE TABLE users",
+			columns: []string{"Table", "Create Table"},
+			rows: []sql.Row{
+				{
+					datatype.NewText("users"),
+					datatype.NewText("CREATE TABLE users (\n    id INTEGER PRIMARY KEY,\n    name TEXT,\n    code TEXT NOT NULL\n)"),
+				},
+			},
+		},
+		{
+			input: "SHOW COLUMNS FROM orders",
+			err:   `table "orders" not found`,
+		},
+	}
+
+	for _, test := range tests {
+		result, err := engine.Exec(test.input)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err, test.input)
+			continue
+		}
+
+		assert.NoError(t, err, test.input)
+		assert.Equal(t, test.columns, result.Columns, test.input)
+		assert.Equal(t, test.rows, collect(t, result), test.input)
+	}
+}
+
+func TestDrop(t *testing.T) {
+	catalog := storage.NewCatalog()
+	engine := New(*catalog)
+
+	columns := []ast.Column{
+		{Name: "id", Type: "INT", PrimaryKey: true},
+	}
+
+	_, err := engine.CreateDatabase("demo")
+	assert.NoError(t, err)
+	_, err = engine.Use("demo")
+	assert.NoError(t, err)
+
+	for _, name := range []string{"users", "orders", "tickets"} {
+		_, err = engine.CreateTable("demo", name, columns)
+		assert.NoError(t, err)
+	}
+
+	db, err := catalog.GetDatabase("demo")
+	assert.NoError(t, err)
+
+	_, err = engine.Exec("DROP TABLE users, unknown")
+	assert.EqualError(t, err, `table "unknown" not found`)
+	assert.Len(t, db.ListTables(), 3)
+
+	result, err := engine.Exec("DROP TABLE IF EXISTS users, unknown")
+	assert.NoError(t, err)
+	assert.Equal(t, "table \"unknown\" does not exist, skipping\ndrop table users\n", result.Message)
+	assert.Len(t, db.ListTables(), 2)
+
+	result, err = engine.Exec("DROP TABLE orders, tickets CASCADE")
+	assert.NoError(t, err)
+	assert.Equal(t, "drop table orders\ndrop table tickets\n", result.Message)
+	assert.Empty(t, db.ListTables())
+
+	result, err = engine.Exec("CREATE DATABASE IF NOT EXISTS demo")
+	assert.NoError(t, err)
+	assert.Equal(t, "database \"demo\" already exists, skipping\n", result.Message)
+
+	_, err = engine.Exec("DROP DATABASE demo")
+	assert.EqualError(t, err, `cannot drop the currently open database "demo"`)
+
+	_, err = engine.Exec("DROP DATABASE unknown")
+	assert.EqualError(t, err, `database "unknown" not found`)
+
+	result, err = engine.Exec("DROP DATABASE IF EXISTS unknown")
+	assert.NoError(t, err)
+	assert.Equal(t, "database \"unknown\" does not exist, skipping\n", result.Message)
+
+	result, err = engine.Exec("DROP DATABASE demo WITH (FORCE)")
+	assert.NoError(t, err)
+	assert.Equal(t, "drop database demo\n", result.Message)
+	assert.Equal(t, "", engine.Database())
+
+	_, err = catalog.GetDatabase("demo")
+	assert.Error(t, err)
+
+	_, err = engine.Exec("DROP TABLE users")
+	assert.EqualError(t, err, "no database selected")
+}
+
+func TestTruncate(t *testing.T) {
+	catalog := storage.NewCatalog()
+	engine := New(*catalog)
+
+	_, err := engine.CreateDatabase("demo")
+	assert.NoError(t, err)
+	_, err = engine.Use("demo")
+	assert.NoError(t, err)
+	_, err = engine.CreateTable("demo", "users", []ast.Column{
+		{Name: "id", Type: "INT", PrimaryKey: true},
+	})
+	assert.NoError(t, err)
+
+	db, err := catalog.GetDatabase("demo")
+	assert.NoError(t, err)
+	table, err := db.GetTable("users")
+	assert.NoError(t, err)
+
+	for i := int64(1); i <= 3; i++ {
+		assert.NoError(t, table.Insert(i, sql.Row{datatype.NewInteger(i)}))
+	}
+
+	_, err = engine.Exec("TRUNCATE TABLE users, unknown")
+	assert.EqualError(t, err, `table "unknown" not found`)
+
+	result, err := engine.Exec("TRUNCATE TABLE users")
+	assert.NoError(t, err)
+	assert.Equal(t, "truncate table\n", result.Message)
+
+	rows, err := table.Scan()
+	assert.NoError(t, err)
+	assert.Empty(t, collect(t, &Result{Rows: rows}))
+
+	assert.NoError(t, table.Insert(1, sql.Row{datatype.NewInteger(1)}))
+}
+
+func TestAlterTable(t *testing.T) {
+	catalog := storage.NewCatalog()
+	engine := New(*catalog)
+
+	_, err := engine.CreateDatabase("demo")
+	assert.NoError(t, err)
+	_, err = engine.Use("demo")
+	assert.NoError(t, err)
+	_, err = engine.Exec("CREATE TABLE users (id INT PRIMARY KEY, name TEXT, age TEXT)")
+	assert.NoError(t, err)
+
+	db, err := catalog.GetDatabase("demo")
+	assert.NoError(t, err)
+	table, err := db.GetTable("users")
+	assert.NoError(t, err)
+
+	assert.NoError(t, table.Insert(1, sql.Row{datatype.NewInteger(1), datatype.NewText("Max"), datatype.NewText("30")}))
+	assert.NoError(t, table.Insert(2, sql.Row{datatype.NewInteger(2), datatype.NewNull(), datatype.NewNull()}))
+
+	rows := func() []sql.Row {
+		iter, err := table.Scan()
+		assert.NoError(t, err)
+		return collect(t, &Result{Rows: iter})
+	}
+
+	tests := []struct {
+		input  string
+		err    string
+		rows   []sql.Row
+		scheme string
+	}{
+		{
+			input: "ALTER TABLE users ADD COLUMN active BOOLEAN NOT NULL",
+			err:   `column "active" of relation "users" contains null values`,
+		},
+		{
+			input: "ALTER TABLE users ALTER COLUMN name SET NOT NULL",
+			err:   `column "name" of relation "users" contains null values`,
+		},
+		{
+			input: "ALTER TABLE users DROP COLUMN id",
+			err:   `cannot drop primary key column "id"`,
+		},
+		{
+			input: "ALTER TABLE users DROP COLUMN email",
+			err:   `column "email" of relation "users" does not exist`,
+		},
+		{
+			input: "ALTER TABLE users RENAME COLUMN name TO age",
+			err:   `column "age" of relation "users" already exists`,
+		},
+		{
+			// Rejected actions leave the earlier ones unapplied.
+			input: "ALTER TABLE users DROP COLUMN name, ALTER COLUMN id DROP NOT NULL",
+			err:   `column "id" is in a primary key`,
+		},
+		{
+			input: "ALTER TABLE users ADD COLUMN email TEXT DEFAULT name",
+			err:   "cannot use column reference in DEFAULT expression",
+		},
+		{
+			input: "ALTER TABLE users ADD COLUMN level INTEGER DEFAULT 'high'",
+			err:   `column "level" is of type integer: invalid input syntax for type integer: "high"`,
+		},
+		{
+			input: "ALTER TABLE users ADD COLUMN active BOOLEAN NOT NULL DEFAULT true, ALTER COLUMN age TYPE INTEGER",
+			rows: []sql.Row{
+				{datatype.NewInteger(1), datatype.NewText("Max"), datatype.NewInteger(30), datatype.NewBoolean(true)},
+				{datatype.NewInteger(2), datatype.NewNull(), datatype.NewNull(), datatype.NewBoolean(true)},
+			},
+			scheme: "CREATE TABLE users (\n    id INTEGER PRIMARY KEY,\n    name TEXT,\n    age INTEGER,\n    active BOOLEAN NOT NULL DEFAULT true\n)",
+		},
+		{
+			input: "ALTER TABLE users DROP COLUMN name, RENAME COLUMN age TO years, ADD score FLOAT DEFAULT -1.5",
+			rows: []sql.Row{
+				{datatype.NewInteger(1), datatype.NewInteger(30), datatype.NewBoolean(true), datatype.NewFloat(-1.5)},
+				{datatype.NewInteger(2), datatype.NewNull(), datatype.NewBoolean(true), datatype.NewFloat(-1.5)},
+			},
+			scheme: "CREATE TABLE users (\n    id INTEGER PRIMARY KEY,\n    years INTEGER,\n    active BOOLEAN NOT NULL DEFAULT true,\n    score FLOAT DEFAULT -1.5\n)",
+		},
+		{
+			input: "ALTER TABLE users ALTER COLUMN active DROP NOT NULL, ALTER COLUMN score TYPE TEXT",
+			rows: []sql.Row{
+				{datatype.NewInteger(1), datatype.NewInteger(30), datatype.NewBoolean(true), datatype.NewText("-1.5E+00")},
+				{datatype.NewInteger(2), datatype.NewNull(), datatype.NewBoolean(true), datatype.NewText("-1.5E+00")},
+			},
+			scheme: "CREATE TABLE users (\n    id INTEGER PRIMARY KEY,\n    years INTEGER,\n    active BOOLEAN DEFAULT true,\n    score TEXT DEFAULT -1.5\n)",
+		},
+		{
+			input: "ALTER TABLE users ALTER COLUMN score TYPE BOOLEAN",
+			err:   `column "score" is of type boolean: cannot cast type numeric to boolean`,
+		},
+	}
+
+	for _, test := range tests {
+		_, err := engine.Exec(test.input)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err, test.input)
+			continue
+		}
+
+		assert.NoError(t, err, test.input)
+		assert.Equal(t, test.rows, rows(), test.input)
+		assert.Equal(t, test.scheme, createTableStatement(table.Name(), table.Scheme(), table.Constraints(), table.Indexes()), test.input)
+	}
+
+	result, err := engine.Exec("ALTER TABLE users RENAME TO customers")
+	assert.NoError(t, err)
+	assert.Equal(t, "alter table users\n", result.Message)
+
+	_, err = db.GetTable("users")
+	assert.Error(t, err)
+
+	renamed, err := db.GetTable("customers")
+	assert.NoError(t, err)
+	assert.Same(t, table, renamed)
+	assert.Equal(t, "customers", renamed.Name())
+}
+
+func TestAlterTable_CheckNull(t *testing.T) {
+	engine, db := newTestEngine(t,
+		"CREATE TABLE d (id INT PRIMARY KEY, a INT, b INT, c INT, CONSTRAINT a_or_b CHECK (a IS NOT NULL OR b > 0), CONSTRAINT c_not_b CHECK (c IS DISTINCT FROM b))",
+	)
+
+	_, err := engine.Exec("ALTER TABLE d RENAME COLUMN a TO aa, RENAME COLUMN c TO cc")
+	assert.NoError(t, err)
+
+	table, err := db.GetTable("d")
+	assert.NoError(t, err)
+	assert.Equal(t,
+		"CREATE TABLE d (\n    id INTEGER PRIMARY KEY,\n    aa INTEGER,\n    b INTEGER,\n    cc INTEGER,\n"+
+			"    CONSTRAINT a_or_b CHECK ((aa IS NOT NULL) OR (b > 0)),\n    CONSTRAINT c_not_b CHECK (cc IS DISTINCT FROM b)\n)",
+		createTableStatement(table.Name(), table.Scheme(), table.Constraints(), table.Indexes()),
+	)
+
+	for _, column := range []string{"aa", "cc"} {
+		_, err = engine.Exec("ALTER TABLE d DROP COLUMN " + column)
+		assert.EqualError(t, err, fmt.Sprintf("cannot drop column %q of table \"d\" because other objects depend on it", column))
+	}
+
+	_, err = engine.Exec("INSERT INTO d (aa, b, cc) VALUES (NULL, 1, 2)")
+	assert.NoError(t, err)
+}
+
+// newTestEngine returns an engine using the database "demo" with the tables
+// created by the statements.
+func newTestEngine(t *testing.T, statements ...string) (*Engine, storage.Database) {
+	t.Helper()
+
+	catalog := storage.NewCatalog()
+	engine := New(*catalog)
+
+	_, err := engine.CreateDatabase("demo")
+	assert.NoError(t, err)
+	_, err = engine.Use("demo")
+	assert.NoError(t, err)
+
+	for _, stmt := range statements {
+		_, err := engine.Exec(stmt)
+		assert.NoError(t, err, stmt)
+	}
+
+	db, err := catalog.GetDatabase("demo")
+	assert.NoError(t, err)
+
+	return engine, db
+}
+
+// scan returns all rows of the table.
+func scan(t *testing.T, db storage.Database, name string) []sql.Row {
+	t.Helper()
+
+	table, err := db.GetTable(name)
+	assert.NoError(t, err)
+
+	iter, err := table.Scan()
+	assert.NoError(t, err)
+
+	return collect(t, &Result{Rows: iter})
+}
+
+func TestInsert(t *testing.T) {
+	engine, db := newTestEngine(t,
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL DEFAULT 'anonymous', age INT DEFAULT 18 + 2, score FLOAT)",
+	)
+
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{input: "INSERT INTO users (id, name, age, score) VALUES (1, 'Max', 30, 1)"},
+		{input: "INSERT INTO users (name) VALUES ('Tom')"},
+		{input: "INSERT INTO users DEFAULT VALUES"},
+		{input: "INSERT INTO users VALUES (10, DEFAULT, NULL, 2.5)"},
+		{input: "INSERT INTO users (id) VALUES (1)", err: `duplicate key value violates unique constraint "users_pkey"`},
+		{
+			input: "INSERT INTO users (name, age) VALUES (NULL, 1)",
+			err:   `null value in column "name" of relation "users" violates not-null constraint`,
+		},
+		{input: "INSERT INTO users (name, name) VALUES ('a', 'b')", err: `column "name" specified more than once`},
+		{input: "INSERT INTO users (name, age) VALUES ('a')", err: "INSERT has more target columns than expressions"},
+		{input: "INSERT INTO users (name) VALUES ('a'), ('b', 1)", err: "VALUES lists must all be the same length"},
+		{input: "INSERT INTO users (email) VALUES ('a')", err: `column "email" of relation "users" does not exist`},
+		{input: "INSERT INTO users (age) VALUES ('old')", err: `column "age" is of type integer: invalid input syntax for type integer: "old"`},
+	}
+
+	for _, test := range tests {
+		result, err := engine.Exec(test.input)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err, test.input)
+			continue
+		}
+
+		assert.NoError(t, err, test.input)
+		assert.Equal(t, "insert 1\n", result.Message, test.input)
+	}
+
+	assert.Equal(t, []sql.Row{
+		{datatype.NewInteger(1), datatype.NewText("Max"), datatype.NewInteger(30), datatype.NewFloat(1)},
+		{datatype.NewInteger(2), datatype.NewText("Tom"), datatype.NewInteger(20), datatype.NewNull()},
+		{datatype.NewInteger(3), datatype.NewText("anonymous"), datatype.NewInteger(20), datatype.NewNull()},
+		{datatype.NewInteger(10), datatype.NewText("anonymous"), datatype.NewNull(), datatype.NewFloat(2.5)},
+	}, scan(t, db, "users"))
+
+	result, err := engine.Exec("INSERT INTO users (name, score) VALUES ('Ann', 1), ('Bob', DEFAULT);")
+	assert.NoError(t, err)
+	assert.Equal(t, "insert 2\n", result.Message)
+
+	for _, input := range []string{
+		"INSERT INTO users (name) VALUES ('Eve'), (NULL)",
+		"INSERT INTO users (name) VALUES ('Eve'), ('Joe', 1)",
+		"INSERT INTO users (name) VALUES ('Eve') ('Joe')",
+	} {
+		_, err := engine.Exec(input)
+		assert.Error(t, err, input)
+	}
+
+	assert.Equal(t, []sql.Row{
+		{datatype.NewInteger(1), datatype.NewText("Max"), datatype.NewInteger(30), datatype.NewFloat(1)},
+		{datatype.NewInteger(2), datatype.NewText("Tom"), datatype.NewInteger(20), datatype.NewNull()},
+		{datatype.NewInteger(3), datatype.NewText("anonymous"), datatype.NewInteger(20), datatype.NewNull()},
+		{datatype.NewInteger(10), datatype.NewText("anonymous"), datatype.NewNull(), datatype.NewFloat(2.5)},
+		{datatype.NewInteger(12), datatype.NewText("Ann"), datatype.NewInteger(20), datatype.NewFloat(1)},
+		{datatype.NewInteger(13), datatype.NewText("Bob"), datatype.NewInteger(20), datatype.NewNull()},
+	}, scan(t, db, "users"))
+
+	_, err = engine.Exec("INSERT INTO users (name, age) VALUES (NULL, 1)")
+	var constraintErr *ConstraintError
+	assert.ErrorAs(t, err, &constraintErr)
+	assert.Equal(t, "name", constraintErr.Column)
+}
+
+func TestInsert_Now(t *testing.T) {
+	engine, db := newTestEngine(t,
+		"CREATE TABLE events (id INT PRIMARY KEY, created TEXT NOT NULL DEFAULT now())",
+	)
+
+	_, err := engine.Exec("INSERT INTO events DEFAULT VALUES")
+	assert.NoError(t, err)
+
+	rows := scan(t, db, "events")
+	assert.Len(t, rows, 1)
+
+	created, err := time.Parse("2006-01-02 15:04:05.999999-07", rows[0][1].String())
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), created, time.Minute)
+}
+
+func TestUpdate(t *testing.T) {
+	engine, db := newTestEngine(t,
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL DEFAULT 'anonymous', age INT)",
+		"INSERT INTO users (name, age) VALUES ('Max', 30)",
+		"INSERT INTO users (name, age) VALUES ('Tom', 40)",
+		"INSERT INTO users (name) VALUES ('Ann')",
+	)
+
+	tests := []struct {
+		input    string
+		message  string
+		err      string
+		expected []sql.Row
+	}{
+		{
+			input: "UPDATE users SET age = 0 WEHRE id = 1",
+			err:   `syntax error at or near "WEHRE"`,
+		},
+		{
+			input: "DELETE FROM users WHRE id = 1",
+			err:   `syntax error at or near "WHRE"`,
+		},
+		{
+			input: "UPDATE users SET name = NULL WHERE id = 1",
+			err:   `null value in column "name" of relation "users" violates not-null constraint`,
+		},
+		{
+			input: "UPDATE users SET id = 2 WHERE id = 1",
+			err:   `duplicate key value violates unique constraint "users_pkey"`,
+		},
+		{
+			input:   "UPDATE users SET name = DEFAULT, age = age + 1 WHERE age > 35 OR id = 1",
+			message: "update 2\n",
+			expected: []sql.Row{
+				{datatype.NewInteger(1), datatype.NewText("anonymous"), datatype.NewInteger(31)},
+				{datatype.NewInteger(2), datatype.NewText("anonymous"), datatype.NewInteger(41)},
+				{datatype.NewInteger(3), datatype.NewText("Ann"), datatype.NewNull()},
+			},
+		},
+		{
+			input:   "UPDATE users SET id = id + 10 WHERE age = 31",
+			message: "update 1\n",
+			expected: []sql.Row{
+				{datatype.NewInteger(2), datatype.NewText("anonymous"), datatype.NewInteger(41)},
+				{datatype.NewInteger(3), datatype.NewText("Ann"), datatype.NewNull()},
+				{datatype.NewInteger(11), datatype.NewText("anonymous"), datatype.NewInteger(31)},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		result, err := engine.Exec(test.input)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err, test.input)
+			continue
+		}
+
+		assert.NoError(t, err, test.input)
+		assert.Equal(t, test.message, result.Message, test.input)
+		assert.Equal(t, test.expected, scan(t, db, "users"), test.input)
+	}
+}
+
+func TestConstraints(t *testing.T) {
+	engine, db := newTestEngine(t,
+		"CREATE TABLE aircrafts (id INT PRIMARY KEY, code TEXT NOT NULL UNIQUE, model TEXT, seats INT CHECK (seats > 0), UNIQUE (model, seats))",
+		"INSERT INTO aircrafts (code, model, seats) VALUES ('773', 'Boeing 777-300', 402)",
+		"INSERT INTO aircrafts (code, model, seats) VALUES ('SU9', 'Sukhoi Superjet-100', 97)",
+	)
+
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{input: "INSERT INTO aircrafts (code, model) VALUES ('CN1', 'Cessna 208 Caravan')"},
+		{input: "INSERT INTO aircrafts (code, model) VALUES ('CR2', 'Cessna 208 Caravan')"},
+		{input: "INSERT INTO aircrafts (code, seats) VALUES ('773', 1)", err: `duplicate key value violates unique constraint "aircrafts_code_key"`},
+		{
+			input: "INSERT INTO aircrafts (code, model, seats) VALUES ('77W', 'Boeing 777-300', 402)",
+			err:   `duplicate key value violates unique constraint "aircrafts_model_seats_key"`,
+		},
+		{input: "INSERT INTO aircrafts (code, seats) VALUES ('320', 0)", err: `new row for relation "aircrafts" violates check constraint "aircrafts_seats_check"`},
+		{input: "UPDATE aircrafts SET seats = seats - 100", err: `new row for relation "aircrafts" violates check constraint "aircrafts_seats_check"`},
+		{input: "UPDATE aircrafts SET code = 'SU9' WHERE id = 1", err: `duplicate key value violates unique constraint "aircrafts_code_key"`},
+	}
+
+	for _, test := range tests {
+		_, err := engine.Exec(test.input)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err, test.input)
+			continue
+		}
+
+		assert.NoError(t, err, test.input)
+	}
+
+	assert.Equal(t, []sql.Row{
+		{datatype.NewInteger(1), datatype.NewText("773"), datatype.NewText("Boeing 777-300"), datatype.NewInteger(402)},
+		{datatype.NewInteger(2), datatype.NewText("SU9"), datatype.NewText("Sukhoi Superjet-100"), datatype.NewInteger(97)},
+		{datatype.NewInteger(3), datatype.NewText("CN1"), datatype.NewText("Cessna 208 Caravan"), datatype.NewNull()},
+		{datatype.NewInteger(4), datatype.NewText("CR2"), datatype.NewText("Cessna 208 Caravan"), datatype.NewNull()},
+	}, scan(t, db, "aircrafts"))
+
+	_, err := engine.Exec("INSERT INTO aircrafts (code, seats) VALUES ('320', -1)")
+	var constraintErr *ConstraintError
+	assert.ErrorAs(t, err, &constraintErr)
+	assert.Equal(t, "aircrafts_seats_check", constraintErr.Constraint)
+
+	_, err = engine.Exec("CREATE TABLE d (id INT PRIMARY KEY, a INT CHECK (a IS DISTINCT FROM 3))")
+	assert.NoError(t, err)
+
+	_, err = engine.Exec("INSERT INTO d (a) VALUES (NULL), (4)")
+	assert.NoError(t, err)
+
+	_, err = engine.Exec("INSERT INTO d (a) VALUES (3)")
+	assert.EqualError(t, err, `new row for relation "d" violates check constraint "d_a_check"`)
+}
+
+func TestForeignKey(t *testing.T) {
+	newEngine := func(t *testing.T) (*Engine, storage.Database) {
+		return newTestEngine(t,
+			"CREATE TABLE aircrafts (id INT PRIMARY KEY, code TEXT UNIQUE)",
+			"CREATE TABLE flights (id INT PRIMARY KEY, aircraft_code TEXT REFERENCES aircrafts (code) ON DELETE CASCADE ON UPDATE CASCADE)",
+			"CREATE TABLE tickets (id INT PRIMARY KEY, flight_id INT, CONSTRAINT tickets_flight FOREIGN KEY (flight_id) REFERENCES flights ON DELETE SET NULL)",
+			"CREATE TABLE seats (id INT PRIMARY KEY, aircraft_code TEXT REFERENCES aircrafts (code), no TEXT)",
+			"INSERT INTO aircrafts (code) VALUES ('773')",
+			"INSERT INTO aircrafts (code) VALUES ('SU9')",
+			"INSERT INTO flights (aircraft_code) VALUES ('773')",
+			"INSERT INTO flights (aircraft_code) VALUES ('SU9')",
+			"INSERT INTO tickets (flight_id) VALUES (1)",
+			"INSERT INTO tickets (flight_id) VALUES (2)",
+		)
+	}
+
+	t.Run("insert", func(t *testing.T) {
+		engine, db := newEngine(t)
+
+		_, err := engine.Exec("INSERT INTO flights (aircraft_code) VALUES ('320')")
+		assert.EqualError(t, err, `insert or update on table "flights" violates foreign key constraint "flights_aircraft_code_fkey"`)
+
+		_, err = engine.Exec("INSERT INTO flights (aircraft_code) VALUES (NULL)")
+		assert.NoError(t, err)
+
+		assert.Len(t, scan(t, db, "flights"), 3)
+	})
+
+	t.Run("delete cascade", func(t *testing.T) {
+		engine, db := newEngine(t)
+
+		result, err := engine.Exec("DELETE FROM aircrafts WHERE code = '773'")
+		assert.NoError(t, err)
+		assert.Equal(t, "delete 1\n", result.Message)
+
+		assert.Equal(t, []sql.Row{
+			{datatype.NewInteger(2), datatype.NewText("SU9")},
+		}, scan(t, db, "flights"))
+		assert.Equal(t, []sql.Row{
+			{datatype.NewInteger(1), datatype.NewNull()},
+			{datatype.NewInteger(2), datatype.NewInteger(2)},
+		}, scan(t, db, "tickets"))
+	})
+
+	t.Run("update cascade", func(t *testing.T) {
+		engine, db := newEngine(t)
+
+		_, err := engine.Exec("UPDATE aircrafts SET code = '77W' WHERE code = '773'")
+		assert.NoError(t, err)
+
+		assert.Equal(t, []sql.Row{
+			{datatype.NewInteger(1), datatype.NewText("77W")},
+			{datatype.NewInteger(2), datatype.NewText("SU9")},
+		}, scan(t, db, "flights"))
+	})
+
+	t.Run("restrict", func(t *testing.T) {
+		engine, db := newEngine(t)
+
+		_, err := engine.Exec("INSERT INTO seats (aircraft_code, no) VALUES ('SU9', '1A')")
+		assert.NoError(t, err)
+
+		_, err = engine.Exec("DELETE FROM aircrafts")
+		assert.EqualError(t, err, `update or delete on table "aircrafts" violates foreign key constraint "seats_aircraft_code_fkey" on table "seats"`)
+
+		// The failed statement changed nothing.
+		assert.Len(t, scan(t, db, "aircrafts"), 2)
+		assert.Len(t, scan(t, db, "flights"), 2)
+		assert.Len(t, scan(t, db, "tickets"), 2)
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		engine, db := newEngine(t)
+
+		for _, input := range []string{
+			"INSERT INTO aircrafts (code) VALUES ('320')",
+			"INSERT INTO flights (aircraft_code) VALUES ('320')",
+			"INSERT INTO flights (aircraft_code) VALUES ('773')",
+			"INSERT INTO tickets (flight_id) VALUES (4)",
+			"INSERT INTO seats (aircraft_code, no) VALUES ('320', '1A')",
+		} {
+			_, err := engine.Exec(input)
+			assert.NoError(t, err, input)
+		}
+
+		tables := []string{"aircrafts", "flights", "tickets", "seats"}
+
+		before := make(map[string][]sql.Row)
+		for _, name := range tables {
+			before[name] = scan(t, db, name)
+		}
+
+		// The rows of 773 and SU9 are deleted with the ones referencing them
+		// before the seat referencing 320 fails the statement.
+		_, err := engine.Exec("DELETE FROM aircrafts")
+		assert.EqualError(t, err, `update or delete on table "aircrafts" violates foreign key constraint "seats_aircraft_code_fkey" on table "seats"`)
+
+		// The rows are restored in their order.
+		for _, name := range tables {
+			assert.Equal(t, before[name], scan(t, db, name), name)
+		}
+
+		_, err = engine.Exec("UPDATE flights SET id = 2 WHERE aircraft_code = '320'")
+		assert.EqualError(t, err, `duplicate key value violates unique constraint "flights_pkey"`)
+		assert.Equal(t, before["flights"], scan(t, db, "flights"))
+	})
+
+	t.Run("multicolumn key", func(t *testing.T) {
+		engine, db := newTestEngine(t,
+			"CREATE TABLE routes (id INT PRIMARY KEY, origin TEXT, destination TEXT, CONSTRAINT routes_key UNIQUE (origin, destination))",
+			"CREATE TABLE legs (id INT PRIMARY KEY, dst TEXT, src TEXT, FOREIGN KEY (dst, src) REFERENCES routes (destination, origin) ON UPDATE CASCADE)",
+			"INSERT INTO routes (origin, destination) VALUES ('OVB', 'DME'), ('DME', 'OVB')",
+			"INSERT INTO legs (dst, src) VALUES ('DME', 'OVB')",
+		)
+
+		_, err := engine.Exec("INSERT INTO legs (dst, src) VALUES ('DME', 'LED')")
+		assert.EqualError(t, err, `insert or update on table "legs" violates foreign key constraint "legs_dst_src_fkey"`)
+
+		_, err = engine.Exec("UPDATE routes SET origin = 'DME', destination = 'OVB' WHERE id = 1")
+		assert.EqualError(t, err, `duplicate key value violates unique constraint "routes_key"`)
+
+		_, err = engine.Exec("UPDATE routes SET destination = 'SVO' WHERE id = 1")
+		assert.NoError(t, err)
+		assert.Equal(t, []sql.Row{
+			{datatype.NewInteger(1), datatype.NewText("SVO"), datatype.NewText("OVB")},
+		}, scan(t, db, "legs"))
+
+		// Without the unique constraint the referenced rows are still found.
+		_, err = engine.Exec("ALTER TABLE routes DROP CONSTRAINT routes_key")
+		assert.NoError(t, err)
+
+		_, err = engine.Exec("INSERT INTO legs (dst, src) VALUES ('OVB', 'DME')")
+		assert.NoError(t, err)
+
+		_, err = engine.Exec("INSERT INTO legs (dst, src) VALUES ('OVB', 'LED')")
+		assert.EqualError(t, err, `insert or update on table "legs" violates foreign key constraint "legs_dst_src_fkey"`)
+	})
+
+	t.Run("drop and truncate", func(t *testing.T) {
+		engine, db := newEngine(t)
+
+		_, err := engine.Exec("DROP TABLE flights")
+		assert.EqualError(t, err, `cannot drop table "flights" because other objects depend on it`)
+
+		_, err = engine.Exec("TRUNCATE flights")
+		assert.EqualError(t, err, `cannot truncate a table referenced in a foreign key constraint: table "tickets" references "flights"`)
+
+		_, err = engine.Exec("TRUNCATE flights, tickets")
+		assert.NoError(t, err)
+
+		result, err := engine.Exec("DROP TABLE flights CASCADE")
+		assert.NoError(t, err)
+		assert.Equal(t, "drop cascades to constraint tickets_flight on table tickets\ndrop table flights\n", result.Message)
+
+		table, err := db.GetTable("tickets")
+		assert.NoError(t, err)
+		assert.Empty(t, table.Constraints())
+	})
+
+	t.Run("alter table", func(t *testing.T) {
+		engine, db := newEngine(t)
+
+		for _, input := range []string{
+			"ALTER TABLE aircrafts RENAME COLUMN code TO aircraft_code",
+			"ALTER TABLE aircrafts RENAME TO planes",
+			"ALTER TABLE tickets DROP CONSTRAINT tickets_flight",
+			"ALTER TABLE tickets ADD CONSTRAINT tickets_flight_check CHECK (flight_id > 0)",
+		} {
+			_, err := engine.Exec(input)
+			assert.NoError(t, err, input)
+		}
+
+		table, err := db.GetTable("flights")
+		assert.NoError(t, err)
+		assert.Equal(t, []storage.Constraint{
+			{
+				Name:    "flights_aircraft_code_fkey",
+				Type:    storage.ForeignKey,
+				Columns: []string{"aircraft_code"},
+				Reference: &storage.Reference{
+					Table:    "planes",
+					Columns:  []string{"aircraft_code"},
+					OnDelete: storage.Cascade,
+					OnUpdate: storage.Cascade,
+				},
+			},
+		}, table.Constraints())
+
+		tests := []struct {
+			input string
+			err   string
+		}{
+			{
+				input: "ALTER TABLE planes DROP COLUMN aircraft_code",
+				err:   `cannot drop column "aircraft_code" of table "planes" because other objects depend on it`,
+			},
+			{
+				input: "ALTER TABLE flights ALTER COLUMN aircraft_code TYPE INT",
+				err:   `cannot change the type of column "aircraft_code" used in a foreign key constraint`,
+			},
+			{
+				input: "ALTER TABLE tickets ADD CHECK (flight_id > 1)",
+				err:   `new row for relation "tickets" violates check constraint "tickets_check"`,
+			},
+			{
+				input: "ALTER TABLE tickets ADD UNIQUE (id), ADD UNIQUE (id)",
+				err:   `constraint "tickets_id_key" for relation "tickets" already exists`,
+			},
+			{
+				input: "ALTER TABLE tickets ADD COLUMN seat TEXT DEFAULT '1A' UNIQUE",
+				err:   `duplicate key value violates unique constraint "tickets_seat_key"`,
+			},
+			{
+				input: "ALTER TABLE tickets ADD FOREIGN KEY (flight_id) REFERENCES planes (aircraft_code)",
+				err:   `foreign key constraint "tickets_flight_id_fkey" cannot be implemented: key columns "flight_id" and "aircraft_code" are of incompatible types: integer and text`,
+			},
+			{
+				input: "ALTER TABLE seats ADD FOREIGN KEY (no) REFERENCES flights (aircraft_code)",
+				err:   `there is no unique constraint matching given keys for referenced table "flights"`,
+			},
+		}
+
+		for _, test := range tests {
+			_, err := engine.Exec(test.input)
+			assert.EqualError(t, err, test.err, test.input)
+		}
+
+		_, err = engine.Exec("UPDATE planes SET aircraft_code = 'SSJ' WHERE aircraft_code = 'SU9'")
+		assert.NoError(t, err)
+		assert.Equal(t, []sql.Row{
+			{datatype.NewInteger(1), datatype.NewText("773")},
+			{datatype.NewInteger(2), datatype.NewText("SSJ")},
+		}, scan(t, db, "flights"))
+	})
+
+	t.Run("invalid definitions", func(t *testing.T) {
+		engine, _ := newEngine(t)
+
+		tests := []struct {
+			input string
+			err   string
+		}{
+			{
+				input: "CREATE TABLE crew (id INT PRIMARY KEY, flight_id INT REFERENCES routes)",
+				err:   `table "routes" not found`,
+			},
+			{
+				input: "CREATE TABLE crew (id INT PRIMARY KEY, code TEXT REFERENCES aircrafts (id, code))",
+				err:   "number of referencing and referenced columns for foreign key disagree",
+			},
+			{
+				input: "CREATE TABLE crew (id INT PRIMARY KEY, UNIQUE (name))",
+				err:   `column "name" of relation "crew" does not exist`,
+			},
+			{
+				input: "CREATE TABLE crew (id INT PRIMARY KEY, CHECK (age > 18))",
+				err:   `column "age" does not exist`,
+			},
+		}
+
+		for _, test := range tests {
+			_, err := engine.Exec(test.input)
+			assert.EqualError(t, err, test.err, test.input)
+		}
+
+		_, err := engine.Exec("CREATE TABLE crew (id INT PRIMARY KEY, lead INT REFERENCES crew (id), CHECK (lead != id))")
+		assert.NoError(t, err)
+
+		_, err = engine.Exec("INSERT INTO crew (id, lead) VALUES (1, 1)")
+		assert.EqualError(t, err, `new row for relation "crew" violates check constraint "crew_check"`)
+
+		_, err = engine.Exec("INSERT INTO crew (id) VALUES (1)")
+		assert.NoError(t, err)
+
+		_, err = engine.Exec("INSERT INTO crew (id, lead) VALUES (2, 1)")
+		assert.NoError(t, err)
+	})
+}
+
+func TestSelect_Null(t *testing.T) {
+	engine, _ := newTestEngine(t,
+		"CREATE TABLE aircrafts (id INT PRIMARY KEY, code TEXT, range INT)",
+		"INSERT INTO aircrafts (code, range) VALUES ('773', 11100)",
+		"INSERT INTO aircrafts (code) VALUES ('SU9')",
+		"INSERT INTO aircrafts (range) VALUES (1200)",
+	)
+
+	null := datatype.NewNull()
+
+	tests := []struct {
+		input    string
+		columns  []string
+		expected []sql.Row
+		err      string
+	}{
+		{
+			input:   "SELECT NULL IS NULL, NULL = NULL, 1 IS NOT NULL, NULL IS DISTINCT FROM NULL, 1 IS DISTINCT FROM NULL, 1 IS NOT DISTINCT FROM 1.0",
+			columns: []string{"?column?", "?column?", "?column?", "?column?", "?column?", "?column?"},
+			expected: []sql.Row{
+				{
+					datatype.NewBoolean(true), null, datatype.NewBoolean(true),
+					datatype.NewBoolean(false), datatype.NewBoolean(true), datatype.NewBoolean(true),
+				},
+			},
+		},
+		{
+			input:    "SELECT coalesce(NULL, NULL, 2, 1 / 0), coalesce(NULL), nullif(1, 1), nullif(1, 2), nullif(NULL, 1)",
+			columns:  []string{"coalesce", "coalesce", "nullif", "nullif", "nullif"},
+			expected: []sql.Row{{datatype.NewInteger(2), null, null, datatype.NewInteger(1), null}},
+		},
+		{
+			input:    "SELECT id FROM aircrafts WHERE range IS NULL OR code IS NULL",
+			columns:  []string{"id"},
+			expected: []sql.Row{{datatype.NewInteger(2)}, {datatype.NewInteger(3)}},
+		},
+		{
+			input:    "SELECT id FROM aircrafts WHERE range > 0",
+			columns:  []string{"id"},
+			expected: []sql.Row{{datatype.NewInteger(1)}, {datatype.NewInteger(3)}},
+		},
+		{
+			input:    "SELECT id, coalesce(code, 'none') FROM aircrafts WHERE code IS DISTINCT FROM 'SU9'",
+			columns:  []string{"id", "coalesce"},
+			expected: []sql.Row{{datatype.NewInteger(1), datatype.NewText("773")}, {datatype.NewInteger(3), datatype.NewText("none")}},
+		},
+		{
+			input:    "SELECT id FROM aircrafts ORDER BY range",
+			columns:  []string{"id"},
+			expected: []sql.Row{{datatype.NewInteger(3)}, {datatype.NewInteger(1)}, {datatype.NewInteger(2)}},
+		},
+		{
+			input:    "SELECT id FROM aircrafts ORDER BY range DESC",
+			columns:  []string{"id"},
+			expected: []sql.Row{{datatype.NewInteger(2)}, {datatype.NewInteger(1)}, {datatype.NewInteger(3)}},
+		},
+		{
+			input:    "SELECT id FROM aircrafts ORDER BY range ASC NULLS FIRST LIMIT 2",
+			columns:  []string{"id"},
+			expected: []sql.Row{{datatype.NewInteger(2)}, {datatype.NewInteger(3)}},
+		},
+		{
+			input:    "SELECT id FROM aircrafts ORDER BY range DESC NULLS LAST OFFSET 1",
+			columns:  []string{"id"},
+			expected: []sql.Row{{datatype.NewInteger(3)}, {datatype.NewInteger(2)}},
+		},
+		{
+			input:    "SELECT * FROM aircrafts WHERE code IS NULL",
+			columns:  []string{"id", "code", "range"},
+			expected: []sql.Row{{datatype.NewInteger(3), null, datatype.NewInteger(1200)}},
+		},
+		{input: "SELECT nullif(1)", err: "function nullif(integer) does not exist"},
+		{input: "SELECT * ", err: "SELECT * with no tables specified is not valid"},
+	}
+
+	for _, test := range tests {
+		result, err := engine.Exec(test.input)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err, test.input)
+			continue
+		}
+
+		assert.NoError(t, err, test.input)
+		assert.Equal(t, test.columns, result.Columns, test.input)
+		assert.Equal(t, test.expected, collect(t, result), test.input)
+	}
+}
+
+func TestSelect_GroupBy(t *testing.T) {
+	engine, _ := newTestEngine(t,
+		"CREATE TABLE airports (id INT PRIMARY KEY, code TEXT, city TEXT, timezone TEXT, lat FLOAT)",
+		"INSERT INTO airports (code, city, timezone, lat) VALUES ('SVO', 'Moscow', 'Europe/Moscow', 55.97)",
+		"INSERT INTO airports (code, city, timezone, lat) VALUES ('VKO', 'Moscow', 'Europe/Moscow', 55.59)",
+		"INSERT INTO airports (code, city, timezone, lat) VALUES ('LED', 'St. Petersburg', 'Europe/Moscow', 59.8)",
+		"INSERT INTO airports (code, city, timezone, lat) VALUES ('KUF', 'Samara', 'Europe/Samara', 53.5)",
+		"INSERT INTO airports (code, city, timezone) VALUES ('SVX', 'Yekaterinburg', 'Asia/Yekaterinburg')",
+		"INSERT INTO airports (code, city) VALUES ('XXX', NULL)",
+	)
+
+	integer, text, float := datatype.NewInteger, datatype.NewText, datatype.NewFloat
+	null := datatype.NewNull()
+
+	tests := []struct {
+		input    string
+		columns  []string
+		expected []sql.Row
+		err      string
+	}{
+		{
+			input:    "SELECT timezone, COUNT(*) FROM airports GROUP BY timezone HAVING COUNT(*) > 2",
+			columns:  []string{"timezone", "count"},
+			expected: []sql.Row{{text("Europe/Moscow"), integer(3)}},
+		},
+		{
+			input:   "SELECT timezone, count(*), count(lat), count(DISTINCT city) FROM airports GROUP BY 1 ORDER BY timezone",
+			columns: []string{"timezone", "count", "count", "count"},
+			expected: []sql.Row{
+				{text("Asia/Yekaterinburg"), integer(1), integer(0), integer(1)},
+				{text("Europe/Moscow"), integer(3), integer(3), integer(2)},
+				{text("Europe/Samara"), integer(1), integer(1), integer(1)},
+				{null, integer(1), integer(0), integer(0)},
+			},
+		},
+		{
+			input:    "SELECT count(*), sum(id), avg(id), min(city), max(lat), sum(lat) > 200 FROM airports",
+			columns:  []string{"count", "sum", "avg", "min", "max", "?column?"},
+			expected: []sql.Row{{integer(6), integer(21), float(3.5), text("Moscow"), float(59.8), datatype.NewBoolean(true)}},
+		},
+		{
+			input:    "SELECT count(*), sum(id), max(code) FROM airports WHERE id > 10",
+			columns:  []string{"count", "sum", "max"},
+			expected: []sql.Row{{integer(0), null, null}},
+		},
+		{
+			input:   "SELECT city FROM airports WHERE id > 10 GROUP BY city",
+			columns: []string{"city"},
+		},
+		{
+			input:   "SELECT city IS NULL, count(*) + 1 FROM airports GROUP BY city IS NULL",
+			columns: []string{"?column?", "?column?"},
+			expected: []sql.Row{
+				{datatype.NewBoolean(false), integer(6)},
+				{datatype.NewBoolean(true), integer(2)},
+			},
+		},
+		{
+			input:    "SELECT max(timezone) FROM airports GROUP BY city HAVING count(*) > 1",
+			columns:  []string{"max"},
+			expected: []sql.Row{{text("Europe/Moscow")}},
+		},
+		{
+			input: "SELECT city, count(*) FROM airports",
+			err:   `column "airports.city" must appear in the GROUP BY clause or be used in an aggregate function`,
+		},
+		{
+			input: "SELECT timezone FROM airports GROUP BY city",
+			err:   `column "airports.timezone" must appear in the GROUP BY clause or be used in an aggregate function`,
+		},
+		{
+			input: "SELECT * FROM airports GROUP BY city",
+			err:   `column "airports.id" must appear in the GROUP BY clause or be used in an aggregate function`,
+		},
+		{input: "SELECT id FROM airports WHERE count(*) > 1", err: "aggregate functions are not allowed in WHERE"},
+		{input: "SELECT count(*) FROM airports GROUP BY count(*)", err: "aggregate functions are not allowed in GROUP BY"},
+		{input: "SELECT count(*) FROM airports GROUP BY 2", err: "GROUP BY position 2 is not in select list"},
+		{input: "SELECT sum(max(id)) FROM airports", err: "aggregate function calls cannot be nested"},
+		{input: "SELECT sum(city) FROM airports", err: "function sum(text) does not exist"},
+		{input: "SELECT sum(*) FROM airports", err: "sum(*) is not valid"},
+		{input: "SELECT count(id, city) FROM airports", err: "function count() takes exactly one argument"},
+	}
+
+	for _, test := range tests {
+		result, err := engine.Exec(test.input)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err, test.input)
+			continue
+		}
+
+		assert.NoError(t, err, test.input)
+		assert.Equal(t, test.columns, result.Columns, test.input)
+		assert.Equal(t, test.expected, collect(t, result), test.input)
+	}
+}
+
+func TestSelect_Join(t *testing.T) {
+	engine, _ := newTestEngine(t,
+		"CREATE TABLE airports (id INT PRIMARY KEY, code TEXT, city TEXT)",
+		"INSERT INTO airports (code, city) VALUES ('SVO', 'Moscow')",
+		"INSERT INTO airports (code, city) VALUES ('LED', 'St. Petersburg')",
+		"INSERT INTO airports (code, city) VALUES ('KUF', 'Samara')",
+		"CREATE TABLE flights (id INT PRIMARY KEY, departure TEXT, arrival TEXT)",
+		"INSERT INTO flights (departure, arrival) VALUES ('SVO', 'LED')",
+		"INSERT INTO flights (departure, arrival) VALUES ('LED', 'SVO')",
+		"INSERT INTO flights (departure, arrival) VALUES ('SVO', 'XXX')",
+		"INSERT INTO flights (arrival) VALUES ('SVO')",
+		"CREATE TABLE runways (id INT PRIMARY KEY, length FLOAT)",
+		"INSERT INTO runways (id, length) VALUES (1, 3700.0)",
+		"INSERT INTO runways (id, length) VALUES (3, 3000.5)",
+		"INSERT INTO runways (id, length) VALUES (4, 2500.0)",
+	)
+
+	integer, text, float := datatype.NewInteger, datatype.NewText, datatype.NewFloat
+	null := datatype.NewNull()
+
+	tests := []struct {
+		input    string
+		columns  []string
+		expected []sql.Row
+		err      string
+	}{
+		{
+			input:   "SELECT f.id, a.city FROM flights f JOIN airports a ON f.departure = a.code",
+			columns: []string{"id", "city"},
+			expected: []sql.Row{
+				{integer(1), text("Moscow")},
+				{integer(2), text("St. Petersburg")},
+				{integer(3), text("Moscow")},
+			},
+		},
+		{
+			input:   "SELECT f.id, a.city FROM flights AS f INNER JOIN airports AS a ON a.code = f.departure AND f.id > 1",
+			columns: []string{"id", "city"},
+			expected: []sql.Row{
+				{integer(2), text("St. Petersburg")},
+				{integer(3), text("Moscow")},
+			},
+		},
+		{
+			input:   "SELECT f.id, a.city FROM flights f LEFT JOIN airports a ON f.departure = a.code",
+			columns: []string{"id", "city"},
+			expected: []sql.Row{
+				{integer(1), text("Moscow")},
+				{integer(2), text("St. Petersburg")},
+				{integer(3), text("Moscow")},
+				{integer(4), null},
+			},
+		},
+		{
+			input:   "SELECT f.id, a.city FROM flights f RIGHT OUTER JOIN airports a ON f.arrival = a.code",
+			columns: []string{"id", "city"},
+			expected: []sql.Row{
+				{integer(1), text("St. Petersburg")},
+				{integer(2), text("Moscow")},
+				{integer(4), text("Moscow")},
+				{null, text("Samara")},
+			},
+		},
+		{
+			input:   "SELECT f.id, arrival, a.code FROM flights f FULL JOIN airports a ON arrival = code",
+			columns: []string{"id", "arrival", "code"},
+			expected: []sql.Row{
+				{integer(1), text("LED"), text("LED")},
+				{integer(2), text("SVO"), text("SVO")},
+				{integer(3), text("XXX"), null},
+				{integer(4), text("SVO"), text("SVO")},
+				{null, null, text("KUF")},
+			},
+		},
+		{
+			input:   "SELECT * FROM airports JOIN runways USING (id)",
+			columns: []string{"id", "code", "city", "length"},
+			expected: []sql.Row{
+				{integer(1), text("SVO"), text("Moscow"), float(3700)},
+				{integer(3), text("KUF"), text("Samara"), float(3000.5)},
+			},
+		},
+		{
+			input:   "SELECT id, airports.id, runways.id, code FROM airports FULL JOIN runways USING (id)",
+			columns: []string{"id", "id", "id", "code"},
+			expected: []sql.Row{
+				{integer(1), integer(1), integer(1), text("SVO")},
+				{integer(2), integer(2), null, text("LED")},
+				{integer(3), integer(3), integer(3), text("KUF")},
+				{integer(4), null, integer(4), null},
+			},
+		},
+		{
+			input:   "SELECT a.code, b.code FROM airports a JOIN airports b ON a.id < b.id",
+			columns: []string{"code", "code"},
+			expected: []sql.Row{
+				{text("SVO"), text("LED")},
+				{text("SVO"), text("KUF")},
+				{text("LED"), text("KUF")},
+			},
+		},
+		{
+			input:   "SELECT r.*, a.code FROM runways r, airports a WHERE r.id = a.id",
+			columns: []string{"id", "length", "code"},
+			expected: []sql.Row{
+				{integer(1), float(3700), text("SVO")},
+				{integer(3), float(3000.5), text("KUF")},
+			},
+		},
+		{
+			input:    "SELECT count(*) FROM airports CROSS JOIN flights, runways",
+			columns:  []string{"count"},
+			expected: []sql.Row{{integer(36)}},
+		},
+		{
+			input:   "SELECT city, count(f.id) FROM airports a LEFT JOIN flights f ON a.code = f.departure GROUP BY a.city ORDER BY city",
+			columns: []string{"city", "count"},
+			expected: []sql.Row{
+				{text("Moscow"), integer(2)},
+				{text("Samara"), integer(0)},
+				{text("St. Petersburg"), integer(1)},
+			},
+		},
+		{input: "SELECT id FROM airports, flights", err: `column reference "id" is ambiguous`},
+		{input: "SELECT x.id FROM airports", err: `missing FROM-clause entry for table "x"`},
+		{input: "SELECT x.* FROM airports", err: `missing FROM-clause entry for table "x"`},
+		{input: "SELECT airports.name FROM airports", err: `column "airports.name" does not exist`},
+		{input: "SELECT a.id FROM airports a JOIN airports a ON true", err: `table name "a" specified more than once`},
+		{input: "SELECT * FROM airports JOIN flights", err: `expected ON or USING but found "EOF"`},
+		{input: "SELECT * FROM airports JOIN flights USING (code)", err: `column "code" specified in USING clause does not exist in right table`},
+		{input: "SELECT * FROM airports JOIN flights USING (id, id)", err: `column name "id" appears more than once in USING clause`},
+		{input: "SELECT * FROM airports a JOIN flights f ON a.id = f.departure", err: "cannot compare integer with text"},
+		{input: "SELECT * FROM airports a JOIN flights f ON count(*) > 1", err: "aggregate functions are not allowed in JOIN conditions"},
+	}
+
+	for _, test := range tests {
+		result, err := engine.Exec(test.input)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err, test.input)
+			continue
+		}
+
+		assert.NoError(t, err, test.input)
+		assert.Equal(t, test.columns, result.Columns, test.input)
+		assert.Equal(t, test.expected, collect(t, result), test.input)
+	}
+}
+
+func TestSelect_Subquery(t *testing.T) {
+	engine, db := newTestEngine(t,
+		"CREATE TABLE airports (id INT PRIMARY KEY, code TEXT, city TEXT)",
+		"INSERT INTO airports (code, city) VALUES ('SVO', 'Moscow')",
+		"INSERT INTO airports (code, city) VALUES ('LED', 'St. Petersburg')",
+		"INSERT INTO airports (code, city) VALUES ('KUF', 'Samara')",
+		"CREATE TABLE flights (id INT PRIMARY KEY, departure TEXT, arrival TEXT)",
+		"INSERT INTO flights (departure, arrival) VALUES ('SVO', 'LED')",
+		"INSERT INTO flights (departure, arrival) VALUES ('LED', 'SVO')",
+		"INSERT INTO flights (departure, arrival) VALUES ('SVO', 'XXX')",
+		"INSERT INTO flights (arrival) VALUES ('SVO')",
+	)
+
+	integer, text := datatype.NewInteger, datatype.NewText
+	boolean, null := datatype.NewBoolean, datatype.NewNull()
+
+	tests := []struct {
+		input    string
+		columns  []string
+		expected []sql.Row
+		err      string
+	}{
+		{
+			input:    "SELECT (SELECT count(*) FROM flights), (SELECT city FROM airports WHERE id = 4)",
+			columns:  []string{"count", "city"},
+			expected: []sql.Row{{integer(4), null}},
+		},
+		{
+			input:   "SELECT code, (SELECT count(*) FROM flights WHERE departure = code) FROM airports",
+			columns: []string{"code", "count"},
+			expected: []sql.Row{
+				{text("SVO"), integer(2)},
+				{text("LED"), integer(1)},
+				{text("KUF"), integer(0)},
+			},
+		},
+		{
+			input:    "SELECT city FROM airports a WHERE (SELECT count(*) FROM flights f WHERE f.arrival = a.code) > 1",
+			columns:  []string{"city"},
+			expected: []sql.Row{{text("Moscow")}},
+		},
+		{
+			input:   "SELECT id FROM flights WHERE departure IN (SELECT code FROM airports WHERE id < 3)",
+			columns: []string{"id"},
+			expected: []sql.Row{
+				{integer(1)},
+				{integer(2)},
+				{integer(3)},
+			},
+		},
+		{
+			input:    "SELECT code FROM airports WHERE code NOT IN (SELECT departure FROM flights WHERE departure IS NOT NULL)",
+			columns:  []string{"code"},
+			expected: []sql.Row{{text("KUF")}},
+		},
+		{
+			input:   "SELECT code FROM airports WHERE code NOT IN (SELECT departure FROM flights)",
+			columns: []string{"code"},
+		},
+		{
+			input:   "SELECT id, departure IN (SELECT code FROM airports), departure NOT IN (SELECT departure FROM flights WHERE id > 4) FROM flights",
+			columns: []string{"id", "?column?", "?column?"},
+			expected: []sql.Row{
+				{integer(1), boolean(true), boolean(true)},
+				{integer(2), boolean(true), boolean(true)},
+				{integer(3), boolean(true), boolean(true)},
+				{integer(4), null, boolean(true)},
+			},
+		},
+		{
+			input:   "SELECT city FROM airports a WHERE EXISTS (SELECT * FROM flights WHERE departure = a.code)",
+			columns: []string{"city"},
+			expected: []sql.Row{
+				{text("Moscow")},
+				{text("St. Petersburg")},
+			},
+		},
+		{
+			input:    "SELECT city FROM airports a WHERE NOT EXISTS (SELECT * FROM flights WHERE departure = a.code)",
+			columns:  []string{"city"},
+			expected: []sql.Row{{text("Samara")}},
+		},
+		{
+			input:   "SELECT t.departure, t.count FROM (SELECT departure, count(*) FROM flights GROUP BY departure) AS t WHERE t.count > 0 ORDER BY count",
+			columns: []string{"departure", "count"},
+			expected: []sql.Row{
+				{text("LED"), integer(1)},
+				{null, integer(1)},
+				{text("SVO"), integer(2)},
+			},
+		},
+		{
+			input:   "SELECT a.city, t.id FROM airports a JOIN (SELECT id, arrival FROM flights) t ON t.arrival = a.code",
+			columns: []string{"city", "id"},
+			expected: []sql.Row{
+				{text("Moscow"), integer(2)},
+				{text("Moscow"), integer(4)},
+				{text("St. Petersburg"), integer(1)},
+			},
+		},
+		{
+			input:    "SELECT code FROM airports a WHERE code = (SELECT min(code) FROM airports WHERE id > a.id OR id = a.id)",
+			columns:  []string{"code"},
+			expected: []sql.Row{{text("KUF")}},
+		},
+		{input: "SELECT * FROM (SELECT id FROM flights)", err: "subquery in FROM must have an alias"},
+		{input: "SELECT (SELECT id FROM flights)", err: "more than one row returned by a subquery used as an expression"},
+		{input: "SELECT (SELECT id, code FROM airports WHERE id = 1)", err: "subquery must return only one column"},
+		{input: "SELECT 1 IN (SELECT id, code FROM airports)", err: "subquery has too many columns"},
+		{input: "SELECT id FROM flights WHERE EXISTS (SELECT * FROM airports WHERE x.code = departure)", err: `missing FROM-clause entry for table "x"`},
+		{input: "SELECT id FROM flights WHERE (SELECT city FROM airports WHERE code = departure) = 1", err: "cannot compare text with integer"},
+	}
+
+	for _, test := range tests {
+		result, err := engine.Exec(test.input)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err, test.input)
+			continue
+		}
+
+		assert.NoError(t, err, test.input)
+		assert.Equal(t, test.columns, result.Columns, test.input)
+		assert.Equal(t, test.expected, collect(t, result), test.input)
+	}
+
+	_, err := engine.Exec("UPDATE airports SET city = (SELECT arrival FROM flights WHERE id = 3) WHERE code NOT IN (SELECT arrival FROM flights)")
+	assert.NoError(t, err)
+	_, err = engine.Exec("DELETE FROM flights WHERE NOT EXISTS (SELECT * FROM airports WHERE code = departure)")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []sql.Row{
+		{integer(1), text("SVO"), text("Moscow")},
+		{integer(2), text("LED"), text("St. Petersburg")},
+		{integer(3), text("KUF"), text("XXX")},
+	}, scan(t, db, "airports"))
+	assert.Len(t, scan(t, db, "flights"), 3)
+}
+
+func TestSelect_With(t *testing.T) {
+	engine, db := newTestEngine(t,
+		"CREATE TABLE airports (id INT PRIMARY KEY, code TEXT, city TEXT)",
+		"INSERT INTO airports (code, city) VALUES ('SVO', 'Moscow')",
+		"INSERT INTO airports (code, city) VALUES ('LED', 'St. Petersburg')",
+		"INSERT INTO airports (code, city) VALUES ('KUF', 'Samara')",
+		"CREATE TABLE flights (id INT PRIMARY KEY, departure TEXT, arrival TEXT)",
+		"INSERT INTO flights (departure, arrival) VALUES ('SVO', 'LED')",
+		"INSERT INTO flights (departure, arrival) VALUES ('LED', 'SVO')",
+		"INSERT INTO flights (departure, arrival) VALUES ('SVO', 'XXX')",
+		"INSERT INTO flights (arrival) VALUES ('SVO')",
+	)
+
+	integer, text := datatype.NewInteger, datatype.NewText
+	null := datatype.NewNull()
+
+	tests := []struct {
+		input    string
+		columns  []string
+		expected []sql.Row
+		err      string
+	}{
+		{
+			input: "WITH d AS (SELECT departure FROM flights WHERE departure IS NOT NULL), " +
+				"c (code, n) AS (SELECT departure, count(*) FROM d GROUP BY departure) " +
+				"SELECT a.city, c.n FROM airports a JOIN c ON c.code = a.code ORDER BY n",
+			columns: []string{"city", "n"},
+			expected: []sql.Row{
+				{text("St. Petersburg"), integer(1)},
+				{text("Moscow"), integer(2)},
+			},
+		},
+		{
+			input:   "WITH RECURSIVE t (n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM t WHERE n < 4) SELECT n FROM t",
+			columns: []string{"n"},
+			expected: []sql.Row{
+				{integer(1)},
+				{integer(2)},
+				{integer(3)},
+				{integer(4)},
+			},
+		},
+		{
+			input: "WITH RECURSIVE reachable (code) AS (SELECT 'SVO' UNION SELECT f.arrival FROM flights f JOIN reachable r ON f.departure = r.code) " +
+				"SELECT code FROM reachable ORDER BY code",
+			columns: []string{"code"},
+			expected: []sql.Row{
+				{text("LED")},
+				{text("SVO")},
+				{text("XXX")},
+			},
+		},
+		{
+			input: "WITH RECURSIVE routes (code, hops) AS (SELECT 'LED', 0 UNION ALL " +
+				"SELECT f.arrival, r.hops + 1 FROM routes r JOIN flights f ON f.departure = r.code WHERE r.hops < 2) " +
+				"SELECT code, hops FROM routes ORDER BY hops",
+			columns: []string{"code", "hops"},
+			expected: []sql.Row{
+				{text("LED"), integer(0)},
+				{text("SVO"), integer(1)},
+				{text("LED"), integer(2)},
+				{text("XXX"), integer(2)},
+			},
+		},
+		{
+			input:   "SELECT a.code, (WITH RECURSIVE t (n) AS (SELECT a.id UNION SELECT n - 1 FROM t WHERE n > 1) SELECT count(*) FROM t) FROM airports a",
+			columns: []string{"code", "count"},
+			expected: []sql.Row{
+				{text("SVO"), integer(1)},
+				{text("LED"), integer(2)},
+				{text("KUF"), integer(3)},
+			},
+		},
+		{
+			input:    "WITH flights AS (SELECT 1) SELECT * FROM flights",
+			columns:  []string{"?column?"},
+			expected: []sql.Row{{integer(1)}},
+		},
+		{
+			input:   "SELECT departure FROM flights UNION SELECT code FROM airports ORDER BY departure",
+			columns: []string{"departure"},
+			expected: []sql.Row{
+				{text("KUF")},
+				{text("LED")},
+				{text("SVO")},
+				{null},
+			},
+		},
+		{
+			input:   "SELECT arrival FROM flights WHERE id < 3 UNION ALL SELECT 'LED' LIMIT 2 OFFSET 1",
+			columns: []string{"arrival"},
+			expected: []sql.Row{
+				{text("SVO")},
+				{text("LED")},
+			},
+		},
+		{input: "WITH t AS (SELECT 1), t AS (SELECT 2) SELECT * FROM t", err: `WITH query name "t" specified more than once`},
+		{input: "WITH t (a, b) AS (SELECT 1) SELECT * FROM t", err: `WITH query "t" has 1 columns available but 2 columns specified`},
+		{input: "WITH t AS (SELECT * FROM t) SELECT * FROM t", err: `table "t" not found`},
+		{
+			input: "WITH RECURSIVE t AS (SELECT * FROM t UNION SELECT 1) SELECT * FROM t",
+			err:   `recursive reference to query "t" must not appear within its non-recursive term`,
+		},
+		{
+			input: "WITH RECURSIVE t AS (SELECT * FROM t) SELECT * FROM t",
+			err:   `recursive query "t" does not have the form non-recursive-term UNION [ALL] recursive-term`,
+		},
+		{
+			input: "WITH RECURSIVE t (code) AS (SELECT 'SVO' UNION ALL SELECT f.arrival FROM flights f JOIN t ON f.departure = t.code) SELECT * FROM t",
+			err:   `recursive query "t" exceeded the limit of 1000 iterations`,
+		},
+		{input: "WITH RECURSIVE t AS (SELECT 1 UNION SELECT 1, 2 FROM t) SELECT * FROM t", err: "each UNION query must have the same number of columns"},
+		{input: "WITH RECURSIVE t AS (SELECT 1 UNION SELECT 2 LIMIT 1) SELECT * FROM t", err: "LIMIT in a recursive query is not implemented"},
+		{input: "SELECT 1 UNION SELECT 'a'", err: "UNION types integer and text cannot be matched"},
+	}
+
+	for _, test := range tests {
+		result, err := engine.Exec(test.input)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err, test.input)
+			continue
+		}
+
+		assert.NoError(t, err, test.input)
+		assert.Equal(t, test.columns, result.Columns, test.input)
+		assert.Equal(t, test.expected, collect(t, result), test.input)
+	}
+
+	_, err := engine.Exec("WITH t AS (SELECT departure FROM flights) UPDATE airports SET city = 'Closed' WHERE code NOT IN (SELECT departure FROM t WHERE departure IS NOT NULL)")
+	assert.NoError(t, err)
+	_, err = engine.Exec("WITH closed AS (SELECT code FROM airports WHERE city = 'Closed') DELETE FROM airports WHERE code IN (SELECT code FROM closed)")
+	assert.NoError(t, err)
+	_, err = engine.Exec("WITH t AS (SELECT 'KUF') INSERT INTO airports (code, city) VALUES ((SELECT * FROM t), 'Samara')")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []sql.Row{
+		{integer(1), text("SVO"), text("Moscow")},
+		{integer(2), text("LED"), text("St. Petersburg")},
+		{integer(4), text("KUF"), text("Samara")},
+	}, scan(t, db, "airports"))
+}
+
+func TestSelect_Window(t *testing.T) {
+	engine, _ := newTestEngine(t,
+		"CREATE TABLE aircrafts (id INT PRIMARY KEY, model TEXT, manufacturer TEXT, range INT)",
+		"INSERT INTO aircrafts (model, manufacturer, range) VALUES ('Boeing 777-300', 'Boeing', 11100)",
+		"INSERT INTO aircrafts (model, manufacturer, range) VALUES ('Boeing 767-300', 'Boeing', 7900)",
+		"INSERT INTO aircrafts (model, manufacturer, range) VALUES ('Sukhoi Superjet-100', 'Sukhoi', 3000)",
+		"INSERT INTO aircrafts (model, manufacturer, range) VALUES ('Airbus A320-200', 'Airbus', 5700)",
+		"INSERT INTO aircrafts (model, manufacturer, range) VALUES ('Airbus A321-200', 'Airbus', 5600)",
+		"INSERT INTO aircrafts (model, manufacturer, range) VALUES ('Airbus A319-100', 'Airbus', 6700)",
+		"INSERT INTO aircrafts (model, manufacturer, range) VALUES ('Boeing 737-300', 'Boeing', 4200)",
+		"INSERT INTO aircrafts (model, manufacturer, range) VALUES ('Cessna 208 Caravan', 'Cessna', 1200)",
+		"INSERT INTO aircrafts (model, manufacturer, range) VALUES ('Bombardier CRJ-200', 'Bombardier', 2700)",
+		"INSERT INTO aircrafts (model, manufacturer, range) VALUES ('Airbus A318-100', 'Airbus', 5700)",
+	)
+
+	integer, text := datatype.NewInteger, datatype.NewText
+	null := datatype.NewNull()
+
+	tests := []struct {
+		input    string
+		columns  []string
+		expected []sql.Row
+		err      string
+	}{
+		{
+			input: "SELECT model, rank() OVER (PARTITION BY manufacturer ORDER BY range DESC), " +
+				"dense_rank() OVER (PARTITION BY manufacturer ORDER BY range DESC), " +
+				"row_number() OVER (PARTITION BY manufacturer ORDER BY range DESC) FROM aircrafts WHERE manufacturer = 'Airbus'",
+			columns: []string{"model", "rank", "dense_rank", "row_number"},
+			expected: []sql.Row{
+				{text("Airbus A320-200"), integer(2), integer(2), integer(2)},
+				{text("Airbus A321-200"), integer(4), integer(3), integer(4)},
+				{text("Airbus A319-100"), integer(1), integer(1), integer(1)},
+				{text("Airbus A318-100"), integer(2), integer(2), integer(3)},
+			},
+		},
+		{
+			input:   "SELECT id, lag(range) OVER (ORDER BY id), lead(range, 2, 0) OVER (ORDER BY id) FROM aircrafts WHERE id < 5",
+			columns: []string{"id", "lag", "lead"},
+			expected: []sql.Row{
+				{integer(1), null, integer(3000)},
+				{integer(2), integer(11100), integer(5700)},
+				{integer(3), integer(7900), integer(0)},
+				{integer(4), integer(3000), integer(0)},
+			},
+		},
+		{
+			input: "SELECT id, sum(range) OVER (ORDER BY id), sum(range) OVER (ORDER BY id ROWS BETWEEN 1 PRECEDING AND 1 FOLLOWING), " +
+				"count(*) OVER () FROM aircrafts WHERE id < 5",
+			columns: []string{"id", "sum", "sum", "count"},
+			expected: []sql.Row{
+				{integer(1), integer(11100), integer(19000), integer(4)},
+				{integer(2), integer(19000), integer(22000), integer(4)},
+				{integer(3), integer(22000), integer(16600), integer(4)},
+				{integer(4), integer(27700), integer(8700), integer(4)},
+			},
+		},
+		{
+			input: "SELECT id, count(*) OVER (ORDER BY range RANGE BETWEEN 1000 PRECEDING AND 1000 FOLLOWING), " +
+				"max(range) OVER (ORDER BY range DESC RANGE 100 PRECEDING) FROM aircrafts WHERE manufacturer = 'Airbus' ORDER BY id",
+			columns: []string{"id", "count", "max"},
+			expected: []sql.Row{
+				{integer(4), integer(4), integer(5700)},
+				{integer(5), integer(3), integer(5700)},
+				{integer(6), integer(3), integer(6700)},
+				{integer(10), integer(4), integer(5700)},
+			},
+		},
+		{
+			input: "SELECT model, first_value(model) OVER (PARTITION BY manufacturer ORDER BY range DESC), " +
+				"last_value(model) OVER (PARTITION BY manufacturer ORDER BY range DESC ROWS BETWEEN UNBOUNDED PRECEDING AND UNBOUNDED FOLLOWING), " +
+				"ntile(2) OVER (ORDER BY range DESC) FROM aircrafts WHERE manufacturer = 'Boeing'",
+			columns: []string{"model", "first_value", "last_value", "ntile"},
+			expected: []sql.Row{
+				{text("Boeing 777-300"), text("Boeing 777-300"), text("Boeing 737-300"), integer(1)},
+				{text("Boeing 767-300"), text("Boeing 777-300"), text("Boeing 737-300"), integer(1)},
+				{text("Boeing 737-300"), text("Boeing 777-300"), text("Boeing 737-300"), integer(2)},
+			},
+		},
+		{
+			input:   "SELECT manufacturer, count(*), rank() OVER (ORDER BY count(*) DESC) FROM aircrafts GROUP BY manufacturer ORDER BY manufacturer",
+			columns: []string{"manufacturer", "count", "rank"},
+			expected: []sql.Row{
+				{text("Airbus"), integer(4), integer(1)},
+				{text("Boeing"), integer(3), integer(2)},
+				{text("Bombardier"), integer(1), integer(3)},
+				{text("Cessna"), integer(1), integer(3)},
+				{text("Sukhoi"), integer(1), integer(3)},
+			},
+		},
+		{
+			input:   "SELECT id, range - lag(range) OVER (ORDER BY id) FROM aircrafts WHERE id < 3",
+			columns: []string{"id", "?column?"},
+			expected: []sql.Row{
+				{integer(1), null},
+				{integer(2), integer(-3200)},
+			},
+		},
+		{input: "SELECT id FROM aircrafts WHERE rank() OVER () > 1", err: "window functions are not allowed in WHERE"},
+		{input: "SELECT rank() FROM aircrafts", err: "window function rank requires an OVER clause"},
+		{input: "SELECT now() OVER () FROM aircrafts", err: "OVER specified, but now is not a window function nor an aggregate function"},
+		{input: "SELECT sum(rank() OVER ()) OVER () FROM aircrafts", err: "window function calls cannot be nested"},
+		{input: "SELECT ntile(0) OVER () FROM aircrafts", err: "argument of ntile must be greater than zero"},
+		{input: "SELECT lag() OVER () FROM aircrafts", err: "function lag() takes 1 to 3 arguments"},
+		{input: "SELECT count(DISTINCT id) OVER () FROM aircrafts", err: "DISTINCT is not implemented for window functions"},
+		{input: "SELECT count(*) OVER (RANGE 1 PRECEDING) FROM aircrafts", err: "RANGE with offset PRECEDING/FOLLOWING requires exactly one ORDER BY column"},
+		{input: "SELECT count(*) OVER (ORDER BY model RANGE 1 PRECEDING) FROM aircrafts", err: "RANGE with offset PRECEDING/FOLLOWING is not supported for column type text"},
+		{input: "SELECT count(*) OVER (ROWS 1.5 PRECEDING) FROM aircrafts", err: "frame starting offset must be type integer, not type numeric"},
+		{input: "SELECT count(*) OVER (ROWS BETWEEN CURRENT ROW AND UNBOUNDED PRECEDING) FROM aircrafts", err: "frame end cannot be UNBOUNDED PRECEDING"},
+		{
+			input: "SELECT manufacturer, rank() OVER (ORDER BY range) FROM aircrafts GROUP BY manufacturer",
+			err:   `column "aircrafts.range" must appear in the GROUP BY clause or be used in an aggregate function`,
+		},
+	}
+
+	for _, test := range tests {
+		result, err := engine.Exec(test.input)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err, test.input)
+			continue
+		}
+
+		assert.NoError(t, err, test.input)
+		assert.Equal(t, test.columns, result.Columns, test.input)
+		assert.Equal(t, test.expected, collect(t, result), test.input)
+	}
+}
+
+func TestSelect_SetOperations(t *testing.T) {
+	engine, _ := newTestEngine(t,
+		"CREATE TABLE flights (id INT PRIMARY KEY, departure TEXT, arrival TEXT)",
+		"INSERT INTO flights (departure, arrival) VALUES ('SVO', 'LED')",
+		"INSERT INTO flights (departure, arrival) VALUES ('SVO', 'LED')",
+		"INSERT INTO flights (departure, arrival) VALUES ('LED', 'SVO')",
+		"INSERT INTO flights (departure, arrival) VALUES ('DME', 'LED')",
+		"INSERT INTO flights (arrival) VALUES ('SVO')",
+		"INSERT INTO flights (departure) VALUES ('VKO')",
+	)
+
+	integer, text := datatype.NewInteger, datatype.NewText
+	null := datatype.NewNull()
+
+	tests := []struct {
+		input    string
+		columns  []string
+		expected []sql.Row
+		err      string
+	}{
+		{
+			input:    "SELECT departure FROM flights INTERSECT SELECT arrival FROM flights ORDER BY departure",
+			columns:  []string{"departure"},
+			expected: []sql.Row{{text("LED")}, {text("SVO")}, {null}},
+		},
+		{
+			input:    "SELECT departure FROM flights INTERSECT ALL SELECT arrival FROM flights ORDER BY departure",
+			columns:  []string{"departure"},
+			expected: []sql.Row{{text("LED")}, {text("SVO")}, {text("SVO")}, {null}},
+		},
+		{
+			input:    "SELECT departure FROM flights EXCEPT SELECT arrival FROM flights ORDER BY departure",
+			columns:  []string{"departure"},
+			expected: []sql.Row{{text("DME")}, {text("VKO")}},
+		},
+		{
+			input:    "SELECT arrival FROM flights EXCEPT ALL SELECT departure FROM flights",
+			columns:  []string{"arrival"},
+			expected: []sql.Row{{text("LED")}, {text("LED")}},
+		},
+		{
+			input:    "SELECT 'DME' UNION SELECT departure FROM flights INTERSECT SELECT 'SVO'",
+			columns:  []string{"?column?"},
+			expected: []sql.Row{{text("DME")}, {text("SVO")}},
+		},
+		{
+			input:    "(SELECT 'DME' UNION SELECT departure FROM flights) INTERSECT SELECT 'SVO'",
+			columns:  []string{"?column?"},
+			expected: []sql.Row{{text("SVO")}},
+		},
+		{
+			input:    "SELECT departure FROM flights EXCEPT SELECT arrival FROM flights EXCEPT SELECT 'VKO'",
+			columns:  []string{"departure"},
+			expected: []sql.Row{{text("DME")}},
+		},
+		{
+			input:    "(SELECT arrival FROM flights ORDER BY arrival LIMIT 1) UNION ALL (SELECT departure FROM flights ORDER BY departure LIMIT 1)",
+			columns:  []string{"arrival"},
+			expected: []sql.Row{{text("LED")}, {text("DME")}},
+		},
+		{
+			input:    "SELECT departure FROM flights EXCEPT SELECT arrival FROM flights ORDER BY departure DESC LIMIT 1",
+			columns:  []string{"departure"},
+			expected: []sql.Row{{text("VKO")}},
+		},
+		{
+			input:    "SELECT count(*) FROM ((SELECT departure FROM flights) INTERSECT ALL (SELECT arrival FROM flights)) AS c",
+			columns:  []string{"count"},
+			expected: []sql.Row{{integer(4)}},
+		},
+		{
+			input:    "SELECT id FROM flights WHERE departure IN (SELECT departure FROM flights EXCEPT SELECT arrival FROM flights)",
+			columns:  []string{"id"},
+			expected: []sql.Row{{integer(4)}, {integer(6)}},
+		},
+		{input: "SELECT id FROM flights INTERSECT SELECT departure FROM flights", err: "INTERSECT types integer and text cannot be matched"},
+		{input: "SELECT id, departure FROM flights EXCEPT SELECT id FROM flights", err: "each EXCEPT query must have the same number of columns"},
+		{input: "(SELECT id FROM flights LIMIT 1) LIMIT 2", err: "multiple LIMIT clauses not allowed"},
+		{input: "SELECT id FROM flights EXCEPT 1", err: `expected "SELECT" but found "INT"`},
+	}
+
+	for _, test := range tests {
+		result, err := engine.Exec(test.input)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err, test.input)
+			continue
+		}
+
+		assert.NoError(t, err, test.input)
+		assert.Equal(t, test.columns, result.Columns, test.input)
+		assert.Equal(t, test.expected, collect(t, result), test.input)
+	}
+}
+
+func TestSelect_Distinct(t *testing.T) {
+	engine, _ := newTestEngine(t,
+		"CREATE TABLE airports (id INT PRIMARY KEY, code TEXT, city TEXT, timezone TEXT)",
+		"INSERT INTO airports (code, city, timezone) VALUES ('SVO', 'Moscow', 'Europe/Moscow')",
+		"INSERT INTO airports (code, city, timezone) VALUES ('DME', 'Moscow', 'Europe/Moscow')",
+		"INSERT INTO airports (code, city, timezone) VALUES ('LED', 'St. Petersburg', 'Europe/Moscow')",
+		"INSERT INTO airports (code, city, timezone) VALUES ('KJA', 'Krasnoyarsk', 'Asia/Krasnoyarsk')",
+		"INSERT INTO airports (code, city, timezone) VALUES ('OVB', 'Novosibirsk', 'Asia/Novosibirsk')",
+		"INSERT INTO airports (code, city, timezone) VALUES ('VKO', 'Moscow', 'Europe/Moscow')",
+	)
+
+	integer, text, boolean := datatype.NewInteger, datatype.NewText, datatype.NewBoolean
+
+	tests := []struct {
+		input    string
+		columns  []string
+		expected []sql.Row
+		err      string
+	}{
+		{
+			input:    "SELECT DISTINCT city FROM airports ORDER BY city",
+			columns:  []string{"city"},
+			expected: []sql.Row{{text("Krasnoyarsk")}, {text("Moscow")}, {text("Novosibirsk")}, {text("St. Petersburg")}},
+		},
+		{
+			input:   "SELECT DISTINCT timezone, city = 'Moscow' AS moscow FROM airports ORDER BY moscow DESC",
+			columns: []string{"timezone", "moscow"},
+			expected: []sql.Row{
+				{text("Europe/Moscow"), boolean(true)},
+				{text("Europe/Moscow"), boolean(false)},
+				{text("Asia/Krasnoyarsk"), boolean(false)},
+				{text("Asia/Novosibirsk"), boolean(false)},
+			},
+		},
+		{
+			input:    "SELECT ALL city FROM airports WHERE id < 3",
+			columns:  []string{"city"},
+			expected: []sql.Row{{text("Moscow")}, {text("Moscow")}},
+		},
+		{
+			input:   "SELECT DISTINCT ON (city) city, code FROM airports ORDER BY city",
+			columns: []string{"city", "code"},
+			expected: []sql.Row{
+				{text("Krasnoyarsk"), text("KJA")},
+				{text("Moscow"), text("SVO")},
+				{text("Novosibirsk"), text("OVB")},
+				{text("St. Petersburg"), text("LED")},
+			},
+		},
+		{
+			input:   "SELECT DISTINCT ON (timezone) code FROM airports",
+			columns: []string{"code"},
+			expected: []sql.Row{
+				{text("SVO")},
+				{text("KJA")},
+				{text("OVB")},
+			},
+		},
+		{
+			input:   "SELECT DISTINCT ON (zone) timezone AS zone, count(*) AS n FROM airports GROUP BY timezone ORDER BY zone",
+			columns: []string{"zone", "n"},
+			expected: []sql.Row{
+				{text("Asia/Krasnoyarsk"), integer(1)},
+				{text("Asia/Novosibirsk"), integer(1)},
+				{text("Europe/Moscow"), integer(4)},
+			},
+		},
+		{
+			input:    "SELECT code AS airport, id * 10 x FROM airports WHERE id < 3 ORDER BY x DESC",
+			columns:  []string{"airport", "x"},
+			expected: []sql.Row{{text("DME"), integer(20)}, {text("SVO"), integer(10)}},
+		},
+		{
+			input:    "SELECT id, -id AS code FROM airports ORDER BY code LIMIT 2",
+			columns:  []string{"id", "code"},
+			expected: []sql.Row{{integer(6), integer(-6)}, {integer(5), integer(-5)}},
+		},
+		{
+			input:    "SELECT *, id AS n FROM airports ORDER BY n DESC LIMIT 1",
+			columns:  []string{"id", "code", "city", "timezone", "n"},
+			expected: []sql.Row{{integer(6), text("VKO"), text("Moscow"), text("Europe/Moscow"), integer(6)}},
+		},
+		{
+			input:    "SELECT (SELECT max(id) FROM airports), EXISTS (SELECT 1), TRUE, (SELECT count(*) AS n FROM airports)",
+			columns:  []string{"max", "exists", "bool", "n"},
+			expected: []sql.Row{{integer(6), boolean(true), boolean(true), integer(6)}},
+		},
+		{
+			input:    "SELECT t.n FROM (SELECT count(*) AS n FROM airports) AS t",
+			columns:  []string{"n"},
+			expected: []sql.Row{{integer(6)}},
+		},
+		{
+			input:    "SELECT code AS c FROM airports WHERE id = 1 UNION SELECT 'XXX' ORDER BY c DESC",
+			columns:  []string{"c"},
+			expected: []sql.Row{{text("XXX")}, {text("SVO")}},
+		},
+		{input: "SELECT DISTINCT city FROM airports ORDER BY id", err: "for SELECT DISTINCT, ORDER BY expressions must appear in select list"},
+		{input: "SELECT DISTINCT ON (city) code FROM airports ORDER BY code", err: "SELECT DISTINCT ON expressions must match initial ORDER BY expressions"},
+		{input: "SELECT id AS x FROM airports WHERE x = 1", err: `column "x" does not exist`},
+		{input: "SELECT * AS x FROM airports", err: "cannot alias *"},
+	}
+
+	for _, test := range tests {
+		result, err := engine.Exec(test.input)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err, test.input)
+			continue
+		}
+
+		assert.NoError(t, err, test.input)
+		assert.Equal(t, test.columns, result.Columns, test.input)
+		assert.Equal(t, test.expected, collect(t, result), test.input)
+	}
+}
+
+func TestSelect_OrderBy(t *testing.T) {
+	engine, _ := newTestEngine(t,
+		"CREATE TABLE airports (id INT PRIMARY KEY, code TEXT, city TEXT, timezone TEXT)",
+		"INSERT INTO airports (code, city, timezone) VALUES ('SVO', 'Moscow', 'Europe/Moscow')",
+		"INSERT INTO airports (code, city, timezone) VALUES ('LED', 'St. Petersburg', 'Europe/Moscow')",
+		"INSERT INTO airports (code, city, timezone) VALUES ('KJA', 'Krasnoyarsk', 'Asia/Krasnoyarsk')",
+		"INSERT INTO airports (code, city, timezone) VALUES ('DME', 'Moscow', 'Europe/Moscow')",
+		"INSERT INTO airports (code, timezone) VALUES ('OVB', 'Asia/Novosibirsk')",
+		"INSERT INTO airports (code, city, timezone) VALUES ('AER', 'Sochi', 'Europe/Moscow')",
+	)
+
+	text := datatype.NewText
+	null := datatype.NewNull()
+
+	tests := []struct {
+		input    string
+		columns  []string
+		expected []sql.Row
+		err      string
+	}{
+		{
+			input:   "SELECT city, code FROM airports ORDER BY city DESC, code",
+			columns: []string{"city", "code"},
+			expected: []sql.Row{
+				{null, text("OVB")},
+				{text("St. Petersburg"), text("LED")},
+				{text("Sochi"), text("AER")},
+				{text("Moscow"), text("DME")},
+				{text("Moscow"), text("SVO")},
+				{text("Krasnoyarsk"), text("KJA")},
+			},
+		},
+		{
+			input:   "SELECT code, city FROM airports ORDER BY 2 NULLS FIRST, 1 DESC",
+			columns: []string{"code", "city"},
+			expected: []sql.Row{
+				{text("OVB"), null},
+				{text("KJA"), text("Krasnoyarsk")},
+				{text("SVO"), text("Moscow")},
+				{text("DME"), text("Moscow")},
+				{text("AER"), text("Sochi")},
+				{text("LED"), text("St. Petersburg")},
+			},
+		},
+		{
+			input:    "SELECT code FROM airports ORDER BY city = 'Moscow' DESC NULLS LAST, -id",
+			columns:  []string{"code"},
+			expected: []sql.Row{{text("DME")}, {text("SVO")}, {text("AER")}, {text("KJA")}, {text("LED")}, {text("OVB")}},
+		},
+		{
+			input:    "SELECT timezone FROM airports GROUP BY timezone ORDER BY count(*) DESC, 1",
+			columns:  []string{"timezone"},
+			expected: []sql.Row{{text("Europe/Moscow")}, {text("Asia/Krasnoyarsk")}, {text("Asia/Novosibirsk")}},
+		},
+		{
+			input:    "SELECT code FROM airports ORDER BY row_number() OVER (ORDER BY id DESC) LIMIT 2",
+			columns:  []string{"code"},
+			expected: []sql.Row{{text("AER")}, {text("OVB")}},
+		},
+		{
+			input:    "SELECT code FROM airports ORDER BY code LIMIT 2 OFFSET 1",
+			columns:  []string{"code"},
+			expected: []sql.Row{{text("DME")}, {text("KJA")}},
+		},
+		{
+			input:   "SELECT code FROM airports ORDER BY code DESC LIMIT 0",
+			columns: []string{"code"},
+		},
+		{
+			input:    "SELECT code AS c, a.city FROM airports a ORDER BY a.city, c DESC LIMIT 3",
+			columns:  []string{"c", "city"},
+			expected: []sql.Row{{text("KJA"), text("Krasnoyarsk")}, {text("SVO"), text("Moscow")}, {text("DME"), text("Moscow")}},
+		},
+		{
+			input:    "SELECT DISTINCT city FROM airports ORDER BY city DESC NULLS LAST, 1",
+			columns:  []string{"city"},
+			expected: []sql.Row{{text("St. Petersburg")}, {text("Sochi")}, {text("Moscow")}, {text("Krasnoyarsk")}, {null}},
+		},
+		{
+			input:    "SELECT code FROM airports WHERE id < 3 UNION SELECT 'AAA' ORDER BY 1 DESC LIMIT 2",
+			columns:  []string{"code"},
+			expected: []sql.Row{{text("SVO")}, {text("LED")}},
+		},
+		{input: "SELECT code, city FROM airports ORDER BY 3", err: "ORDER BY position 3 is not in select list"},
+		{input: "SELECT code FROM airports ORDER BY 'x'", err: "non-integer constant in ORDER BY"},
+		{input: "SELECT code FROM airports UNION SELECT 'AAA' ORDER BY 2", err: "ORDER BY position 2 is not in select list"},
+		{input: "SELECT DISTINCT city FROM airports ORDER BY city, code", err: "for SELECT DISTINCT, ORDER BY expressions must appear in select list"},
+		{input: "SELECT DISTINCT ON (city) city, code FROM airports ORDER BY code, city", err: "SELECT DISTINCT ON expressions must match initial ORDER BY expressions"},
+		{
+			input: "SELECT timezone FROM airports GROUP BY timezone ORDER BY code",
+			err:   `column "airports.code" must appear in the GROUP BY clause or be used in an aggregate function`,
+		},
+	}
+
+	for _, test := range tests {
+		result, err := engine.Exec(test.input)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err, test.input)
+			continue
+		}
+
+		assert.NoError(t, err, test.input)
+		assert.Equal(t, test.columns, result.Columns, test.input)
+		assert.Equal(t, test.expected, collect(t, result), test.input)
+	}
+}
+
+func TestSelect_ExternalSort(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", dir)
+
+	statements := []string{"CREATE TABLE numbers (id INT PRIMARY KEY, n INT, name TEXT)"}
+
+	var rows []sql.Row
+	for i := 1; i <= 200; i++ {
+		n, name := (i*37)%50, fmt.Sprintf("number %d", i)
+		statements = append(statements, fmt.Sprintf("INSERT INTO numbers (n, name) VALUES (%d, '%s')", n, name))
+		rows = append(rows, sql.Row{datatype.NewInteger(int64(n)), datatype.NewText(name)})
+	}
+
+	engine, _ := newTestEngine(t, statements...)
+	engine.SetSortMemory(1 << 10)
+
+	sorted := append([]sql.Row(nil), rows...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i][0].Raw().(int64) > sorted[j][0].Raw().(int64)
+	})
+
+	result, err := engine.Exec("SELECT n, name FROM numbers ORDER BY n DESC")
+	assert.NoError(t, err)
+
+	// The rows were sorted into runs, which are merged as they are read.
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, entries)
+
+	assert.Equal(t, sorted, collect(t, result))
+
+	entries, err = os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	result, err = engine.Exec("SELECT name FROM numbers WHERE n < 10 ORDER BY n DESC, id OFFSET 3")
+	assert.NoError(t, err)
+
+	var names []sql.Row
+	for _, row := range sorted {
+		if row[0].Raw().(int64) < 10 {
+			names = append(names, row[1:])
+		}
+	}
+	assert.Equal(t, names[3:], collect(t, result))
+
+	result, err = engine.Exec("SELECT n, name FROM numbers ORDER BY n DESC LIMIT 10 OFFSET 5")
+	assert.NoError(t, err)
+	assert.Equal(t, sorted[5:15], collect(t, result))
+
+	entries, err = os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestExternalSort(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", dir)
+
+	var rows []sql.Row
+	for i := 0; i < 100; i++ {
+		rows = append(rows, sql.Row{datatype.NewInteger(int64(i % 7)), datatype.NewText(fmt.Sprintf("row %03d", i))})
+	}
+
+	sorted := append([]sql.Row(nil), rows...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i][0].Raw().(int64) < sorted[j][0].Raw().(int64)
+	})
+
+	keys := []ast.SortKey{{Expr: &ast.IdentExpr{Name: "n"}, Direction: token.ASC}}
+
+	// Runs of 11 rows exceed the memory of 10.
+	iter, err := externalSort(sql.NewSliceRowsIter(rows...), keys, 10*rowSize(rows[0]))
+	assert.NoError(t, err)
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 10)
+
+	result, err := readRows(iter)
+	assert.NoError(t, err)
+	assert.Equal(t, sorted, result)
+
+	entries, err = os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	iter, err = externalSort(sql.NewSliceRowsIter(rows...), keys, 100*rowSize(rows[0]))
+	assert.NoError(t, err)
+
+	entries, err = os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	result, err = readRows(iter)
+	assert.NoError(t, err)
+	assert.Equal(t, sorted, result)
+}
+
+func TestSelect_Expressions(t *testing.T) {
+	engine, _ := newTestEngine(t,
+		"CREATE TABLE airports (id INT PRIMARY KEY, code TEXT, city TEXT, runways INT)",
+		"INSERT INTO airports (code, city, runways) VALUES ('SVO', 'Moscow', 3)",
+		"INSERT INTO airports (code, city, runways) VALUES ('DME', 'Moscow', 2)",
+		"INSERT INTO airports (code, city, runways) VALUES ('LED', 'St. Petersburg', 2)",
+		"INSERT INTO airports (code, city, runways) VALUES ('KJA', 'Krasnoyarsk', 1)",
+		"INSERT INTO airports (code, city, runways) VALUES ('OVB', '100% Novosibirsk', NULL)",
+	)
+
+	integer, float, text, boolean, null := datatype.NewInteger, datatype.NewFloat, datatype.NewText, datatype.NewBoolean, datatype.NewNull()
+
+	tests := []struct {
+		input    string
+		columns  []string
+		expected []sql.Row
+		err      string
+	}{
+		{
+			input:   "SELECT code, CASE WHEN runways > 2 THEN 'large' WHEN runways > 1 THEN 'medium' ELSE 'small' END FROM airports",
+			columns: []string{"code", "case"},
+			expected: []sql.Row{
+				{text("SVO"), text("large")},
+				{text("DME"), text("medium")},
+				{text("LED"), text("medium")},
+				{text("KJA"), text("small")},
+				{text("OVB"), text("small")},
+			},
+		},
+		{
+			input:    "SELECT CASE runways WHEN 1 THEN 'one' WHEN 2 THEN 'two' END AS n FROM airports WHERE id > 2",
+			columns:  []string{"n"},
+			expected: []sql.Row{{text("two")}, {text("one")}, {null}},
+		},
+		{
+			input:    "SELECT sum(CASE WHEN city = 'Moscow' THEN 1 ELSE 0 END) FROM airports",
+			columns:  []string{"sum"},
+			expected: []sql.Row{{integer(2)}},
+		},
+		{
+			input:    "SELECT id, CASE WHEN id = 1 THEN runways ELSE 2.5::FLOAT END FROM airports WHERE id < 3",
+			columns:  []string{"id", "case"},
+			expected: []sql.Row{{integer(1), float(3)}, {integer(2), float(2.5)}},
+		},
+		{
+			input:    "SELECT CAST(runways AS FLOAT), '42'::INT, CAST(1 AS BOOLEAN), (-runways)::TEXT, '3.5'::FLOAT::INT, runways::FLOAT::TEXT FROM airports WHERE id = 1",
+			columns:  []string{"runways", "int", "boolean", "text", "int", "runways"},
+			expected: []sql.Row{{float(3), integer(42), boolean(true), text("-3"), integer(4), text("3E+00")}},
+		},
+		{
+			input:    "SELECT 9223372036854775806 + 1, -9223372036854775807 - 1, -4611686018427387904 * 2",
+			columns:  []string{"?column?", "?column?", "?column?"},
+			expected: []sql.Row{{integer(math.MaxInt64), integer(math.MinInt64), integer(math.MinInt64)}},
+		},
+		{
+			input:    "SELECT code FROM airports WHERE runways BETWEEN 2 AND 3 AND city = 'Moscow'",
+			columns:  []string{"code"},
+			expected: []sql.Row{{text("SVO")}, {text("DME")}},
+		},
+		{
+			input:    "SELECT code, runways NOT BETWEEN 2 AND 3 FROM airports WHERE id > 3",
+			columns:  []string{"code", "?column?"},
+			expected: []sql.Row{{text("KJA"), boolean(true)}, {text("OVB"), null}},
+		},
+		{
+			input:    "SELECT code FROM airports WHERE code IN ('SVO', 'LED', 'AER')",
+			columns:  []string{"code"},
+			expected: []sql.Row{{text("SVO")}, {text("LED")}},
+		},
+		{
+			input:    "SELECT code, runways NOT IN (1, 2), runways IN (1, NULL) FROM airports WHERE id > 2",
+			columns:  []string{"code", "?column?", "?column?"},
+			expected: []sql.Row{{text("LED"), boolean(false), null}, {text("KJA"), boolean(false), boolean(true)}, {text("OVB"), null, null}},
+		},
+		{
+			input:    "SELECT code FROM airports WHERE city LIKE 'M%' AND code NOT LIKE '_ME'",
+			columns:  []string{"code"},
+			expected: []sql.Row{{text("SVO")}},
+		},
+		{
+			input:    "SELECT code FROM airports WHERE city ILIKE '%PETERS%' OR city LIKE '%\\%%'",
+			columns:  []string{"code"},
+			expected: []sql.Row{{text("LED")}, {text("OVB")}},
+		},
+		{
+			input:    "SELECT 'a_c' LIKE 'a!_c' ESCAPE '!', 'abc' LIKE 'a!_c' ESCAPE '!', 'a\\c' LIKE 'a\\c' ESCAPE ''",
+			columns:  []string{"?column?", "?column?", "?column?"},
+			expected: []sql.Row{{boolean(true), boolean(false), boolean(true)}},
+		},
+		{input: "SELECT CAST('abc' AS INT)", err: `invalid input syntax for type integer: "abc"`},
+		{input: "SELECT CAST(1.5 AS BOOLEAN)", err: "cannot cast type numeric to boolean"},
+		{input: "SELECT -'1'::TEXT", err: "operator does not exist: -text"},
+		{input: "SELECT 9223372036854775807 + 1", err: "integer out of range"},
+		{input: "SELECT -9223372036854775807 - 2", err: "integer out of range"},
+		{input: "SELECT 4611686018427387904 * 2", err: "integer out of range"},
+		{input: "SELECT (-9223372036854775807 - 1) / -1", err: "integer out of range"},
+		{input: "SELECT -(-9223372036854775807 - 1)", err: "integer out of range"},
+		{input: "SELECT CASE WHEN 1 THEN 2 END", err: "argument must be type boolean, not type integer"},
+		{input: "SELECT CASE WHEN true THEN 1 ELSE 'a' END", err: "CASE types integer and text cannot be matched"},
+		{input: "SELECT CASE WHEN id > 1 THEN code WHEN id > 2 THEN NULL ELSE runways END FROM airports", err: "CASE types text and integer cannot be matched"},
+		{input: "SELECT 'abc' LIKE 'ab\\'", err: "LIKE pattern must not end with escape character"},
+		{input: "SELECT 'abc' LIKE 'a' ESCAPE 'xy'", err: "invalid escape string"},
+		{input: "SELECT 1 LIKE 'a'", err: "operator does not exist: integer LIKE text"},
+	}
+
+	for _, test := range tests {
+		result, err := engine.Exec(test.input)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err, test.input)
+			continue
+		}
+
+		assert.NoError(t, err, test.input)
+		assert.Equal(t, test.columns, result.Columns, test.input)
+		assert.Equal(t, test.expected, collect(t, result), test.input)
+	}
+}
+
+func TestSelect_Functions(t *testing.T) {
+	engine, _ := newTestEngine(t,
+		"CREATE TABLE airports (id INT PRIMARY KEY, code TEXT, city TEXT)",
+		"INSERT INTO airports (code, city) VALUES ('svo', 'Moscow')",
+		"INSERT INTO airports (code, city) VALUES ('led', 'St. Petersburg')",
+		"INSERT INTO airports (code, city) VALUES ('kja', 'Krasnoyarsk')",
+	)
+
+	integer, float, text, boolean, null := datatype.NewInteger, datatype.NewFloat, datatype.NewText, datatype.NewBoolean, datatype.NewNull()
+	numeric := func(s string) datatype.Decimal {
+		d, err := datatype.ParseDecimal(s)
+		assert.NoError(t, err)
+		return d
+	}
+
+	tests := []struct {
+		input    string
+		columns  []string
+		expected []sql.Row
+		err      string
+	}{
+		{
+			input:    "SELECT lower(city), upper(code), length(city), substr(city, 2, 3), substr(city, 0, 2), substr(city, 4) FROM airports WHERE id = 1",
+			columns:  []string{"lower", "upper", "length", "substr", "substr", "substr"},
+			expected: []sql.Row{{text("moscow"), text("SVO"), integer(6), text("osc"), text("M"), text("cow")}},
+		},
+		{
+			input:    "SELECT trim('  a b  '), trim('xxaxx', 'x'), replace('a-b-c', '-', '+'), concat('a', 1, NULL, true), position('c' IN 'abcd'), position('z', 'abc')",
+			columns:  []string{"trim", "trim", "replace", "concat", "position", "position"},
+			expected: []sql.Row{{text("a b"), text("a"), text("a+b+c"), text("a1true"), integer(3), integer(0)}},
+		},
+		{
+			input:   "SELECT abs(-3), abs(-1.5), round(2.5), round(3.14159, 2), round(1234, -2), ceil(1.2), floor(-1.2), sqrt(16), power(2, 10), mod(7, -3), mod(-7.5, 2)",
+			columns: []string{"abs", "abs", "round", "round", "round", "ceil", "floor", "sqrt", "power", "mod", "mod"},
+			expected: []sql.Row{{
+				integer(3), numeric("1.5"), numeric("3"), numeric("3.14"), float(1200), numeric("2"), numeric("-2"),
+				float(4), float(1024), integer(1), numeric("-1.5"),
+			}},
+		},
+		{
+			input:    "SELECT greatest(1, 2.5, NULL), least('b', 'a'), greatest(NULL, NULL), coalesce(NULL, 1.5, 2), nullif('a', 'b'), abs(NULL)",
+			columns:  []string{"greatest", "least", "greatest", "coalesce", "nullif", "abs"},
+			expected: []sql.Row{{numeric("2.5"), text("a"), null, numeric("1.5"), text("a"), null}},
+		},
+		{
+			input:    "SELECT coalesce(NULL, 1, 2.5), coalesce(2, 1.5::FLOAT), coalesce(NULL::INT, 3)",
+			columns:  []string{"coalesce", "coalesce", "coalesce"},
+			expected: []sql.Row{{numeric("1"), float(2), integer(3)}},
+		},
+		{
+			input:    "SELECT random() BETWEEN 0 AND 1",
+			columns:  []string{"?column?"},
+			expected: []sql.Row{{boolean(true)}},
+		},
+		{
+			input:    "SELECT code FROM airports WHERE length(city) > 6 ORDER BY upper(code)",
+			columns:  []string{"code"},
+			expected: []sql.Row{{text("kja")}, {text("led")}},
+		},
+		{input: "SELECT lower(1)", err: "function lower(integer) does not exist"},
+		{input: "SELECT upper(id) FROM airports WHERE false", err: "function upper(integer) does not exist"},
+		{input: "SELECT code FROM airports ORDER BY length(code, 1)", err: "function length(text, integer) does not exist"},
+		{input: "SELECT foo(NULL)", err: "function foo(unknown) does not exist"},
+		{input: "SELECT greatest(1, 'a')", err: "function greatest(integer, text) does not exist"},
+		{input: "SELECT coalesce()", err: "function coalesce() does not exist"},
+		{input: "SELECT sqrt(-1)", err: "cannot take square root of a negative number"},
+		{input: "SELECT mod(1, 0)", err: "division by zero"},
+		{input: "SELECT substr('abc', 1, -1)", err: "negative substring length not allowed"},
+		{input: "UPDATE airports SET code = upper(id)", err: "function upper(integer) does not exist"},
+	}
+
+	for _, test := range tests {
+		result, err := engine.Exec(test.input)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err, test.input)
+			continue
+		}
+
+		assert.NoError(t, err, test.input)
+		assert.Equal(t, test.columns, result.Columns, test.input)
+		assert.Equal(t, test.expected, collect(t, result), test.input)
+	}
+}
+
+// registerTestFunctions registers the user-defined functions of the tests
+// with the engine.
+func registerTestFunctions(t *testing.T, engine *Engine) {
+	t.Helper()
+
+	float := []sql.DataType{sql.Float}
+
+	assert.NoError(t, engine.RegisterFunction(ScalarFunction{
+		Name:      "haversine",
+		Signature: Signature{Args: []sql.DataType{sql.Float, sql.Float, sql.Float, sql.Float}, Result: sql.Float},
+		Strict:    true,
+		Call: func(args []sql.Value) (sql.Value, error) {
+			rad := make([]float64, len(args))
+			for i, arg := range args {
+				rad[i] = arg.Raw().(float64) * math.Pi / 180
+			}
+
+			lat := math.Sin((rad[2] - rad[0]) / 2)
+			lon := math.Sin((rad[3] - rad[1]) / 2)
+			h := lat*lat + math.Cos(rad[0])*math.Cos(rad[2])*lon*lon
+
+			return datatype.NewFloat(2 * 6371 * math.Asin(math.Sqrt(h))), nil
+		},
+	}))
+
+	assert.NoError(t, engine.RegisterFunction(ScalarFunction{
+		Name:      "bad_result",
+		Signature: Signature{Result: sql.Integer},
+		Call: func([]sql.Value) (sql.Value, error) {
+			return datatype.NewText("a"), nil
+		},
+	}))
+
+	assert.NoError(t, engine.RegisterAggregate(AggregateFunction{
+		Name:      "join_codes",
+		Signature: Signature{Args: []sql.DataType{sql.Text}, Result: sql.Text},
+		Init:      func() any { return []string(nil) },
+		Step: func(state any, args []sql.Value) (any, error) {
+			return append(state.([]string), args[0].Raw().(string)), nil
+		},
+		Merge: func(a, b any) (any, error) {
+			return append(append([]string(nil), a.([]string)...), b.([]string)...), nil
+		},
+		Final: func(state any) (sql.Value, error) {
+			return datatype.NewText(strings.Join(state.([]string), ",")), nil
+		},
+	}))
+
+	assert.NoError(t, engine.RegisterAggregate(AggregateFunction{
+		Name:      "product",
+		Signature: Signature{Args: float, Result: sql.Float},
+		Init:      func() any { return 1.0 },
+		Step: func(state any, args []sql.Value) (any, error) {
+			return state.(float64) * args[0].Raw().(float64), nil
+		},
+		Merge: func(a, b any) (any, error) {
+			return a.(float64) * b.(float64), nil
+		},
+		Final: func(state any) (sql.Value, error) {
+			return datatype.NewFloat(state.(float64)), nil
+		},
+	}))
+}
+
+func TestRegisterFunction(t *testing.T) {
+	engine, _ := newTestEngine(t)
+	registerTestFunctions(t, engine)
+
+	call := func([]sql.Value) (sql.Value, error) { return datatype.NewNull(), nil }
+
+	tests := []struct {
+		fn  ScalarFunction
+		err string
+	}{
+		{fn: ScalarFunction{Name: "Haversine", Call: call}, err: `function "haversine" already exists`},
+		{fn: ScalarFunction{Name: "count", Call: call}, err: `function "count" already exists`},
+		{fn: ScalarFunction{Name: "rank", Call: call}, err: `function "rank" already exists`},
+		{fn: ScalarFunction{Name: "select", Call: call}, err: `function name "select" is a keyword`},
+		{fn: ScalarFunction{Name: "1st", Call: call}, err: `invalid function name "1st"`},
+		{fn: ScalarFunction{Name: "", Call: call}, err: "function name is empty"},
+		{fn: ScalarFunction{Name: "no_call"}, err: "function no_call has no implementation"},
+		{fn: ScalarFunction{Name: "no_args", Signature: Signature{Variadic: true}, Call: call}, err: "variadic function no_args has no arguments"},
+	}
+
+	for _, test := range tests {
+		assert.EqualError(t, engine.RegisterFunction(test.fn), test.err, test.fn.Name)
+	}
+
+	err := engine.RegisterAggregate(AggregateFunction{Name: "no_merge", Signature: Signature{Result: sql.Integer}})
+	assert.EqualError(t, err, "aggregate no_merge needs Init, Step, Merge and Final")
+
+	// Functions are registered with one engine only.
+	other, _ := newTestEngine(t)
+
+	_, err = other.Exec("SELECT haversine(0, 0, 0, 0)")
+	assert.EqualError(t, err, "function haversine(integer, integer, integer, integer) does not exist")
+
+	assert.NoError(t, other.RegisterFunction(ScalarFunction{Name: "haversine", Call: call}))
+}
+
+func TestSelect_UserDefinedFunctions(t *testing.T) {
+	engine, _ := newTestEngine(t)
+	registerTestFunctions(t, engine)
+
+	for _, stmt := range []string{
+		"CREATE TABLE airports (id INT PRIMARY KEY, code TEXT, lat FLOAT, lon FLOAT, km FLOAT DEFAULT haversine(0, 0, 0, 90), CHECK (haversine(lat, lon, 0, 0) < 15000))",
+		"CREATE INDEX airports_km ON airports (round(haversine(lat, lon, 0, 0)))",
+		"INSERT INTO airports (code, lat, lon) VALUES ('aaa', 0, 0)",
+		"INSERT INTO airports (code, lat, lon) VALUES ('bbb', 0, 90)",
+		"INSERT INTO airports (code, lat, lon) VALUES ('ccc', 90, 0)",
+		"INSERT INTO airports (code, lat, lon) VALUES ('ddd', NULL, 0)",
+		"CREATE TABLE routes (id INT PRIMARY KEY, km FLOAT DEFAULT haversine(0, 0, 0, 90))",
+		"INSERT INTO routes DEFAULT VALUES",
+	} {
+		_, err := engine.Exec(stmt)
+		assert.NoError(t, err, stmt)
+	}
+
+	integer, float, text, boolean, null := datatype.NewInteger, datatype.NewFloat, datatype.NewText, datatype.NewBoolean, datatype.NewNull()
+
+	tests := []struct {
+		input    string
+		columns  []string
+		expected []sql.Row
+		err      string
+	}{
+		{
+			input:    "SELECT code, round(HAVERSINE(lat, lon, 0, 0)) AS km FROM airports ORDER BY id",
+			columns:  []string{"code", "km"},
+			expected: []sql.Row{{text("aaa"), float(0)}, {text("bbb"), float(10008)}, {text("ccc"), float(10008)}, {text("ddd"), null}},
+		},
+		{
+			input:    "SELECT code FROM airports WHERE haversine(lat, lon, 0, 90) < 1",
+			columns:  []string{"code"},
+			expected: []sql.Row{{text("bbb")}},
+		},
+		{
+			input:    "SELECT join_codes(code), product(id), count(*) FROM airports",
+			columns:  []string{"join_codes", "product", "count"},
+			expected: []sql.Row{{text("aaa,bbb,ccc,ddd"), float(24), integer(4)}},
+		},
+		{
+			input:    "SELECT lat, join_codes(code) FROM airports WHERE lat IS NOT NULL GROUP BY lat ORDER BY lat",
+			columns:  []string{"lat", "join_codes"},
+			expected: []sql.Row{{float(0), text("aaa,bbb")}, {float(90), text("ccc")}},
+		},
+		{
+			input:    "SELECT join_codes(DISTINCT lower(substr(code, 1, 0))), product(lat) FROM airports WHERE id > 10",
+			columns:  []string{"join_codes", "product"},
+			expected: []sql.Row{{text(""), float(1)}},
+		},
+		{
+			input:   "SELECT id, join_codes(code) OVER (ORDER BY id ROWS BETWEEN 1 PRECEDING AND 1 FOLLOWING), join_codes(code) OVER (ORDER BY id) FROM airports",
+			columns: []string{"id", "join_codes", "join_codes"},
+			expected: []sql.Row{
+				{integer(1), text("aaa,bbb"), text("aaa")},
+				{integer(2), text("aaa,bbb,ccc"), text("aaa,bbb")},
+				{integer(3), text("bbb,ccc,ddd"), text("aaa,bbb,ccc")},
+				{integer(4), text("ccc,ddd"), text("aaa,bbb,ccc,ddd")},
+			},
+		},
+		{
+			input:    "SELECT haversine(0, 0, 0, 0) = 0, join_codes(code) IS NULL FROM airports WHERE false",
+			columns:  []string{"?column?", "?column?"},
+			expected: []sql.Row{{boolean(true), boolean(false)}},
+		},
+		{input: "SELECT haversine(0, 0, 0)", err: "function haversine(integer, integer, integer) does not exist"},
+		{input: "SELECT haversine(code, 0, 0, 0) FROM airports", err: "function haversine(text, integer, integer, integer) does not exist"},
+		{input: "SELECT product(code) FROM airports", err: "function product(text) does not exist"},
+		{input: "SELECT upper(product(id)) FROM airports", err: "function upper(float) does not exist"},
+		{
+			input:    "SELECT code, round(km) FROM airports WHERE round(haversine(lat, lon, 0, 0)) = 10008 ORDER BY id",
+			columns:  []string{"code", "round"},
+			expected: []sql.Row{{text("bbb"), float(10008)}, {text("ccc"), float(10008)}},
+		},
+		{input: "SELECT bad_result()", err: "function bad_result returned text instead of integer"},
+		{input: "INSERT INTO airports (code, lat, lon) VALUES ('eee', 0, 180)", err: `new row for relation "airports" violates check constraint "airports_check"`},
+		{
+			input:    "SELECT id, round(km) FROM routes",
+			columns:  []string{"id", "round"},
+			expected: []sql.Row{{integer(1), float(10008)}},
+		},
+	}
+
+	for _, test := range tests {
+		result, err := engine.Exec(test.input)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err, test.input)
+			continue
+		}
+
+		assert.NoError(t, err, test.input)
+		assert.Equal(t, test.columns, result.Columns, test.input)
+		assert.Equal(t, test.expected, collect(t, result), test.input)
+	}
+
+	result, err := engine.Exec("SHOW FUNCTIONS")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Name", "Result", "Arguments", "Type", "Volatility", "User"}, result.Columns)
+
+	var rows []sql.Row
+	for _, row := range collect(t, result) {
+		if name := row[0].Raw(); name == "haversine" || name == "join_codes" || name == "random" || name == "sum" {
+			rows = append(rows, row)
+		}
+	}
+	assert.Equal(t, []sql.Row{
+		{text("haversine"), text("float"), text("float, float, float, float"), text("func"), text("immutable"), boolean(true)},
+		{text("join_codes"), text("text"), text("text"), text("agg"), text("immutable"), boolean(true)},
+		{text("random"), text("float"), text(""), text("func"), text("volatile"), boolean(false)},
+		{text("sum"), text("integer"), text("integer"), text("agg"), text("immutable"), boolean(false)},
+		{text("sum"), text("float"), text("float"), text("agg"), text("immutable"), boolean(false)},
+		{text("sum"), text("numeric"), text("numeric"), text("agg"), text("immutable"), boolean(false)},
+	}, rows)
+}
+
+func TestSelect_Datetime(t *testing.T) {
+	engine, _ := newTestEngine(t,
+		"CREATE TABLE flights (id INT PRIMARY KEY, day DATE, departs TIMESTAMPTZ, length INTERVAL, boarding TIME)",
+		"INSERT INTO flights VALUES (1, '2024-01-31', '2024-01-31 22:30:00+00', '1 day 02:00:00', '09:30')",
+		"INSERT INTO flights VALUES (2, '2024-02-29', '2024-02-29 10:15:30.5 Europe/Berlin', '90 minutes', '23:00')",
+	)
+
+	integer, float, text, boolean := datatype.NewInteger, datatype.NewFloat, datatype.NewText, datatype.NewBoolean
+	parse := func(s string, to sql.DataType) sql.Value {
+		value, err := datatype.Cast(text(s), to)
+		assert.NoError(t, err)
+		return value
+	}
+	date := func(s string) sql.Value { return parse(s, sql.Date) }
+	timestamp := func(s string) sql.Value { return parse(s, sql.Timestamp) }
+	timestamptz := func(s string) sql.Value { return parse(s, sql.TimestampTZ) }
+	interval := func(s string) sql.Value { return parse(s, sql.Interval) }
+
+	tests := []struct {
+		input    string
+		columns  []string
+		expected []sql.Row
+		err      string
+	}{
+		{
+			input:   "SELECT day + 1, day - DATE '2024-01-01', day + INTERVAL '1 month', day + boarding FROM flights ORDER BY id",
+			columns: []string{"?column?", "?column?", "?column?", "?column?"},
+			expected: []sql.Row{
+				{date("2024-02-01"), integer(30), timestamp("2024-02-29"), timestamp("2024-01-31 09:30")},
+				{date("2024-03-01"), integer(59), timestamp("2024-03-29"), timestamp("2024-02-29 23:00")},
+			},
+		},
+		{
+			input:   "SELECT departs + length, departs - TIMESTAMPTZ '2024-01-01 00:00+00', length * 2, -length FROM flights ORDER BY id",
+			columns: []string{"?column?", "?column?", "?column?", "?column?"},
+			expected: []sql.Row{
+				{timestamptz("2024-02-02 00:30+00"), interval("30 days 22:30:00"), interval("2 days 04:00:00"), interval("-1 day -02:00:00")},
+				{timestamptz("2024-02-29 10:45:30.5+00"), interval("59 days 09:15:30.5"), interval("03:00:00"), interval("-01:30:00")},
+			},
+		},
+		{
+			input:   "SELECT date_trunc('month', departs), date_trunc('hour', departs::TIMESTAMP), extract(YEAR FROM day), extract(epoch FROM length), date_part('dow', day) FROM flights ORDER BY id",
+			columns: []string{"date_trunc", "date_trunc", "extract", "extract", "date_part"},
+			expected: []sql.Row{
+				{timestamptz("2024-01-01 00:00+00"), timestamp("2024-01-31 22:00"), float(2024), float(93600), float(3)},
+				{timestamptz("2024-02-01 00:00+00"), timestamp("2024-02-29 09:00"), float(2024), float(5400), float(4)},
+			},
+		},
+		{
+			input:   "SELECT departs AT TIME ZONE 'Asia/Yakutsk', (departs AT TIME ZONE 'UTC') AT TIME ZONE '+09' FROM flights ORDER BY id",
+			columns: []string{"timezone", "timezone"},
+			expected: []sql.Row{
+				{timestamp("2024-02-01 07:30"), timestamptz("2024-01-31 13:30+00")},
+				{timestamp("2024-02-29 18:15:30.5"), timestamptz("2024-02-29 00:15:30.5+00")},
+			},
+		},
+		{
+			input:    "SELECT id FROM flights WHERE departs > '2024-02-01' AND day BETWEEN '2024-02-01' AND '2024-03-01'",
+			columns:  []string{"id"},
+			expected: []sql.Row{{integer(2)}},
+		},
+		{
+			input:    "SELECT max(departs), min(day), max(length) FROM flights",
+			columns:  []string{"max", "min", "max"},
+			expected: []sql.Row{{timestamptz("2024-02-29 09:15:30.5+00"), date("2024-01-31"), interval("1 day 02:00:00")}},
+		},
+		{
+			input:    "SELECT DATE '2024-01-01' < now(), TIME '12:00' + INTERVAL '13 hours'",
+			columns:  []string{"?column?", "?column?"},
+			expected: []sql.Row{{boolean(true), parse("01:00", sql.Time)}},
+		},
+		{input: "SELECT DATE '2024-02-30'", err: `invalid input syntax for type date: "2024-02-30"`},
+		{input: "SELECT day + day FROM flights", err: "operator does not exist: date + date"},
+		{input: "SELECT date_trunc('fortnight', departs) FROM flights", err: `unit "fortnight" not recognized for type timestamptz`},
+		{input: "SELECT extract(hour FROM day) FROM flights", err: `unit "hour" not supported for type date`},
+		{input: "SELECT departs AT TIME ZONE 'Mars' FROM flights", err: `time zone "Mars" not recognized`},
+		{input: "SELECT length / 0 FROM flights", err: "division by zero"},
+		{input: "INSERT INTO flights (id, day) VALUES (3, 'tomorrow')", err: `column "day" is of type date: invalid input syntax for type date: "tomorrow"`},
+	}
+
+	for _, test := range tests {
+		result, err := engine.Exec(test.input)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err, test.input)
+			continue
+		}
+
+		assert.NoError(t, err, test.input)
+		assert.Equal(t, test.columns, result.Columns, test.input)
+		assert.Equal(t, test.expected, collect(t, result), test.input)
+	}
+}
+
+func TestSelect_Decimal(t *testing.T) {
+	engine, db := newTestEngine(t,
+		"CREATE TABLE payments (id INT PRIMARY KEY, amount NUMERIC(10, 2), rate DECIMAL(5), raw NUMERIC)",
+		"INSERT INTO payments (amount, rate, raw) VALUES (19.99, 3, '0.1')",
+		"INSERT INTO payments (amount, rate, raw) VALUES ('0.005', 2.5, 0.2)",
+		"INSERT INTO payments (amount, rate, raw) VALUES (-0.015, -2.5, '1e-3')",
+	)
+
+	tests := []struct {
+		input    string
+		columns  []string
+		expected [][]string
+		err      string
+	}{
+		{
+			input:   "SELECT amount, rate, raw FROM payments ORDER BY id",
+			columns: []string{"amount", "rate", "raw"},
+			expected: [][]string{
+				{"19.99", "3", "0.1"},
+				{"0.01", "3", "0.2"},
+				{"-0.02", "-3", "0.001"},
+			},
+		},
+		{
+			input:    "SELECT sum(amount), avg(amount), sum(raw), avg(raw), max(raw), count(DISTINCT rate) FROM payments",
+			columns:  []string{"sum", "avg", "sum", "avg", "max", "count"},
+			expected: [][]string{{"19.98", "6.660000000000000", "0.301", "0.10033333333333333", "0.2", "2"}},
+		},
+		{
+			input:   "SELECT raw + 1, raw * amount, amount / 3, -amount, raw + 0.5 FROM payments ORDER BY id",
+			columns: []string{"?column?", "?column?", "?column?", "?column?", "?column?"},
+			expected: [][]string{
+				{"1.1", "1.999", "6.663333333333333", "-19.99", "0.6"},
+				{"1.2", "0.002", "0.003333333333333333", "-0.01", "0.7"},
+				{"1.001", "-0.00002", "-0.006666666666666667", "0.02", "0.501"},
+			},
+		},
+		{
+			input:   "SELECT round(amount, 1), trunc(amount, 1), ceil(amount), floor(amount), round(amount), abs(amount), mod(amount, 3) FROM payments ORDER BY id",
+			columns: []string{"round", "trunc", "ceil", "floor", "round", "abs", "mod"},
+			expected: [][]string{
+				{"20.0", "19.9", "20", "19", "20", "19.99", "1.99"},
+				{"0.0", "0.0", "1", "0", "0", "0.01", "0.01"},
+				{"0.0", "0.0", "0", "-1", "0", "0.02", "-0.02"},
+			},
+		},
+		{
+			input:    "SELECT NUMERIC '0.1' + NUMERIC '0.2' = NUMERIC '0.3', 0.1 + 0.2 = 0.3, CAST('2.5' AS NUMERIC)::INT, '1.005'::NUMERIC(4, 2), 1::NUMERIC / 3",
+			columns:  []string{"?column?", "?column?", "int", "numeric", "?column?"},
+			expected: [][]string{{"true", "true", "3", "1.01", "0.3333333333333333"}},
+		},
+		{
+			input:    "SELECT amount + 0.1, raw * 0.3, 0.1 + 0.2, CAST(0.12345678901234567890123 AS NUMERIC), 1.50 FROM payments WHERE id = 1",
+			columns:  []string{"?column?", "?column?", "?column?", "numeric", "?column?"},
+			expected: [][]string{{"20.09", "0.03", "0.3", "0.12345678901234567890123", "1.50"}},
+		},
+		{
+			input:    "SELECT round(5), round(1234.5::NUMERIC, -2), 10::NUMERIC(5, 2)",
+			columns:  []string{"round", "round", "numeric"},
+			expected: [][]string{{"5E+00", "1200", "10.00"}},
+		},
+		{
+			input:    "SELECT id FROM payments WHERE amount > 1 OR raw = 0.2 ORDER BY id",
+			columns:  []string{"id"},
+			expected: [][]string{{"1"}, {"2"}},
+		},
+		{input: "INSERT INTO payments (amount) VALUES (99999999.995)", err: `column "amount" is of type numeric(10,2): numeric field overflow: a field with precision 10, scale 2 must round to an absolute value less than 10^8`},
+		{input: "INSERT INTO payments (amount) VALUES ('abc')", err: `column "amount" is of type numeric(10,2): invalid input syntax for type numeric: "abc"`},
+		{input: "SELECT 1::NUMERIC(0)", err: "NUMERIC precision 0 must be at least 1"},
+		{input: "SELECT 1::NUMERIC(3, 4)", err: "NUMERIC scale 4 must be between 0 and precision 3"},
+		{input: "SELECT 1::NUMERIC / 0", err: "division by zero"},
+		{input: "CREATE TABLE bad (id INT PRIMARY KEY, n NUMERIC(1001))", err: "NUMERIC precision 1001 must be between 1 and 1000"},
+		{input: "ALTER TABLE payments ALTER COLUMN rate TYPE NUMERIC(1, 1)", err: `column "rate" cannot be cast to type numeric(1,1): numeric field overflow: a field with precision 1, scale 1 must round to an absolute value less than 10^0`},
+	}
+
+	for _, test := range tests {
+		result, err := engine.Exec(test.input)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err, test.input)
+			continue
+		}
+
+		assert.NoError(t, err, test.input)
+		assert.Equal(t, test.columns, result.Columns, test.input)
+		// Decimals compare by their text, which is what their scale is about.
+		var rows [][]string
+		for _, row := range collect(t, result) {
+			var values []string
+			for _, value := range row {
+				values = append(values, value.String())
+			}
+			rows = append(rows, values)
+		}
+		assert.Equal(t, test.expected, rows, test.input)
+	}
+
+	// Decimal literals are stored exactly, not through a float.
+	_, err := engine.Exec("CREATE TABLE ledger (id INT PRIMARY KEY, balance NUMERIC(30, 20))")
+	assert.NoError(t, err)
+	_, err = engine.Exec("INSERT INTO ledger (balance) VALUES (1234567890.12345678901234567890), (-0.00000000000000000001)")
+	assert.NoError(t, err)
+
+	var balances []string
+	for _, row := range scan(t, db, "ledger") {
+		balances = append(balances, row[1].String())
+	}
+	assert.Equal(t, []string{"1234567890.12345678901234567890", "-0.00000000000000000001"}, balances)
+
+	_, err = engine.Exec("ALTER TABLE payments ALTER COLUMN rate TYPE NUMERIC(6, 1)")
+	assert.NoError(t, err)
+
+	result, err := engine.Exec("SHOW COLUMNS FROM payments")
+	assert.NoError(t, err)
+
+	var types []string
+	for _, row := range collect(t, result) {
+		types = append(types, row[1].String())
+	}
+	assert.Equal(t, []string{"integer", "numeric(10,2)", "numeric(6,1)", "numeric"}, types)
+}
+
+func TestSelect_JSON(t *testing.T) {
+	engine, db := newTestEngine(t,
+		"CREATE TABLE docs (id INT PRIMARY KEY, body JSONB NOT NULL)",
+		`INSERT INTO docs (body) VALUES ('{"kind": "post", "tags": ["go", "sql"], "meta": {"views": 10}}')`,
+		`INSERT INTO docs (body) VALUES ('{"kind": "page", "tags": [], "meta": {"views": 2.5}}')`,
+		`INSERT INTO docs (body) VALUES ('{"kind": "post", "tags": ["db"], "draft": true}')`,
+		"CREATE INDEX docs_kind ON docs ((body->>'kind'))",
+	)
+
+	tests := []struct {
+		input    string
+		columns  []string
+		expected [][]string
+		err      string
+	}{
+		{
+			input:   "SELECT body->'tags', body->'tags'->>0, body #> '{meta,views}', body->>'draft' FROM docs ORDER BY id",
+			columns: []string{"?column?", "?column?", "?column?", "?column?"},
+			expected: [][]string{
+				{`["go", "sql"]`, "go", "10", "NULL"},
+				{`[]`, "NULL", "2.5", "NULL"},
+				{`["db"]`, "db", "NULL", "true"},
+			},
+		},
+		{
+			input:    "SELECT id FROM docs WHERE body->>'kind' = 'post' AND body @> '{\"tags\": [\"db\"]}'",
+			columns:  []string{"id"},
+			expected: [][]string{{"3"}},
+		},
+		{
+			input:    "SELECT id FROM docs d WHERE 'post' = d.body->>'kind' ORDER BY id",
+			columns:  []string{"id"},
+			expected: [][]string{{"1"}, {"3"}},
+		},
+		{
+			input:   "SELECT json_extract(body, '$.meta.views'), json_array_length(body->'tags'), jsonb_contains(body, '{\"kind\": \"page\"}') FROM docs ORDER BY id",
+			columns: []string{"json_extract", "json_array_length", "jsonb_contains"},
+			expected: [][]string{
+				{"10", "2", "false"},
+				{"2.5", "0", "true"},
+				{"NULL", "1", "false"},
+			},
+		},
+		{
+			input:    "SELECT json_object('id', id, 'kind', body->>'kind', 'tags', body->'tags') FROM docs WHERE id = 1",
+			columns:  []string{"json_object"},
+			expected: [][]string{{`{"id": 1, "kind": "post", "tags": ["go", "sql"]}`}},
+		},
+		{
+			input:    "SELECT body->>'kind', json_agg(id), json_agg(body->'draft') FROM docs GROUP BY body->>'kind' ORDER BY 1",
+			columns:  []string{"?column?", "json_agg", "json_agg"},
+			expected: [][]string{{"page", "[2]", "[null]"}, {"post", "[1, 3]", "[null, true]"}},
+		},
+		{
+			input:    "SELECT json_agg(id) FROM docs WHERE false",
+			columns:  []string{"json_agg"},
+			expected: [][]string{{"NULL"}},
+		},
+		{
+			input:    `SELECT body->'meta' = '{"views": 10.0}', (body->'meta'->'views')::NUMERIC * 2, '[1, 2]'::JSONB, '{"a": 1}'::JSON->'a' FROM docs WHERE id = 1`,
+			columns:  []string{"?column?", "?column?", "jsonb", "?column?"},
+			expected: [][]string{{"true", "20", "[1, 2]", "1"}},
+		},
+		{input: "INSERT INTO docs (body) VALUES ('{\"kind\": ')", err: `column "body" is of type jsonb: invalid input syntax for type jsonb: "{\"kind\": "`},
+		{input: "SELECT id->'a' FROM docs", err: "operator does not exist: integer -> text"},
+		{input: "SELECT body #> 'a' FROM docs", err: `malformed array literal: "a"`},
+		{input: "SELECT json_extract(body, 'meta') FROM docs", err: `invalid JSON path: "meta"`},
+		{input: "SELECT json_array_length(body) FROM docs", err: "cannot get array length of a non-array"},
+		{input: "SELECT json_object('a', 1, 'b')", err: "argument list must have even number of elements"},
+		{input: "SELECT (body->'tags')::INT FROM docs", err: "cannot cast jsonb array to type integer"},
+		{input: "CREATE INDEX docs_kind ON docs (id)", err: `relation "docs_kind" already exists`},
+		{input: "CREATE INDEX docs_random ON docs ((body->>'kind' = random()::TEXT))", err: "functions in index expression must be marked IMMUTABLE"},
+		{input: "CREATE INDEX docs_missing ON docs (missing)", err: `column "missing" does not exist`},
+		{input: "ALTER TABLE docs DROP COLUMN body", err: `cannot drop column "body" of table "docs" because other objects depend on it`},
+	}
+
+	for _, test := range tests {
+		result, err := engine.Exec(test.input)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err, test.input)
+			continue
+		}
+
+		assert.NoError(t, err, test.input)
+		assert.Equal(t, test.columns, result.Columns, test.input)
+		// Documents compare by their text, as their numbers are decimals.
+		var rows [][]string
+		for _, row := range collect(t, result) {
+			var values []string
+			for _, value := range row {
+				values = append(values, value.String())
+			}
+			rows = append(rows, values)
+		}
+		assert.Equal(t, test.expected, rows, test.input)
+	}
+
+	table, err := db.GetTable("docs")
+	assert.NoError(t, err)
+
+	lookup := func(kind string) []int64 {
+		keys, _, err := table.Lookup("docs_kind", datatype.NewText(kind))
+		assert.NoError(t, err)
+		return keys
+	}
+
+	_, err = engine.Exec(`UPDATE docs SET body = '{"kind": "page"}' WHERE body->>'kind' = 'post' AND id = 3`)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1}, lookup("post"))
+	assert.Equal(t, []int64{2, 3}, lookup("page"))
+
+	_, err = engine.Exec("ALTER TABLE docs RENAME COLUMN body TO doc")
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{2, 3}, lookup("page"))
+
+	result, err := engine.Exec("SHOW CREATE TABLE docs")
+	assert.NoError(t, err)
+	assert.Equal(t, []sql.Row{{
+		datatype.NewText("docs"),
+		datatype.NewText("CREATE TABLE docs (\n    id INTEGER PRIMARY KEY,\n    doc JSONB NOT NULL\n);\nCREATE INDEX docs_kind ON docs (doc ->> 'kind')"),
+	}}, collect(t, result))
+
+	_, err = engine.Exec("DELETE FROM docs WHERE doc->>'kind' = 'page'")
+	assert.NoError(t, err)
+	assert.Empty(t, lookup("page"))
+
+	result, err = engine.Exec("DROP INDEX docs_kind")
+	assert.NoError(t, err)
+	assert.Equal(t, "drop index docs_kind\n", result.Message)
+
+	result, err = engine.Exec("DROP INDEX IF EXISTS docs_kind")
+	assert.NoError(t, err)
+	assert.Equal(t, "index \"docs_kind\" does not exist, skipping\n", result.Message)
+	assert.Empty(t, table.Indexes())
+}
+
+func TestJoin_Operators(t *testing.T) {
+	t.Parallel()
+
+	integer, null := datatype.NewInteger, datatype.NewNull()
+
+	columns := func(table string) []column {
+		return []column{{table: table, name: "k", dataType: sql.Integer}}
+	}
+
+	// Both sides are sorted by the key, so the merge join can be used too.
+	left := source{columns: columns("l"), rows: []sql.Row{{integer(1)}, {integer(2)}, {integer(2)}, {integer(4)}, {null}}}
+	right := source{columns: columns("r"), rows: []sql.Row{{integer(2)}, {integer(2)}, {integer(3)}, {integer(4)}, {null}}}
+
+	for _, joinType := range []string{"INNER", "LEFT", "RIGHT", "FULL"} {
+		stmt := ast.JoinStatement{Type: token.TokenType(joinType)}
+		keys := []joinKey{{left: 0, right: 0}}
+
+		var results [][]sql.Row
+
+		for _, run := range []func(j *joiner) error{
+			func(j *joiner) error {
+				j.residual = &ast.ConditionExpr{
+					Left:     &ast.IdentExpr{Table: "l", Name: "k"},
+					Operator: token.EQ,
+					Right:    &ast.IdentExpr{Table: "r", Name: "k"},
+				}
+				return j.nestedLoop()
+			},
+			func(j *joiner) error { return j.hash(keys) },
+			func(j *joiner) error { return j.merge(keys) },
+		} {
+			j := newJoiner(left, right, stmt)
+			j.columns = append(columns("l"), columns("r")...)
+			j.scope = &scope{columns: j.columns}
+
+			assert.NoError(t, run(j))
+			results = append(results, j.result())
+		}
+
+		assert.ElementsMatch(t, results[0], results[1], joinType)
+		assert.ElementsMatch(t, results[0], results[2], joinType)
+	}
 }
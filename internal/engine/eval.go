@@ -0,0 +1,675 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/okazaki-kk/miniDB/internal/parser/ast"
+	"github.com/okazaki-kk/miniDB/internal/parser/token"
+	"github.com/okazaki-kk/miniDB/internal/sql"
+	"github.com/okazaki-kk/miniDB/internal/sql/datatype"
+	"github.com/okazaki-kk/miniDB/storage"
+)
+
+// column describes a column of the rows an expression is evaluated against.
+type column struct {
+	table    string
+	name     string
+	dataType sql.DataType
+	// hidden columns are only found by qualified references. They are the
+	// columns of a join USING them, which are merged into one.
+	hidden bool
+}
+
+// scope binds the column references of an expression to the values of a row.
+// A nil scope evaluates constant expressions only.
+type scope struct {
+	columns []column
+	row     sql.Row
+	// aggregates binds the aggregate calls of a grouped query to the index of
+	// their value in the row, after the columns.
+	aggregates map[*ast.CallExpr]int
+	// windows binds the window function calls of a query to the index of
+	// their value in the row, after the aggregates.
+	windows map[*ast.WindowExpr]int
+	// outer is the scope of the query a subquery is nested in. Columns which
+	// are not found in the scope are looked up there.
+	outer *scope
+	// correlated is set once a column of an outer scope is referenced.
+	correlated bool
+	// executor runs the subqueries of the expression.
+	executor *executor
+	// tables binds the names of a WITH clause to their queries.
+	tables map[string]*commonTable
+	// registry holds the functions registered with the engine, which
+	// expressions evaluated outside of a query call.
+	registry *registry
+}
+
+// functions returns the functions registered with the engine evaluating the
+// expression, found in the innermost scope knowing them.
+func (s *scope) functions() *registry {
+	for ; s != nil; s = s.outer {
+		if s.registry != nil {
+			return s.registry
+		}
+		if s.executor != nil {
+			return s.executor.engine.registry
+		}
+	}
+
+	return nil
+}
+
+func (s *scope) lookup(ident *ast.IdentExpr) (sql.Value, error) {
+	owner, i, err := s.resolve(ident)
+	if err != nil {
+		return nil, err
+	}
+
+	return owner.row[i], nil
+}
+
+// index returns the index of the column the identifier references. An
+// unqualified name must be unique among the tables of the scope. Outer scopes
+// are not searched.
+func (s *scope) index(ident *ast.IdentExpr) (int, error) {
+	i, err := s.find(ident)
+	if err != nil {
+		return 0, err
+	}
+	if i < 0 {
+		return 0, s.missing(ident)
+	}
+
+	return i, nil
+}
+
+// resolve returns the scope of the column the identifier references and its
+// index, searching the outer scopes from the innermost. The scopes between
+// are marked correlated.
+func (s *scope) resolve(ident *ast.IdentExpr) (*scope, int, error) {
+	for owner := s; owner != nil; owner = owner.outer {
+		i, err := owner.find(ident)
+		if err != nil {
+			return nil, 0, err
+		}
+		if i < 0 {
+			continue
+		}
+
+		s.correlate(owner)
+		return owner, i, nil
+	}
+
+	return nil, 0, s.missing(ident)
+}
+
+// correlate marks the scopes from the scope up to the outer scope correlated,
+// excluding the outer scope.
+func (s *scope) correlate(outer *scope) {
+	for inner := s; inner != nil && inner != outer; inner = inner.outer {
+		inner.correlated = true
+	}
+}
+
+// commonTable returns the query of a WITH clause with the name, searching the
+// outer scopes from the innermost.
+func (s *scope) commonTable(name string) *commonTable {
+	for bound := s; bound != nil; bound = bound.outer {
+		if c, ok := bound.tables[name]; ok {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// find returns the index of the column in the scope, or -1.
+func (s *scope) find(ident *ast.IdentExpr) (int, error) {
+	found := -1
+
+	if s != nil {
+		for i, c := range s.columns {
+			if c.name != ident.Name || (ident.Table == "" && c.hidden) || (ident.Table != "" && c.table != ident.Table) {
+				continue
+			}
+
+			if found >= 0 {
+				return 0, fmt.Errorf("column reference %q is ambiguous", ident.String())
+			}
+			found = i
+		}
+	}
+
+	return found, nil
+}
+
+func (s *scope) missing(ident *ast.IdentExpr) error {
+	if ident.Table != "" {
+		found := false
+		for owner := s; owner != nil && !found; owner = owner.outer {
+			found = owner.hasTable(ident.Table)
+		}
+
+		if !found {
+			return fmt.Errorf("missing FROM-clause entry for table %q", ident.Table)
+		}
+	}
+
+	return fmt.Errorf("column %q does not exist", ident.String())
+}
+
+// expand returns the indexes of the columns the asterisk stands for: the
+// columns of the table or, unqualified, all columns but the hidden ones.
+func (s *scope) expand(asterisk *ast.AsteriskExpr) ([]int, error) {
+	if s == nil || len(s.columns) == 0 {
+		return nil, fmt.Errorf("SELECT * with no tables specified is not valid")
+	}
+
+	if asterisk.Table != "" && !s.hasTable(asterisk.Table) {
+		return nil, fmt.Errorf("missing FROM-clause entry for table %q", asterisk.Table)
+	}
+
+	var indexes []int
+	for i, c := range s.columns {
+		if (asterisk.Table == "" && !c.hidden) || (asterisk.Table != "" && c.table == asterisk.Table) {
+			indexes = append(indexes, i)
+		}
+	}
+
+	return indexes, nil
+}
+
+func (s *scope) hasTable(table string) bool {
+	if s != nil {
+		for _, c := range s.columns {
+			if c.table == table {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// eval evaluates the expression. NULL is datatype.Null.
+func eval(expr ast.Expression, s *scope) (sql.Value, error) {
+	switch expr := expr.(type) {
+	case *ast.ScalarExpr:
+		return literal(expr)
+	case *ast.IdentExpr:
+		return s.lookup(expr)
+	case *ast.UnaryExpr:
+		return evalUnary(expr, s)
+	case *ast.ConditionExpr:
+		return evalBinary(expr, s)
+	case *ast.IsNullExpr:
+		value, err := eval(expr.Expr, s)
+		if err != nil {
+			return nil, err
+		}
+		return datatype.NewBoolean(sql.IsNull(value) != expr.Not), nil
+	case *ast.IsDistinctExpr:
+		return evalDistinct(expr, s)
+	case *ast.CallExpr:
+		return evalCall(expr, s)
+	case *ast.SubqueryExpr:
+		return evalSubquery(expr, s)
+	case *ast.ExistsExpr:
+		return evalExists(expr, s)
+	case *ast.InExpr:
+		return evalIn(expr, s)
+	case *ast.BetweenExpr:
+		return evalBetween(expr, s)
+	case *ast.LikeExpr:
+		return evalLike(expr, s)
+	case *ast.CaseExpr:
+		return evalCase(expr, s)
+	case *ast.CastExpr:
+		value, err := eval(expr.Expr, s)
+		if err != nil {
+			return nil, err
+		}
+
+		to, err := castType(expr)
+		if err != nil {
+			return nil, err
+		}
+		return storage.ConvertValue(value, to, expr.Precision, expr.Scale)
+	case *ast.AtTimeZoneExpr:
+		return evalAtTimeZone(expr, s)
+	case *ast.WindowExpr:
+		if s != nil {
+			if i, ok := s.windows[expr]; ok {
+				return s.row[i], nil
+			}
+		}
+		return nil, fmt.Errorf("window function %s is not allowed here", strings.ToLower(expr.Call.Name))
+	case *ast.DefaultExpr:
+		return nil, fmt.Errorf("DEFAULT is not allowed in this context")
+	default:
+		return nil, fmt.Errorf("unsupported expression %T", expr)
+	}
+}
+
+// castType returns the data type of the conversion, verifying the precision
+// and scale of NUMERIC(p, s).
+func castType(expr *ast.CastExpr) (sql.DataType, error) {
+	if expr.Precision > 0 {
+		if err := datatype.CheckDecimalType(expr.Precision, expr.Scale); err != nil {
+			return sql.Null, err
+		}
+	}
+
+	return storage.ColumnType(expr.Type)
+}
+
+// operands returns the expressions the predicate, CASE or CAST expression is
+// made of.
+func operands(expr ast.Expression) []ast.Expression {
+	switch expr := expr.(type) {
+	case *ast.InExpr:
+		return append([]ast.Expression{expr.Expr}, expr.List...)
+	case *ast.BetweenExpr:
+		return []ast.Expression{expr.Expr, expr.Low, expr.High}
+	case *ast.LikeExpr:
+		if expr.Escape != nil {
+			return []ast.Expression{expr.Expr, expr.Pattern, expr.Escape}
+		}
+		return []ast.Expression{expr.Expr, expr.Pattern}
+	case *ast.CaseExpr:
+		var exprs []ast.Expression
+		if expr.Operand != nil {
+			exprs = append(exprs, expr.Operand)
+		}
+		for _, when := range expr.Whens {
+			exprs = append(exprs, when.Cond, when.Result)
+		}
+		if expr.Else != nil {
+			exprs = append(exprs, expr.Else)
+		}
+		return exprs
+	case *ast.CastExpr:
+		return []ast.Expression{expr.Expr}
+	case *ast.AtTimeZoneExpr:
+		return []ast.Expression{expr.Expr, expr.Zone}
+	default:
+		return nil
+	}
+}
+
+// evalDistinct compares the operands treating NULL like an ordinary value,
+// so the result is never NULL.
+func evalDistinct(expr *ast.IsDistinctExpr, s *scope) (sql.Value, error) {
+	left, err := eval(expr.Left, s)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := eval(expr.Right, s)
+	if err != nil {
+		return nil, err
+	}
+
+	if sql.IsNull(left) || sql.IsNull(right) {
+		distinct := sql.IsNull(left) != sql.IsNull(right)
+		return datatype.NewBoolean(distinct != expr.Not), nil
+	}
+
+	c, err := left.Compare(right)
+	if err != nil {
+		return nil, err
+	}
+
+	return datatype.NewBoolean((c != 0) != expr.Not), nil
+}
+
+// evalCase returns the result of the first WHEN branch whose condition is
+// true or, in a simple CASE, equal to the operand. Without one it returns the
+// ELSE result.
+func evalCase(expr *ast.CaseExpr, s *scope) (sql.Value, error) {
+	var operand sql.Value
+	if expr.Operand != nil {
+		var err error
+		if operand, err = eval(expr.Operand, s); err != nil {
+			return nil, err
+		}
+	}
+
+	result := expr.Else
+	for _, when := range expr.Whens {
+		cond, err := eval(when.Cond, s)
+		if err != nil {
+			return nil, err
+		}
+
+		matched := false
+		if operand != nil {
+			if !sql.IsNull(operand) && !sql.IsNull(cond) {
+				c, err := operand.Compare(cond)
+				if err != nil {
+					return nil, err
+				}
+				matched = c == 0
+			}
+		} else {
+			b, ok, err := truth(cond)
+			if err != nil {
+				return nil, err
+			}
+			matched = ok && b
+		}
+
+		if matched {
+			result = when.Result
+			break
+		}
+	}
+
+	if result == nil {
+		return datatype.NewNull(), nil
+	}
+
+	value, err := eval(result, s)
+	if err != nil {
+		return nil, err
+	}
+
+	// The value converts to the common type of the results of all branches.
+	t, err := typeOf(expr, s)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertResult(value, t)
+}
+
+// evalBetween evaluates x BETWEEN low AND high like low <= x AND x <= high.
+func evalBetween(expr *ast.BetweenExpr, s *scope) (sql.Value, error) {
+	value, err := eval(expr.Expr, s)
+	if err != nil {
+		return nil, err
+	}
+
+	low, err := eval(expr.Low, s)
+	if err != nil {
+		return nil, err
+	}
+
+	high, err := eval(expr.High, s)
+	if err != nil {
+		return nil, err
+	}
+
+	above, err := lessOrEqual(low, value)
+	if err != nil {
+		return nil, err
+	}
+
+	below, err := lessOrEqual(value, high)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := logical(token.AND, above, below)
+	if err != nil || sql.IsNull(result) || !expr.Not {
+		return result, err
+	}
+
+	return datatype.NewBoolean(!result.Raw().(bool)), nil
+}
+
+// lessOrEqual compares the values, NULL when one of them is NULL.
+func lessOrEqual(a, b sql.Value) (sql.Value, error) {
+	if sql.IsNull(a) || sql.IsNull(b) {
+		return datatype.NewNull(), nil
+	}
+
+	c, err := compareValues(a, b)
+	if err != nil {
+		return nil, err
+	}
+
+	return datatype.NewBoolean(c <= 0), nil
+}
+
+func literal(expr *ast.ScalarExpr) (sql.Value, error) {
+	switch expr.Type {
+	case token.INT:
+		i, err := strconv.ParseInt(expr.Literal, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q", expr.Literal)
+		}
+		return datatype.NewInteger(i), nil
+	case token.FLOAT:
+		// A number with a point is a NUMERIC like in PostgreSQL, exactly the
+		// literal, not the nearest float.
+		d, err := datatype.ParseDecimal(expr.Literal)
+		if err != nil {
+			return nil, fmt.Errorf("invalid numeric %q: %w", expr.Literal, err)
+		}
+		return d, nil
+	case token.TEXT:
+		return datatype.NewText(expr.Literal), nil
+	case token.TRUE:
+		return datatype.NewBoolean(true), nil
+	case token.FALSE:
+		return datatype.NewBoolean(false), nil
+	case token.NULL:
+		return datatype.NewNull(), nil
+	default:
+		return nil, fmt.Errorf("unexpected literal %s(%q)", expr.Type, expr.Literal)
+	}
+}
+
+func evalUnary(expr *ast.UnaryExpr, s *scope) (sql.Value, error) {
+	value, err := eval(expr.Operand, s)
+	if err != nil || sql.IsNull(value) {
+		return value, err
+	}
+
+	switch expr.Operator {
+	case token.NOT:
+		b, _, err := truth(value)
+		if err != nil {
+			return nil, err
+		}
+		return datatype.NewBoolean(!b), nil
+	case token.PLUS:
+		if _, ok := number(value); ok {
+			return value, nil
+		}
+	case token.MINUS:
+		switch v := value.Raw().(type) {
+		case int64:
+			if v == math.MinInt64 {
+				return nil, fmt.Errorf("integer out of range")
+			}
+			return datatype.NewInteger(-v), nil
+		case float64:
+			return datatype.NewFloat(-v), nil
+		case datatype.Decimal:
+			return v.Neg(), nil
+		case datatype.Interval:
+			return datatype.NewInterval(-v.Months(), -v.Days(), -v.Micros()), nil
+		}
+	}
+
+	return nil, fmt.Errorf("operator does not exist: %s%s", expr.Operator, value.DataType())
+}
+
+func evalBinary(expr *ast.ConditionExpr, s *scope) (sql.Value, error) {
+	left, err := eval(expr.Left, s)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := eval(expr.Right, s)
+	if err != nil {
+		return nil, err
+	}
+
+	switch expr.Operator {
+	case token.AND, token.OR:
+		return logical(expr.Operator, left, right)
+	}
+
+	if sql.IsNull(left) || sql.IsNull(right) {
+		return datatype.NewNull(), nil
+	}
+
+	switch expr.Operator {
+	case token.PLUS, token.MINUS, token.ASTERISK, token.SLASH:
+		return arithmetic(expr.Operator, left, right)
+	case token.ARROW, token.DOUBLE_ARROW, token.HASH_ARROW, token.CONTAINS:
+		return jsonOperation(expr.Operator, left, right)
+	case token.EQ, token.NOT_EQ, token.LT, token.GT:
+		c, err := compareValues(left, right)
+		if err != nil {
+			return nil, err
+		}
+
+		switch expr.Operator {
+		case token.EQ:
+			return datatype.NewBoolean(c == 0), nil
+		case token.NOT_EQ:
+			return datatype.NewBoolean(c != 0), nil
+		case token.LT:
+			return datatype.NewBoolean(c < 0), nil
+		default:
+			return datatype.NewBoolean(c > 0), nil
+		}
+	default:
+		return nil, fmt.Errorf("unsupported operator %s", expr.Operator)
+	}
+}
+
+// logical implements the three-valued AND and OR.
+func logical(operator token.TokenType, left, right sql.Value) (sql.Value, error) {
+	l, lok, err := truth(left)
+	if err != nil {
+		return nil, err
+	}
+
+	r, rok, err := truth(right)
+	if err != nil {
+		return nil, err
+	}
+
+	if operator == token.AND {
+		switch {
+		case (lok && !l) || (rok && !r):
+			return datatype.NewBoolean(false), nil
+		case lok && rok:
+			return datatype.NewBoolean(true), nil
+		}
+		return datatype.NewNull(), nil
+	}
+
+	switch {
+	case (lok && l) || (rok && r):
+		return datatype.NewBoolean(true), nil
+	case lok && rok:
+		return datatype.NewBoolean(false), nil
+	}
+	return datatype.NewNull(), nil
+}
+
+// truth returns the boolean and whether the value is not NULL.
+func truth(value sql.Value) (bool, bool, error) {
+	if sql.IsNull(value) {
+		return false, false, nil
+	}
+
+	b, ok := value.Raw().(bool)
+	if !ok {
+		return false, false, fmt.Errorf("argument must be type boolean, not type %s", value.DataType())
+	}
+
+	return b, true, nil
+}
+
+func arithmetic(operator token.TokenType, left, right sql.Value) (sql.Value, error) {
+	if _, ok := datetimeOperations[operation{operator, left.DataType(), right.DataType()}]; ok {
+		return datetimeArithmetic(operator, left, right)
+	}
+
+	if l, ok := left.Raw().(int64); ok {
+		if r, ok := right.Raw().(int64); ok {
+			return integerArithmetic(operator, l, r)
+		}
+	}
+
+	if l, r, ok := decimals(left, right); ok {
+		return decimalArithmetic(operator, l, r)
+	}
+
+	l, lok := number(left)
+	r, rok := number(right)
+	if !lok || !rok {
+		return nil, fmt.Errorf("operator does not exist: %s %s %s", left.DataType(), operator, right.DataType())
+	}
+
+	switch operator {
+	case token.PLUS:
+		return datatype.NewFloat(l + r), nil
+	case token.MINUS:
+		return datatype.NewFloat(l - r), nil
+	case token.ASTERISK:
+		return datatype.NewFloat(l * r), nil
+	default:
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return datatype.NewFloat(l / r), nil
+	}
+}
+
+// integerArithmetic applies the operator to the integers, failing when the
+// result doesn't fit in an integer rather than wrapping around.
+func integerArithmetic(operator token.TokenType, l, r int64) (sql.Value, error) {
+	var (
+		result   int64
+		overflow bool
+	)
+
+	switch operator {
+	case token.PLUS:
+		result = l + r
+		overflow = (r > 0 && result < l) || (r < 0 && result > l)
+	case token.MINUS:
+		result = l - r
+		overflow = (r > 0 && result > l) || (r < 0 && result < l)
+	case token.ASTERISK:
+		result = l * r
+		overflow = l != 0 && (result/l != r || (l == -1 && r == math.MinInt64))
+	default:
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		result = l / r
+		overflow = l == math.MinInt64 && r == -1
+	}
+
+	if overflow {
+		return nil, fmt.Errorf("integer out of range")
+	}
+
+	return datatype.NewInteger(result), nil
+}
+
+func number(value sql.Value) (float64, bool) {
+	switch v := value.Raw().(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case datatype.Decimal:
+		return v.Float64(), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,820 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/okazaki-kk/miniDB/internal/parser/ast"
+	"github.com/okazaki-kk/miniDB/internal/parser/token"
+	"github.com/okazaki-kk/miniDB/internal/sql"
+	"github.com/okazaki-kk/miniDB/internal/sql/datatype"
+)
+
+// Signature is the argument and result types of a function. sql.Null stands
+// for any type. When the result is of any type, the arguments of any type
+// are converted to their common type, which is the type of the result.
+type Signature struct {
+	Args []sql.DataType
+	// Variadic functions take the last argument one or more times.
+	Variadic bool
+	Result   sql.DataType
+}
+
+// ArgumentTypes returns the names of the argument types, like psql's \df.
+func (s Signature) ArgumentTypes() string {
+	names := make([]string, len(s.Args))
+	for i, arg := range s.Args {
+		switch {
+		case arg != sql.Null:
+			names[i] = arg.String()
+		case s.Result == sql.Null:
+			names[i] = "anyelement"
+		default:
+			names[i] = `"any"`
+		}
+	}
+
+	if s.Variadic {
+		names[len(names)-1] = "VARIADIC " + names[len(names)-1]
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// ResultType returns the name of the result type, like psql's \df.
+func (s Signature) ResultType() string {
+	if s.Result == sql.Null {
+		return "anyelement"
+	}
+	return s.Result.String()
+}
+
+// Function describes a function callable from SQL.
+type Function struct {
+	Name       string
+	Signatures []Signature
+	Aggregate  bool
+	// Volatile functions may return another result for the same arguments.
+	Volatile bool
+	// User is set for functions registered with RegisterFunction or
+	// RegisterAggregate.
+	User bool
+}
+
+// Functions returns the scalar and aggregate functions ordered by name.
+func (e *Engine) Functions() []Function {
+	e.registry.mu.RLock()
+	defer e.registry.mu.RUnlock()
+
+	list := make([]Function, 0, len(functions)+len(aggregates)+len(e.registry.functions)+len(e.registry.aggregates))
+	for _, scalars := range []map[string]*function{functions, e.registry.functions} {
+		for name, fn := range scalars {
+			list = append(list, Function{Name: name, Signatures: fn.signatures, Volatile: fn.volatile, User: fn.user})
+		}
+	}
+	for _, aggs := range []map[string]*aggregateFunction{aggregates, e.registry.aggregates} {
+		for name, agg := range aggs {
+			list = append(list, Function{Name: name, Signatures: agg.signatures, Aggregate: true, User: agg.user})
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+
+	return list
+}
+
+// function is a scalar function. Its implementation is called with arguments
+// of the types of the signature matching them.
+type function struct {
+	signatures []Signature
+	// strict functions return NULL for a NULL argument without being called.
+	strict   bool
+	volatile bool
+	// user is set for functions registered with RegisterFunction.
+	user bool
+	call func(args []sql.Value) (sql.Value, error)
+}
+
+var (
+	textArg    = []sql.DataType{sql.Text}
+	integerArg = []sql.DataType{sql.Integer}
+	floatArg   = []sql.DataType{sql.Float}
+	decimalArg = []sql.DataType{sql.Decimal}
+	jsonArg    = []sql.DataType{sql.JSON}
+	anyArg     = []sql.DataType{sql.Null}
+
+	// roundingSignatures are the ones of round and trunc, optionally with a
+	// number of decimal places.
+	roundingSignatures = []Signature{
+		{Args: floatArg, Result: sql.Float},
+		{Args: []sql.DataType{sql.Float, sql.Integer}, Result: sql.Float},
+		{Args: decimalArg, Result: sql.Decimal},
+		{Args: []sql.DataType{sql.Decimal, sql.Integer}, Result: sql.Decimal},
+	}
+)
+
+// functions are the built-in scalar functions by their name.
+var functions = map[string]*function{
+	"lower":    {signatures: []Signature{{Args: textArg, Result: sql.Text}}, strict: true, call: lower},
+	"upper":    {signatures: []Signature{{Args: textArg, Result: sql.Text}}, strict: true, call: upper},
+	"length":   {signatures: []Signature{{Args: textArg, Result: sql.Integer}}, strict: true, call: length},
+	"substr":   {signatures: signatures(sql.Text, []sql.DataType{sql.Text, sql.Integer}, []sql.DataType{sql.Text, sql.Integer, sql.Integer}), strict: true, call: substr},
+	"trim":     {signatures: signatures(sql.Text, textArg, []sql.DataType{sql.Text, sql.Text}), strict: true, call: trim},
+	"replace":  {signatures: signatures(sql.Text, []sql.DataType{sql.Text, sql.Text, sql.Text}), strict: true, call: replace},
+	"concat":   {signatures: []Signature{{Args: anyArg, Variadic: true, Result: sql.Text}}, call: concat},
+	"position": {signatures: signatures(sql.Integer, []sql.DataType{sql.Text, sql.Text}), strict: true, call: position},
+
+	"abs":    {signatures: []Signature{{Args: integerArg, Result: sql.Integer}, {Args: floatArg, Result: sql.Float}, {Args: decimalArg, Result: sql.Decimal}}, strict: true, call: abs},
+	"round":  {signatures: roundingSignatures, strict: true, call: round},
+	"trunc":  {signatures: roundingSignatures, strict: true, call: trunc},
+	"ceil":   {signatures: []Signature{{Args: floatArg, Result: sql.Float}, {Args: decimalArg, Result: sql.Decimal}}, strict: true, call: rounding(math.Ceil, datatype.Decimal.Ceil)},
+	"floor":  {signatures: []Signature{{Args: floatArg, Result: sql.Float}, {Args: decimalArg, Result: sql.Decimal}}, strict: true, call: rounding(math.Floor, datatype.Decimal.Floor)},
+	"sqrt":   {signatures: signatures(sql.Float, floatArg), strict: true, call: sqrt},
+	"power":  {signatures: signatures(sql.Float, []sql.DataType{sql.Float, sql.Float}), strict: true, call: power},
+	"mod":    {signatures: []Signature{{Args: []sql.DataType{sql.Integer, sql.Integer}, Result: sql.Integer}, {Args: []sql.DataType{sql.Float, sql.Float}, Result: sql.Float}, {Args: []sql.DataType{sql.Decimal, sql.Decimal}, Result: sql.Decimal}}, strict: true, call: mod},
+	"random": {signatures: signatures(sql.Float, nil), volatile: true, call: random},
+
+	// COALESCE evaluates its arguments lazily, so it is only listed here.
+	"coalesce": {signatures: []Signature{{Args: anyArg, Variadic: true, Result: sql.Null}}},
+	"nullif":   {signatures: signatures(sql.Null, []sql.DataType{sql.Null, sql.Null}), call: nullif},
+	"greatest": {signatures: []Signature{{Args: anyArg, Variadic: true, Result: sql.Null}}, call: extremum(1)},
+	"least":    {signatures: []Signature{{Args: anyArg, Variadic: true, Result: sql.Null}}, call: extremum(-1)},
+
+	"now":        {signatures: signatures(sql.TimestampTZ, nil), volatile: true, call: now},
+	"date_trunc": {signatures: []Signature{{Args: []sql.DataType{sql.Text, sql.Timestamp}, Result: sql.Timestamp}, {Args: []sql.DataType{sql.Text, sql.TimestampTZ}, Result: sql.TimestampTZ}}, strict: true, call: dateTrunc},
+	"extract":    {signatures: extractSignatures, strict: true, call: extract},
+	"date_part":  {signatures: extractSignatures, strict: true, call: extract},
+
+	"json_extract":      {signatures: signatures(sql.JSON, []sql.DataType{sql.JSON, sql.Text}), strict: true, call: jsonExtract},
+	"json_array_length": {signatures: signatures(sql.Integer, jsonArg), strict: true, call: jsonArrayLength},
+	"jsonb_contains":    {signatures: signatures(sql.Boolean, []sql.DataType{sql.JSON, sql.JSON}), strict: true, call: jsonContains},
+	"json_object":       {signatures: []Signature{{Args: anyArg, Variadic: true, Result: sql.JSON}}, call: jsonObject},
+}
+
+// extractSignatures are the signatures of extract(field FROM source) and
+// date_part(field, source).
+var extractSignatures = signatures(sql.Float,
+	[]sql.DataType{sql.Text, sql.Date},
+	[]sql.DataType{sql.Text, sql.Time},
+	[]sql.DataType{sql.Text, sql.Timestamp},
+	[]sql.DataType{sql.Text, sql.TimestampTZ},
+	[]sql.DataType{sql.Text, sql.Interval},
+)
+
+// signatures returns the signatures of the argument lists with the result.
+func signatures(result sql.DataType, args ...[]sql.DataType) []Signature {
+	list := make([]Signature, len(args))
+	for i := range args {
+		list[i] = Signature{Args: args[i], Result: result}
+	}
+	return list
+}
+
+func evalCall(expr *ast.CallExpr, s *scope) (sql.Value, error) {
+	if s.functions().isAggregate(expr) {
+		if s != nil {
+			if i, ok := s.aggregates[expr]; ok {
+				return s.row[i], nil
+			}
+		}
+		return nil, fmt.Errorf("aggregate function %s is not allowed here", strings.ToLower(expr.Name))
+	}
+
+	if isWindowFunction(expr) {
+		return nil, fmt.Errorf("window function %s requires an OVER clause", strings.ToLower(expr.Name))
+	}
+
+	name := strings.ToLower(expr.Name)
+	if name == "coalesce" {
+		return coalesce(expr, s)
+	}
+
+	args := make([]sql.Value, 0, len(expr.Args))
+	types := make([]sql.DataType, 0, len(expr.Args))
+	for _, arg := range expr.Args {
+		value, err := eval(arg, s)
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, value)
+		types = append(types, value.DataType())
+	}
+
+	fn, ok := s.functions().lookupFunction(name)
+	if !ok {
+		return nil, noFunction(name, types)
+	}
+
+	signature, ok := resolve(fn.signatures, types)
+	if !ok {
+		return nil, noFunction(name, types)
+	}
+
+	for _, value := range args {
+		if sql.IsNull(value) && fn.strict {
+			return datatype.NewNull(), nil
+		}
+	}
+
+	if err := convertArgs(args, signature); err != nil {
+		return nil, err
+	}
+
+	return fn.call(args)
+}
+
+// convertArgs converts the arguments to the types of the parameters of the
+// signature. NULLs stay NULL.
+func convertArgs(args []sql.Value, signature Signature) error {
+	for i, value := range args {
+		if sql.IsNull(value) {
+			continue
+		}
+
+		if to := signature.param(i); value.DataType() != to && to != sql.Null {
+			var err error
+			if args[i], err = datatype.Cast(value, to); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// resolve returns the signature the arguments of the types match with the
+// fewest implicit casts, the first one of those that match with as few, with
+// the arguments of any type replaced by their common type if the result has
+// it. An argument of type sql.Null, a NULL, matches every type and integers
+// match decimals and floats.
+func resolve(signatures []Signature, types []sql.DataType) (Signature, bool) {
+	best, casts := -1, 0
+	var common sql.DataType
+
+	for n, signature := range signatures {
+		c, ok := signature.match(types)
+		if !ok {
+			continue
+		}
+
+		if k := signature.casts(types); best < 0 || k < casts {
+			best, casts, common = n, k, c
+		}
+	}
+
+	if best < 0 {
+		return Signature{}, false
+	}
+
+	signature := signatures[best]
+	if signature.Result != sql.Null {
+		return signature, true
+	}
+
+	resolved := Signature{Args: make([]sql.DataType, len(signature.Args)), Variadic: signature.Variadic, Result: common}
+	for i, param := range signature.Args {
+		if param == sql.Null {
+			param = common
+		}
+		resolved.Args[i] = param
+	}
+
+	return resolved, true
+}
+
+// casts returns the number of arguments of the types which the matching
+// signature converts to another type.
+func (s Signature) casts(types []sql.DataType) int {
+	n := 0
+	for i, t := range types {
+		if param := s.param(i); t != sql.Null && param != sql.Null && t != param {
+			n++
+		}
+	}
+	return n
+}
+
+// match reports whether arguments of the types match the signature and
+// returns the common type of the arguments of any type.
+func (s Signature) match(types []sql.DataType) (sql.DataType, bool) {
+	if len(types) != len(s.Args) && !(s.Variadic && len(types) > len(s.Args)) {
+		return sql.Null, false
+	}
+
+	common := sql.Null
+	for i, t := range types {
+		switch param := s.param(i); {
+		case param == sql.Null:
+			if s.Result != sql.Null {
+				continue
+			}
+
+			var ok bool
+			if common, ok = commonType(common, t); !ok {
+				return sql.Null, false
+			}
+		case !implicitCast(t, param):
+			return sql.Null, false
+		}
+	}
+
+	return common, true
+}
+
+// param returns the type of the argument at the position.
+func (s Signature) param(i int) sql.DataType {
+	if i >= len(s.Args) {
+		return s.Args[len(s.Args)-1]
+	}
+	return s.Args[i]
+}
+
+// implicitCast reports whether values of a type convert to another type
+// without a cast: NULL to every type, integers to decimals and floats,
+// decimals to floats, dates to timestamps and text to JSON.
+func implicitCast(from, to sql.DataType) bool {
+	switch {
+	case from == to || from == sql.Null:
+		return true
+	case from == sql.Integer && (to == sql.Float || to == sql.Decimal):
+		return true
+	case from == sql.Decimal && to == sql.Float:
+		return true
+	case from == sql.Date && to == sql.Timestamp:
+		return true
+	case from == sql.Text && to == sql.JSON:
+		return true
+	default:
+		return false
+	}
+}
+
+// commonType returns the type values of both types convert to: the type
+// of the other for NULL, float for integers and floats and timestamp for
+// dates and timestamps.
+func commonType(a, b sql.DataType) (sql.DataType, bool) {
+	switch {
+	case implicitCast(b, a):
+		return a, true
+	case implicitCast(a, b):
+		return b, true
+	default:
+		return sql.Null, false
+	}
+}
+
+// convertResult converts the value to the type resolved for the result of an
+// expression. NULLs and values of unknown type stay as they are.
+func convertResult(value sql.Value, t sql.DataType) (sql.Value, error) {
+	if sql.IsNull(value) || t == sql.Null || value.DataType() == t {
+		return value, nil
+	}
+
+	return datatype.Cast(value, t)
+}
+
+func noFunction(name string, types []sql.DataType) error {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = typeName(t)
+	}
+
+	return fmt.Errorf("function %s(%s) does not exist", name, strings.Join(names, ", "))
+}
+
+// typeName returns the name of the type, "unknown" for the type of NULL.
+func typeName(t sql.DataType) string {
+	if t == sql.Null {
+		return "unknown"
+	}
+	return t.String()
+}
+
+// checkTypes infers the types of the expressions in the scope, failing for
+// function calls without a signature matching their arguments. The types
+// of columns of queries not run yet are unknown and match every type.
+func checkTypes(s *scope, exprs ...ast.Expression) error {
+	for _, expr := range exprs {
+		if expr == nil {
+			continue
+		}
+
+		if _, err := typeOf(expr, s); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// typeOf returns the type of the values of the expression, sql.Null when it
+// is not known before evaluating it.
+func typeOf(expr ast.Expression, s *scope) (sql.DataType, error) {
+	switch expr := expr.(type) {
+	case *ast.ScalarExpr:
+		value, err := literal(expr)
+		if err != nil {
+			return sql.Null, err
+		}
+		return value.DataType(), nil
+	case *ast.IdentExpr:
+		for owner := s; owner != nil; owner = owner.outer {
+			if i, err := owner.find(expr); err == nil && i >= 0 {
+				return owner.columns[i].dataType, nil
+			}
+		}
+		return sql.Null, nil
+	case *ast.UnaryExpr:
+		t, err := typeOf(expr.Operand, s)
+		if expr.Operator == token.NOT {
+			return sql.Boolean, err
+		}
+		return t, err
+	case *ast.ConditionExpr:
+		left, err := typeOf(expr.Left, s)
+		if err != nil {
+			return sql.Null, err
+		}
+
+		right, err := typeOf(expr.Right, s)
+		if err != nil {
+			return sql.Null, err
+		}
+
+		switch expr.Operator {
+		case token.PLUS, token.MINUS, token.ASTERISK, token.SLASH:
+			if t, ok := datetimeOperations[operation{expr.Operator, left, right}]; ok {
+				return t, nil
+			}
+			if left == sql.Integer || left == sql.Float || left == sql.Decimal {
+				if t, ok := commonType(left, right); ok {
+					return t, nil
+				}
+			}
+			return sql.Null, nil
+		case token.ARROW, token.DOUBLE_ARROW, token.HASH_ARROW, token.CONTAINS:
+			return jsonOperators[expr.Operator], jsonOperands(expr.Operator, left, right)
+		default:
+			return sql.Boolean, nil
+		}
+	case *ast.IsNullExpr:
+		_, err := typeOf(expr.Expr, s)
+		return sql.Boolean, err
+	case *ast.IsDistinctExpr:
+		return sql.Boolean, checkTypes(s, expr.Left, expr.Right)
+	case *ast.InExpr, *ast.BetweenExpr, *ast.LikeExpr:
+		return sql.Boolean, checkTypes(s, operands(expr)...)
+	case *ast.ExistsExpr:
+		return sql.Boolean, nil
+	case *ast.CastExpr:
+		if _, err := typeOf(expr.Expr, s); err != nil {
+			return sql.Null, err
+		}
+		return castType(expr)
+	case *ast.AtTimeZoneExpr:
+		t, err := typeOf(expr.Expr, s)
+		if err != nil {
+			return sql.Null, err
+		}
+		if _, err := typeOf(expr.Zone, s); err != nil {
+			return sql.Null, err
+		}
+		return atTimeZoneType(t), nil
+	case *ast.CaseExpr:
+		if err := checkTypes(s, expr.Operand); err != nil {
+			return sql.Null, err
+		}
+
+		results := make([]ast.Expression, 0, len(expr.Whens)+1)
+		for _, when := range expr.Whens {
+			if _, err := typeOf(when.Cond, s); err != nil {
+				return sql.Null, err
+			}
+			results = append(results, when.Result)
+		}
+		if expr.Else != nil {
+			results = append(results, expr.Else)
+		}
+
+		common := sql.Null
+		for _, result := range results {
+			t, err := typeOf(result, s)
+			if err != nil {
+				return sql.Null, err
+			}
+			c, ok := commonType(common, t)
+			if !ok {
+				return sql.Null, fmt.Errorf("CASE types %s and %s cannot be matched", common, t)
+			}
+			common = c
+		}
+		return common, nil
+	case *ast.CallExpr:
+		return callType(expr, s)
+	case *ast.WindowExpr:
+		if err := checkTypes(s, windowExprs(expr)...); err != nil {
+			return sql.Null, err
+		}
+		if s.functions().isAggregate(expr.Call) {
+			return callType(expr.Call, s)
+		}
+		return sql.Null, nil
+	default:
+		return sql.Null, nil
+	}
+}
+
+// callType returns the result type of the function call.
+func callType(expr *ast.CallExpr, s *scope) (sql.DataType, error) {
+	types := make([]sql.DataType, len(expr.Args))
+	for i, arg := range expr.Args {
+		var err error
+		if types[i], err = typeOf(arg, s); err != nil {
+			return sql.Null, err
+		}
+	}
+
+	name := strings.ToLower(expr.Name)
+
+	agg, isAgg := s.functions().lookupAggregate(name)
+	fn, isFunc := s.functions().lookupFunction(name)
+
+	var list []Signature
+	switch {
+	case isAgg && !agg.user && len(types) != 1:
+		return sql.Null, fmt.Errorf("function %s() takes exactly one argument", name)
+	case isAgg:
+		list = agg.signatures
+	case isWindowFunction(expr):
+		return sql.Null, nil
+	case isFunc:
+		list = fn.signatures
+	default:
+		return sql.Null, noFunction(name, types)
+	}
+
+	signature, ok := resolve(list, types)
+	if !ok {
+		return sql.Null, noFunction(name, types)
+	}
+
+	return signature.Result, nil
+}
+
+func lower(args []sql.Value) (sql.Value, error) {
+	return datatype.NewText(strings.ToLower(args[0].Raw().(string))), nil
+}
+
+func upper(args []sql.Value) (sql.Value, error) {
+	return datatype.NewText(strings.ToUpper(args[0].Raw().(string))), nil
+}
+
+// length returns the number of characters of the text.
+func length(args []sql.Value) (sql.Value, error) {
+	return datatype.NewInteger(int64(utf8.RuneCountInString(args[0].Raw().(string)))), nil
+}
+
+// substr returns the characters of the text from a position counted from 1,
+// to its end or as many as given. Positions before the text count towards
+// the characters returned.
+func substr(args []sql.Value) (sql.Value, error) {
+	runes := []rune(args[0].Raw().(string))
+
+	start := args[1].Raw().(int64) - 1
+	end := int64(len(runes))
+
+	if len(args) > 2 {
+		n := args[2].Raw().(int64)
+		if n < 0 {
+			return nil, fmt.Errorf("negative substring length not allowed")
+		}
+		if start+n < end {
+			end = start + n
+		}
+	}
+
+	if start < 0 {
+		start = 0
+	}
+	if start >= end {
+		return datatype.NewText(""), nil
+	}
+
+	return datatype.NewText(string(runes[start:end])), nil
+}
+
+// trim removes spaces, or the given characters, from both ends of the text.
+func trim(args []sql.Value) (sql.Value, error) {
+	chars := " "
+	if len(args) > 1 {
+		chars = args[1].Raw().(string)
+	}
+
+	return datatype.NewText(strings.Trim(args[0].Raw().(string), chars)), nil
+}
+
+func replace(args []sql.Value) (sql.Value, error) {
+	s, from, to := args[0].Raw().(string), args[1].Raw().(string), args[2].Raw().(string)
+	if from == "" {
+		return args[0], nil
+	}
+
+	return datatype.NewText(strings.ReplaceAll(s, from, to)), nil
+}
+
+// concat joins the text of the arguments, skipping NULLs.
+func concat(args []sql.Value) (sql.Value, error) {
+	var b strings.Builder
+	for _, arg := range args {
+		if !sql.IsNull(arg) {
+			b.WriteString(arg.String())
+		}
+	}
+
+	return datatype.NewText(b.String()), nil
+}
+
+// position returns the position of the first character of the substring in
+// the text counted from 1, or 0 when the text doesn't contain it.
+func position(args []sql.Value) (sql.Value, error) {
+	sub, s := args[0].Raw().(string), args[1].Raw().(string)
+
+	i := strings.Index(s, sub)
+	if i < 0 {
+		return datatype.NewInteger(0), nil
+	}
+
+	return datatype.NewInteger(int64(utf8.RuneCountInString(s[:i]) + 1)), nil
+}
+
+func abs(args []sql.Value) (sql.Value, error) {
+	switch v := args[0].Raw().(type) {
+	case datatype.Decimal:
+		return v.Abs(), nil
+	case int64:
+		if v == math.MinInt64 {
+			return nil, fmt.Errorf("integer out of range")
+		}
+		if v < 0 {
+			v = -v
+		}
+		return datatype.NewInteger(v), nil
+	default:
+		return datatype.NewFloat(math.Abs(v.(float64))), nil
+	}
+}
+
+// round rounds half away from zero to the given number of decimal places,
+// negative ones rounding to tens, hundreds and so on.
+func round(args []sql.Value) (sql.Value, error) {
+	if d, ok := args[0].Raw().(datatype.Decimal); ok {
+		return d.Round(places(args)), nil
+	}
+
+	v := args[0].Raw().(float64)
+	if len(args) == 1 {
+		return datatype.NewFloat(math.Round(v)), nil
+	}
+
+	scale := math.Pow(10, float64(args[1].Raw().(int64)))
+	return datatype.NewFloat(math.Round(v*scale) / scale), nil
+}
+
+// mathFunc returns a function of a float.
+func mathFunc(fn func(float64) float64) func(args []sql.Value) (sql.Value, error) {
+	return func(args []sql.Value) (sql.Value, error) {
+		return datatype.NewFloat(fn(args[0].Raw().(float64))), nil
+	}
+}
+
+func sqrt(args []sql.Value) (sql.Value, error) {
+	v := args[0].Raw().(float64)
+	if v < 0 {
+		return nil, fmt.Errorf("cannot take square root of a negative number")
+	}
+
+	return datatype.NewFloat(math.Sqrt(v)), nil
+}
+
+func power(args []sql.Value) (sql.Value, error) {
+	x, y := args[0].Raw().(float64), args[1].Raw().(float64)
+
+	switch {
+	case x == 0 && y < 0:
+		return nil, fmt.Errorf("zero raised to a negative power is undefined")
+	case x < 0 && y != math.Trunc(y):
+		return nil, fmt.Errorf("a negative number raised to a non-integer power yields a complex result")
+	}
+
+	return datatype.NewFloat(math.Pow(x, y)), nil
+}
+
+// mod returns the remainder of the division, which has the sign of the
+// dividend.
+func mod(args []sql.Value) (sql.Value, error) {
+	if x, ok := args[0].Raw().(datatype.Decimal); ok {
+		return x.Rem(args[1].(datatype.Decimal))
+	}
+
+	if x, ok := args[0].Raw().(int64); ok {
+		y := args[1].Raw().(int64)
+		if y == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		if y == -1 {
+			return datatype.NewInteger(0), nil
+		}
+		return datatype.NewInteger(x % y), nil
+	}
+
+	x, y := args[0].Raw().(float64), args[1].Raw().(float64)
+	if y == 0 {
+		return nil, fmt.Errorf("division by zero")
+	}
+
+	return datatype.NewFloat(math.Mod(x, y)), nil
+}
+
+// random returns a random number in [0, 1).
+func random([]sql.Value) (sql.Value, error) {
+	return datatype.NewFloat(rand.Float64()), nil
+}
+
+// coalesce returns the first argument that is not NULL, converted to the
+// common type of the arguments. The arguments after it are not evaluated.
+func coalesce(expr *ast.CallExpr, s *scope) (sql.Value, error) {
+	if len(expr.Args) == 0 {
+		return nil, noFunction("coalesce", nil)
+	}
+
+	t, err := callType(expr, s)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, arg := range expr.Args {
+		value, err := eval(arg, s)
+		if err != nil {
+			return nil, err
+		}
+
+		if !sql.IsNull(value) {
+			return convertResult(value, t)
+		}
+	}
+
+	return datatype.NewNull(), nil
+}
+
+// nullif returns NULL when both arguments are equal, the first one otherwise.
+func nullif(args []sql.Value) (sql.Value, error) {
+	if sql.IsNull(args[0]) || sql.IsNull(args[1]) {
+		return args[0], nil
+	}
+
+	c, err := args[0].Compare(args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if c == 0 {
+		return datatype.NewNull(), nil
+	}
+
+	return args[0], nil
+}
+
+// extremum returns a function returning the largest argument with sign 1,
+// the smallest with sign -1. NULLs are skipped.
+func extremum(sign int) func(args []sql.Value) (sql.Value, error) {
+	return func(args []sql.Value) (sql.Value, error) {
+		var result sql.Value = datatype.NewNull()
+
+		for _, arg := range args {
+			if sql.IsNull(arg) {
+				continue
+			}
+
+			if !sql.IsNull(result) {
+				c, err := arg.Compare(result)
+				if err != nil {
+					return nil, err
+				}
+				if c*sign <= 0 {
+					continue
+				}
+			}
+
+			result = arg
+		}
+
+		return result, nil
+	}
+}
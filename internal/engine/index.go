@@ -0,0 +1,265 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/okazaki-kk/miniDB/internal/parser/ast"
+	"github.com/okazaki-kk/miniDB/internal/parser/token"
+	"github.com/okazaki-kk/miniDB/internal/sql"
+	"github.com/okazaki-kk/miniDB/internal/sql/datatype"
+	"github.com/okazaki-kk/miniDB/storage"
+)
+
+// CreateIndex indexes an expression of the columns of the table in the
+// current database. Index names are unique in the database.
+func (e *Engine) CreateIndex(stmt *ast.CreateIndexStatement) (string, error) {
+	db, err := e.currentDatabase()
+	if err != nil {
+		return "", err
+	}
+
+	if table, _ := findIndex(db, stmt.Index); table != nil {
+		if stmt.IfNotExists {
+			return fmt.Sprintf("relation %q already exists, skipping\n", stmt.Index), nil
+		}
+		return "", fmt.Errorf("relation %q already exists", stmt.Index)
+	}
+
+	table, err := db.GetTable(stmt.Table)
+	if err != nil {
+		return "", err
+	}
+
+	index, err := newIndex(stmt.Table, table.Scheme().Columns(), stmt.Index, stmt.Expr, e.registry)
+	if err != nil {
+		return "", err
+	}
+
+	if err := table.CreateIndex(index); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("create index %s\n", stmt.Index), nil
+}
+
+// DropIndex drops the index from its table in the current database.
+func (e *Engine) DropIndex(name string, ifExists bool) (string, error) {
+	db, err := e.currentDatabase()
+	if err != nil {
+		return "", err
+	}
+
+	table, _ := findIndex(db, name)
+	if table == nil {
+		if ifExists {
+			return fmt.Sprintf("index %q does not exist, skipping\n", name), nil
+		}
+		return "", fmt.Errorf("index %q does not exist", name)
+	}
+
+	if err := table.DropIndex(name); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("drop index %s\n", name), nil
+}
+
+// findIndex returns the index of the name and its table, nil when no table
+// of the database has it.
+func findIndex(db storage.Database, name string) (*storage.Table, *storage.Index) {
+	for _, table := range db.ListTables() {
+		for _, index := range table.Indexes() {
+			if index.Name == name {
+				return table, index
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// newIndex creates an index of the expression, which may only use the
+// columns of the table and functions returning the same result for the same
+// arguments.
+func newIndex(table string, columns []storage.Column, name string, expr ast.Expression, r *registry) (*storage.Index, error) {
+	if err := immutable(expr, r); err != nil {
+		return nil, err
+	}
+
+	columns = append([]storage.Column(nil), columns...)
+
+	if err := checkTypes(&scope{columns: scopeColumns(table, columns), registry: r}, expr); err != nil {
+		return nil, err
+	}
+
+	value := func(row sql.Row) (sql.Value, error) {
+		return eval(expr, &scope{columns: scopeColumns(table, columns), row: row, registry: r})
+	}
+
+	// Evaluating the expression on a row of NULLs finds unknown columns.
+	if _, err := value(nullRow(len(columns))); err != nil {
+		return nil, err
+	}
+
+	return storage.NewIndex(name, expr, value), nil
+}
+
+// immutable verifies the expression calls no volatile functions and has no
+// subqueries.
+func immutable(expr ast.Expression, r *registry) error {
+	var exprs []ast.Expression
+
+	switch expr := expr.(type) {
+	case *ast.SubqueryExpr, *ast.ExistsExpr:
+		return fmt.Errorf("cannot use subquery in index expression")
+	case *ast.InExpr:
+		if expr.Select != nil {
+			return fmt.Errorf("cannot use subquery in index expression")
+		}
+		exprs = operands(expr)
+	case *ast.UnaryExpr:
+		exprs = []ast.Expression{expr.Operand}
+	case *ast.ConditionExpr:
+		exprs = []ast.Expression{expr.Left, expr.Right}
+	case *ast.IsNullExpr:
+		exprs = []ast.Expression{expr.Expr}
+	case *ast.IsDistinctExpr:
+		exprs = []ast.Expression{expr.Left, expr.Right}
+	case *ast.CallExpr:
+		if fn, ok := r.lookupFunction(expr.Name); ok && fn.volatile {
+			return fmt.Errorf("functions in index expression must be marked IMMUTABLE")
+		}
+		exprs = expr.Args
+	default:
+		exprs = operands(expr)
+	}
+
+	for _, e := range exprs {
+		if err := immutable(e, r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// candidateRows returns the keys and rows of the table, referred to by the
+// name, which the WHERE clause may match. They are found with an index of
+// the table when one applies and are all of its rows otherwise.
+func candidateRows(table *storage.Table, name string, where ast.Expression, r *registry) ([]int64, []sql.Row, error) {
+	if where != nil {
+		if keys, rows, ok, err := indexLookup(table, name, where, r); ok || err != nil {
+			return keys, rows, err
+		}
+	}
+
+	keys, rows := table.Snapshot()
+	return keys, rows, nil
+}
+
+// whereExpr returns the condition of the WHERE clause, nil without one.
+func whereExpr(where *ast.WhereStatement) ast.Expression {
+	if where == nil {
+		return nil
+	}
+	return where.Expr
+}
+
+// indexLookup returns the keys and rows of the table found with one of its
+// indexes for a condition of the WHERE clause comparing the indexed
+// expression with a constant. It returns false when no index applies.
+func indexLookup(table *storage.Table, name string, where ast.Expression, r *registry) ([]int64, []sql.Row, bool, error) {
+	s := &scope{columns: scopeColumns(table.Name(), table.Scheme().Columns()), registry: r}
+
+	for _, cond := range conjuncts(where) {
+		eq, ok := cond.(*ast.ConditionExpr)
+		if !ok || eq.Operator != token.EQ {
+			continue
+		}
+
+		for _, sides := range [][2]ast.Expression{{eq.Left, eq.Right}, {eq.Right, eq.Left}} {
+			indexed, constant := unqualified(sides[0], name), sides[1]
+			if !isConstant(constant) {
+				continue
+			}
+
+			for _, index := range table.Indexes() {
+				if index.Expr.String() != indexed.String() {
+					continue
+				}
+
+				value, ok := indexKey(index, constant, s)
+				if !ok {
+					continue
+				}
+
+				keys, rows, err := table.Lookup(index.Name, value)
+				return keys, rows, err == nil, err
+			}
+		}
+	}
+
+	return nil, nil, false, nil
+}
+
+// indexKey evaluates the constant compared with the indexed expression,
+// converting text like the comparison does. It returns false when the
+// values of the index don't compare with the constant by equality or the
+// constant fails to evaluate, which the WHERE clause then reports.
+func indexKey(index *storage.Index, constant ast.Expression, s *scope) (sql.Value, bool) {
+	t, err := typeOf(index.Expr, s)
+	if err != nil || t == sql.Null {
+		return nil, false
+	}
+
+	value, err := eval(constant, &scope{registry: s.registry})
+	if err != nil {
+		return nil, false
+	}
+
+	if sql.IsNull(value) {
+		// No row equals NULL.
+		return value, true
+	}
+
+	if value.DataType() == sql.Text && castsFromText(t) {
+		if value, err = datatype.Cast(value, t); err != nil {
+			return nil, false
+		}
+	}
+
+	if !comparableTypes(t, value.DataType()) {
+		return nil, false
+	}
+
+	return value, true
+}
+
+// isConstant reports whether the expression is made of literals only, so
+// that it has the same value for every row.
+func isConstant(expr ast.Expression) bool {
+	switch expr := expr.(type) {
+	case *ast.ScalarExpr:
+		return true
+	case *ast.UnaryExpr:
+		return isConstant(expr.Operand)
+	case *ast.ConditionExpr:
+		return isConstant(expr.Left) && isConstant(expr.Right)
+	case *ast.CastExpr:
+		return isConstant(expr.Expr)
+	default:
+		return false
+	}
+}
+
+// unqualified returns the expression with the columns of the table not
+// qualified by its name, like the columns of index expressions.
+func unqualified(expr ast.Expression, table string) ast.Expression {
+	return mapIdents(expr, func(ident *ast.IdentExpr) *ast.IdentExpr {
+		if strings.EqualFold(ident.Table, table) {
+			return &ast.IdentExpr{Name: ident.Name}
+		}
+		return ident
+	})
+}
@@ -0,0 +1,543 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/okazaki-kk/miniDB/internal/parser/ast"
+	"github.com/okazaki-kk/miniDB/internal/parser/token"
+	"github.com/okazaki-kk/miniDB/internal/sql"
+)
+
+// source is the columns and rows of a table or a join of tables in FROM.
+type source struct {
+	columns []column
+	rows    []sql.Row
+}
+
+// from reads the tables of the FROM clause and joins them from left to right.
+// Derived tables and join conditions are evaluated in the scope of the query.
+// A table read alone is filtered with an index when the WHERE clause allows.
+func (x *executor) from(stmt *ast.FromStatement, where ast.Expression, s *scope) (source, error) {
+	if len(stmt.Joins) > 0 {
+		where = nil
+	}
+
+	left, err := x.table(stmt.Table, stmt.Subquery, stmt.Alias, where, s)
+	if err != nil {
+		return source{}, err
+	}
+
+	names := map[string]bool{tableName(stmt.Table, stmt.Alias): true}
+
+	for _, j := range stmt.Joins {
+		name := tableName(j.Table, j.Alias)
+		if names[name] {
+			return source{}, fmt.Errorf("table name %q specified more than once", name)
+		}
+		names[name] = true
+
+		right, err := x.table(j.Table, j.Subquery, j.Alias, nil, s)
+		if err != nil {
+			return source{}, err
+		}
+
+		if left, err = join(left, right, j, s); err != nil {
+			return source{}, err
+		}
+	}
+
+	return left, nil
+}
+
+// tableName returns the name the columns of the table are qualified with.
+func tableName(table, alias string) string {
+	if alias != "" {
+		return alias
+	}
+	return table
+}
+
+// table reads the rows of the table the WHERE clause, if any, may match,
+// unless a query of a WITH clause has its name, or runs the query of the
+// derived table.
+func (x *executor) table(name string, subquery *ast.SelectStatement, alias string, where ast.Expression, s *scope) (source, error) {
+	if subquery != nil {
+		derived, err := x.query(subquery, &scope{outer: s})
+		if err != nil {
+			return source{}, err
+		}
+		return qualified(derived, alias), nil
+	}
+
+	if c := s.commonTable(name); c != nil {
+		result, err := x.commonTable(c, s)
+		if err != nil {
+			return source{}, err
+		}
+		return qualified(result, tableName(name, alias)), nil
+	}
+
+	db, err := x.engine.currentDatabase()
+	if err != nil {
+		return source{}, err
+	}
+
+	table, err := db.GetTable(name)
+	if err != nil {
+		return source{}, err
+	}
+
+	_, rows, err := candidateRows(table, tableName(name, alias), where, s.functions())
+	if err != nil {
+		return source{}, err
+	}
+
+	return source{
+		columns: scopeColumns(tableName(table.Name(), alias), table.Scheme().Columns()),
+		rows:    rows,
+	}, nil
+}
+
+// qualified returns the result of a query with its columns qualified by the
+// table name.
+func qualified(result source, table string) source {
+	columns := make([]column, len(result.columns))
+	for i, c := range result.columns {
+		columns[i] = column{table: table, name: c.name, dataType: c.dataType}
+	}
+
+	return source{columns: columns, rows: result.rows}
+}
+
+// join joins the sources in the scope of the query. Equality conditions between columns of either side
+// are matched with a merge join when both sides are sorted by them and with a
+// hash join otherwise. Other conditions are checked for every pair of rows
+// matched by the keys, or by a nested loop join when there are no keys.
+func join(left, right source, stmt ast.JoinStatement, s *scope) (source, error) {
+	j := newJoiner(left, right, stmt)
+
+	var (
+		keys []joinKey
+		err  error
+	)
+
+	switch {
+	case len(stmt.Using) > 0:
+		if keys, err = j.using(left, right, stmt.Using); err != nil {
+			return source{}, err
+		}
+	default:
+		j.columns = append(append([]column(nil), left.columns...), right.columns...)
+
+		if stmt.On != nil {
+			if err := s.functions().noAggregates("JOIN conditions", stmt.On); err != nil {
+				return source{}, err
+			}
+
+			if err := noWindows("JOIN conditions", stmt.On); err != nil {
+				return source{}, err
+			}
+
+			keys, j.residual = equiJoinKeys(left, right, stmt.On)
+		}
+	}
+
+	j.scope = &scope{columns: j.columns, outer: s, executor: s.executor}
+
+	switch {
+	case len(keys) == 0:
+		err = j.nestedLoop()
+	case sorted(left.rows, keys, leftKey) && sorted(right.rows, keys, rightKey):
+		err = j.merge(keys)
+	default:
+		err = j.hash(keys)
+	}
+	if err != nil {
+		return source{}, err
+	}
+
+	return source{columns: j.columns, rows: j.result()}, nil
+}
+
+// joinKey is a pair of columns of the left and right side which must be equal
+// for rows to join.
+type joinKey struct {
+	left, right int
+}
+
+func leftKey(k joinKey) int  { return k.left }
+func rightKey(k joinKey) int { return k.right }
+
+// equiJoinKeys splits the ON condition into the equalities between a column
+// of either side, which become keys, and the remaining residual condition.
+func equiJoinKeys(left, right source, on ast.Expression) ([]joinKey, ast.Expression) {
+	ls, rs := &scope{columns: left.columns}, &scope{columns: right.columns}
+
+	var (
+		keys     []joinKey
+		residual ast.Expression
+	)
+
+	for _, expr := range conjuncts(on) {
+		if key, ok := equiJoinKey(ls, rs, expr); ok {
+			keys = append(keys, key)
+			continue
+		}
+
+		if residual == nil {
+			residual = expr
+		} else {
+			residual = &ast.ConditionExpr{Left: residual, Operator: token.AND, Right: expr}
+		}
+	}
+
+	return keys, residual
+}
+
+func equiJoinKey(ls, rs *scope, expr ast.Expression) (joinKey, bool) {
+	cond, ok := expr.(*ast.ConditionExpr)
+	if !ok || cond.Operator != token.EQ {
+		return joinKey{}, false
+	}
+
+	a, aok := cond.Left.(*ast.IdentExpr)
+	b, bok := cond.Right.(*ast.IdentExpr)
+	if !aok || !bok {
+		return joinKey{}, false
+	}
+
+	// Either column must be found on one side only, otherwise the reference
+	// is ambiguous and left to fail when evaluated.
+	only := func(s, other *scope, ident *ast.IdentExpr) (int, bool) {
+		i, err := s.index(ident)
+		if err != nil {
+			return 0, false
+		}
+
+		_, err = other.index(ident)
+		return i, err != nil
+	}
+
+	key, ok := joinKey{}, false
+	if l, lok := only(ls, rs, a); lok {
+		if r, rok := only(rs, ls, b); rok {
+			key, ok = joinKey{left: l, right: r}, true
+		}
+	} else if l, lok := only(ls, rs, b); lok {
+		if r, rok := only(rs, ls, a); rok {
+			key, ok = joinKey{left: l, right: r}, true
+		}
+	}
+
+	// Incomparable columns are left to fail like the = operator does.
+	if !ok || !comparableTypes(ls.columns[key.left].dataType, rs.columns[key.right].dataType) {
+		return joinKey{}, false
+	}
+
+	return key, true
+}
+
+// conjuncts returns the operands of the top level ANDs of the expression.
+func conjuncts(expr ast.Expression) []ast.Expression {
+	if cond, ok := expr.(*ast.ConditionExpr); ok && cond.Operator == token.AND {
+		return append(conjuncts(cond.Left), conjuncts(cond.Right)...)
+	}
+	return []ast.Expression{expr}
+}
+
+func comparableTypes(a, b sql.DataType) bool {
+	numeric := func(t sql.DataType) bool { return t == sql.Integer || t == sql.Float || t == sql.Decimal }
+	return a == b || (numeric(a) && numeric(b))
+}
+
+// joiner collects the rows of a join and which rows of either side matched.
+type joiner struct {
+	joinType    token.TokenType
+	left, right []sql.Row
+	// leftWidth and rightWidth are the numbers of columns of either side.
+	leftWidth, rightWidth int
+
+	columns []column
+	// merged are the pairs of columns a join USING them merges into one,
+	// they precede the columns of the left and the right side.
+	merged   []joinKey
+	residual ast.Expression
+	scope    *scope
+
+	rows         []sql.Row
+	leftMatched  []bool
+	rightMatched []bool
+}
+
+func newJoiner(left, right source, stmt ast.JoinStatement) *joiner {
+	return &joiner{
+		joinType:     stmt.Type,
+		left:         left.rows,
+		right:        right.rows,
+		leftWidth:    len(left.columns),
+		rightWidth:   len(right.columns),
+		leftMatched:  make([]bool, len(left.rows)),
+		rightMatched: make([]bool, len(right.rows)),
+	}
+}
+
+// result returns the joined rows followed, in a RIGHT or FULL join, by the
+// right rows without a match.
+func (j *joiner) result() []sql.Row {
+	if j.joinType == token.RIGHT || j.joinType == token.FULL {
+		for ri, matched := range j.rightMatched {
+			if !matched {
+				j.rows = append(j.rows, j.combine(nullRow(j.leftWidth), j.right[ri]))
+			}
+		}
+	}
+
+	return j.rows
+}
+
+// using merges the columns the sides join USING into one and hides the
+// columns of either side from unqualified references.
+func (j *joiner) using(left, right source, names []string) ([]joinKey, error) {
+	ls, rs := &scope{columns: left.columns}, &scope{columns: right.columns}
+
+	leftColumns := append([]column(nil), left.columns...)
+	rightColumns := append([]column(nil), right.columns...)
+
+	var merged []column
+
+	for i, name := range names {
+		if contains(names[:i], name) {
+			return nil, fmt.Errorf("column name %q appears more than once in USING clause", name)
+		}
+
+		l, err := ls.index(&ast.IdentExpr{Name: name})
+		if err != nil {
+			return nil, fmt.Errorf("column %q specified in USING clause does not exist in left table", name)
+		}
+
+		r, err := rs.index(&ast.IdentExpr{Name: name})
+		if err != nil {
+			return nil, fmt.Errorf("column %q specified in USING clause does not exist in right table", name)
+		}
+
+		if !comparableTypes(leftColumns[l].dataType, rightColumns[r].dataType) {
+			return nil, fmt.Errorf("JOIN/USING types %s and %s cannot be matched", leftColumns[l].dataType, rightColumns[r].dataType)
+		}
+
+		leftColumns[l].hidden = true
+		rightColumns[r].hidden = true
+
+		merged = append(merged, column{name: name, dataType: leftColumns[l].dataType})
+		j.merged = append(j.merged, joinKey{left: l, right: r})
+	}
+
+	j.columns = append(append(merged, leftColumns...), rightColumns...)
+
+	return j.merged, nil
+}
+
+// combine returns the joined row of the rows of either side. A merged column
+// has the value of the left side unless it is NULL.
+func (j *joiner) combine(l, r sql.Row) sql.Row {
+	row := make(sql.Row, 0, len(j.merged)+len(l)+len(r))
+
+	for _, k := range j.merged {
+		if sql.IsNull(l[k.left]) {
+			row = append(row, r[k.right])
+		} else {
+			row = append(row, l[k.left])
+		}
+	}
+
+	return append(append(row, l...), r...)
+}
+
+// match joins the rows unless they fail the residual condition.
+func (j *joiner) match(li, ri int) error {
+	row := j.combine(j.left[li], j.right[ri])
+
+	if j.residual != nil {
+		j.scope.row = row
+
+		ok, err := matches(j.residual, j.scope)
+		if err != nil || !ok {
+			return err
+		}
+	}
+
+	j.rows = append(j.rows, row)
+	j.leftMatched[li] = true
+	j.rightMatched[ri] = true
+
+	return nil
+}
+
+// finish is called once all matches of the left row are found and keeps it
+// when it has none in a LEFT or FULL join.
+func (j *joiner) finish(li int) {
+	if j.leftMatched[li] || (j.joinType != token.LEFT && j.joinType != token.FULL) {
+		return
+	}
+
+	j.rows = append(j.rows, j.combine(j.left[li], nullRow(j.rightWidth)))
+}
+
+// nestedLoop matches every pair of rows.
+func (j *joiner) nestedLoop() error {
+	for li := range j.left {
+		for ri := range j.right {
+			if err := j.match(li, ri); err != nil {
+				return err
+			}
+		}
+
+		j.finish(li)
+	}
+
+	return nil
+}
+
+// hash builds a hash table of the right rows by their keys and probes it with
+// the keys of every left row. Rows with a NULL key never match.
+func (j *joiner) hash(keys []joinKey) error {
+	table := make(map[uint64][]int, len(j.right))
+	rightKeys := make([]sql.Row, len(j.right))
+
+	for ri, row := range j.right {
+		key := joinKeyValues(row, keys, rightKey)
+		if hasNull(key) {
+			continue
+		}
+
+		rightKeys[ri] = key
+		table[key.Hash()] = append(table[key.Hash()], ri)
+	}
+
+	for li, row := range j.left {
+		key := joinKeyValues(row, keys, leftKey)
+
+		if !hasNull(key) {
+			for _, ri := range table[key.Hash()] {
+				if !rightKeys[ri].Equal(key) {
+					continue
+				}
+
+				if err := j.match(li, ri); err != nil {
+					return err
+				}
+			}
+		}
+
+		j.finish(li)
+	}
+
+	return nil
+}
+
+// merge walks both sides sorted by their keys at once, matching the runs of
+// rows with equal keys. Rows with a NULL key never match.
+func (j *joiner) merge(keys []joinKey) error {
+	leftKeys := make([]sql.Row, len(j.left))
+	for li, row := range j.left {
+		leftKeys[li] = joinKeyValues(row, keys, leftKey)
+	}
+
+	rightKeys := make([]sql.Row, len(j.right))
+	for ri, row := range j.right {
+		rightKeys[ri] = joinKeyValues(row, keys, rightKey)
+	}
+
+	// run returns the end of the run of keys equal to the key at start.
+	run := func(keys []sql.Row, start int) int {
+		end := start + 1
+		for end < len(keys) && keys[end].Equal(keys[start]) {
+			end++
+		}
+		return end
+	}
+
+	li, ri := 0, 0
+
+	for li < len(j.left) {
+		if ri == len(j.right) {
+			j.finish(li)
+			li++
+			continue
+		}
+
+		c, err := compareKeys(leftKeys[li], rightKeys[ri])
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case c < 0:
+			j.finish(li)
+			li++
+		case c > 0:
+			ri++
+		default:
+			le, re := run(leftKeys, li), run(rightKeys, ri)
+
+			if !hasNull(leftKeys[li]) {
+				for l := li; l < le; l++ {
+					for r := ri; r < re; r++ {
+						if err := j.match(l, r); err != nil {
+							return err
+						}
+					}
+				}
+			}
+
+			for l := li; l < le; l++ {
+				j.finish(l)
+			}
+
+			li, ri = le, re
+		}
+	}
+
+	return nil
+}
+
+func joinKeyValues(row sql.Row, keys []joinKey, side func(joinKey) int) sql.Row {
+	values := make(sql.Row, len(keys))
+	for i, k := range keys {
+		values[i] = row[side(k)]
+	}
+	return values
+}
+
+// sorted reports whether the rows are in ascending order of the keys of the
+// side, NULLs last.
+func sorted(rows []sql.Row, keys []joinKey, side func(joinKey) int) bool {
+	for i := 1; i < len(rows); i++ {
+		c, err := compareKeys(joinKeyValues(rows[i-1], keys, side), joinKeyValues(rows[i], keys, side))
+		if err != nil || c > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// compareKeys compares the keys value by value.
+func compareKeys(a, b sql.Row) (int, error) {
+	for i := range a {
+		c, err := a[i].Compare(b[i])
+		if err != nil || c != 0 {
+			return c, err
+		}
+	}
+
+	return 0, nil
+}
+
+func hasNull(row sql.Row) bool {
+	for _, v := range row {
+		if sql.IsNull(v) {
+			return true
+		}
+	}
+	return false
+}
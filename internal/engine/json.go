@@ -0,0 +1,255 @@
+package engine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/okazaki-kk/miniDB/internal/parser/token"
+	"github.com/okazaki-kk/miniDB/internal/sql"
+	"github.com/okazaki-kk/miniDB/internal/sql/datatype"
+)
+
+// jsonOperators are the result types of the JSON operators.
+var jsonOperators = map[token.TokenType]sql.DataType{
+	token.ARROW:        sql.JSON,
+	token.DOUBLE_ARROW: sql.Text,
+	token.HASH_ARROW:   sql.JSON,
+	token.CONTAINS:     sql.Boolean,
+}
+
+// jsonOperands checks the types of the operands of a JSON operator. The
+// document may be given as text, which is parsed, -> and ->> take a key or
+// a position, #> a path like '{a,0}' and @> another document.
+func jsonOperands(operator token.TokenType, left, right sql.DataType) error {
+	ok := left == sql.Null || left == sql.JSON || left == sql.Text
+
+	switch operator {
+	case token.ARROW, token.DOUBLE_ARROW:
+		ok = ok && (right == sql.Null || right == sql.Text || right == sql.Integer)
+	case token.HASH_ARROW:
+		ok = ok && (right == sql.Null || right == sql.Text)
+	default:
+		ok = ok && (right == sql.Null || right == sql.JSON || right == sql.Text)
+	}
+
+	if !ok {
+		return fmt.Errorf("operator does not exist: %s %s %s", typeName(left), operator, typeName(right))
+	}
+
+	return nil
+}
+
+// jsonOperation applies the JSON operator to the values, which are not
+// NULL. A missing key, position or path gives NULL.
+func jsonOperation(operator token.TokenType, left, right sql.Value) (sql.Value, error) {
+	if err := jsonOperands(operator, left.DataType(), right.DataType()); err != nil {
+		return nil, err
+	}
+
+	doc, err := toJSON(left)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		result datatype.JSON
+		ok     bool
+	)
+
+	switch operator {
+	case token.CONTAINS:
+		other, err := toJSON(right)
+		if err != nil {
+			return nil, err
+		}
+		return datatype.NewBoolean(doc.Contains(other)), nil
+	case token.HASH_ARROW:
+		path, err := parseTextArray(right.Raw().(string))
+		if err != nil {
+			return nil, err
+		}
+		result, ok = doc.Path(path)
+	default:
+		switch step := right.Raw().(type) {
+		case int64:
+			result, ok = doc.Element(int(step))
+		case string:
+			result, ok = doc.Field(step)
+		}
+	}
+
+	if !ok {
+		return datatype.NewNull(), nil
+	}
+
+	if operator == token.DOUBLE_ARROW {
+		text, ok := result.Text()
+		if !ok {
+			return datatype.NewNull(), nil
+		}
+		return datatype.NewText(text), nil
+	}
+
+	return result, nil
+}
+
+// toJSON returns the document of a JSON value or parses the one of text.
+func toJSON(value sql.Value) (datatype.JSON, error) {
+	if doc, ok := value.(datatype.JSON); ok {
+		return doc, nil
+	}
+	return datatype.ParseJSON(value.String())
+}
+
+// parseTextArray parses an array literal of text like '{a,"b c",0}'.
+func parseTextArray(s string) ([]string, error) {
+	trimmed := strings.TrimSpace(s)
+	if len(trimmed) < 2 || trimmed[0] != '{' || trimmed[len(trimmed)-1] != '}' {
+		return nil, fmt.Errorf("malformed array literal: %q", s)
+	}
+
+	body := trimmed[1 : len(trimmed)-1]
+	if strings.TrimSpace(body) == "" {
+		return nil, nil
+	}
+
+	var elements []string
+	for {
+		body = strings.TrimLeft(body, " ")
+
+		var element string
+		if strings.HasPrefix(body, `"`) {
+			end := strings.IndexByte(body[1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("malformed array literal: %q", s)
+			}
+			element, body = body[1:end+1], strings.TrimLeft(body[end+2:], " ")
+		} else {
+			end := strings.IndexByte(body, ',')
+			if end < 0 {
+				end = len(body)
+			}
+			element, body = strings.TrimSpace(body[:end]), body[end:]
+			if element == "" {
+				return nil, fmt.Errorf("malformed array literal: %q", s)
+			}
+		}
+
+		elements = append(elements, element)
+
+		if body == "" {
+			return elements, nil
+		}
+		if body[0] != ',' {
+			return nil, fmt.Errorf("malformed array literal: %q", s)
+		}
+		body = body[1:]
+	}
+}
+
+// parseJSONPath parses a path like '$.a."b c"[0]' into its steps.
+func parseJSONPath(s string) ([]string, error) {
+	if !strings.HasPrefix(s, "$") {
+		return nil, fmt.Errorf("invalid JSON path: %q", s)
+	}
+
+	var path []string
+	for rest := s[1:]; rest != ""; {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+
+			if strings.HasPrefix(rest, `"`) {
+				end := strings.IndexByte(rest[1:], '"')
+				if end < 0 {
+					return nil, fmt.Errorf("invalid JSON path: %q", s)
+				}
+				path, rest = append(path, rest[1:end+1]), rest[end+2:]
+				continue
+			}
+
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid JSON path: %q", s)
+			}
+			path, rest = append(path, rest[:end]), rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid JSON path: %q", s)
+			}
+			if _, err := strconv.Atoi(rest[1:end]); err != nil {
+				return nil, fmt.Errorf("invalid JSON path: %q", s)
+			}
+			path, rest = append(path, rest[1:end]), rest[end+1:]
+		default:
+			return nil, fmt.Errorf("invalid JSON path: %q", s)
+		}
+	}
+
+	return path, nil
+}
+
+// jsonExtract returns the value of the document at a path like '$.a[0]',
+// NULL when there is none.
+func jsonExtract(args []sql.Value) (sql.Value, error) {
+	path, err := parseJSONPath(args[1].Raw().(string))
+	if err != nil {
+		return nil, err
+	}
+
+	result, ok := args[0].(datatype.JSON).Path(path)
+	if !ok {
+		return datatype.NewNull(), nil
+	}
+
+	return result, nil
+}
+
+func jsonArrayLength(args []sql.Value) (sql.Value, error) {
+	doc := args[0].(datatype.JSON)
+
+	n, ok := doc.Len()
+	if !ok {
+		if doc.Kind() == "object" {
+			return nil, fmt.Errorf("cannot get array length of a non-array")
+		}
+		return nil, fmt.Errorf("cannot get array length of a scalar")
+	}
+
+	return datatype.NewInteger(int64(n)), nil
+}
+
+func jsonContains(args []sql.Value) (sql.Value, error) {
+	return datatype.NewBoolean(args[0].(datatype.JSON).Contains(args[1].(datatype.JSON))), nil
+}
+
+// jsonObject builds an object of the alternating keys and values.
+func jsonObject(args []sql.Value) (sql.Value, error) {
+	if len(args)%2 != 0 {
+		return nil, fmt.Errorf("argument list must have even number of elements")
+	}
+
+	keys := make([]string, 0, len(args)/2)
+	values := make([]datatype.JSON, 0, len(args)/2)
+
+	for i := 0; i < len(args); i += 2 {
+		if sql.IsNull(args[i]) {
+			return nil, fmt.Errorf("null value not allowed for object key")
+		}
+
+		key := args[i].String()
+		if text, ok := datatype.ToJSON(args[i]).Text(); ok {
+			key = text
+		}
+
+		keys = append(keys, key)
+		values = append(values, datatype.ToJSON(args[i+1]))
+	}
+
+	return datatype.NewJSONObject(keys, values), nil
+}
@@ -0,0 +1,136 @@
+package engine
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/okazaki-kk/miniDB/internal/parser/ast"
+	"github.com/okazaki-kk/miniDB/internal/parser/token"
+	"github.com/okazaki-kk/miniDB/internal/sql"
+	"github.com/okazaki-kk/miniDB/internal/sql/datatype"
+)
+
+// defaultEscape escapes the wildcards of a LIKE pattern without an ESCAPE
+// clause.
+const defaultEscape = '\\'
+
+// likeChar is a character of a LIKE pattern: % matching any sequence of
+// characters, _ matching any character, or a character matching itself.
+type likeChar struct {
+	r    rune
+	any  bool
+	one  bool
+	fold bool
+}
+
+// evalLike matches the text against the pattern. ILIKE ignores case.
+func evalLike(expr *ast.LikeExpr, s *scope) (sql.Value, error) {
+	var values []sql.Value
+	for _, e := range operands(expr) {
+		value, err := eval(e, s)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+	}
+
+	for _, value := range values {
+		if sql.IsNull(value) {
+			return datatype.NewNull(), nil
+		}
+	}
+
+	text, ok := values[0].Raw().(string)
+	pattern, pok := values[1].Raw().(string)
+	if !ok || !pok {
+		return nil, fmt.Errorf("operator does not exist: %s %s %s", values[0].DataType(), expr.Operator, values[1].DataType())
+	}
+
+	escape := rune(defaultEscape)
+	if len(values) > 2 {
+		e, ok := values[2].Raw().(string)
+		if !ok || utf8.RuneCountInString(e) > 1 {
+			return nil, fmt.Errorf("invalid escape string")
+		}
+
+		// An empty escape string disables escaping.
+		escape = -1
+		if e != "" {
+			escape, _ = utf8.DecodeRuneInString(e)
+		}
+	}
+
+	chars, err := likePattern(pattern, escape, expr.Operator == token.ILIKE)
+	if err != nil {
+		return nil, err
+	}
+
+	return datatype.NewBoolean(like([]rune(text), chars) != expr.Not), nil
+}
+
+// likePattern splits the pattern into its characters.
+func likePattern(pattern string, escape rune, fold bool) ([]likeChar, error) {
+	var chars []likeChar
+
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; {
+		case r == escape:
+			i++
+			if i == len(runes) {
+				return nil, fmt.Errorf("LIKE pattern must not end with escape character")
+			}
+			chars = append(chars, likeChar{r: runes[i], fold: fold})
+		case r == '%':
+			chars = append(chars, likeChar{any: true})
+		case r == '_':
+			chars = append(chars, likeChar{one: true})
+		default:
+			chars = append(chars, likeChar{r: r, fold: fold})
+		}
+	}
+
+	return chars, nil
+}
+
+// like reports whether the text matches the pattern. After a mismatch it
+// backtracks to the last %, letting it match one more character.
+func like(text []rune, pattern []likeChar) bool {
+	i, j := 0, 0
+	star, mark := -1, 0
+
+	for i < len(text) {
+		switch {
+		case j < len(pattern) && pattern[j].any:
+			star, mark = j, i
+			j++
+		case j < len(pattern) && pattern[j].matches(text[i]):
+			i++
+			j++
+		case star >= 0:
+			mark++
+			i, j = mark, star+1
+		default:
+			return false
+		}
+	}
+
+	for j < len(pattern) && pattern[j].any {
+		j++
+	}
+
+	return j == len(pattern)
+}
+
+func (c likeChar) matches(r rune) bool {
+	switch {
+	case c.one:
+		return true
+	case c.fold:
+		return unicode.ToLower(c.r) == unicode.ToLower(r)
+	default:
+		return c.r == r
+	}
+}
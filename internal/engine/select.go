@@ -0,0 +1,996 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/okazaki-kk/miniDB/internal/parser/ast"
+	"github.com/okazaki-kk/miniDB/internal/parser/token"
+	"github.com/okazaki-kk/miniDB/internal/sql"
+)
+
+// Select runs the query against the current database. Without FROM the
+// result expressions are evaluated once.
+func (e *Engine) Select(stmt *ast.SelectStatement) (*Result, error) {
+	x := newExecutor(e)
+
+	if result, ok, err := x.stream(stmt, &scope{}); ok || err != nil {
+		return result, err
+	}
+
+	result, err := x.query(stmt, &scope{})
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(result.columns))
+	for _, c := range result.columns {
+		names = append(names, c.name)
+	}
+
+	return &Result{Columns: names, Rows: sql.NewSliceRowsIter(result.rows...)}, nil
+}
+
+// executor runs the queries of a statement, including its subqueries.
+type executor struct {
+	engine *Engine
+	// results holds the results of the subqueries which don't reference the
+	// columns of an outer query, so that they run once.
+	results map[*ast.SelectStatement]source
+}
+
+func newExecutor(e *Engine) *executor {
+	return &executor{engine: e, results: make(map[*ast.SelectStatement]source)}
+}
+
+// query runs the query in the scope, which is empty but for the scope of the
+// outer query of a subquery.
+func (x *executor) query(stmt *ast.SelectStatement, s *scope) (source, error) {
+	s.executor = x
+
+	if stmt.With != nil {
+		if err := x.with(stmt.With, s); err != nil {
+			return source{}, err
+		}
+	}
+
+	if stmt.Operator != "" {
+		return x.setOperation(stmt, s)
+	}
+
+	rows := []sql.Row{{}}
+
+	if stmt.From != nil {
+		from, err := x.from(stmt.From, whereExpr(stmt.Where), s)
+		if err != nil {
+			return source{}, err
+		}
+
+		s.columns, rows = from.columns, from.rows
+	}
+
+	if err := checkTypes(s, queryExprs(stmt)...); err != nil {
+		return source{}, err
+	}
+
+	if stmt.Where != nil {
+		if err := s.functions().noAggregates("WHERE", stmt.Where.Expr); err != nil {
+			return source{}, err
+		}
+
+		if err := noWindows("WHERE", stmt.Where.Expr); err != nil {
+			return source{}, err
+		}
+
+		filtered := rows[:0:0]
+		for _, row := range rows {
+			s.row = row
+
+			ok, err := matches(stmt.Where.Expr, s)
+			if err != nil {
+				return source{}, err
+			}
+			if ok {
+				filtered = append(filtered, row)
+			}
+		}
+		rows = filtered
+	}
+
+	grouped, err := isGrouped(stmt, s)
+	if err != nil {
+		return source{}, err
+	}
+
+	if grouped {
+		if rows, err = groupRows(rows, s, stmt); err != nil {
+			return source{}, err
+		}
+	}
+
+	var calls []*ast.WindowExpr
+	for _, result := range stmt.Result {
+		if calls, err = collectWindows(result.Expr, calls); err != nil {
+			return source{}, err
+		}
+	}
+	for _, expr := range sortExprs(stmt) {
+		if calls, err = collectWindows(expr, calls); err != nil {
+			return source{}, err
+		}
+	}
+
+	if len(calls) > 0 {
+		if rows, err = windows(rows, s, calls); err != nil {
+			return source{}, err
+		}
+	}
+
+	result, err := project(rows, s, stmt.Result)
+	if err != nil {
+		return source{}, err
+	}
+
+	// The values of the DISTINCT ON expressions follow the ones of the
+	// result columns, so that they are sorted along with them.
+	if len(stmt.DistinctOn) > 0 {
+		if result.rows, err = appendDistinctOn(result.rows, rows, s, stmt); err != nil {
+			return source{}, err
+		}
+	}
+
+	if stmt.OrderBy != nil {
+		if result.rows, err = x.sortResult(result.rows, rows, s, stmt); err != nil {
+			return source{}, err
+		}
+	}
+
+	if stmt.Distinct {
+		result.rows = distinct(result.rows, len(result.columns))
+	}
+
+	result.rows, err = paginate(result.rows, stmt.Offset, stmt.Limit, s.functions())
+	if err != nil {
+		return source{}, err
+	}
+
+	return result, nil
+}
+
+// stream runs a query whose rows stream from the table it reads through
+// the sort of its ORDER BY to the result, so that only the sort holds on to
+// them, within the memory of the engine. It reports false for other queries,
+// ones reading more than a table, grouped, windowed or DISTINCT ones.
+func (x *executor) stream(stmt *ast.SelectStatement, s *scope) (*Result, bool, error) {
+	s.executor = x
+
+	if stmt.With != nil || stmt.Operator != "" || stmt.OrderBy == nil || stmt.From == nil ||
+		stmt.From.Subquery != nil || len(stmt.From.Joins) > 0 || stmt.Distinct || len(stmt.DistinctOn) > 0 {
+		return nil, false, nil
+	}
+
+	// Queries failing these checks are left to query, which reports why.
+	if grouped, err := isGrouped(stmt, s); err != nil || grouped {
+		return nil, false, nil
+	}
+
+	for _, expr := range append(queryExprs(stmt), sortExprs(stmt)...) {
+		if calls, err := collectWindows(expr, nil); err != nil || len(calls) > 0 {
+			return nil, false, nil
+		}
+	}
+
+	db, err := x.engine.currentDatabase()
+	if err != nil {
+		return nil, true, err
+	}
+
+	table, err := db.GetTable(stmt.From.Table)
+	if err != nil {
+		return nil, true, err
+	}
+
+	name := tableName(stmt.From.Table, stmt.From.Alias)
+	s.columns = scopeColumns(tableName(table.Name(), stmt.From.Alias), table.Scheme().Columns())
+
+	if err := checkTypes(s, queryExprs(stmt)...); err != nil {
+		return nil, true, err
+	}
+
+	where := whereExpr(stmt.Where)
+	if where != nil {
+		if err := s.functions().noAggregates("WHERE", where); err != nil {
+			return nil, true, err
+		}
+
+		if err := noWindows("WHERE", where); err != nil {
+			return nil, true, err
+		}
+	}
+
+	p, err := newProjection(s, stmt.Result)
+	if err != nil {
+		return nil, true, err
+	}
+
+	order, err := newResultOrder(stmt, s)
+	if err != nil {
+		return nil, true, err
+	}
+
+	n, err := sortLimit(stmt.Offset, stmt.Limit, s.functions())
+	if err != nil {
+		return nil, true, err
+	}
+
+	paged := &pagedRows{limit: -1}
+	if stmt.Offset != nil {
+		if paged.offset, err = count("OFFSET", stmt.Offset.Value, s.functions()); err != nil {
+			return nil, true, err
+		}
+	}
+	if stmt.Limit != nil {
+		if paged.limit, err = count("LIMIT", stmt.Limit.Value, s.functions()); err != nil {
+			return nil, true, err
+		}
+	}
+
+	var rows sql.RowIter
+	if where != nil {
+		_, found, ok, err := indexLookup(table, name, where, s.functions())
+		if err != nil {
+			return nil, true, err
+		}
+		if ok {
+			rows = sql.NewSliceRowsIter(found...)
+		}
+	}
+	if rows == nil {
+		if rows, err = table.Scan(); err != nil {
+			return nil, true, err
+		}
+	}
+
+	items := &queryItems{rows: rows, where: where, s: s, projection: p, order: order}
+	if paged.rows, err = x.sortRows(items, order.keys, n); err != nil {
+		return nil, true, err
+	}
+
+	names := make([]string, 0, len(p.columns))
+	for _, c := range p.columns {
+		names = append(names, c.name)
+	}
+
+	return &Result{Columns: names, Rows: paged}, true, nil
+}
+
+// queryItems reads the rows of a query matching its WHERE clause and makes
+// the rows to sort of them, the values of the sort keys followed by the
+// result.
+type queryItems struct {
+	rows       sql.RowIter
+	where      ast.Expression
+	s          *scope
+	projection *projection
+	order      resultOrder
+}
+
+func (q *queryItems) Next() (sql.Row, error) {
+	for {
+		row, err := q.rows.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		q.s.row = row
+
+		if q.where != nil {
+			ok, err := matches(q.where, q.s)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		result, err := q.projection.row(q.s)
+		if err != nil {
+			return nil, err
+		}
+
+		return q.order.item(result, q.s)
+	}
+}
+
+func (q *queryItems) Close() error {
+	return q.rows.Close()
+}
+
+// isGrouped reports whether the query groups its rows, which it does with
+// GROUP BY, HAVING or aggregates in the result.
+func isGrouped(stmt *ast.SelectStatement, s *scope) (bool, error) {
+	if stmt.GroupBy != nil || stmt.Having != nil {
+		return true, nil
+	}
+
+	exprs := sortExprs(stmt)
+	for _, result := range stmt.Result {
+		exprs = append(exprs, result.Expr)
+	}
+
+	for _, expr := range exprs {
+		calls, err := s.functions().collectAggregates(expr, nil)
+		if err != nil {
+			return false, err
+		}
+		if len(calls) > 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// groupRows aggregates the rows of a grouped query after verifying the result,
+// HAVING and ORDER BY only reference the GROUP BY keys outside of aggregates.
+func groupRows(rows []sql.Row, s *scope, stmt *ast.SelectStatement) ([]sql.Row, error) {
+	var (
+		keys  []ast.Expression
+		calls []*ast.CallExpr
+		err   error
+	)
+
+	if stmt.GroupBy != nil {
+		if keys, err = groupKeys(stmt, s); err != nil {
+			return nil, err
+		}
+	}
+
+	exprs := make([]ast.Expression, 0, len(stmt.Result)+2)
+	for _, result := range stmt.Result {
+		exprs = append(exprs, result.Expr)
+	}
+	if stmt.Having != nil {
+		exprs = append(exprs, stmt.Having.Expr)
+	}
+	for _, expr := range stmt.DistinctOn {
+		if !referencesResult(stmt.Result, expr) {
+			exprs = append(exprs, expr)
+		}
+	}
+	exprs = append(exprs, sortExprs(stmt)...)
+
+	for _, expr := range exprs {
+		if calls, err = s.functions().collectAggregates(expr, calls); err != nil {
+			return nil, err
+		}
+
+		if err := checkGrouped(expr, keys, s); err != nil {
+			return nil, err
+		}
+	}
+
+	return aggregate(rows, s, keys, stmt.Having, calls)
+}
+
+// orderBy sorts the rows of a set operation by the ORDER BY keys, which
+// reference its columns by position or are evaluated on its rows.
+func (x *executor) orderBy(rows []sql.Row, s *scope, order *ast.OrderByStatement, offset *ast.OffsetStatement, limit *ast.LimitStatement) ([]sql.Row, error) {
+	positions := make([]int, len(order.Keys))
+	for i, key := range order.Keys {
+		position, err := sortPosition(key.Expr, len(s.columns))
+		if err != nil {
+			return nil, err
+		}
+		positions[i] = position
+	}
+
+	items := make([]sql.Row, len(rows))
+	for i, row := range rows {
+		s.row = row
+
+		item := make(sql.Row, 0, len(order.Keys)+len(row))
+		for j, key := range order.Keys {
+			if positions[j] >= 0 {
+				item = append(item, row[positions[j]])
+				continue
+			}
+
+			value, err := eval(key.Expr, s)
+			if err != nil {
+				return nil, err
+			}
+			item = append(item, value)
+		}
+		items[i] = append(item, row...)
+	}
+
+	n, err := sortLimit(offset, limit, s.functions())
+	if err != nil {
+		return nil, err
+	}
+
+	return sortSlice(items, order.Keys, n)
+}
+
+// sortResult sorts the result rows by the ORDER BY keys, which reference
+// result columns by position, name or expression or else are evaluated on
+// the rows the result was projected from. Unless the query is DISTINCT, the
+// rows after OFFSET and LIMIT are dropped.
+func (x *executor) sortResult(result []sql.Row, rows []sql.Row, s *scope, stmt *ast.SelectStatement) ([]sql.Row, error) {
+	order, err := newResultOrder(stmt, s)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]sql.Row, len(rows))
+	for i := range rows {
+		s.row = rows[i]
+
+		if items[i], err = order.item(result[i], s); err != nil {
+			return nil, err
+		}
+	}
+
+	n := -1
+	if !stmt.Distinct {
+		if n, err = sortLimit(stmt.Offset, stmt.Limit, s.functions()); err != nil {
+			return nil, err
+		}
+	}
+
+	return sortSlice(items, order.keys, n)
+}
+
+// resultOrder takes the values of the ORDER BY keys of a query from its
+// result columns they reference or else evaluates them on the row the
+// result was projected from.
+type resultOrder struct {
+	keys []ast.SortKey
+	// columns has the result column of every key, -1 for an evaluated one.
+	columns []int
+}
+
+func newResultOrder(stmt *ast.SelectStatement, s *scope) (resultOrder, error) {
+	order := resultOrder{keys: stmt.OrderBy.Keys, columns: make([]int, len(stmt.OrderBy.Keys))}
+
+	for i, key := range order.keys {
+		column, err := resultColumn(stmt.Result, key.Expr, s, "ORDER BY")
+		if err != nil {
+			return resultOrder{}, err
+		}
+		order.columns[i] = column
+	}
+
+	if stmt.Distinct {
+		if err := checkDistinctOrder(stmt, order.columns); err != nil {
+			return resultOrder{}, err
+		}
+	}
+
+	return order, nil
+}
+
+// item returns the row to sort for the result row projected from the row of
+// the scope: the values of the sort keys followed by the result.
+func (o resultOrder) item(result sql.Row, s *scope) (sql.Row, error) {
+	item := make(sql.Row, 0, len(o.keys)+len(result))
+
+	for i, key := range o.keys {
+		if o.columns[i] >= 0 {
+			item = append(item, result[o.columns[i]])
+			continue
+		}
+
+		value, err := eval(key.Expr, s)
+		if err != nil {
+			return nil, err
+		}
+		item = append(item, value)
+	}
+
+	return append(item, result...), nil
+}
+
+// sortLimit returns the number of sorted rows OFFSET and LIMIT keep, or -1
+// without LIMIT.
+func sortLimit(offset *ast.OffsetStatement, limit *ast.LimitStatement, r *registry) (int, error) {
+	if limit == nil {
+		return -1, nil
+	}
+
+	n, err := count("LIMIT", limit.Value, r)
+	if err != nil {
+		return 0, err
+	}
+
+	if offset != nil {
+		m, err := count("OFFSET", offset.Value, r)
+		if err != nil {
+			return 0, err
+		}
+		n += m
+	}
+
+	return int(n), nil
+}
+
+// queryExprs returns the expressions of the query evaluated on its rows.
+func queryExprs(stmt *ast.SelectStatement) []ast.Expression {
+	var exprs []ast.Expression
+
+	for _, result := range stmt.Result {
+		exprs = append(exprs, result.Expr)
+	}
+	if stmt.Where != nil {
+		exprs = append(exprs, stmt.Where.Expr)
+	}
+	if stmt.GroupBy != nil {
+		exprs = append(exprs, stmt.GroupBy.Exprs...)
+	}
+	if stmt.Having != nil {
+		exprs = append(exprs, stmt.Having.Expr)
+	}
+	exprs = append(exprs, stmt.DistinctOn...)
+
+	return append(exprs, sortExprs(stmt)...)
+}
+
+// sortExprs returns the expressions of the ORDER BY keys which don't
+// reference result columns, evaluated on the rows of the query.
+func sortExprs(stmt *ast.SelectStatement) []ast.Expression {
+	if stmt.OrderBy == nil {
+		return nil
+	}
+
+	var exprs []ast.Expression
+	for _, key := range stmt.OrderBy.Keys {
+		if !referencesResult(stmt.Result, key.Expr) {
+			exprs = append(exprs, key.Expr)
+		}
+	}
+
+	return exprs
+}
+
+// checkDistinctOrder verifies the ORDER BY keys of a SELECT DISTINCT are in
+// the result, or with DISTINCT ON start with its expressions, as the rows it
+// keeps would be arbitrary otherwise.
+func checkDistinctOrder(stmt *ast.SelectStatement, columns []int) error {
+	if len(stmt.DistinctOn) == 0 {
+		for _, column := range columns {
+			if column < 0 {
+				return fmt.Errorf("for SELECT DISTINCT, ORDER BY expressions must appear in select list")
+			}
+		}
+		return nil
+	}
+
+	for i, key := range stmt.OrderBy.Keys {
+		if i == len(stmt.DistinctOn) {
+			break
+		}
+
+		matched := false
+		for _, expr := range stmt.DistinctOn {
+			if sortTarget(stmt.Result, expr) == sortTarget(stmt.Result, key.Expr) {
+				matched = true
+			}
+		}
+		if !matched {
+			return fmt.Errorf("SELECT DISTINCT ON expressions must match initial ORDER BY expressions")
+		}
+	}
+
+	return nil
+}
+
+// sortTarget returns the SQL text of the result expression the expression
+// references, or else of the expression itself.
+func sortTarget(results []ast.ResultStatement, expr ast.Expression) string {
+	if scalar, ok := expr.(*ast.ScalarExpr); ok {
+		if value, err := literal(scalar); err == nil {
+			if position, ok := value.Raw().(int64); ok && position >= 1 && position <= int64(len(results)) {
+				return results[position-1].Expr.String()
+			}
+		}
+	}
+
+	if index := resultIndex(results, expr); index >= 0 {
+		return results[index].Expr.String()
+	}
+
+	return expr.String()
+}
+
+// referencesResult reports whether the expression references a result
+// column, by position if it's a constant.
+func referencesResult(results []ast.ResultStatement, expr ast.Expression) bool {
+	if _, ok := expr.(*ast.ScalarExpr); ok {
+		return true
+	}
+
+	return resultIndex(results, expr) >= 0
+}
+
+// resultIndex returns the index of the result an unqualified column
+// reference names, if exactly one result is named like it, or else of the
+// first result with the same expression. It's -1 if there's none.
+func resultIndex(results []ast.ResultStatement, expr ast.Expression) int {
+	if ident, ok := expr.(*ast.IdentExpr); ok && ident.Table == "" {
+		index := -1
+		for i, result := range results {
+			if _, ok := result.Expr.(*ast.AsteriskExpr); ok {
+				continue
+			}
+
+			if resultName(result) == ident.Name {
+				if index >= 0 {
+					index = -1
+					break
+				}
+				index = i
+			}
+		}
+
+		if index >= 0 {
+			return index
+		}
+	}
+
+	for i, result := range results {
+		if result.Expr.String() == expr.String() {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// resultColumn returns the index of the result column the expression of the
+// clause references by position, name or expression, or -1 if it doesn't
+// reference one.
+func resultColumn(results []ast.ResultStatement, expr ast.Expression, s *scope, clause string) (int, error) {
+	var index int
+
+	if scalar, ok := expr.(*ast.ScalarExpr); ok {
+		value, err := literal(scalar)
+		if err != nil {
+			return 0, err
+		}
+
+		position, ok := value.Raw().(int64)
+		if !ok {
+			return 0, fmt.Errorf("non-integer constant in %s", clause)
+		}
+		if position < 1 || position > int64(len(results)) {
+			return 0, fmt.Errorf("%s position %d is not in select list", clause, position)
+		}
+		if _, ok := results[position-1].Expr.(*ast.AsteriskExpr); ok {
+			return 0, fmt.Errorf("%s position %d is not in select list", clause, position)
+		}
+
+		index = int(position - 1)
+	} else if index = resultIndex(results, expr); index < 0 {
+		return -1, nil
+	}
+
+	column := 0
+	for _, result := range results[:index] {
+		asterisk, ok := result.Expr.(*ast.AsteriskExpr)
+		if !ok {
+			column++
+			continue
+		}
+
+		indexes, err := s.expand(asterisk)
+		if err != nil {
+			return 0, err
+		}
+		column += len(indexes)
+	}
+
+	return column, nil
+}
+
+// sortPosition returns the index of the column at the position of a
+// constant sort key, or -1 for other sort keys.
+func sortPosition(expr ast.Expression, columns int) (int, error) {
+	scalar, ok := expr.(*ast.ScalarExpr)
+	if !ok {
+		return -1, nil
+	}
+
+	value, err := literal(scalar)
+	if err != nil {
+		return 0, err
+	}
+
+	position, ok := value.Raw().(int64)
+	if !ok {
+		return 0, fmt.Errorf("non-integer constant in ORDER BY")
+	}
+	if position < 1 || position > int64(columns) {
+		return 0, fmt.Errorf("ORDER BY position %d is not in select list", position)
+	}
+
+	return int(position - 1), nil
+}
+
+// appendDistinctOn appends the values of the DISTINCT ON expressions to the
+// result rows. The expressions reference result columns like ORDER BY keys
+// or else are evaluated on the rows the result was projected from.
+func appendDistinctOn(result []sql.Row, rows []sql.Row, s *scope, stmt *ast.SelectStatement) ([]sql.Row, error) {
+	columns := make([]int, len(stmt.DistinctOn))
+	for i, expr := range stmt.DistinctOn {
+		column, err := resultColumn(stmt.Result, expr, s, "DISTINCT ON")
+		if err != nil {
+			return nil, err
+		}
+		columns[i] = column
+	}
+
+	for i, row := range rows {
+		s.row = row
+
+		values := make(sql.Row, 0, len(result[i])+len(stmt.DistinctOn))
+		values = append(values, result[i]...)
+		for j, expr := range stmt.DistinctOn {
+			if columns[j] >= 0 {
+				values = append(values, result[i][columns[j]])
+				continue
+			}
+
+			value, err := eval(expr, s)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		result[i] = values
+	}
+
+	return result, nil
+}
+
+// distinct drops the duplicate result rows. Rows longer than the result
+// columns end with the values of the DISTINCT ON expressions, of which only
+// the first row with equal values is kept.
+func distinct(rows []sql.Row, columns int) []sql.Row {
+	if len(rows) == 0 || len(rows[0]) == columns {
+		return distinctRows(rows)
+	}
+
+	set := make(rowSet)
+
+	kept := rows[:0:0]
+	for _, row := range rows {
+		if set.add(row[columns:]) {
+			kept = append(kept, row[:columns])
+		}
+	}
+
+	return kept
+}
+
+// compareSortKeys compares the values of the sort keys, in the direction of
+// the keys and sorting NULLs as the keys say.
+func compareSortKeys(a, b sql.Row, keys []ast.SortKey) (int, error) {
+	for i, key := range keys {
+		desc := key.Direction == token.DESC
+		nullsFirst := key.Nulls == token.FIRST || (key.Nulls == "" && desc)
+
+		if sql.IsNull(a[i]) || sql.IsNull(b[i]) {
+			if sql.IsNull(a[i]) == sql.IsNull(b[i]) {
+				continue
+			}
+			if sql.IsNull(a[i]) == nullsFirst {
+				return -1, nil
+			}
+			return 1, nil
+		}
+
+		c, err := a[i].Compare(b[i])
+		if err != nil {
+			return 0, err
+		}
+		if desc {
+			c = -c
+		}
+		if c != 0 {
+			return c, nil
+		}
+	}
+
+	return 0, nil
+}
+
+// paginate skips the OFFSET rows and returns at most LIMIT of the rest.
+func paginate(rows []sql.Row, offset *ast.OffsetStatement, limit *ast.LimitStatement, r *registry) ([]sql.Row, error) {
+	if offset != nil {
+		n, err := count("OFFSET", offset.Value, r)
+		if err != nil {
+			return nil, err
+		}
+
+		if n > int64(len(rows)) {
+			n = int64(len(rows))
+		}
+		rows = rows[n:]
+	}
+
+	if limit != nil {
+		n, err := count("LIMIT", limit.Value, r)
+		if err != nil {
+			return nil, err
+		}
+
+		if n < int64(len(rows)) {
+			rows = rows[:n]
+		}
+	}
+
+	return rows, nil
+}
+
+func count(clause string, expr ast.Expression, r *registry) (int64, error) {
+	value, err := eval(expr, &scope{registry: r})
+	if err != nil {
+		return 0, err
+	}
+
+	n, ok := value.Raw().(int64)
+	if !ok || n < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer", clause)
+	}
+
+	return n, nil
+}
+
+// project evaluates the result expressions for every row. The data type of a
+// result column is the one of the column it references or else the one of
+// its first value which isn't NULL.
+func project(rows []sql.Row, s *scope, results []ast.ResultStatement) (source, error) {
+	p, err := newProjection(s, results)
+	if err != nil {
+		return source{}, err
+	}
+
+	projected := make([]sql.Row, 0, len(rows))
+
+	for _, row := range rows {
+		s.row = row
+
+		values, err := p.row(s)
+		if err != nil {
+			return source{}, err
+		}
+
+		projected = append(projected, values)
+	}
+
+	return source{columns: p.columns, rows: projected}, nil
+}
+
+// projection evaluates the result expressions of a query.
+type projection struct {
+	results []ast.ResultStatement
+	columns []column
+	// expanded has the columns of the scope an asterisk expands to.
+	expanded [][]int
+}
+
+func newProjection(s *scope, results []ast.ResultStatement) (*projection, error) {
+	p := &projection{results: results, expanded: make([][]int, len(results))}
+
+	for i, result := range results {
+		switch expr := result.Expr.(type) {
+		case *ast.AsteriskExpr:
+			indexes, err := s.expand(expr)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, index := range indexes {
+				p.columns = append(p.columns, column{name: s.columns[index].name, dataType: s.columns[index].dataType})
+			}
+			p.expanded[i] = indexes
+		case *ast.IdentExpr:
+			c := column{name: resultName(result)}
+			if owner, index, err := s.resolve(expr); err == nil {
+				c.dataType = owner.columns[index].dataType
+			}
+			p.columns = append(p.columns, c)
+		default:
+			p.columns = append(p.columns, column{name: resultName(result)})
+		}
+	}
+
+	return p, nil
+}
+
+// row evaluates the result expressions on the row of the scope, setting the
+// data types of the columns still unknown.
+func (p *projection) row(s *scope) (sql.Row, error) {
+	values := make(sql.Row, 0, len(p.columns))
+
+	for i, result := range p.results {
+		if _, ok := result.Expr.(*ast.AsteriskExpr); ok {
+			for _, index := range p.expanded[i] {
+				values = append(values, s.row[index])
+			}
+			continue
+		}
+
+		value, err := eval(result.Expr, s)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	for i, value := range values {
+		if p.columns[i].dataType == sql.Null {
+			p.columns[i].dataType = value.DataType()
+		}
+	}
+
+	return values, nil
+}
+
+// resultName returns the alias of the result or else the name of its
+// expression.
+func resultName(result ast.ResultStatement) string {
+	if result.Alias != "" {
+		return result.Alias
+	}
+
+	return columnName(result.Expr)
+}
+
+// columnName names the result column of the expression like PostgreSQL.
+func columnName(expr ast.Expression) string {
+	switch expr := expr.(type) {
+	case *ast.IdentExpr:
+		return expr.Name
+	case *ast.CallExpr:
+		return strings.ToLower(expr.Name)
+	case *ast.WindowExpr:
+		return columnName(expr.Call)
+	case *ast.SubqueryExpr:
+		query := expr.Select
+		for query.Operator != "" {
+			query = query.Left
+		}
+		if len(query.Result) == 1 {
+			if _, ok := query.Result[0].Expr.(*ast.AsteriskExpr); !ok {
+				return resultName(query.Result[0])
+			}
+		}
+		return "?column?"
+	case *ast.ExistsExpr:
+		return "exists"
+	case *ast.CaseExpr:
+		return "case"
+	case *ast.CastExpr:
+		// Nested casts are named after the outermost type.
+		inner := expr.Expr
+		for cast, ok := inner.(*ast.CastExpr); ok; cast, ok = inner.(*ast.CastExpr) {
+			inner = cast.Expr
+		}
+		if name := columnName(inner); name != "?column?" {
+			return name
+		}
+		return strings.ToLower(string(expr.Type))
+	case *ast.AtTimeZoneExpr:
+		return "timezone"
+	case *ast.ScalarExpr:
+		if expr.Type == token.TRUE || expr.Type == token.FALSE {
+			return "bool"
+		}
+		return "?column?"
+	default:
+		return "?column?"
+	}
+}
@@ -0,0 +1,171 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/okazaki-kk/miniDB/internal/parser/ast"
+	"github.com/okazaki-kk/miniDB/internal/parser/token"
+	"github.com/okazaki-kk/miniDB/internal/sql"
+)
+
+// setOperation combines the results of the queries of the set operation.
+// ORDER BY, LIMIT and OFFSET apply to the combined rows, whose columns are
+// named like the ones of the left query. Without ALL the combined rows have
+// no duplicates, with ALL a row INTERSECT keeps as many times as it is in
+// both queries and EXCEPT as many more times as it is in the left one.
+func (x *executor) setOperation(stmt *ast.SelectStatement, s *scope) (source, error) {
+	left, err := x.query(stmt.Left, &scope{outer: s})
+	if err != nil {
+		return source{}, err
+	}
+
+	right, err := x.query(stmt.Right, &scope{outer: s})
+	if err != nil {
+		return source{}, err
+	}
+
+	columns, err := combinedColumns(stmt.Operator, left.columns, right.columns)
+	if err != nil {
+		return source{}, err
+	}
+
+	var rows []sql.Row
+
+	switch stmt.Operator {
+	case token.UNION:
+		rows = make([]sql.Row, 0, len(left.rows)+len(right.rows))
+		rows = append(rows, left.rows...)
+		rows = append(rows, right.rows...)
+
+		if !stmt.All {
+			rows = distinctRows(rows)
+		}
+	case token.INTERSECT, token.EXCEPT:
+		rows = left.rows
+		if !stmt.All {
+			rows = distinctRows(rows)
+		}
+
+		counts := make(rowCounts)
+		for _, row := range right.rows {
+			counts.add(row)
+		}
+
+		intersect := stmt.Operator == token.INTERSECT
+
+		combined := rows[:0:0]
+		for _, row := range rows {
+			if counts.take(row) == intersect {
+				combined = append(combined, row)
+			}
+		}
+		rows = combined
+	default:
+		return source{}, fmt.Errorf("unsupported set operation %s", stmt.Operator)
+	}
+
+	if stmt.OrderBy != nil {
+		q := &scope{columns: columns, outer: s, executor: x}
+		if rows, err = x.orderBy(rows, q, stmt.OrderBy, stmt.Offset, stmt.Limit); err != nil {
+			return source{}, err
+		}
+	}
+
+	rows, err = paginate(rows, stmt.Offset, stmt.Limit, s.functions())
+	if err != nil {
+		return source{}, err
+	}
+
+	return source{columns: columns, rows: rows}, nil
+}
+
+// combinedColumns returns the columns of the result of a set operation. The
+// columns of both queries must have the same types, but NULL matches any.
+func combinedColumns(operator token.TokenType, left, right []column) ([]column, error) {
+	if len(left) != len(right) {
+		return nil, fmt.Errorf("each %s query must have the same number of columns", operator)
+	}
+
+	columns := make([]column, len(left))
+	for i, l := range left {
+		columns[i] = column{name: l.name, dataType: l.dataType}
+
+		switch r := right[i].dataType; {
+		case l.dataType == sql.Null:
+			columns[i].dataType = r
+		case r != sql.Null && r != l.dataType:
+			return nil, fmt.Errorf("%s types %s and %s cannot be matched", operator, l.dataType, r)
+		}
+	}
+
+	return columns, nil
+}
+
+// distinctRows returns the rows without duplicates, in the order they first
+// appear.
+func distinctRows(rows []sql.Row) []sql.Row {
+	set := make(rowSet)
+
+	distinct := rows[:0:0]
+	for _, row := range rows {
+		if set.add(row) {
+			distinct = append(distinct, row)
+		}
+	}
+
+	return distinct
+}
+
+// rowSet is a set of rows, which compares NULLs like ordinary values.
+type rowSet map[uint64][]sql.Row
+
+// add adds the row and reports whether it wasn't in the set already.
+func (set rowSet) add(row sql.Row) bool {
+	h := row.Hash()
+
+	for _, r := range set[h] {
+		if r.Equal(row) {
+			return false
+		}
+	}
+
+	set[h] = append(set[h], row)
+	return true
+}
+
+// rowCounts counts the occurrences of rows, comparing NULLs like ordinary
+// values.
+type rowCounts map[uint64][]rowCount
+
+type rowCount struct {
+	row sql.Row
+	n   int
+}
+
+// add counts an occurrence of the row.
+func (counts rowCounts) add(row sql.Row) {
+	h := row.Hash()
+
+	for i := range counts[h] {
+		if counts[h][i].row.Equal(row) {
+			counts[h][i].n++
+			return
+		}
+	}
+
+	counts[h] = append(counts[h], rowCount{row: row, n: 1})
+}
+
+// take removes an occurrence of the row and reports whether there was one.
+func (counts rowCounts) take(row sql.Row) bool {
+	h := row.Hash()
+
+	for i := range counts[h] {
+		if counts[h][i].row.Equal(row) && counts[h][i].n > 0 {
+			counts[h][i].n--
+			return true
+		}
+	}
+
+	return false
+}
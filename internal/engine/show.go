@@ -0,0 +1,184 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/okazaki-kk/miniDB/internal/sql"
+	"github.com/okazaki-kk/miniDB/internal/sql/datatype"
+	"github.com/okazaki-kk/miniDB/storage"
+)
+
+func (e *Engine) ShowDatabases() (*Result, error) {
+	databases, err := e.catalog.ListDatabases()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(databases, func(i, j int) bool {
+		return databases[i].Name() < databases[j].Name()
+	})
+
+	rows := make([]sql.Row, 0, len(databases))
+	for i := range databases {
+		rows = append(rows, sql.Row{datatype.NewText(databases[i].Name())})
+	}
+
+	return &Result{Columns: []string{"Database"}, Rows: sql.NewSliceRowsIter(rows...)}, nil
+}
+
+func (e *Engine) ShowTables() (*Result, error) {
+	db, err := e.currentDatabase()
+	if err != nil {
+		return nil, err
+	}
+
+	tables := db.ListTables()
+	sort.Slice(tables, func(i, j int) bool {
+		return tables[i].Name() < tables[j].Name()
+	})
+
+	rows := make([]sql.Row, 0, len(tables))
+	for i := range tables {
+		rows = append(rows, sql.Row{datatype.NewText(tables[i].Name())})
+	}
+
+	return &Result{Columns: []string{"Tables_in_" + db.Name()}, Rows: sql.NewSliceRowsIter(rows...)}, nil
+}
+
+// ShowFunctions lists the functions with a row for every signature.
+func (e *Engine) ShowFunctions() (*Result, error) {
+	var rows []sql.Row
+	for _, fn := range e.Functions() {
+		kind, volatility := "func", "immutable"
+		if fn.Aggregate {
+			kind = "agg"
+		}
+		if fn.Volatile {
+			volatility = "volatile"
+		}
+
+		for _, signature := range fn.Signatures {
+			rows = append(rows, sql.Row{
+				datatype.NewText(fn.Name),
+				datatype.NewText(signature.ResultType()),
+				datatype.NewText(signature.ArgumentTypes()),
+				datatype.NewText(kind),
+				datatype.NewText(volatility),
+				datatype.NewBoolean(fn.User),
+			})
+		}
+	}
+
+	return &Result{
+		Columns: []string{"Name", "Result", "Arguments", "Type", "Volatility", "User"},
+		Rows:    sql.NewSliceRowsIter(rows...),
+	}, nil
+}
+
+func (e *Engine) ShowColumns(tableName string) (*Result, error) {
+	db, err := e.currentDatabase()
+	if err != nil {
+		return nil, err
+	}
+
+	table, err := db.GetTable(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := table.Scheme().Columns()
+
+	rows := make([]sql.Row, 0, len(columns))
+	for _, column := range columns {
+		null, key := "YES", ""
+		if !column.Nullable {
+			null = "NO"
+		}
+		if column.PrimaryKey {
+			key = "PRI"
+		}
+
+		var def sql.Value = datatype.NewNull()
+		if column.Default != nil {
+			def = datatype.NewText(column.Default.String())
+		}
+
+		rows = append(rows, sql.Row{
+			datatype.NewText(column.Name),
+			datatype.NewText(column.TypeName()),
+			datatype.NewText(null),
+			datatype.NewText(key),
+			def,
+		})
+	}
+
+	return &Result{
+		Columns: []string{"Field", "Type", "Null", "Key", "Default"},
+		Rows:    sql.NewSliceRowsIter(rows...),
+	}, nil
+}
+
+func (e *Engine) ShowCreateTable(tableName string) (*Result, error) {
+	db, err := e.currentDatabase()
+	if err != nil {
+		return nil, err
+	}
+
+	table, err := db.GetTable(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{
+		Columns: []string{"Table", "Create Table"},
+		Rows: sql.NewSliceRowsIter(sql.Row{
+			datatype.NewText(table.Name()),
+			datatype.NewText(createTableStatement(table.Name(), table.Scheme(), table.Constraints(), table.Indexes())),
+		}),
+	}, nil
+}
+
+// createTableStatement renders the CREATE TABLE statement of the scheme and
+// the constraints, followed by the CREATE INDEX statements of the indexes.
+func createTableStatement(name string, scheme storage.Scheme, constraints []storage.Constraint, indexes []*storage.Index) string {
+	columns := scheme.Columns()
+	definitions := make([]string, 0, len(columns))
+
+	for _, column := range columns {
+		definition := fmt.Sprintf("    %s %s", column.Name, strings.ToUpper(column.TypeName()))
+
+		switch {
+		case column.PrimaryKey:
+			definition += " PRIMARY KEY"
+		case !column.Nullable:
+			definition += " NOT NULL"
+		}
+
+		if column.Default != nil {
+			definition += " DEFAULT " + column.Default.String()
+		}
+
+		definitions = append(definitions, definition)
+	}
+
+	for _, c := range constraints {
+		definitions = append(definitions, "    "+c.String())
+	}
+
+	statements := []string{fmt.Sprintf("CREATE TABLE %s (\n%s\n)", name, strings.Join(definitions, ",\n"))}
+	for _, index := range indexes {
+		statements = append(statements, index.String(name))
+	}
+
+	return strings.Join(statements, ";\n")
+}
+
+func (e *Engine) currentDatabase() (storage.Database, error) {
+	if e.session.database == "" {
+		return storage.Database{}, fmt.Errorf("no database selected")
+	}
+
+	return e.catalog.GetDatabase(e.session.database)
+}
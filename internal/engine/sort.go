@@ -0,0 +1,440 @@
+package engine
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/okazaki-kk/miniDB/internal/parser/ast"
+	"github.com/okazaki-kk/miniDB/internal/sql"
+	"github.com/okazaki-kk/miniDB/storage"
+)
+
+// DefaultSortMemory is the memory in bytes a sort uses before it spills its
+// rows to temporary files.
+const DefaultSortMemory = 4 << 20
+
+// sortRows sorts the rows by their first len(keys) values, the values of the
+// sort keys, and returns an iterator of them without those. Rows with equal
+// keys keep their order. With a limit of zero or more only the first limit
+// rows are kept, which a heap of that many rows finds. Otherwise the rows
+// are sorted with the memory of the engine, spilling to temporary files.
+func (x *executor) sortRows(rows sql.RowIter, keys []ast.SortKey, limit int) (sql.RowIter, error) {
+	var (
+		sorted sql.RowIter
+		err    error
+	)
+
+	if limit >= 0 {
+		sorted, err = topRows(rows, keys, limit)
+	} else {
+		sorted, err = externalSort(rows, keys, x.engine.sortMemory)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &trimmedRows{rows: sorted, n: len(keys)}, nil
+}
+
+// sortSlice sorts rows held in memory like sortRows, but in memory, which
+// spilling them to temporary files would only add to.
+func sortSlice(rows []sql.Row, keys []ast.SortKey, limit int) ([]sql.Row, error) {
+	var err error
+
+	if limit >= 0 {
+		var top sql.RowIter
+		if top, err = topRows(sql.NewSliceRowsIter(rows...), keys, limit); err == nil {
+			rows, err = readRows(top)
+		}
+	} else {
+		rows, err = sortStable(rows, keys)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i, row := range rows {
+		rows[i] = row[len(keys):]
+	}
+
+	return rows, nil
+}
+
+// readRows reads the rows of the iterator and closes it.
+func readRows(iter sql.RowIter) ([]sql.Row, error) {
+	defer iter.Close()
+
+	var rows []sql.Row
+	for {
+		row, err := iter.Next()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		rows = append(rows, row)
+	}
+}
+
+// trimmedRows drops the first n values of the rows, their sort keys.
+type trimmedRows struct {
+	rows sql.RowIter
+	n    int
+}
+
+func (t *trimmedRows) Next() (sql.Row, error) {
+	row, err := t.rows.Next()
+	if err != nil {
+		return nil, err
+	}
+	return row[t.n:], nil
+}
+
+func (t *trimmedRows) Close() error {
+	return t.rows.Close()
+}
+
+// pagedRows skips the first offset rows and ends after limit rows, or with a
+// negative limit after the last one.
+type pagedRows struct {
+	rows          sql.RowIter
+	offset, limit int64
+}
+
+func (p *pagedRows) Next() (sql.Row, error) {
+	for ; p.offset > 0; p.offset-- {
+		if _, err := p.rows.Next(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.limit == 0 {
+		return nil, io.EOF
+	}
+
+	row, err := p.rows.Next()
+	if err == nil && p.limit > 0 {
+		p.limit--
+	}
+
+	return row, err
+}
+
+func (p *pagedRows) Close() error {
+	return p.rows.Close()
+}
+
+// sortStable sorts the rows in memory.
+func sortStable(rows []sql.Row, keys []ast.SortKey) ([]sql.Row, error) {
+	var err error
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		c, cerr := compareSortKeys(rows[i], rows[j], keys)
+		if cerr != nil && err == nil {
+			err = cerr
+		}
+		return c < 0
+	})
+
+	return rows, err
+}
+
+// topRows returns the first limit rows in order, keeping the ones sorting
+// first in a heap with the one sorting last on top.
+func topRows(rows sql.RowIter, keys []ast.SortKey, limit int) (sql.RowIter, error) {
+	defer rows.Close()
+
+	if limit == 0 {
+		return sql.NewSliceRowsIter(), nil
+	}
+
+	h := &rowHeap{keys: keys, max: true}
+
+	for i := 0; ; i++ {
+		row, err := rows.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		item := sortedRow{row: row, seq: i}
+
+		switch {
+		case h.Len() < limit:
+			heap.Push(h, item)
+		case h.compare(item, h.rows[0]) < 0:
+			h.rows[0] = item
+			heap.Fix(h, 0)
+		}
+	}
+
+	if h.err != nil {
+		return nil, h.err
+	}
+
+	sorted := make([]sql.Row, h.Len())
+	for i := len(sorted) - 1; i >= 0; i-- {
+		sorted[i] = heap.Pop(h).(sortedRow).row
+	}
+
+	return sql.NewSliceRowsIter(sorted...), nil
+}
+
+// externalSort reads the rows until they take up more than the memory,
+// sorts them into a run written to a temporary file and goes on with the
+// next rows. Rows fitting in the memory are sorted there, otherwise the
+// returned iterator merges the runs as it reads them.
+func externalSort(rows sql.RowIter, keys []ast.SortKey, memory int) (sql.RowIter, error) {
+	defer rows.Close()
+
+	var (
+		buffer []sql.Row
+		size   int
+		runs   []*os.File
+	)
+
+	spill := func() error {
+		run, err := writeRun(buffer, keys)
+		if run != nil {
+			runs = append(runs, run)
+		}
+		buffer, size = nil, 0
+		return err
+	}
+
+	for {
+		row, err := rows.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			removeRuns(runs)
+			return nil, err
+		}
+
+		buffer = append(buffer, row)
+		if size += rowSize(row); size <= memory {
+			continue
+		}
+
+		if err := spill(); err != nil {
+			removeRuns(runs)
+			return nil, err
+		}
+	}
+
+	if len(runs) == 0 {
+		sorted, err := sortStable(buffer, keys)
+		if err != nil {
+			return nil, err
+		}
+		return sql.NewSliceRowsIter(sorted...), nil
+	}
+
+	if len(buffer) > 0 {
+		if err := spill(); err != nil {
+			removeRuns(runs)
+			return nil, err
+		}
+	}
+
+	return mergeRuns(runs, keys)
+}
+
+// writeRun sorts the rows and writes them to a temporary file, which it
+// rewinds for reading them back. Every row is prefixed with the length of
+// its record.
+func writeRun(rows []sql.Row, keys []ast.SortKey) (*os.File, error) {
+	rows, err := sortStable(rows, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.CreateTemp("", "minidb-sort-*")
+	if err != nil {
+		return nil, err
+	}
+
+	w := bufio.NewWriter(f)
+	for _, row := range rows {
+		record, err := storage.EncodeRow(row)
+		if err != nil {
+			return f, err
+		}
+
+		if _, err := w.Write(binary.AppendUvarint(nil, uint64(len(record)))); err != nil {
+			return f, err
+		}
+		if _, err := w.Write(record); err != nil {
+			return f, err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return f, err
+	}
+
+	_, err = f.Seek(0, io.SeekStart)
+	return f, err
+}
+
+// removeRuns closes and removes the temporary files of the runs.
+func removeRuns(runs []*os.File) {
+	for _, run := range runs {
+		run.Close()
+		os.Remove(run.Name())
+	}
+}
+
+// mergeIter merges sorted runs, taking the next row from a heap of the
+// current rows of the runs. Equal rows are taken from the earlier run first.
+// Closing it removes the runs.
+type mergeIter struct {
+	runs    []*os.File
+	readers []*bufio.Reader
+	heap    *rowHeap
+}
+
+// mergeRuns returns an iterator merging the runs, which it removes when it
+// fails.
+func mergeRuns(runs []*os.File, keys []ast.SortKey) (*mergeIter, error) {
+	m := &mergeIter{runs: runs, readers: make([]*bufio.Reader, len(runs)), heap: &rowHeap{keys: keys}}
+
+	for i, run := range runs {
+		m.readers[i] = bufio.NewReader(run)
+
+		row, err := readRun(m.readers[i])
+		if err == io.EOF {
+			continue
+		}
+		if err != nil {
+			m.Close()
+			return nil, err
+		}
+
+		heap.Push(m.heap, sortedRow{row: row, seq: i})
+	}
+
+	if m.heap.err != nil {
+		m.Close()
+		return nil, m.heap.err
+	}
+
+	return m, nil
+}
+
+func (m *mergeIter) Next() (sql.Row, error) {
+	h := m.heap
+	if h.Len() == 0 {
+		return nil, io.EOF
+	}
+
+	item := h.rows[0]
+
+	row, err := readRun(m.readers[item.seq])
+	switch {
+	case err == io.EOF:
+		heap.Pop(h)
+	case err != nil:
+		return nil, err
+	default:
+		h.rows[0] = sortedRow{row: row, seq: item.seq}
+		heap.Fix(h, 0)
+	}
+
+	if h.err != nil {
+		return nil, h.err
+	}
+
+	return item.row, nil
+}
+
+func (m *mergeIter) Close() error {
+	removeRuns(m.runs)
+	m.runs, m.readers, m.heap = nil, nil, &rowHeap{}
+	return nil
+}
+
+// readRun reads the next row of a run, io.EOF after the last one.
+func readRun(r *bufio.Reader) (sql.Row, error) {
+	length, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+
+	record := make([]byte, length)
+	if _, err := io.ReadFull(r, record); err != nil {
+		return nil, err
+	}
+
+	return storage.DecodeRow(record)
+}
+
+// rowSize estimates the memory the row takes up, counting the headers of
+// the row and its values besides the bytes of text.
+func rowSize(row sql.Row) int {
+	size := 24
+	for _, value := range row {
+		size += 16
+		if s, ok := value.Raw().(string); ok {
+			size += len(s)
+		}
+	}
+	return size
+}
+
+// sortedRow is a row with its sequence number, which orders equal rows.
+type sortedRow struct {
+	row sql.Row
+	seq int
+}
+
+// rowHeap is a heap of rows with the row sorting first, or with max the one
+// sorting last, on top. It keeps the first error comparing rows.
+type rowHeap struct {
+	rows []sortedRow
+	keys []ast.SortKey
+	max  bool
+	err  error
+}
+
+func (h *rowHeap) compare(a, b sortedRow) int {
+	c, err := compareSortKeys(a.row, b.row, h.keys)
+	if err != nil && h.err == nil {
+		h.err = err
+	}
+
+	if c == 0 {
+		c = a.seq - b.seq
+	}
+
+	return c
+}
+
+func (h *rowHeap) Len() int { return len(h.rows) }
+
+func (h *rowHeap) Less(i, j int) bool {
+	if h.max {
+		return h.compare(h.rows[i], h.rows[j]) > 0
+	}
+	return h.compare(h.rows[i], h.rows[j]) < 0
+}
+
+func (h *rowHeap) Swap(i, j int) { h.rows[i], h.rows[j] = h.rows[j], h.rows[i] }
+
+func (h *rowHeap) Push(x any) { h.rows = append(h.rows, x.(sortedRow)) }
+
+func (h *rowHeap) Pop() any {
+	last := h.rows[len(h.rows)-1]
+	h.rows = h.rows[:len(h.rows)-1]
+	return last
+}
@@ -0,0 +1,133 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/okazaki-kk/miniDB/internal/parser/ast"
+	"github.com/okazaki-kk/miniDB/internal/sql"
+	"github.com/okazaki-kk/miniDB/internal/sql/datatype"
+)
+
+// subquery runs the subquery for the current row of the scope.
+func (x *executor) subquery(stmt *ast.SelectStatement, outer *scope) (source, error) {
+	if result, ok := x.results[stmt]; ok {
+		return result, nil
+	}
+
+	s := &scope{outer: outer}
+
+	result, err := x.query(stmt, s)
+	if err != nil {
+		return source{}, err
+	}
+
+	// A subquery which read no column of an outer query has the same result
+	// for every row of the outer query.
+	if !s.correlated {
+		x.results[stmt] = result
+	}
+
+	return result, nil
+}
+
+// runSubquery runs the subquery with the executor of the scope.
+func runSubquery(stmt *ast.SelectStatement, s *scope) (source, error) {
+	if s == nil || s.executor == nil {
+		return source{}, fmt.Errorf("cannot use subquery in this context")
+	}
+
+	return s.executor.subquery(stmt, s)
+}
+
+// evalSubquery returns the value of the single column of the single row of
+// the subquery, or NULL when it has no rows.
+func evalSubquery(expr *ast.SubqueryExpr, s *scope) (sql.Value, error) {
+	result, err := runSubquery(expr.Select, s)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result.columns) != 1 {
+		return nil, fmt.Errorf("subquery must return only one column")
+	}
+
+	switch len(result.rows) {
+	case 0:
+		return datatype.NewNull(), nil
+	case 1:
+		return result.rows[0][0], nil
+	default:
+		return nil, fmt.Errorf("more than one row returned by a subquery used as an expression")
+	}
+}
+
+func evalExists(expr *ast.ExistsExpr, s *scope) (sql.Value, error) {
+	result, err := runSubquery(expr.Select, s)
+	if err != nil {
+		return nil, err
+	}
+
+	return datatype.NewBoolean(len(result.rows) > 0), nil
+}
+
+// evalIn compares the operand with the values of the subquery or the list.
+// Without an equal value the result is NULL when the operand or one of the
+// values is NULL, otherwise false.
+func evalIn(expr *ast.InExpr, s *scope) (sql.Value, error) {
+	value, err := eval(expr.Expr, s)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []sql.Value
+
+	if expr.Select != nil {
+		result, err := runSubquery(expr.Select, s)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(result.columns) != 1 {
+			return nil, fmt.Errorf("subquery has too many columns")
+		}
+
+		for _, row := range result.rows {
+			values = append(values, row[0])
+		}
+	} else {
+		for _, e := range expr.List {
+			v, err := eval(e, s)
+			if err != nil {
+				return nil, err
+			}
+
+			values = append(values, v)
+		}
+	}
+
+	found, unknown := false, false
+	for _, v := range values {
+		if sql.IsNull(value) || sql.IsNull(v) {
+			unknown = true
+			continue
+		}
+
+		c, err := compareValues(value, v)
+		if err != nil {
+			return nil, err
+		}
+		if c == 0 {
+			found = true
+			break
+		}
+	}
+
+	switch {
+	case found:
+		return datatype.NewBoolean(!expr.Not), nil
+	case unknown:
+		return datatype.NewNull(), nil
+	default:
+		return datatype.NewBoolean(expr.Not), nil
+	}
+}
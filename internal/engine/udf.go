@@ -0,0 +1,273 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/okazaki-kk/miniDB/internal/parser/ast"
+	"github.com/okazaki-kk/miniDB/internal/parser/token"
+	"github.com/okazaki-kk/miniDB/internal/sql"
+)
+
+// registry holds the functions registered with an engine, which may be
+// registered while it runs queries. They are looked up after the builtin
+// functions, which all engines share. A nil registry has no functions.
+type registry struct {
+	mu         sync.RWMutex
+	functions  map[string]*function
+	aggregates map[string]*aggregateFunction
+}
+
+func newRegistry() *registry {
+	return &registry{
+		functions:  make(map[string]*function),
+		aggregates: make(map[string]*aggregateFunction),
+	}
+}
+
+// lookupFunction returns the scalar function of the name.
+func (r *registry) lookupFunction(name string) (*function, bool) {
+	name = strings.ToLower(name)
+	if fn, ok := functions[name]; ok || r == nil {
+		return fn, ok
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	fn, ok := r.functions[name]
+	return fn, ok
+}
+
+// lookupAggregate returns the aggregate function of the name.
+func (r *registry) lookupAggregate(name string) (*aggregateFunction, bool) {
+	name = strings.ToLower(name)
+	if agg, ok := aggregates[name]; ok || r == nil {
+		return agg, ok
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	agg, ok := r.aggregates[name]
+	return agg, ok
+}
+
+func (r *registry) isAggregate(expr *ast.CallExpr) bool {
+	_, ok := r.lookupAggregate(expr.Name)
+	return ok
+}
+
+// ScalarFunction is a scalar function implemented in Go.
+type ScalarFunction struct {
+	Name string
+	// Signature declares the types of the arguments and the result.
+	Signature
+	// Volatile functions may return another result for the same arguments,
+	// like random().
+	Volatile bool
+	// Strict functions return NULL for a NULL argument without being called.
+	Strict bool
+	// Call is called with the arguments converted to the declared types.
+	Call func(args []sql.Value) (sql.Value, error)
+}
+
+// AggregateFunction is an aggregate function implemented in Go. Its state
+// accumulates the arguments of the rows of a group, skipping rows with a
+// NULL argument.
+type AggregateFunction struct {
+	Name string
+	// Signature declares the types of the arguments and the result.
+	Signature
+	// Init returns the state of a group without rows.
+	Init func() any
+	// Step adds the arguments of a row, converted to the declared types, to
+	// the state and returns the new state.
+	Step func(state any, args []sql.Value) (any, error)
+	// Merge adds the rows of state b, which follow the rows of state a, to a
+	// and returns the new state. It must not modify b.
+	Merge func(a, b any) (any, error)
+	// Final returns the aggregate of the state. It must not modify the state,
+	// which window frames go on adding rows to.
+	Final func(state any) (sql.Value, error)
+}
+
+// RegisterFunction makes the scalar function callable by its name from the
+// SQL the engine runs.
+func (e *Engine) RegisterFunction(fn ScalarFunction) error {
+	name, err := checkRegistration(fn.Name, fn.Signature)
+	if err != nil {
+		return err
+	}
+
+	if fn.Call == nil {
+		return fmt.Errorf("function %s has no implementation", name)
+	}
+
+	signature := fn.Signature
+	call := func(args []sql.Value) (sql.Value, error) {
+		result, err := fn.Call(args)
+		if err != nil {
+			return nil, err
+		}
+		return checkResult(name, signature, result)
+	}
+
+	e.registry.mu.Lock()
+	defer e.registry.mu.Unlock()
+
+	if err := e.registry.checkUnique(name); err != nil {
+		return err
+	}
+
+	e.registry.functions[name] = &function{
+		signatures: []Signature{signature},
+		strict:     fn.Strict,
+		volatile:   fn.Volatile,
+		user:       true,
+		call:       call,
+	}
+
+	return nil
+}
+
+// RegisterAggregate makes the aggregate function callable by its name from
+// the SQL the engine runs.
+func (e *Engine) RegisterAggregate(agg AggregateFunction) error {
+	name, err := checkRegistration(agg.Name, agg.Signature)
+	if err != nil {
+		return err
+	}
+
+	if agg.Init == nil || agg.Step == nil || agg.Merge == nil || agg.Final == nil {
+		return fmt.Errorf("aggregate %s needs Init, Step, Merge and Final", name)
+	}
+
+	e.registry.mu.Lock()
+	defer e.registry.mu.Unlock()
+
+	if err := e.registry.checkUnique(name); err != nil {
+		return err
+	}
+
+	e.registry.aggregates[name] = &aggregateFunction{
+		signatures: []Signature{agg.Signature},
+		user:       true,
+		new: func() accumulator {
+			return &userAccumulator{name: name, agg: agg, state: agg.Init()}
+		},
+	}
+
+	return nil
+}
+
+// checkRegistration verifies the name and the signature of a function to
+// register and returns the name in lower case.
+func checkRegistration(name string, signature Signature) (string, error) {
+	name = strings.ToLower(name)
+
+	if name == "" {
+		return "", fmt.Errorf("function name is empty")
+	}
+
+	for i, r := range name {
+		if !(r == '_' || r >= 'a' && r <= 'z' || i > 0 && r >= '0' && r <= '9') {
+			return "", fmt.Errorf("invalid function name %q", name)
+		}
+	}
+
+	if token.LookupIdent(name) != token.IDENT {
+		return "", fmt.Errorf("function name %q is a keyword", name)
+	}
+
+	if signature.Variadic && len(signature.Args) == 0 {
+		return "", fmt.Errorf("variadic function %s has no arguments", name)
+	}
+
+	return name, nil
+}
+
+// checkUnique fails when a function of the name exists. The registry must be
+// locked.
+func (r *registry) checkUnique(name string) error {
+	_, isFunc := functions[name]
+	_, isAgg := aggregates[name]
+	_, isWindow := windowFunctions[name]
+	_, isUserFunc := r.functions[name]
+	_, isUserAgg := r.aggregates[name]
+
+	if isFunc || isAgg || isWindow || isUserFunc || isUserAgg {
+		return fmt.Errorf("function %q already exists", name)
+	}
+
+	return nil
+}
+
+// checkResult fails when a user-defined function returns a value of another
+// type than the result of the signature.
+func checkResult(name string, signature Signature, result sql.Value) (sql.Value, error) {
+	if result == nil {
+		return nil, fmt.Errorf("function %s returned no value", name)
+	}
+
+	if !sql.IsNull(result) && signature.Result != sql.Null && result.DataType() != signature.Result {
+		return nil, fmt.Errorf("function %s returned %s instead of %s", name, result.DataType(), signature.Result)
+	}
+
+	return result, nil
+}
+
+// userAccumulator computes an aggregate registered with RegisterAggregate.
+type userAccumulator struct {
+	name  string
+	agg   AggregateFunction
+	state any
+}
+
+func (a *userAccumulator) add(args []sql.Value) error {
+	types := make([]sql.DataType, len(args))
+	for i, value := range args {
+		if sql.IsNull(value) {
+			return nil
+		}
+		types[i] = value.DataType()
+	}
+
+	signature, ok := resolve([]Signature{a.agg.Signature}, types)
+	if !ok {
+		return noFunction(a.name, types)
+	}
+
+	args = append([]sql.Value(nil), args...)
+	if err := convertArgs(args, signature); err != nil {
+		return err
+	}
+
+	state, err := a.agg.Step(a.state, args)
+	if err != nil {
+		return err
+	}
+	a.state = state
+
+	return nil
+}
+
+func (a *userAccumulator) merge(other accumulator) error {
+	state, err := a.agg.Merge(a.state, other.(*userAccumulator).state)
+	if err != nil {
+		return err
+	}
+	a.state = state
+
+	return nil
+}
+
+func (a *userAccumulator) result() (sql.Value, error) {
+	result, err := a.agg.Final(a.state)
+	if err != nil {
+		return nil, err
+	}
+
+	return checkResult(a.name, a.agg.Signature, result)
+}
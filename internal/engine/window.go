@@ -0,0 +1,779 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/okazaki-kk/miniDB/internal/parser/ast"
+	"github.com/okazaki-kk/miniDB/internal/parser/token"
+	"github.com/okazaki-kk/miniDB/internal/sql"
+	"github.com/okazaki-kk/miniDB/internal/sql/datatype"
+)
+
+// windowFunction computes the value of a window function for the row at the
+// position of the partition.
+type windowFunction func(w *window, position int) (sql.Value, error)
+
+// windowFunctions are the built-in window functions with the number of
+// arguments they take at least and at most.
+var windowFunctions = map[string]struct{ min, max int }{
+	"row_number":  {},
+	"rank":        {},
+	"dense_rank":  {},
+	"ntile":       {min: 1, max: 1},
+	"lag":         {min: 1, max: 3},
+	"lead":        {min: 1, max: 3},
+	"first_value": {min: 1, max: 1},
+	"last_value":  {min: 1, max: 1},
+}
+
+func isWindowFunction(expr *ast.CallExpr) bool {
+	_, ok := windowFunctions[strings.ToLower(expr.Name)]
+	return ok
+}
+
+// collectWindows appends the window function calls of the expression. Window
+// functions can't be nested.
+func collectWindows(expr ast.Expression, windows []*ast.WindowExpr) ([]*ast.WindowExpr, error) {
+	var err error
+
+	switch expr := expr.(type) {
+	case *ast.UnaryExpr:
+		return collectWindows(expr.Operand, windows)
+	case *ast.ConditionExpr:
+		if windows, err = collectWindows(expr.Left, windows); err != nil {
+			return nil, err
+		}
+		return collectWindows(expr.Right, windows)
+	case *ast.IsNullExpr:
+		return collectWindows(expr.Expr, windows)
+	case *ast.IsDistinctExpr:
+		if windows, err = collectWindows(expr.Left, windows); err != nil {
+			return nil, err
+		}
+		return collectWindows(expr.Right, windows)
+	case *ast.InExpr, *ast.BetweenExpr, *ast.LikeExpr, *ast.CaseExpr, *ast.CastExpr, *ast.AtTimeZoneExpr:
+		for _, e := range operands(expr) {
+			if windows, err = collectWindows(e, windows); err != nil {
+				return nil, err
+			}
+		}
+	case *ast.CallExpr:
+		for _, arg := range expr.Args {
+			if windows, err = collectWindows(arg, windows); err != nil {
+				return nil, err
+			}
+		}
+	case *ast.WindowExpr:
+		for _, e := range windowExprs(expr) {
+			nested, err := collectWindows(e, nil)
+			if err != nil {
+				return nil, err
+			}
+			if len(nested) > 0 {
+				return nil, fmt.Errorf("window function calls cannot be nested")
+			}
+		}
+
+		return append(windows, expr), nil
+	}
+
+	return windows, nil
+}
+
+// windowExprs returns the arguments, the PARTITION BY and the ORDER BY
+// expressions of the window function call.
+func windowExprs(expr *ast.WindowExpr) []ast.Expression {
+	exprs := make([]ast.Expression, 0, len(expr.Call.Args)+len(expr.PartitionBy)+len(expr.OrderBy))
+	exprs = append(exprs, expr.Call.Args...)
+	exprs = append(exprs, expr.PartitionBy...)
+	for _, key := range expr.OrderBy {
+		exprs = append(exprs, key.Expr)
+	}
+
+	return exprs
+}
+
+// noWindows fails when the expression of the clause has a window function.
+func noWindows(clause string, expr ast.Expression) error {
+	windows, err := collectWindows(expr, nil)
+	if err != nil {
+		return err
+	}
+
+	if len(windows) > 0 {
+		return fmt.Errorf("window functions are not allowed in %s", clause)
+	}
+
+	return nil
+}
+
+// window is a partition of the rows of a window function call, sorted by the
+// ORDER BY keys of the window.
+type window struct {
+	expr *ast.WindowExpr
+	s    *scope
+	rows []sql.Row
+	// keys are the values of the ORDER BY keys of the rows.
+	keys []sql.Row
+	// peers are the positions of the first and after the last peer of every
+	// row, the rows with equal keys.
+	peers [][2]int
+	// groups are the numbers of the peer groups of the rows, from 1.
+	groups []int
+}
+
+// value evaluates the expression for the row at the position.
+func (w *window) value(expr ast.Expression, position int) (sql.Value, error) {
+	w.s.row = w.rows[position]
+	return eval(expr, w.s)
+}
+
+// windows computes the window function calls for every row, sorting the rows
+// by their PARTITION BY and ORDER BY keys, and appends their values to the
+// rows in the order of the calls. The calls of the scope are bound to the
+// values.
+func windows(rows []sql.Row, s *scope, calls []*ast.WindowExpr) ([]sql.Row, error) {
+	values := make([][]sql.Value, len(calls))
+	for i, call := range calls {
+		v, err := computeWindow(rows, s, call)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+
+	extended := make([]sql.Row, len(rows))
+	for i, row := range rows {
+		extended[i] = make(sql.Row, 0, len(row)+len(calls))
+		extended[i] = append(extended[i], row...)
+
+		for j := range calls {
+			extended[i] = append(extended[i], values[j][i])
+		}
+	}
+
+	base := len(s.columns) + len(s.aggregates)
+
+	s.windows = make(map[*ast.WindowExpr]int, len(calls))
+	for i, call := range calls {
+		s.windows[call] = base + i
+	}
+
+	return extended, nil
+}
+
+// computeWindow returns the values of the window function call for the rows.
+func computeWindow(rows []sql.Row, s *scope, expr *ast.WindowExpr) ([]sql.Value, error) {
+	fn, err := newWindowFunction(expr, s.functions())
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]ast.SortKey, 0, len(expr.PartitionBy)+len(expr.OrderBy))
+	for _, e := range expr.PartitionBy {
+		keys = append(keys, ast.SortKey{Expr: e, Direction: token.ASC})
+	}
+	keys = append(keys, expr.OrderBy...)
+
+	sortKeys := make([]sql.Row, len(rows))
+	for i, row := range rows {
+		s.row = row
+
+		sortKeys[i] = make(sql.Row, len(keys))
+		for j, key := range keys {
+			if sortKeys[i][j], err = eval(key.Expr, s); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	indexes := make([]int, len(rows))
+	for i := range indexes {
+		indexes[i] = i
+	}
+
+	sort.SliceStable(indexes, func(i, j int) bool {
+		c, cerr := compareSortKeys(sortKeys[indexes[i]], sortKeys[indexes[j]], keys)
+		if cerr != nil && err == nil {
+			err = cerr
+		}
+		return c < 0
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(expr.PartitionBy)
+	values := make([]sql.Value, len(rows))
+
+	for start := 0; start < len(indexes); {
+		end := start + 1
+		for end < len(indexes) && sortKeys[indexes[end]][:n].Equal(sortKeys[indexes[start]][:n]) {
+			end++
+		}
+
+		w := &window{expr: expr, s: s}
+		for _, index := range indexes[start:end] {
+			w.rows = append(w.rows, rows[index])
+			w.keys = append(w.keys, sortKeys[index][n:])
+		}
+		w.peers, w.groups = peers(w.keys)
+
+		for position, index := range indexes[start:end] {
+			if values[index], err = fn(w, position); err != nil {
+				return nil, err
+			}
+		}
+
+		start = end
+	}
+
+	return values, nil
+}
+
+// peers returns the positions of the first and after the last row with keys
+// equal to the ones of every row, and the number of the group of these rows.
+func peers(keys []sql.Row) ([][2]int, []int) {
+	peers := make([][2]int, len(keys))
+	groups := make([]int, len(keys))
+
+	for start, group := 0, 1; start < len(keys); group++ {
+		end := start + 1
+		for end < len(keys) && keys[end].Equal(keys[start]) {
+			end++
+		}
+
+		for i := start; i < end; i++ {
+			peers[i] = [2]int{start, end}
+			groups[i] = group
+		}
+
+		start = end
+	}
+
+	return peers, groups
+}
+
+// newWindowFunction returns the function computing the window function or
+// aggregate of the call.
+func newWindowFunction(expr *ast.WindowExpr, r *registry) (windowFunction, error) {
+	name := strings.ToLower(expr.Call.Name)
+
+	if expr.Call.Distinct {
+		return nil, fmt.Errorf("DISTINCT is not implemented for window functions")
+	}
+
+	if r.isAggregate(expr.Call) {
+		// Invalid calls fail even when there are no rows.
+		if _, err := r.newAccumulator(expr.Call); err != nil {
+			return nil, err
+		}
+
+		frame, err := newFrame(expr, r)
+		if err != nil {
+			return nil, err
+		}
+
+		return frame.aggregate, nil
+	}
+
+	f, ok := windowFunctions[name]
+	if !ok {
+		return nil, fmt.Errorf("OVER specified, but %s is not a window function nor an aggregate function", name)
+	}
+
+	if n := len(expr.Call.Args); n < f.min || n > f.max {
+		if f.min == f.max {
+			return nil, fmt.Errorf("function %s() takes exactly %d arguments", name, f.min)
+		}
+		return nil, fmt.Errorf("function %s() takes %d to %d arguments", name, f.min, f.max)
+	}
+
+	switch name {
+	case "row_number":
+		return rowNumber, nil
+	case "rank":
+		return rank, nil
+	case "dense_rank":
+		return denseRank, nil
+	case "ntile":
+		return ntile, nil
+	case "lag":
+		return lag, nil
+	case "lead":
+		return lead, nil
+	}
+
+	// The values of the first and last row depend on the frame.
+	frame, err := newFrame(expr, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "first_value" {
+		return frame.firstValue, nil
+	}
+	return frame.lastValue, nil
+}
+
+func rowNumber(w *window, position int) (sql.Value, error) {
+	return datatype.NewInteger(int64(position + 1)), nil
+}
+
+// rank numbers the rows by the position of their first peer, leaving gaps.
+func rank(w *window, position int) (sql.Value, error) {
+	return datatype.NewInteger(int64(w.peers[position][0] + 1)), nil
+}
+
+// denseRank numbers the rows by the number of peer groups up to theirs,
+// without gaps.
+func denseRank(w *window, position int) (sql.Value, error) {
+	return datatype.NewInteger(int64(w.groups[position])), nil
+}
+
+// ntile divides the rows into the given number of buckets of sizes which
+// differ by one at most, the larger buckets first.
+func ntile(w *window, position int) (sql.Value, error) {
+	value, err := w.value(w.expr.Call.Args[0], position)
+	if err != nil || sql.IsNull(value) {
+		return value, err
+	}
+
+	buckets, ok := value.Raw().(int64)
+	if !ok {
+		return nil, fmt.Errorf("function ntile(%s) does not exist", value.DataType())
+	}
+	if buckets <= 0 {
+		return nil, fmt.Errorf("argument of ntile must be greater than zero")
+	}
+
+	size, rest := int64(len(w.rows))/buckets, int64(len(w.rows))%buckets
+	p := int64(position)
+
+	// The first rest buckets have a row more.
+	if p < rest*(size+1) {
+		return datatype.NewInteger(p/(size+1) + 1), nil
+	}
+	return datatype.NewInteger(rest + (p-rest*(size+1))/size + 1), nil
+}
+
+func lag(w *window, position int) (sql.Value, error) {
+	return offsetValue(w, position, -1)
+}
+
+func lead(w *window, position int) (sql.Value, error) {
+	return offsetValue(w, position, 1)
+}
+
+// offsetValue evaluates the argument for the row the offset, 1 by default,
+// before or after the row in the direction. Without such a row the value is
+// the default, NULL by default.
+func offsetValue(w *window, position, direction int) (sql.Value, error) {
+	args := w.expr.Call.Args
+
+	offset := int64(1)
+	if len(args) > 1 {
+		value, err := w.value(args[1], position)
+		if err != nil {
+			return nil, err
+		}
+		if sql.IsNull(value) {
+			return value, nil
+		}
+
+		n, ok := value.Raw().(int64)
+		if !ok {
+			return nil, fmt.Errorf("function %s offset must be type integer, not type %s", strings.ToLower(w.expr.Call.Name), value.DataType())
+		}
+		offset = n
+	}
+
+	target := int64(position) + offset*int64(direction)
+	if target < 0 || target >= int64(len(w.rows)) {
+		if len(args) > 2 {
+			return w.value(args[2], position)
+		}
+		return datatype.NewNull(), nil
+	}
+
+	return w.value(args[0], int(target))
+}
+
+// frame computes functions over the frame of every row.
+type frame struct {
+	expr  *ast.WindowExpr
+	mode  token.TokenType
+	start ast.FrameBound
+	end   ast.FrameBound
+	// startOffset and endOffset are the values of the offsets of the bounds.
+	startOffset sql.Value
+	endOffset   sql.Value
+
+	// last is the aggregate of the last frame, which consecutive rows often
+	// share or extend.
+	last struct {
+		w          *window
+		start, end int
+		acc        accumulator
+		value      sql.Value
+	}
+	// segments aggregates the frames of the window whose start moves.
+	segments *segments
+	// registry has the aggregate computed over the frames.
+	registry *registry
+}
+
+// newFrame returns the frame of the window. By default the frame is the
+// partition, and with ORDER BY the rows up to the last peer of the row.
+func newFrame(expr *ast.WindowExpr, r *registry) (*frame, error) {
+	f := &frame{
+		expr:     expr,
+		mode:     token.RANGE,
+		start:    ast.FrameBound{Type: token.PRECEDING},
+		end:      ast.FrameBound{Type: token.FOLLOWING},
+		registry: r,
+	}
+
+	switch {
+	case expr.Frame != nil:
+		f.mode, f.start, f.end = expr.Frame.Mode, expr.Frame.Start, expr.Frame.End
+	case len(expr.OrderBy) > 0:
+		f.end = ast.FrameBound{Type: token.CURRENT}
+	}
+
+	var err error
+	if f.startOffset, err = f.offset("starting", f.start); err != nil {
+		return nil, err
+	}
+	if f.endOffset, err = f.offset("ending", f.end); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// offset evaluates the offset of the bound, a non-negative integer for ROWS
+// and a non-negative number for RANGE over a single numeric ORDER BY key.
+func (f *frame) offset(name string, bound ast.FrameBound) (sql.Value, error) {
+	if bound.Offset == nil {
+		return nil, nil
+	}
+
+	if f.mode == token.RANGE && len(f.expr.OrderBy) != 1 {
+		return nil, fmt.Errorf("RANGE with offset PRECEDING/FOLLOWING requires exactly one ORDER BY column")
+	}
+
+	value, err := eval(bound.Offset, &scope{registry: f.registry})
+	if err != nil {
+		return nil, err
+	}
+
+	n, ok := number(value)
+	if !ok || (f.mode == token.ROWS && value.DataType() != sql.Integer) {
+		return nil, fmt.Errorf("frame %s offset must be type %s, not type %s", name, offsetType(f.mode), value.DataType())
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("frame %s offset must not be negative", name)
+	}
+
+	return value, nil
+}
+
+func offsetType(mode token.TokenType) string {
+	if mode == token.ROWS {
+		return "integer"
+	}
+	return "numeric"
+}
+
+// bounds returns the positions of the first row and after the last row of
+// the frame of the row at the position.
+func (f *frame) bounds(w *window, position int) (int, int, error) {
+	start, err := f.bound(w, position, f.start, f.startOffset, false)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	end, err := f.bound(w, position, f.end, f.endOffset, true)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return start, end, nil
+}
+
+// bound returns the position of the bound of the frame, after the last row
+// in the frame for the end.
+func (f *frame) bound(w *window, position int, bound ast.FrameBound, offset sql.Value, end bool) (int, error) {
+	switch {
+	case bound.Type == token.CURRENT && f.mode == token.ROWS:
+		if end {
+			return position + 1, nil
+		}
+		return position, nil
+	case bound.Type == token.CURRENT:
+		if end {
+			return w.peers[position][1], nil
+		}
+		return w.peers[position][0], nil
+	case offset == nil && bound.Type == token.PRECEDING:
+		return 0, nil
+	case offset == nil:
+		return len(w.rows), nil
+	case f.mode == token.ROWS:
+		n := int(offset.Raw().(int64))
+		if bound.Type == token.PRECEDING {
+			n = -n
+		}
+		if end {
+			n++
+		}
+		return clamp(position+n, 0, len(w.rows)), nil
+	default:
+		return f.rangeBound(w, position, bound, offset, end)
+	}
+}
+
+// rangeBound returns the bound of a RANGE frame, the rows whose key differs
+// from the one of the row by the offset at most. A row with a NULL key only
+// has its peers in the frame.
+func (f *frame) rangeBound(w *window, position int, bound ast.FrameBound, offset sql.Value, end bool) (int, error) {
+	key := w.keys[position][0]
+	if sql.IsNull(key) {
+		if end {
+			return w.peers[position][1], nil
+		}
+		return w.peers[position][0], nil
+	}
+
+	if _, ok := number(key); !ok {
+		return 0, fmt.Errorf("RANGE with offset PRECEDING/FOLLOWING is not supported for column type %s", key.DataType())
+	}
+
+	// The limit is the key of the bound, which precedes the key of the row
+	// in the order of the keys.
+	var operator token.TokenType = token.PLUS
+	if (bound.Type == token.PRECEDING) != (f.expr.OrderBy[0].Direction == token.DESC) {
+		operator = token.MINUS
+	}
+
+	limit, err := arithmetic(operator, key, offset)
+	if err != nil {
+		return 0, err
+	}
+
+	desc := f.expr.OrderBy[0].Direction == token.DESC
+
+	// inside reports whether the key at the position doesn't precede the
+	// start or doesn't follow the end.
+	inside := func(i int) (bool, error) {
+		k := w.keys[i][0]
+		if sql.IsNull(k) {
+			return false, nil
+		}
+
+		c, err := k.Compare(limit)
+		if err != nil {
+			return false, err
+		}
+		if desc {
+			c = -c
+		}
+
+		if end {
+			return c <= 0, nil
+		}
+		return c >= 0, nil
+	}
+
+	if end {
+		for i := len(w.rows) - 1; i >= 0; i-- {
+			ok, err := inside(i)
+			if err != nil || ok {
+				return i + 1, err
+			}
+		}
+		return 0, nil
+	}
+
+	for i := range w.rows {
+		ok, err := inside(i)
+		if err != nil || ok {
+			return i, err
+		}
+	}
+	return len(w.rows), nil
+}
+
+func clamp(n, min, max int) int {
+	switch {
+	case n < min:
+		return min
+	case n > max:
+		return max
+	default:
+		return n
+	}
+}
+
+// aggregate computes the aggregate over the rows of the frame. A frame
+// extending the last one only adds the rows after it, other frames merge the
+// segments covering them.
+func (f *frame) aggregate(w *window, position int) (sql.Value, error) {
+	start, end, err := f.bounds(w, position)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case f.last.w == w && f.last.start == start && f.last.end <= end:
+	case f.last.w == w:
+		if f.segments == nil || f.segments.w != w {
+			if f.segments, err = newSegments(f.expr.Call, w, f.registry); err != nil {
+				return nil, err
+			}
+		}
+
+		if f.last.acc, err = f.segments.aggregate(start, end); err != nil {
+			return nil, err
+		}
+		f.last.start, f.last.end, f.last.value = start, end, nil
+	default:
+		if f.last.acc, err = f.registry.newAccumulator(f.expr.Call); err != nil {
+			return nil, err
+		}
+		f.last.w, f.last.start, f.last.end, f.last.value = w, start, start, nil
+	}
+
+	for ; f.last.end < end; f.last.end++ {
+		args, err := rowArgs(f.expr.Call, w, f.last.end)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := f.last.acc.add(args); err != nil {
+			return nil, err
+		}
+		f.last.value = nil
+	}
+
+	if f.last.value == nil {
+		if f.last.value, err = f.last.acc.result(); err != nil {
+			return nil, err
+		}
+	}
+
+	return f.last.value, nil
+}
+
+// rowArgs returns the arguments of the aggregate call for the row at the
+// position.
+func rowArgs(call *ast.CallExpr, w *window, position int) ([]sql.Value, error) {
+	return aggregateArgs(call, func(expr ast.Expression) (sql.Value, error) {
+		return w.value(expr, position)
+	})
+}
+
+// segments is a segment tree over the rows of a window. Its nodes[n+i] is
+// the accumulator of the row at position i, and nodes[i] merges nodes[2i]
+// and nodes[2i+1].
+type segments struct {
+	w        *window
+	call     *ast.CallExpr
+	nodes    []accumulator
+	registry *registry
+}
+
+func newSegments(call *ast.CallExpr, w *window, r *registry) (*segments, error) {
+	n := len(w.rows)
+	t := &segments{w: w, call: call, nodes: make([]accumulator, 2*n), registry: r}
+
+	for i := 0; i < n; i++ {
+		args, err := rowArgs(call, w, i)
+		if err != nil {
+			return nil, err
+		}
+
+		a, err := r.newAccumulator(call)
+		if err != nil {
+			return nil, err
+		}
+		if err := a.add(args); err != nil {
+			return nil, err
+		}
+
+		t.nodes[n+i] = a
+	}
+
+	for i := n - 1; i > 0; i-- {
+		a, err := t.merge(t.nodes[2*i], t.nodes[2*i+1])
+		if err != nil {
+			return nil, err
+		}
+		t.nodes[i] = a
+	}
+
+	return t, nil
+}
+
+// aggregate returns a new accumulator of the rows from start to before end,
+// merging the nodes covering them in the order of the rows.
+func (t *segments) aggregate(start, end int) (accumulator, error) {
+	var left, right []accumulator
+
+	n := len(t.w.rows)
+	for l, r := start+n, end+n; l < r; l, r = l/2, r/2 {
+		if l%2 == 1 {
+			left = append(left, t.nodes[l])
+			l++
+		}
+		if r%2 == 1 {
+			r--
+			right = append(right, t.nodes[r])
+		}
+	}
+
+	for i := len(right) - 1; i >= 0; i-- {
+		left = append(left, right[i])
+	}
+
+	return t.merge(left...)
+}
+
+// merge returns a new accumulator merging the accumulators.
+func (t *segments) merge(accumulators ...accumulator) (accumulator, error) {
+	a, err := t.registry.newAccumulator(t.call)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, other := range accumulators {
+		if err := a.merge(other); err != nil {
+			return nil, err
+		}
+	}
+
+	return a, nil
+}
+
+// firstValue evaluates the argument for the first row of the frame.
+func (f *frame) firstValue(w *window, position int) (sql.Value, error) {
+	start, end, err := f.bounds(w, position)
+	if err != nil || start >= end {
+		return datatype.NewNull(), err
+	}
+
+	return w.value(f.expr.Call.Args[0], start)
+}
+
+// lastValue evaluates the argument for the last row of the frame.
+func (f *frame) lastValue(w *window, position int) (sql.Value, error) {
+	start, end, err := f.bounds(w, position)
+	if err != nil || start >= end {
+		return datatype.NewNull(), err
+	}
+
+	return w.value(f.expr.Call.Args[0], end-1)
+}
@@ -0,0 +1,313 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/okazaki-kk/miniDB/internal/sql"
+	"github.com/okazaki-kk/miniDB/internal/sql/datatype"
+	"github.com/okazaki-kk/miniDB/storage"
+)
+
+// rowChange is the change of a stored row, which may move it to a new key.
+type rowChange struct {
+	key, newKey int64
+	old, row    sql.Row
+}
+
+// writer changes the rows of the tables on behalf of a statement. It enforces
+// the constraints of the tables, applies the referential actions of foreign
+// keys and undoes all changes of the statement when one of them fails.
+type writer struct {
+	db       storage.Database
+	registry *registry
+	// undo logs the changes of the statement, undone in reverse order.
+	undo []undoEntry
+	// stored has the keys of the rows the statement stored per table, whose
+	// unique keys are verified on commit.
+	stored map[*storage.Table]map[int64]bool
+}
+
+// undoEntry is a change of a row. The row was inserted when old is nil,
+// otherwise it was updated or, when deleted is set, deleted at the position.
+type undoEntry struct {
+	table    *storage.Table
+	key      int64
+	old      sql.Row
+	deleted  bool
+	position int
+}
+
+func newWriter(db storage.Database, r *registry) *writer {
+	return &writer{db: db, registry: r, stored: make(map[*storage.Table]map[int64]bool)}
+}
+
+// commit verifies the unique constraints on the stored rows. When they are
+// violated the changes are rolled back.
+func (w *writer) commit() error {
+	for table, keys := range w.stored {
+		if err := relationOf(table, w.registry).checkStored(table, keys); err != nil {
+			w.rollback()
+			return err
+		}
+	}
+
+	w.undo = nil
+	w.stored = make(map[*storage.Table]map[int64]bool)
+
+	return nil
+}
+
+// rollback undoes the changes in reverse order, which restores the rows at
+// the keys and positions they had.
+func (w *writer) rollback() {
+	for i := len(w.undo) - 1; i >= 0; i-- {
+		u := w.undo[i]
+
+		var err error
+		switch {
+		case u.old == nil:
+			err = u.table.Delete(u.key)
+		case u.deleted:
+			err = u.table.InsertAt(u.position, u.key, u.old)
+		default:
+			err = u.table.Update(u.key, u.old)
+		}
+
+		// The rows were stored before, undoing the changes can't fail.
+		if err != nil {
+			panic(fmt.Sprintf("rollback table %s, key %d: %v", u.table.Name(), u.key, err))
+		}
+	}
+
+	w.undo = nil
+	w.stored = make(map[*storage.Table]map[int64]bool)
+}
+
+// store remembers that the statement stored a row with the key.
+func (w *writer) store(table *storage.Table, key int64) {
+	keys, ok := w.stored[table]
+	if !ok {
+		keys = make(map[int64]bool)
+		w.stored[table] = keys
+	}
+
+	keys[key] = true
+}
+
+func (w *writer) insertRow(table *storage.Table, key int64, row sql.Row) error {
+	if err := w.put(table, key, row); err != nil {
+		return err
+	}
+
+	return relationOf(table, w.registry).checkRow(row, w.referenced)
+}
+
+// updateRows applies the changes to the table, updating or deleting the rows
+// referencing the changed keys.
+func (w *writer) updateRows(table *storage.Table, changes []rowChange) error {
+	// Moved rows are removed first, so that rows can swap their keys.
+	for _, c := range changes {
+		if c.key != c.newKey {
+			if err := w.remove(table, c.key); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, c := range changes {
+		var err error
+		if c.key != c.newKey {
+			err = w.put(table, c.newKey, c.row)
+		} else {
+			err = w.replace(table, c.key, c.row)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	r := relationOf(table, w.registry)
+
+	for _, c := range changes {
+		if err := r.checkRow(c.row, w.referenced); err != nil {
+			return err
+		}
+
+		if err := w.cascade(table, c.old, c.row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteRow deletes the row, unless a cascading delete did already.
+func (w *writer) deleteRow(table *storage.Table, key int64) error {
+	row, ok := table.Get(key)
+	if !ok {
+		return nil
+	}
+
+	if err := w.remove(table, key); err != nil {
+		return err
+	}
+
+	return w.cascade(table, row, nil)
+}
+
+// cascade applies the referential actions of the foreign keys referencing
+// the table to the rows referencing the old row. The row was deleted when
+// updated is nil.
+func (w *writer) cascade(table *storage.Table, old, updated sql.Row) error {
+	parent := relationOf(table, w.registry)
+
+	for _, child := range w.db.ListTables() {
+		for _, c := range child.Constraints() {
+			if c.Type != storage.ForeignKey || c.Reference.Table != parent.name {
+				continue
+			}
+
+			if err := w.cascadeConstraint(parent, child, c, old, updated); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (w *writer) cascadeConstraint(parent relation, child *storage.Table, c storage.Constraint, old, updated sql.Row) error {
+	tuple, ok, err := parent.tuple(old, c.Reference.Columns)
+	if err != nil || !ok {
+		return err
+	}
+
+	action := c.Reference.OnDelete
+
+	if updated != nil {
+		newTuple, _, err := parent.tuple(updated, c.Reference.Columns)
+		if err != nil {
+			return err
+		}
+		if newTuple == tuple {
+			return nil
+		}
+
+		action = c.Reference.OnUpdate
+	}
+
+	values, _, err := parent.values(old, c.Reference.Columns)
+	if err != nil {
+		return err
+	}
+
+	r := relationOf(child, w.registry)
+
+	keys, rows, err := child.LookupKey(c.Columns, values)
+	if err != nil {
+		return err
+	}
+
+	var changes []rowChange
+
+	for i, row := range rows {
+		switch {
+		case action == storage.Restrict:
+			return referencedViolation(parent.name, c.Name, r.name)
+		case action == storage.Cascade && updated == nil:
+			if err := w.deleteRow(child, keys[i]); err != nil {
+				return err
+			}
+			continue
+		}
+
+		changed := make(sql.Row, len(row))
+		copy(changed, row)
+
+		for j, name := range c.Columns {
+			ci, err := columnIndex(r.name, r.columns, name)
+			if err != nil {
+				return err
+			}
+
+			changed[ci] = datatype.NewNull()
+
+			if action == storage.Cascade {
+				pi, err := columnIndex(parent.name, parent.columns, c.Reference.Columns[j])
+				if err != nil {
+					return err
+				}
+
+				changed[ci] = updated[pi]
+			}
+		}
+
+		newKey := keys[i]
+		if pk, ok := primaryKeyIndex(r.columns); ok && r.columns[pk].DataType == sql.Integer && !sql.IsNull(changed[pk]) {
+			newKey = changed[pk].Raw().(int64)
+		}
+
+		changes = append(changes, rowChange{key: keys[i], newKey: newKey, old: row, row: changed})
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	return w.updateRows(child, changes)
+}
+
+// referenced reports whether a row of the table has the values in the
+// columns, which are a key of the table.
+func (w *writer) referenced(name string, columns []string, values []sql.Value) (bool, error) {
+	table, err := w.db.GetTable(name)
+	if err != nil {
+		return false, err
+	}
+
+	keys, _, err := table.LookupKey(columns, values)
+
+	return len(keys) > 0, err
+}
+
+func (w *writer) put(table *storage.Table, key int64, row sql.Row) error {
+	if err := table.Insert(key, row); err != nil {
+		if errors.Is(err, storage.ErrDuplicateKey) {
+			return uniqueViolation(table.Name(), table.Name()+"_pkey")
+		}
+		return err
+	}
+
+	w.undo = append(w.undo, undoEntry{table: table, key: key})
+	w.store(table, key)
+
+	return nil
+}
+
+func (w *writer) replace(table *storage.Table, key int64, row sql.Row) error {
+	old, ok := table.Get(key)
+	if !ok {
+		return fmt.Errorf("update %s: key %d not found", table.Name(), key)
+	}
+
+	if err := table.Update(key, row); err != nil {
+		return err
+	}
+
+	w.undo = append(w.undo, undoEntry{table: table, key: key, old: old})
+	w.store(table, key)
+
+	return nil
+}
+
+func (w *writer) remove(table *storage.Table, key int64) error {
+	position, old, err := table.Remove(key)
+	if err != nil {
+		return fmt.Errorf("delete from %s: %w", table.Name(), err)
+	}
+
+	w.undo = append(w.undo, undoEntry{table: table, key: key, old: old, deleted: true, position: position})
+
+	return nil
+}
@@ -0,0 +1,433 @@
+// Package format renders query results in the output formats supported by the
+// miniDB client.
+package format
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/okazaki-kk/miniDB/internal/sql"
+)
+
+// Format is the name of an output format.
+type Format string
+
+const (
+	Aligned  Format = "aligned"
+	CSV      Format = "csv"
+	JSON     Format = "json"
+	NDJSON   Format = "ndjson"
+	Markdown Format = "markdown"
+)
+
+// Formats lists the supported output formats.
+var Formats = []Format{Aligned, CSV, JSON, NDJSON, Markdown}
+
+// Parse returns the format with the given name.
+func Parse(name string) (Format, error) {
+	for _, f := range Formats {
+		if strings.EqualFold(name, string(f)) {
+			return f, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown format %q", name)
+}
+
+// Options controls how results are rendered.
+type Options struct {
+	Format Format
+	// Expanded prints every row as a vertical list of column/value pairs.
+	// It only applies to the aligned format.
+	Expanded bool
+	// Null is the text displayed for NULL values in text based formats.
+	Null string
+}
+
+// Result is a set of rows to render.
+type Result struct {
+	Title   string
+	Columns []string
+	Rows    sql.RowIter
+}
+
+// Write renders the result to w. It consumes and closes the result rows.
+func Write(w io.Writer, result Result, opts Options) error {
+	rows, err := collect(result.Rows)
+	if err != nil {
+		return err
+	}
+
+	switch opts.Format {
+	case Aligned, "":
+		if opts.Expanded {
+			return writeExpanded(w, result.Columns, rows, opts)
+		}
+		return writeAligned(w, result.Title, result.Columns, rows, opts)
+	case CSV:
+		return writeCSV(w, result.Columns, rows, opts)
+	case JSON:
+		return writeJSON(w, result.Columns, rows)
+	case NDJSON:
+		return writeNDJSON(w, result.Columns, rows)
+	case Markdown:
+		return writeMarkdown(w, result.Columns, rows, opts)
+	default:
+		return fmt.Errorf("unknown format %q", opts.Format)
+	}
+}
+
+func collect(iter sql.RowIter) ([]sql.Row, error) {
+	if iter == nil {
+		return nil, nil
+	}
+	defer iter.Close()
+
+	var rows []sql.Row
+	for {
+		row, err := iter.Next()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		rows = append(rows, row)
+	}
+}
+
+func isNumeric(v sql.Value) bool {
+	if sql.IsNull(v) {
+		return false
+	}
+
+	switch v.DataType() {
+	case sql.Integer, sql.Float:
+		return true
+	default:
+		return false
+	}
+}
+
+// numericColumns reports for every column whether all its non-NULL values are
+// numbers, in which case the column is right-aligned.
+func numericColumns(columns []string, rows []sql.Row) []bool {
+	numeric := make([]bool, len(columns))
+	for i := range columns {
+		for _, row := range rows {
+			if sql.IsNull(row[i]) {
+				continue
+			}
+			if !isNumeric(row[i]) {
+				numeric[i] = false
+				break
+			}
+			numeric[i] = true
+		}
+	}
+	return numeric
+}
+
+func text(v sql.Value, opts Options) string {
+	if sql.IsNull(v) {
+		return opts.Null
+	}
+	return v.String()
+}
+
+func writeAligned(w io.Writer, title string, columns []string, rows []sql.Row, opts Options) error {
+	widths := make([]int, len(columns))
+	for i := range columns {
+		widths[i] = len(columns[i])
+	}
+	for _, row := range rows {
+		for i := range row {
+			if n := len(text(row[i], opts)); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	numeric := numericColumns(columns, rows)
+
+	var b strings.Builder
+
+	if title != "" {
+		total := len(widths) - 1
+		for _, width := range widths {
+			total += width + 2
+		}
+		if padding := (total - len(title)) / 2; padding > 0 {
+			b.WriteString(strings.Repeat(" ", padding))
+		}
+		b.WriteString(title)
+		b.WriteString("\n")
+	}
+
+	for i := range columns {
+		padding := widths[i] - len(columns[i])
+		if i > 0 {
+			b.WriteString("|")
+		}
+		b.WriteString(" ")
+		b.WriteString(strings.Repeat(" ", padding/2))
+		b.WriteString(columns[i])
+		if i < len(columns)-1 {
+			b.WriteString(strings.Repeat(" ", padding-padding/2+1))
+		}
+	}
+	b.WriteString("\n")
+
+	for i, width := range widths {
+		if i > 0 {
+			b.WriteString("+")
+		}
+		b.WriteString(strings.Repeat("-", width+2))
+	}
+	b.WriteString("\n")
+
+	for _, row := range rows {
+		for i := range row {
+			cell := text(row[i], opts)
+			padding := strings.Repeat(" ", widths[i]-len(cell))
+
+			if i > 0 {
+				b.WriteString("|")
+			}
+			b.WriteString(" ")
+			if numeric[i] {
+				b.WriteString(padding)
+				b.WriteString(cell)
+			} else {
+				b.WriteString(cell)
+				if i < len(row)-1 {
+					b.WriteString(padding)
+				}
+			}
+			if i < len(row)-1 {
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	if len(rows) == 1 {
+		b.WriteString("(1 row)\n")
+	} else {
+		b.WriteString(fmt.Sprintf("(%d rows)\n", len(rows)))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeExpanded(w io.Writer, columns []string, rows []sql.Row, opts Options) error {
+	if len(rows) == 0 {
+		_, err := io.WriteString(w, "(0 rows)\n")
+		return err
+	}
+
+	keyWidth, valueWidth := 0, 0
+	for i := range columns {
+		if len(columns[i]) > keyWidth {
+			keyWidth = len(columns[i])
+		}
+	}
+	for _, row := range rows {
+		for i := range row {
+			if n := len(text(row[i], opts)); n > valueWidth {
+				valueWidth = n
+			}
+		}
+	}
+
+	var b strings.Builder
+
+	for n, row := range rows {
+		label := fmt.Sprintf("-[ RECORD %d ]", n+1)
+		b.WriteString(label)
+		if len(label) < keyWidth+1 {
+			b.WriteString(strings.Repeat("-", keyWidth+1-len(label)))
+		}
+		b.WriteString("+")
+		b.WriteString(strings.Repeat("-", valueWidth+1))
+		b.WriteString("\n")
+
+		for i := range row {
+			b.WriteString(columns[i])
+			b.WriteString(strings.Repeat(" ", keyWidth-len(columns[i])))
+			b.WriteString(" | ")
+			b.WriteString(text(row[i], opts))
+			b.WriteString("\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeCSV(w io.Writer, columns []string, rows []sql.Row, opts Options) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+
+	record := make([]string, len(columns))
+	for _, row := range rows {
+		for i := range row {
+			record[i] = text(row[i], opts)
+		}
+
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// object renders a row as a JSON object keeping the column order.
+func object(columns []string, row sql.Row) ([]byte, error) {
+	var b strings.Builder
+
+	b.WriteString("{")
+	for i := range row {
+		if i > 0 {
+			b.WriteString(",")
+		}
+
+		key, err := json.Marshal(columns[i])
+		if err != nil {
+			return nil, err
+		}
+
+		var raw any
+		if !sql.IsNull(row[i]) {
+			switch v := row[i].Raw().(type) {
+			case float64:
+				// JSON has no numbers for NaN and the infinities, they are
+				// rendered as the strings PostgreSQL's to_json gives.
+				switch {
+				case math.IsNaN(v):
+					raw = "NaN"
+				case math.IsInf(v, 1):
+					raw = "Infinity"
+				case math.IsInf(v, -1):
+					raw = "-Infinity"
+				default:
+					raw = v
+				}
+			case int64, bool, string:
+				raw = v
+			default:
+				// Decimals are rendered as numbers with all their digits,
+				// JSON documents as themselves and dates, times and
+				// intervals as their text.
+				raw = row[i].String()
+				switch row[i].DataType() {
+				case sql.Decimal:
+					raw = json.Number(row[i].String())
+				case sql.JSON:
+					raw = json.RawMessage(row[i].String())
+				}
+			}
+		}
+
+		value, err := json.Marshal(raw)
+		if err != nil {
+			return nil, err
+		}
+
+		b.Write(key)
+		b.WriteString(":")
+		b.Write(value)
+	}
+	b.WriteString("}")
+
+	return []byte(b.String()), nil
+}
+
+func writeJSON(w io.Writer, columns []string, rows []sql.Row) error {
+	var b strings.Builder
+
+	b.WriteString("[")
+	for n, row := range rows {
+		if n > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n  ")
+
+		obj, err := object(columns, row)
+		if err != nil {
+			return err
+		}
+		b.Write(obj)
+	}
+	if len(rows) > 0 {
+		b.WriteString("\n")
+	}
+	b.WriteString("]\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeNDJSON(w io.Writer, columns []string, rows []sql.Row) error {
+	for _, row := range rows {
+		obj, err := object(columns, row)
+		if err != nil {
+			return err
+		}
+
+		if _, err := w.Write(append(obj, '\n')); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeMarkdown(w io.Writer, columns []string, rows []sql.Row, opts Options) error {
+	escape := strings.NewReplacer("|", `\|`, "\n", "<br>")
+
+	numeric := numericColumns(columns, rows)
+
+	var b strings.Builder
+
+	b.WriteString("|")
+	for i := range columns {
+		b.WriteString(" ")
+		b.WriteString(escape.Replace(columns[i]))
+		b.WriteString(" |")
+	}
+	b.WriteString("\n|")
+	for i := range columns {
+		if numeric[i] {
+			b.WriteString(" ---: |")
+		} else {
+			b.WriteString(" --- |")
+		}
+	}
+	b.WriteString("\n")
+
+	for _, row := range rows {
+		b.WriteString("|")
+		for i := range row {
+			b.WriteString(" ")
+			b.WriteString(escape.Replace(text(row[i], opts)))
+			b.WriteString(" |")
+		}
+		b.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
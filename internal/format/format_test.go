@@ -0,0 +1,144 @@
+package format
+
+import (
+	"bytes"
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/okazaki-kk/miniDB/internal/sql"
+	"github.com/okazaki-kk/miniDB/internal/sql/datatype"
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func aircrafts() Result {
+	return Result{
+		Columns: []string{"id", "code", "model", "range", "active"},
+		Rows: sql.NewSliceRowsIter(
+			sql.Row{datatype.NewInteger(1), datatype.NewText("773"), datatype.NewText("Boeing 777-300"), datatype.NewInteger(11100), datatype.NewBoolean(true)},
+			sql.Row{datatype.NewInteger(2), datatype.NewText("SU9"), datatype.NewText("Sukhoi Superjet-100"), datatype.NewInteger(3000), datatype.NewBoolean(false)},
+			sql.Row{datatype.NewInteger(3), datatype.NewText("CN1"), datatype.NewText("Cessna 208, \"Caravan\" | light"), nil, datatype.NewBoolean(true)},
+		),
+	}
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		golden string
+		result func() Result
+		opts   Options
+	}{
+		{
+			golden: "aligned",
+			result: aircrafts,
+			opts:   Options{Format: Aligned, Null: "NULL"},
+		},
+		{
+			golden: "aligned_title",
+			result: func() Result {
+				result := aircrafts()
+				result.Title = "Aircrafts"
+				return result
+			},
+			opts: Options{Format: Aligned},
+		},
+		{
+			golden: "aligned_empty",
+			result: func() Result {
+				return Result{Columns: []string{"id", "name"}, Rows: sql.NewSliceRowsIter()}
+			},
+			opts: Options{Format: Aligned},
+		},
+		{
+			golden: "expanded",
+			result: aircrafts,
+			opts:   Options{Format: Aligned, Expanded: true, Null: "NULL"},
+		},
+		{
+			golden: "csv",
+			result: aircrafts,
+			opts:   Options{Format: CSV},
+		},
+		{
+			golden: "json",
+			result: aircrafts,
+			opts:   Options{Format: JSON},
+		},
+		{
+			golden: "json_empty",
+			result: func() Result {
+				return Result{Columns: []string{"id", "name"}, Rows: sql.NewSliceRowsIter()}
+			},
+			opts: Options{Format: JSON},
+		},
+		{
+			golden: "json_floats",
+			result: func() Result {
+				return Result{
+					Columns: []string{"id", "value"},
+					Rows: sql.NewSliceRowsIter(
+						sql.Row{datatype.NewInteger(1), datatype.NewFloat(1.5)},
+						sql.Row{datatype.NewInteger(2), datatype.NewFloat(math.NaN())},
+						sql.Row{datatype.NewInteger(3), datatype.NewFloat(math.Inf(1))},
+						sql.Row{datatype.NewInteger(4), datatype.NewFloat(math.Inf(-1))},
+					),
+				}
+			},
+			opts: Options{Format: JSON},
+		},
+		{
+			golden: "ndjson",
+			result: aircrafts,
+			opts:   Options{Format: NDJSON},
+		},
+		{
+			golden: "markdown",
+			result: aircrafts,
+			opts:   Options{Format: Markdown},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.golden, func(t *testing.T) {
+			t.Parallel()
+
+			var b bytes.Buffer
+			err := Write(&b, test.result(), test.opts)
+			assert.NoError(t, err)
+
+			path := filepath.Join("testdata", test.golden+".golden")
+			if *update {
+				assert.NoError(t, os.WriteFile(path, b.Bytes(), 0o644))
+			}
+
+			expected, err := os.ReadFile(path)
+			assert.NoError(t, err)
+			assert.Equal(t, string(expected), b.String())
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	for _, f := range Formats {
+		actual, err := Parse(string(f))
+		assert.NoError(t, err)
+		assert.Equal(t, f, actual)
+	}
+
+	actual, err := Parse("CSV")
+	assert.NoError(t, err)
+	assert.Equal(t, CSV, actual)
+
+	_, err = Parse("xml")
+	assert.EqualError(t, err, `unknown format "xml"`)
+}
@@ -0,0 +1,486 @@
+// Package lineedit implements a minimal readline-like line editor with
+// history, reverse search and tab completion for interactive terminals.
+package lineedit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"unicode"
+)
+
+// ErrInterrupted is returned by ReadLine when the user pressed Ctrl-C.
+var ErrInterrupted = errors.New("interrupted")
+
+// Completer returns the completions of the word being typed.
+type Completer func(prefix string) []string
+
+const (
+	ctrlA     = 1
+	ctrlB     = 2
+	ctrlC     = 3
+	ctrlD     = 4
+	ctrlE     = 5
+	ctrlF     = 6
+	ctrlG     = 7
+	ctrlH     = 8
+	tab       = 9
+	ctrlK     = 11
+	ctrlL     = 12
+	ctrlN     = 14
+	ctrlP     = 16
+	ctrlR     = 18
+	ctrlU     = 21
+	ctrlW     = 23
+	escape    = 27
+	backspace = 127
+)
+
+// Editor reads lines from the input. When the input is a terminal the line is
+// edited in raw mode, otherwise lines are read as they are.
+type Editor struct {
+	Completer Completer
+
+	in       io.Reader
+	out      io.Writer
+	reader   *bufio.Reader
+	fd       int
+	terminal bool
+
+	history history
+
+	lines      chan string
+	interrupts chan os.Signal
+	readErr    error
+}
+
+// lineState is the state of the line being edited.
+type lineState struct {
+	prompt  string
+	buf     []rune
+	pos     int
+	draft   []rune // line being typed before browsing the history
+	index   int    // position in the history
+	lastTab bool
+}
+
+func New(in io.Reader, out io.Writer) *Editor {
+	e := &Editor{
+		in:      in,
+		out:     out,
+		reader:  bufio.NewReader(in),
+		history: history{limit: historyLimit},
+	}
+
+	if f, ok := in.(*os.File); ok && isTerminal(int(f.Fd())) {
+		e.fd = int(f.Fd())
+		e.terminal = true
+	}
+
+	return e
+}
+
+// IsTerminal reports whether lines are read from an interactive terminal.
+func (e *Editor) IsTerminal() bool {
+	return e.terminal
+}
+
+// LoadHistory reads the history from the file and appends the lines added
+// afterwards to it.
+func (e *Editor) LoadHistory(path string) error {
+	return e.history.load(path)
+}
+
+// AddHistory appends the line to the history.
+func (e *Editor) AddHistory(line string) error {
+	return e.history.add(line)
+}
+
+// Close releases the resources held by the editor.
+func (e *Editor) Close() error {
+	if e.interrupts != nil {
+		signal.Stop(e.interrupts)
+	}
+	return nil
+}
+
+// ReadLine prints the prompt and returns the next line without the line
+// terminator. It returns io.EOF when the input is exhausted and
+// ErrInterrupted when the user pressed Ctrl-C.
+func (e *Editor) ReadLine(prompt string) (string, error) {
+	if !e.terminal {
+		return e.readPlain(prompt)
+	}
+
+	restore, err := makeRaw(e.fd)
+	if err != nil {
+		e.terminal = false
+		return e.readPlain(prompt)
+	}
+	defer restore()
+
+	return e.edit(prompt)
+}
+
+// readPlain reads lines of non-interactive input. Reading happens in the
+// background so that an interrupt signal can cancel the current line.
+func (e *Editor) readPlain(prompt string) (string, error) {
+	io.WriteString(e.out, prompt)
+
+	if e.lines == nil {
+		e.lines = make(chan string)
+		e.interrupts = make(chan os.Signal, 1)
+		signal.Notify(e.interrupts, os.Interrupt)
+
+		go func() {
+			defer close(e.lines)
+
+			scanner := bufio.NewScanner(e.reader)
+			for scanner.Scan() {
+				e.lines <- scanner.Text()
+			}
+			e.readErr = scanner.Err()
+		}()
+	}
+
+	select {
+	case <-e.interrupts:
+		io.WriteString(e.out, "\n")
+		return "", ErrInterrupted
+	case line, ok := <-e.lines:
+		if !ok {
+			if e.readErr != nil {
+				return "", e.readErr
+			}
+			return "", io.EOF
+		}
+		return line, nil
+	}
+}
+
+func (e *Editor) edit(prompt string) (string, error) {
+	s := &lineState{prompt: prompt, index: len(e.history.entries)}
+	e.refresh(s)
+
+	for {
+		r, _, err := e.reader.ReadRune()
+		if err != nil {
+			if errors.Is(err, io.EOF) && len(s.buf) > 0 {
+				io.WriteString(e.out, "\n")
+				return string(s.buf), nil
+			}
+			return "", err
+		}
+
+		isTab := r == tab
+
+		switch r {
+		case '\r', '\n':
+			io.WriteString(e.out, "\n")
+			return string(s.buf), nil
+		case ctrlC:
+			io.WriteString(e.out, "^C\n")
+			return "", ErrInterrupted
+		case ctrlD:
+			if len(s.buf) == 0 {
+				io.WriteString(e.out, "\n")
+				return "", io.EOF
+			}
+			s.deleteAt(s.pos)
+		case ctrlA:
+			s.pos = 0
+		case ctrlE:
+			s.pos = len(s.buf)
+		case ctrlB:
+			s.left()
+		case ctrlF:
+			s.right()
+		case ctrlH, backspace:
+			if s.pos > 0 {
+				s.pos--
+				s.deleteAt(s.pos)
+			}
+		case ctrlK:
+			s.buf = s.buf[:s.pos]
+		case ctrlU:
+			s.buf = append([]rune{}, s.buf[s.pos:]...)
+			s.pos = 0
+		case ctrlW:
+			s.deleteWord()
+		case ctrlL:
+			io.WriteString(e.out, "\x1b[H\x1b[2J")
+		case ctrlP:
+			e.previous(s)
+		case ctrlN:
+			e.next(s)
+		case ctrlR:
+			accepted, err := e.search(s)
+			if err != nil {
+				return "", err
+			}
+			if accepted {
+				e.refresh(s)
+				io.WriteString(e.out, "\n")
+				return string(s.buf), nil
+			}
+		case tab:
+			e.complete(s)
+		case escape:
+			if err := e.escape(s); err != nil {
+				return "", err
+			}
+		default:
+			if unicode.IsPrint(r) {
+				s.insert(r)
+			}
+		}
+
+		s.lastTab = isTab
+		e.refresh(s)
+	}
+}
+
+// escape handles the ANSI escape sequences sent by cursor and editing keys.
+func (e *Editor) escape(s *lineState) error {
+	r, _, err := e.reader.ReadRune()
+	if err != nil {
+		return err
+	}
+	if r != '[' && r != 'O' {
+		return nil
+	}
+
+	var seq []rune
+	for {
+		r, _, err = e.reader.ReadRune()
+		if err != nil {
+			return err
+		}
+
+		seq = append(seq, r)
+		if r < '0' || r > '9' {
+			break
+		}
+	}
+
+	switch string(seq) {
+	case "A":
+		e.previous(s)
+	case "B":
+		e.next(s)
+	case "C":
+		s.right()
+	case "D":
+		s.left()
+	case "H", "1~", "7~":
+		s.pos = 0
+	case "F", "4~", "8~":
+		s.pos = len(s.buf)
+	case "3~":
+		s.deleteAt(s.pos)
+	}
+
+	return nil
+}
+
+func (e *Editor) refresh(s *lineState) {
+	fmt.Fprintf(e.out, "\r%s%s\x1b[K", s.prompt, string(s.buf))
+	if n := len(s.buf) - s.pos; n > 0 {
+		fmt.Fprintf(e.out, "\x1b[%dD", n)
+	}
+}
+
+func (e *Editor) previous(s *lineState) {
+	if s.index == 0 {
+		return
+	}
+
+	if s.index == len(e.history.entries) {
+		s.draft = s.buf
+	}
+
+	s.index--
+	s.set(e.history.entries[s.index])
+}
+
+func (e *Editor) next(s *lineState) {
+	if s.index >= len(e.history.entries) {
+		return
+	}
+
+	s.index++
+	if s.index == len(e.history.entries) {
+		s.set(string(s.draft))
+		return
+	}
+
+	s.set(e.history.entries[s.index])
+}
+
+// search implements the incremental reverse history search started by
+// Ctrl-R. It reports whether the found line was accepted with Enter. Any
+// other editing key leaves the search with the found line in the buffer.
+func (e *Editor) search(s *lineState) (bool, error) {
+	var (
+		query    []rune
+		index    = len(e.history.entries)
+		match    = string(s.buf)
+		original = s.buf
+		failed   bool
+	)
+
+	find := func(from int) {
+		for i := from; i >= 0; i-- {
+			if i < len(e.history.entries) && strings.Contains(e.history.entries[i], string(query)) {
+				index, match, failed = i, e.history.entries[i], false
+				return
+			}
+		}
+		failed = true
+	}
+
+	for {
+		label := "reverse-i-search"
+		if failed {
+			label = "failed reverse-i-search"
+		}
+		fmt.Fprintf(e.out, "\r(%s)`%s': %s\x1b[K", label, string(query), match)
+
+		r, _, err := e.reader.ReadRune()
+		if err != nil {
+			return false, err
+		}
+
+		switch r {
+		case ctrlR:
+			find(index - 1)
+		case ctrlH, backspace:
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+				find(len(e.history.entries) - 1)
+			}
+		case ctrlC, ctrlG:
+			s.buf = original
+			s.pos = len(s.buf)
+			return false, nil
+		case '\r', '\n':
+			s.set(match)
+			return true, nil
+		default:
+			if unicode.IsPrint(r) {
+				query = append(query, r)
+				find(index)
+				continue
+			}
+
+			s.set(match)
+			s.index = index
+			return false, e.reader.UnreadRune()
+		}
+	}
+}
+
+// complete completes the word before the cursor. A single candidate replaces
+// the word, several candidates extend it to their common prefix and are listed
+// when Tab is pressed twice.
+func (e *Editor) complete(s *lineState) {
+	if e.Completer == nil {
+		return
+	}
+
+	start := s.pos
+	for start > 0 && isWordRune(s.buf[start-1]) {
+		start--
+	}
+
+	prefix := string(s.buf[start:s.pos])
+	candidates := e.Completer(prefix)
+
+	switch len(candidates) {
+	case 0:
+		io.WriteString(e.out, "\a")
+	case 1:
+		s.replace(start, candidates[0]+" ")
+	default:
+		common := commonPrefix(candidates)
+		if len([]rune(common)) > len([]rune(prefix)) {
+			s.replace(start, common)
+			return
+		}
+
+		if s.lastTab {
+			fmt.Fprintf(e.out, "\n%s\n", strings.Join(candidates, "  "))
+		} else {
+			io.WriteString(e.out, "\a")
+		}
+	}
+}
+
+func (s *lineState) set(line string) {
+	s.buf = []rune(line)
+	s.pos = len(s.buf)
+}
+
+func (s *lineState) insert(r rune) {
+	s.buf = append(s.buf, 0)
+	copy(s.buf[s.pos+1:], s.buf[s.pos:])
+	s.buf[s.pos] = r
+	s.pos++
+}
+
+// replace replaces the text between start and the cursor.
+func (s *lineState) replace(start int, text string) {
+	tail := append([]rune(text), s.buf[s.pos:]...)
+	s.buf = append(s.buf[:start], tail...)
+	s.pos = start + len([]rune(text))
+}
+
+func (s *lineState) deleteAt(pos int) {
+	if pos < len(s.buf) {
+		s.buf = append(s.buf[:pos], s.buf[pos+1:]...)
+	}
+}
+
+func (s *lineState) deleteWord() {
+	end := s.pos
+	for s.pos > 0 && s.buf[s.pos-1] == ' ' {
+		s.pos--
+	}
+	for s.pos > 0 && s.buf[s.pos-1] != ' ' {
+		s.pos--
+	}
+	s.buf = append(s.buf[:s.pos], s.buf[end:]...)
+}
+
+func (s *lineState) left() {
+	if s.pos > 0 {
+		s.pos--
+	}
+}
+
+func (s *lineState) right() {
+	if s.pos < len(s.buf) {
+		s.pos++
+	}
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '\\'
+}
+
+func commonPrefix(words []string) string {
+	prefix := []rune(words[0])
+	for _, word := range words[1:] {
+		w := []rune(word)
+		n := 0
+		for n < len(prefix) && n < len(w) && prefix[n] == w[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	return string(prefix)
+}
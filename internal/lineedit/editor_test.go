@@ -0,0 +1,227 @@
+package lineedit
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestEditor(input string, entries ...string) *Editor {
+	return &Editor{
+		in:       strings.NewReader(input),
+		out:      &bytes.Buffer{},
+		reader:   bufio.NewReader(strings.NewReader(input)),
+		terminal: true,
+		history:  history{entries: entries, limit: historyLimit},
+	}
+}
+
+func TestEditor_Edit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    string
+		history  []string
+		expected string
+		err      error
+	}{
+		{
+			name:     "plain line",
+			input:    "SELECT 1\r",
+			expected: "SELECT 1",
+		},
+		{
+			name:     "unicode",
+			input:    "SELECT 'héllo'\r",
+			expected: "SELECT 'héllo'",
+		},
+		{
+			name:     "backspace",
+			input:    "SELECTT\x7f 1\r",
+			expected: "SELECT 1",
+		},
+		{
+			name:     "insert at start",
+			input:    "ELECT 1\x01S\r",
+			expected: "SELECT 1",
+		},
+		{
+			name:     "cursor keys",
+			input:    "SELECT 2\x1b[D\x1b[3~1\x1b[H\x1b[C\x1b[F;\r",
+			expected: "SELECT 1;",
+		},
+		{
+			name:     "kill to end",
+			input:    "SELECT 1 FROM\x02\x02\x02\x02\x02\x0b\r",
+			expected: "SELECT 1",
+		},
+		{
+			name:     "kill to start",
+			input:    "garbage SELECT 1\x01\x06\x06\x06\x06\x06\x06\x06\x06\x15\r",
+			expected: "SELECT 1",
+		},
+		{
+			name:     "delete word",
+			input:    "SELECT 1 FROM users  \x17\x17\r",
+			expected: "SELECT 1 ",
+		},
+		{
+			name:  "interrupt",
+			input: "SELECT\x03",
+			err:   ErrInterrupted,
+		},
+		{
+			name:  "end of input on empty line",
+			input: "\x04",
+			err:   io.EOF,
+		},
+		{
+			name:     "ctrl-d deletes under cursor",
+			input:    "SELECTX\x02\x04\r",
+			expected: "SELECT",
+		},
+		{
+			name:     "history previous",
+			input:    "\x1b[A\x1b[A\r",
+			history:  []string{"first", "second"},
+			expected: "first",
+		},
+		{
+			name:     "history next restores draft",
+			input:    "draft\x10\x10\x0e\x0e\r",
+			history:  []string{"first", "second"},
+			expected: "draft",
+		},
+		{
+			name:     "reverse search",
+			input:    "\x12us\r",
+			history:  []string{"SELECT * FROM users", "SELECT * FROM aircrafts"},
+			expected: "SELECT * FROM users",
+		},
+		{
+			name:     "reverse search older match",
+			input:    "\x12SEL\x12\r",
+			history:  []string{"SELECT 1", "SELECT 2", "SELECT 3"},
+			expected: "SELECT 2",
+		},
+		{
+			name:     "reverse search then edit",
+			input:    "\x12air\x05;\r",
+			history:  []string{"SELECT * FROM users", "SELECT * FROM aircrafts"},
+			expected: "SELECT * FROM aircrafts;",
+		},
+		{
+			name:     "reverse search cancel",
+			input:    "draft\x12air\x07\r",
+			history:  []string{"SELECT * FROM aircrafts"},
+			expected: "draft",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			e := newTestEditor(test.input, test.history...)
+			line, err := e.edit("> ")
+
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, line)
+		})
+	}
+}
+
+func TestEditor_Complete(t *testing.T) {
+	t.Parallel()
+
+	words := []string{"select", "set", "users", "user_id"}
+	completer := func(prefix string) []string {
+		var matches []string
+		for _, word := range words {
+			if strings.HasPrefix(word, prefix) {
+				matches = append(matches, word)
+			}
+		}
+		return matches
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		output   string
+	}{
+		{
+			name:     "single candidate",
+			input:    "sel\t1\r",
+			expected: "select 1",
+		},
+		{
+			name:     "common prefix",
+			input:    "SELECT * FROM us\t\r",
+			expected: "SELECT * FROM user",
+		},
+		{
+			name:     "list candidates",
+			input:    "se\t\t\r",
+			expected: "se",
+			output:   "\nselect  set\n",
+		},
+		{
+			name:     "no candidates",
+			input:    "xyz\t\r",
+			expected: "xyz",
+			output:   "\a",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			e := newTestEditor(test.input)
+			e.Completer = completer
+
+			line, err := e.edit("> ")
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, line)
+			assert.Contains(t, e.out.(*bytes.Buffer).String(), test.output)
+		})
+	}
+}
+
+func TestEditor_ReadLine(t *testing.T) {
+	t.Parallel()
+
+	out := &bytes.Buffer{}
+	e := New(strings.NewReader("SELECT 1\nSELECT 2\n"), out)
+	defer e.Close()
+
+	assert.False(t, e.IsTerminal())
+
+	line, err := e.ReadLine("> ")
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT 1", line)
+
+	line, err = e.ReadLine("> ")
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT 2", line)
+
+	_, err = e.ReadLine("> ")
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, "> > > ", out.String())
+}
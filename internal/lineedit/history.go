@@ -0,0 +1,81 @@
+package lineedit
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"strings"
+)
+
+const historyLimit = 1000
+
+// history keeps the entered lines, oldest first. Once loaded from a file every
+// added line is appended to it, so the history survives crashes.
+type history struct {
+	entries []string
+	limit   int
+	path    string
+}
+
+func (h *history) load(path string) error {
+	h.path = path
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			h.entries = append(h.entries, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if len(h.entries) <= h.limit {
+		return nil
+	}
+
+	h.entries = h.entries[len(h.entries)-h.limit:]
+
+	return os.WriteFile(path, []byte(strings.Join(h.entries, "\n")+"\n"), 0o600)
+}
+
+func (h *history) add(line string) error {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil
+	}
+
+	if n := len(h.entries); n > 0 && h.entries[n-1] == line {
+		return nil
+	}
+
+	h.entries = append(h.entries, line)
+	if len(h.entries) > h.limit {
+		h.entries = h.entries[len(h.entries)-h.limit:]
+	}
+
+	if h.path == "" {
+		return nil
+	}
+
+	file, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.WriteString(line + "\n"); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
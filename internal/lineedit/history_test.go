@@ -0,0 +1,41 @@
+package lineedit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "history")
+
+	h := history{limit: 3}
+	assert.NoError(t, h.load(path))
+	assert.Empty(t, h.entries)
+
+	assert.NoError(t, h.add("SELECT 1"))
+	assert.NoError(t, h.add("SELECT 1"))
+	assert.NoError(t, h.add("   "))
+	assert.NoError(t, h.add("SELECT 2"))
+	assert.Equal(t, []string{"SELECT 1", "SELECT 2"}, h.entries)
+
+	loaded := history{limit: 3}
+	assert.NoError(t, loaded.load(path))
+	assert.Equal(t, []string{"SELECT 1", "SELECT 2"}, loaded.entries)
+
+	assert.NoError(t, loaded.add("SELECT 3"))
+	assert.NoError(t, loaded.add("SELECT 4"))
+	assert.Equal(t, []string{"SELECT 2", "SELECT 3", "SELECT 4"}, loaded.entries)
+
+	truncated := history{limit: 3}
+	assert.NoError(t, truncated.load(path))
+	assert.Equal(t, []string{"SELECT 2", "SELECT 3", "SELECT 4"}, truncated.entries)
+
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT 2\nSELECT 3\nSELECT 4\n", string(content))
+}
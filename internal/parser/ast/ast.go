@@ -18,19 +18,55 @@ type Statement interface {
 type Expression interface {
 	Node
 	expressionNode()
+	String() string
 }
 
-// SelectStatement node represents a SELECT statement.
+// SelectStatement node represents a SELECT statement. A set operation
+// combines the results of Left and Right instead of having the clauses up to
+// HAVING, its ORDER BY, LIMIT and OFFSET apply to the combined result.
 type SelectStatement struct {
+	With *WithStatement
+
+	// Operator is UNION, INTERSECT or EXCEPT for a set operation, All keeps
+	// duplicate rows.
+	Operator token.TokenType
+	All      bool
+	Left     *SelectStatement
+	Right    *SelectStatement
+
+	// Distinct drops duplicate result rows, or with DistinctOn the rows after
+	// the first one of those with equal values of the expressions.
+	Distinct   bool
+	DistinctOn []Expression
+
 	Result  []ResultStatement
 	From    *FromStatement
 	Where   *WhereStatement
+	GroupBy *GroupByStatement
+	Having  *HavingStatement
 	OrderBy *OrderByStatement
 	Limit   *LimitStatement
 	Offset  *OffsetStatement
 }
 
+// WithStatement node represents a WITH clause naming the queries which the
+// statement following it can read like tables. The queries of a RECURSIVE
+// clause can read themselves and the ones declared after them.
+type WithStatement struct {
+	Recursive bool
+	Queries   []CommonTableExpr
+}
+
+// CommonTableExpr node represents a query named in a WITH clause, with its
+// columns renamed by Columns.
+type CommonTableExpr struct {
+	Name    string
+	Columns []string
+	Query   *SelectStatement
+}
+
 type UpdateStatement struct {
+	With  *WithStatement
 	Table string
 	Set   []SetStatement
 	Where *WhereStatement
@@ -42,6 +78,7 @@ type SetStatement struct {
 }
 
 type DeleteStatement struct {
+	With  *WithStatement
 	Table string
 	Where *WhereStatement
 }
@@ -49,28 +86,188 @@ type DeleteStatement struct {
 // ResultStatement node represents a returning expression in a SELECT statement.
 type ResultStatement struct {
 	Expr Expression
+	// Alias names the result column, empty for a name derived from Expr.
+	Alias string
 }
 
-// FromStatement node represents a FROM statement.
+// FromStatement node represents a FROM statement: a table, optionally
+// named by an alias, joined with the tables of Joins from left to right.
 type FromStatement struct {
 	Table string
+	// Subquery is set instead of Table for a derived table, which must have
+	// an alias.
+	Subquery *SelectStatement
+	Alias    string
+	Joins    []JoinStatement
+}
+
+// JoinStatement node represents a table joined to the tables before it in
+// FROM. A comma join is a CROSS join.
+type JoinStatement struct {
+	// Type is INNER, LEFT, RIGHT, FULL or CROSS.
+	Type     token.TokenType
+	Table    string
+	Subquery *SelectStatement
+	Alias    string
+	// On is the join condition, unless the tables join USING columns.
+	On    Expression
+	Using []string
 }
 
 type WhereStatement struct {
 	Expr Expression
 }
 
+// GroupByStatement node represents a GROUP BY clause. An integer literal
+// refers to a result column by its position.
+type GroupByStatement struct {
+	Exprs []Expression
+}
+
+// HavingStatement node represents a HAVING clause.
+type HavingStatement struct {
+	Expr Expression
+}
+
 type CreateTableStatement struct {
-	Table   string
-	Columns []Column
+	Table       string
+	Columns     []Column
+	Constraints []Constraint
+	IfNotExists bool
 }
 
 type CreateDatabaseStatement struct {
-	Database string
+	Database    string
+	IfNotExists bool
 }
 
 type DropDatabaseStatement struct {
 	Database string
+	IfExists bool
+	// Force allows dropping the database the session is using.
+	Force bool
+}
+
+// CreateIndexStatement node represents a CREATE INDEX statement, indexing a
+// column or an expression of the columns of the table.
+type CreateIndexStatement struct {
+	Index       string
+	Table       string
+	Expr        Expression
+	IfNotExists bool
+}
+
+// DropIndexStatement node represents a DROP INDEX statement.
+type DropIndexStatement struct {
+	Index    string
+	IfExists bool
+}
+
+// DropTableStatement node represents a DROP TABLE statement.
+type DropTableStatement struct {
+	Tables   []string
+	IfExists bool
+	// Cascade drops the objects depending on the tables too, otherwise
+	// the tables are dropped only when nothing depends on them (RESTRICT).
+	Cascade bool
+}
+
+// TruncateStatement node represents a TRUNCATE TABLE statement.
+type TruncateStatement struct {
+	Tables []string
+}
+
+// AlterTableStatement node represents an ALTER TABLE statement. The actions
+// are applied in order, either all of them or none.
+type AlterTableStatement struct {
+	Table   string
+	Actions []AlterTableAction
+}
+
+// AlterTableAction represents a single change of an ALTER TABLE statement.
+type AlterTableAction interface {
+	Node
+	alterTableAction()
+}
+
+// AddColumnAction node represents ALTER TABLE ... ADD COLUMN.
+type AddColumnAction struct {
+	Column Column
+}
+
+// AddConstraintAction node represents ALTER TABLE ... ADD CONSTRAINT.
+type AddConstraintAction struct {
+	Constraint Constraint
+}
+
+// DropConstraintAction node represents ALTER TABLE ... DROP CONSTRAINT.
+type DropConstraintAction struct {
+	Name string
+}
+
+// DropColumnAction node represents ALTER TABLE ... DROP COLUMN.
+type DropColumnAction struct {
+	Column string
+}
+
+// RenameColumnAction node represents ALTER TABLE ... RENAME COLUMN ... TO.
+type RenameColumnAction struct {
+	Column  string
+	NewName string
+}
+
+// RenameTableAction node represents ALTER TABLE ... RENAME TO.
+type RenameTableAction struct {
+	NewName string
+}
+
+// SetNotNullAction node represents ALTER TABLE ... ALTER COLUMN ... SET NOT NULL
+// and, when NotNull is false, DROP NOT NULL.
+type SetNotNullAction struct {
+	Column  string
+	NotNull bool
+}
+
+// AlterColumnTypeAction node represents ALTER TABLE ... ALTER COLUMN ... TYPE.
+type AlterColumnTypeAction struct {
+	Column string
+	Type   token.TokenType
+	// Precision and Scale of NUMERIC(p, s), Precision is 0 without them.
+	Precision int
+	Scale     int
+}
+
+func (a *AddColumnAction) alterTableAction()       {}
+func (a *AddConstraintAction) alterTableAction()   {}
+func (a *DropConstraintAction) alterTableAction()  {}
+func (a *DropColumnAction) alterTableAction()      {}
+func (a *RenameColumnAction) alterTableAction()    {}
+func (a *RenameTableAction) alterTableAction()     {}
+func (a *SetNotNullAction) alterTableAction()      {}
+func (a *AlterColumnTypeAction) alterTableAction() {}
+
+// UseStatement node represents a USE statement switching the current database.
+type UseStatement struct {
+	Database string
+}
+
+// ShowDatabasesStatement node represents a SHOW DATABASES statement.
+type ShowDatabasesStatement struct{}
+
+// ShowTablesStatement node represents a SHOW TABLES statement.
+type ShowTablesStatement struct{}
+
+// ShowFunctionsStatement node represents a SHOW FUNCTIONS statement.
+type ShowFunctionsStatement struct{}
+
+// ShowColumnsStatement node represents a SHOW COLUMNS FROM or DESCRIBE statement.
+type ShowColumnsStatement struct {
+	Table string
+}
+
+// ShowCreateTableStatement node represents a SHOW CREATE TABLE statement.
+type ShowCreateTableStatement struct {
+	Table string
 }
 
 // Column node represents a table column definition.
@@ -80,11 +277,41 @@ type Column struct {
 	Default    Expression
 	Nullable   bool
 	PrimaryKey bool
+	// Precision and Scale of NUMERIC(p, s), Precision is 0 without them.
+	Precision int
+	Scale     int
+	// Constraints declared with the column, they apply to the column only.
+	Constraints []Constraint
+}
+
+// Constraint node represents a UNIQUE, CHECK or FOREIGN KEY constraint
+// declared with a column or the table.
+type Constraint struct {
+	// Name is empty unless given with CONSTRAINT name.
+	Name string
+	// Type is UNIQUE, CHECK or FOREIGN.
+	Type    token.TokenType
+	Columns []string
+	// Check is the condition of a CHECK constraint.
+	Check Expression
+	// References is the referenced table of a FOREIGN KEY constraint.
+	References *Reference
+}
+
+// Reference node represents the REFERENCES clause of a foreign key.
+type Reference struct {
+	Table   string
+	Columns []string
+	// OnDelete and OnUpdate are RESTRICT, CASCADE or NULL for SET NULL.
+	OnDelete token.TokenType
+	OnUpdate token.TokenType
 }
 
+// OrderByStatement node represents an ORDER BY clause. A key whose
+// expression is an integer constant sorts by the result column at that
+// position.
 type OrderByStatement struct {
-	Column    string
-	Direction token.TokenType
+	Keys []SortKey
 }
 
 type LimitStatement struct {
@@ -95,24 +322,41 @@ type OffsetStatement struct {
 	Value Expression
 }
 
-func (s *SelectStatement) statementNode()         {}
-func (s *ResultStatement) statementNode()         {}
-func (s *FromStatement) statementNode()           {}
-func (s *WhereStatement) statementNode()          {}
-func (s *CreateTableStatement) statementNode()    {}
-func (s *OrderByStatement) statementNode()        {}
-func (s *LimitStatement) statementNode()          {}
-func (s *OffsetStatement) statementNode()         {}
-func (s *InsertStatement) statementNode()         {}
-func (s *CreateDatabaseStatement) statementNode() {}
-func (s *DropDatabaseStatement) statementNode()   {}
-func (s *UpdateStatement) statementNode()         {}
-func (s *SetStatement) statementNode()            {}
-func (s *DeleteStatement) statementNode()         {}
-
-// IdentExpr node represents an identifier.
+func (s *SelectStatement) statementNode()          {}
+func (s *WithStatement) statementNode()            {}
+func (s *ResultStatement) statementNode()          {}
+func (s *FromStatement) statementNode()            {}
+func (s *JoinStatement) statementNode()            {}
+func (s *WhereStatement) statementNode()           {}
+func (s *GroupByStatement) statementNode()         {}
+func (s *HavingStatement) statementNode()          {}
+func (s *CreateTableStatement) statementNode()     {}
+func (s *OrderByStatement) statementNode()         {}
+func (s *LimitStatement) statementNode()           {}
+func (s *OffsetStatement) statementNode()          {}
+func (s *InsertStatement) statementNode()          {}
+func (s *CreateDatabaseStatement) statementNode()  {}
+func (s *CreateIndexStatement) statementNode()     {}
+func (s *DropIndexStatement) statementNode()       {}
+func (s *DropDatabaseStatement) statementNode()    {}
+func (s *UpdateStatement) statementNode()          {}
+func (s *SetStatement) statementNode()             {}
+func (s *DeleteStatement) statementNode()          {}
+func (s *DropTableStatement) statementNode()       {}
+func (s *TruncateStatement) statementNode()        {}
+func (s *AlterTableStatement) statementNode()      {}
+func (s *UseStatement) statementNode()             {}
+func (s *ShowDatabasesStatement) statementNode()   {}
+func (s *ShowTablesStatement) statementNode()      {}
+func (s *ShowFunctionsStatement) statementNode()   {}
+func (s *ShowColumnsStatement) statementNode()     {}
+func (s *ShowCreateTableStatement) statementNode() {}
+
+// IdentExpr node represents an identifier, qualified by a table name or
+// alias when Table is set (like: a.code).
 type IdentExpr struct {
-	Name string
+	Table string
+	Name  string
 }
 
 // ScalarExpr node represents a literal of basic type.
@@ -121,22 +365,184 @@ type ScalarExpr struct {
 	Literal string
 }
 
+// UnaryExpr node represents a prefix operator applied to an operand (like: -10).
+type UnaryExpr struct {
+	Operator token.TokenType
+	Operand  Expression
+}
+
 type ConditionExpr struct {
 	Left     Expression
 	Operator token.TokenType
 	Right    Expression
 }
 
-// AsteriskExpr node represents asterisk at `SELECT *` expression.
-type AsteriskExpr struct{}
+// AsteriskExpr node represents asterisk at `SELECT *` expression, restricted
+// to the columns of a table when Table is set (like: a.*).
+type AsteriskExpr struct {
+	Table string
+}
+
+// DefaultExpr node represents the DEFAULT keyword standing for the default
+// value of a column in INSERT and UPDATE statements.
+type DefaultExpr struct{}
+
+// IsNullExpr node represents the IS [NOT] NULL predicate.
+type IsNullExpr struct {
+	Expr Expression
+	Not  bool
+}
+
+// IsDistinctExpr node represents the IS [NOT] DISTINCT FROM predicate, which
+// compares NULLs like ordinary values.
+type IsDistinctExpr struct {
+	Left  Expression
+	Right Expression
+	Not   bool
+}
+
+// SubqueryExpr node represents a scalar subquery (like: (SELECT max(id) FROM t)).
+type SubqueryExpr struct {
+	Select *SelectStatement
+}
+
+// ExistsExpr node represents the EXISTS (subquery) predicate.
+type ExistsExpr struct {
+	Select *SelectStatement
+}
+
+// InExpr node represents the [NOT] IN predicate with a subquery or a list of
+// values (like: code IN ('SVO', 'DME')).
+type InExpr struct {
+	Expr   Expression
+	Not    bool
+	Select *SelectStatement
+	// List holds the values when Select is nil.
+	List []Expression
+}
+
+// BetweenExpr node represents the [NOT] BETWEEN predicate (like: x BETWEEN 1
+// AND 10).
+type BetweenExpr struct {
+	Expr Expression
+	Not  bool
+	Low  Expression
+	High Expression
+}
+
+// LikeExpr node represents the [NOT] LIKE and ILIKE predicates (like: city
+// LIKE 'St.%' ESCAPE '!').
+type LikeExpr struct {
+	Expr Expression
+	// Operator is LIKE, or ILIKE for matching ignoring case.
+	Operator token.TokenType
+	Not      bool
+	Pattern  Expression
+	// Escape is nil for the default escape character, a backslash.
+	Escape Expression
+}
+
+// CaseExpr node represents a CASE expression. A simple CASE compares Operand
+// with the conditions of Whens, a searched CASE has no Operand and evaluates
+// them instead.
+type CaseExpr struct {
+	Operand Expression
+	Whens   []WhenClause
+	// Else is nil for ELSE NULL.
+	Else Expression
+}
+
+// WhenClause node represents a WHEN ... THEN ... branch of a CASE expression.
+type WhenClause struct {
+	Cond   Expression
+	Result Expression
+}
+
+// CastExpr node represents a conversion to a data type (like: CAST(x AS INT)
+// or x::INT).
+type CastExpr struct {
+	Expr Expression
+	Type token.TokenType
+	// Precision and Scale of NUMERIC(p, s), Precision is 0 without them.
+	Precision int
+	Scale     int
+}
+
+// AtTimeZoneExpr node represents the conversion of a timestamp to or from a
+// time zone (like: ts AT TIME ZONE 'Asia/Yakutsk').
+type AtTimeZoneExpr struct {
+	Expr Expression
+	Zone Expression
+}
+
+// CallExpr node represents a function call (like: now()).
+type CallExpr struct {
+	Name string
+	Args []Expression
+	// Distinct is set for aggregates over distinct values (like: count(DISTINCT x)).
+	Distinct bool
+}
+
+// WindowExpr node represents a call of a window function or an aggregate
+// over the window of a row (like: rank() OVER (PARTITION BY a ORDER BY b)).
+type WindowExpr struct {
+	Call        *CallExpr
+	PartitionBy []Expression
+	OrderBy     []SortKey
+	// Frame is nil for the default frame.
+	Frame *WindowFrame
+}
+
+// SortKey node represents an expression rows are sorted by.
+type SortKey struct {
+	Expr      Expression
+	Direction token.TokenType
+	// Nulls is FIRST or LAST, empty for the default of NULLs sorting as if
+	// larger than any other value.
+	Nulls token.TokenType
+}
+
+// WindowFrame node represents the frame of a window, the ROWS or RANGE
+// BETWEEN Start AND End.
+type WindowFrame struct {
+	Mode  token.TokenType
+	Start FrameBound
+	End   FrameBound
+}
+
+// FrameBound node represents a bound of a window frame.
+type FrameBound struct {
+	// Type is PRECEDING, CURRENT for CURRENT ROW or FOLLOWING.
+	Type token.TokenType
+	// Offset is nil for UNBOUNDED PRECEDING and FOLLOWING.
+	Offset Expression
+}
 
-func (e *IdentExpr) expressionNode()     {}
-func (e *ScalarExpr) expressionNode()    {}
-func (e *AsteriskExpr) expressionNode()  {}
-func (e *ConditionExpr) expressionNode() {}
+func (e *IdentExpr) expressionNode()      {}
+func (e *ScalarExpr) expressionNode()     {}
+func (e *AsteriskExpr) expressionNode()   {}
+func (e *ConditionExpr) expressionNode()  {}
+func (e *UnaryExpr) expressionNode()      {}
+func (e *DefaultExpr) expressionNode()    {}
+func (e *CallExpr) expressionNode()       {}
+func (e *IsNullExpr) expressionNode()     {}
+func (e *IsDistinctExpr) expressionNode() {}
+func (e *SubqueryExpr) expressionNode()   {}
+func (e *ExistsExpr) expressionNode()     {}
+func (e *InExpr) expressionNode()         {}
+func (e *BetweenExpr) expressionNode()    {}
+func (e *LikeExpr) expressionNode()       {}
+func (e *CaseExpr) expressionNode()       {}
+func (e *CastExpr) expressionNode()       {}
+func (e *AtTimeZoneExpr) expressionNode() {}
+func (e *WindowExpr) expressionNode()     {}
 
 type InsertStatement struct {
+	With    *WithStatement
 	Table   string
 	Columns []string
-	Values  []Expression
+	// Values are the rows of values of VALUES (...), (...).
+	Values [][]Expression
+	// DefaultValues inserts a row of default values (INSERT ... DEFAULT VALUES).
+	DefaultValues bool
 }
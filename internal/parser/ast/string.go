@@ -0,0 +1,381 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/okazaki-kk/miniDB/internal/parser/token"
+)
+
+// String returns the SQL text of the identifier.
+func (e *IdentExpr) String() string {
+	if e.Table != "" {
+		return e.Table + "." + e.Name
+	}
+	return e.Name
+}
+
+// String returns the SQL text of the literal.
+func (e *ScalarExpr) String() string {
+	switch e.Type {
+	case token.TEXT:
+		return "'" + e.Literal + "'"
+	case token.TRUE, token.FALSE, token.NULL:
+		return strings.ToLower(e.Literal)
+	default:
+		return e.Literal
+	}
+}
+
+func (e *AsteriskExpr) String() string {
+	if e.Table != "" {
+		return e.Table + ".*"
+	}
+	return "*"
+}
+
+func (e *DefaultExpr) String() string {
+	return "DEFAULT"
+}
+
+// String returns the SQL text of the call.
+func (e *CallExpr) String() string {
+	args := make([]string, 0, len(e.Args))
+	for _, arg := range e.Args {
+		args = append(args, arg.String())
+	}
+
+	if e.Distinct {
+		return e.Name + "(DISTINCT " + strings.Join(args, ", ") + ")"
+	}
+	return e.Name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// String returns the SQL text of the unary expression.
+func (e *UnaryExpr) String() string {
+	if e.Operator == token.NOT {
+		return "NOT " + operand(e.Operand)
+	}
+	return string(e.Operator) + e.Operand.String()
+}
+
+// String returns the SQL text of the expression. Nested operations are
+// parenthesized, so the text parses back into the same tree.
+func (e *ConditionExpr) String() string {
+	return operand(e.Left) + " " + string(e.Operator) + " " + operand(e.Right)
+}
+
+// String returns the SQL text of the predicate.
+func (e *IsNullExpr) String() string {
+	if e.Not {
+		return operand(e.Expr) + " IS NOT NULL"
+	}
+	return operand(e.Expr) + " IS NULL"
+}
+
+// String returns the SQL text of the predicate.
+func (e *IsDistinctExpr) String() string {
+	if e.Not {
+		return operand(e.Left) + " IS NOT DISTINCT FROM " + operand(e.Right)
+	}
+	return operand(e.Left) + " IS DISTINCT FROM " + operand(e.Right)
+}
+
+// String returns the SQL text of the subquery.
+func (e *SubqueryExpr) String() string {
+	return "(" + e.Select.String() + ")"
+}
+
+// String returns the SQL text of the predicate.
+func (e *ExistsExpr) String() string {
+	return "EXISTS (" + e.Select.String() + ")"
+}
+
+// String returns the SQL text of the predicate.
+func (e *InExpr) String() string {
+	text := operand(e.Expr)
+	if e.Not {
+		text += " NOT"
+	}
+
+	if e.Select != nil {
+		return text + " IN (" + e.Select.String() + ")"
+	}
+
+	values := make([]string, 0, len(e.List))
+	for _, value := range e.List {
+		values = append(values, value.String())
+	}
+	return text + " IN (" + strings.Join(values, ", ") + ")"
+}
+
+// String returns the SQL text of the predicate.
+func (e *BetweenExpr) String() string {
+	text := operand(e.Expr)
+	if e.Not {
+		text += " NOT"
+	}
+	return text + " BETWEEN " + operand(e.Low) + " AND " + operand(e.High)
+}
+
+// String returns the SQL text of the predicate.
+func (e *LikeExpr) String() string {
+	text := operand(e.Expr)
+	if e.Not {
+		text += " NOT"
+	}
+
+	text += " " + string(e.Operator) + " " + operand(e.Pattern)
+	if e.Escape != nil {
+		text += " ESCAPE " + operand(e.Escape)
+	}
+	return text
+}
+
+// String returns the SQL text of the CASE expression.
+func (e *CaseExpr) String() string {
+	var b strings.Builder
+
+	b.WriteString("CASE")
+	if e.Operand != nil {
+		b.WriteString(" " + e.Operand.String())
+	}
+
+	for _, when := range e.Whens {
+		b.WriteString(" WHEN " + when.Cond.String() + " THEN " + when.Result.String())
+	}
+
+	if e.Else != nil {
+		b.WriteString(" ELSE " + e.Else.String())
+	}
+
+	b.WriteString(" END")
+	return b.String()
+}
+
+// String returns the SQL text of the conversion.
+func (e *CastExpr) String() string {
+	t := string(e.Type)
+	if e.Precision > 0 {
+		t += fmt.Sprintf("(%d, %d)", e.Precision, e.Scale)
+	}
+
+	return "CAST(" + e.Expr.String() + " AS " + t + ")"
+}
+
+// String returns the SQL text of the conversion.
+func (e *AtTimeZoneExpr) String() string {
+	return operand(e.Expr) + " AT TIME ZONE " + operand(e.Zone)
+}
+
+// String returns the SQL text of the window function call.
+func (e *WindowExpr) String() string {
+	var clauses []string
+
+	if len(e.PartitionBy) > 0 {
+		exprs := make([]string, 0, len(e.PartitionBy))
+		for _, expr := range e.PartitionBy {
+			exprs = append(exprs, expr.String())
+		}
+		clauses = append(clauses, "PARTITION BY "+strings.Join(exprs, ", "))
+	}
+
+	if len(e.OrderBy) > 0 {
+		keys := make([]string, 0, len(e.OrderBy))
+		for _, key := range e.OrderBy {
+			keys = append(keys, key.String())
+		}
+		clauses = append(clauses, "ORDER BY "+strings.Join(keys, ", "))
+	}
+
+	if e.Frame != nil {
+		clauses = append(clauses, string(e.Frame.Mode)+" BETWEEN "+e.Frame.Start.String()+" AND "+e.Frame.End.String())
+	}
+
+	return e.Call.String() + " OVER (" + strings.Join(clauses, " ") + ")"
+}
+
+// String returns the SQL text of the sort key.
+func (k SortKey) String() string {
+	text := k.Expr.String() + " " + string(k.Direction)
+	if k.Nulls != "" {
+		text += " NULLS " + string(k.Nulls)
+	}
+	return text
+}
+
+// String returns the SQL text of the frame bound.
+func (b FrameBound) String() string {
+	switch {
+	case b.Type == token.CURRENT:
+		return "CURRENT ROW"
+	case b.Offset == nil:
+		return "UNBOUNDED " + string(b.Type)
+	default:
+		return b.Offset.String() + " " + string(b.Type)
+	}
+}
+
+// String returns the SQL text of the query.
+func (s *SelectStatement) String() string {
+	var b strings.Builder
+
+	if s.With != nil {
+		b.WriteString(s.With.String() + " ")
+	}
+
+	if s.Operator != "" {
+		b.WriteString(setOperand(s.Left, setPrecedence(s.Operator)) + " " + string(s.Operator))
+		if s.All {
+			b.WriteString(" ALL")
+		}
+		b.WriteString(" " + setOperand(s.Right, setPrecedence(s.Operator)+1))
+	} else {
+		s.writeClauses(&b)
+	}
+
+	if s.OrderBy != nil {
+		keys := make([]string, 0, len(s.OrderBy.Keys))
+		for _, key := range s.OrderBy.Keys {
+			keys = append(keys, key.String())
+		}
+		b.WriteString(" ORDER BY " + strings.Join(keys, ", "))
+	}
+
+	if s.Limit != nil {
+		b.WriteString(" LIMIT " + s.Limit.Value.String())
+	}
+
+	if s.Offset != nil {
+		b.WriteString(" OFFSET " + s.Offset.Value.String())
+	}
+
+	return b.String()
+}
+
+// setOperand returns the SQL text of an operand of a set operation,
+// parenthesized if it has clauses of its own or binds looser than the
+// precedence.
+func setOperand(s *SelectStatement, precedence int) string {
+	if s.With != nil || s.OrderBy != nil || s.Limit != nil || s.Offset != nil ||
+		s.Operator != "" && setPrecedence(s.Operator) < precedence {
+		return "(" + s.String() + ")"
+	}
+
+	return s.String()
+}
+
+// setPrecedence returns the binding power of the set operation, INTERSECT
+// binds tighter than UNION and EXCEPT.
+func setPrecedence(operator token.TokenType) int {
+	if operator == token.INTERSECT {
+		return 2
+	}
+
+	return 1
+}
+
+// writeClauses writes the clauses of the query up to HAVING.
+func (s *SelectStatement) writeClauses(b *strings.Builder) {
+	b.WriteString("SELECT ")
+	if s.Distinct {
+		b.WriteString("DISTINCT ")
+	}
+	if len(s.DistinctOn) > 0 {
+		exprs := make([]string, 0, len(s.DistinctOn))
+		for _, expr := range s.DistinctOn {
+			exprs = append(exprs, expr.String())
+		}
+		b.WriteString("ON (" + strings.Join(exprs, ", ") + ") ")
+	}
+
+	for i, result := range s.Result {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(result.Expr.String())
+		if result.Alias != "" {
+			b.WriteString(" AS " + result.Alias)
+		}
+	}
+
+	if s.From != nil {
+		b.WriteString(" FROM ")
+		b.WriteString(tableRef(s.From.Table, s.From.Subquery, s.From.Alias))
+
+		for _, join := range s.From.Joins {
+			if join.Type == token.CROSS {
+				b.WriteString(" CROSS JOIN ")
+			} else {
+				b.WriteString(" " + string(join.Type) + " JOIN ")
+			}
+			b.WriteString(tableRef(join.Table, join.Subquery, join.Alias))
+
+			switch {
+			case join.On != nil:
+				b.WriteString(" ON " + join.On.String())
+			case len(join.Using) > 0:
+				b.WriteString(" USING (" + strings.Join(join.Using, ", ") + ")")
+			}
+		}
+	}
+
+	if s.Where != nil {
+		b.WriteString(" WHERE " + s.Where.Expr.String())
+	}
+
+	if s.GroupBy != nil {
+		keys := make([]string, 0, len(s.GroupBy.Exprs))
+		for _, expr := range s.GroupBy.Exprs {
+			keys = append(keys, expr.String())
+		}
+		b.WriteString(" GROUP BY " + strings.Join(keys, ", "))
+	}
+
+	if s.Having != nil {
+		b.WriteString(" HAVING " + s.Having.Expr.String())
+	}
+}
+
+// String returns the SQL text of the WITH clause.
+func (s *WithStatement) String() string {
+	var b strings.Builder
+
+	b.WriteString("WITH ")
+	if s.Recursive {
+		b.WriteString("RECURSIVE ")
+	}
+
+	for i, query := range s.Queries {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+
+		b.WriteString(query.Name)
+		if len(query.Columns) > 0 {
+			b.WriteString(" (" + strings.Join(query.Columns, ", ") + ")")
+		}
+		b.WriteString(" AS (" + query.Query.String() + ")")
+	}
+
+	return b.String()
+}
+
+func tableRef(table string, subquery *SelectStatement, alias string) string {
+	if subquery != nil {
+		table = "(" + subquery.String() + ")"
+	}
+
+	if alias != "" {
+		return table + " AS " + alias
+	}
+	return table
+}
+
+func operand(expr Expression) string {
+	switch expr.(type) {
+	case *ConditionExpr, *IsNullExpr, *IsDistinctExpr, *InExpr, *BetweenExpr, *LikeExpr, *AtTimeZoneExpr:
+		return "(" + expr.String() + ")"
+	}
+	return expr.String()
+}
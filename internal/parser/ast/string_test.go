@@ -0,0 +1,209 @@
+package ast
+
+import (
+	"testing"
+
+	"github.com/okazaki-kk/miniDB/internal/parser/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpression_String(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		expr     Expression
+		expected string
+	}{
+		{expr: &ScalarExpr{Type: token.TEXT, Literal: "Tom"}, expected: "'Tom'"},
+		{expr: &ScalarExpr{Type: token.TRUE, Literal: "TRUE"}, expected: "true"},
+		{expr: &UnaryExpr{Operator: token.MINUS, Operand: &ScalarExpr{Type: token.FLOAT, Literal: "1.5"}}, expected: "-1.5"},
+		{expr: &CallExpr{Name: "now"}, expected: "now()"},
+		{expr: &IdentExpr{Table: "a", Name: "code"}, expected: "a.code"},
+		{expr: &AsteriskExpr{Table: "a"}, expected: "a.*"},
+		{expr: &CallExpr{Name: "count", Args: []Expression{&IdentExpr{Name: "a"}}, Distinct: true}, expected: "count(DISTINCT a)"},
+		{
+			expr: &ConditionExpr{
+				Left: &ConditionExpr{
+					Left:     &IdentExpr{Name: "a"},
+					Operator: token.PLUS,
+					Right:    &ScalarExpr{Type: token.INT, Literal: "1"},
+				},
+				Operator: token.ASTERISK,
+				Right:    &IdentExpr{Name: "b"},
+			},
+			expected: "(a + 1) * b",
+		},
+		{
+			expr:     &IsNullExpr{Expr: &IdentExpr{Name: "a"}, Not: true},
+			expected: "a IS NOT NULL",
+		},
+		{
+			expr: &IsDistinctExpr{
+				Left:  &IsNullExpr{Expr: &IdentExpr{Name: "a"}},
+				Right: &ScalarExpr{Type: token.NULL, Literal: "NULL"},
+			},
+			expected: "(a IS NULL) IS DISTINCT FROM null",
+		},
+		{
+			expr: &UnaryExpr{
+				Operator: token.NOT,
+				Operand: &InExpr{
+					Expr: &IdentExpr{Name: "code"},
+					Not:  true,
+					Select: &SelectStatement{
+						Result: []ResultStatement{{Expr: &IdentExpr{Table: "f", Name: "departure"}}},
+						From: &FromStatement{
+							Table: "flights",
+							Alias: "f",
+							Joins: []JoinStatement{
+								{Type: token.LEFT, Table: "airports", Using: []string{"id"}},
+								{Type: token.CROSS, Subquery: &SelectStatement{Result: []ResultStatement{{Expr: &AsteriskExpr{}}}, From: &FromStatement{Table: "t"}}, Alias: "s"},
+							},
+						},
+						Where:   &WhereStatement{Expr: &ExistsExpr{Select: &SelectStatement{Result: []ResultStatement{{Expr: &ScalarExpr{Type: token.INT, Literal: "1"}}}}}},
+						GroupBy: &GroupByStatement{Exprs: []Expression{&IdentExpr{Name: "a"}, &IdentExpr{Name: "b"}}},
+						Having:  &HavingStatement{Expr: &SubqueryExpr{Select: &SelectStatement{Result: []ResultStatement{{Expr: &ScalarExpr{Type: token.TRUE, Literal: "TRUE"}}}}}},
+						OrderBy: &OrderByStatement{Keys: []SortKey{{Expr: &IdentExpr{Name: "a"}, Direction: token.DESC, Nulls: token.LAST}}},
+						Limit:   &LimitStatement{Value: &ScalarExpr{Type: token.INT, Literal: "10"}},
+						Offset:  &OffsetStatement{Value: &ScalarExpr{Type: token.INT, Literal: "5"}},
+					},
+				},
+			},
+			expected: "NOT (code NOT IN (SELECT f.departure FROM flights AS f LEFT JOIN airports USING (id) CROSS JOIN (SELECT * FROM t) AS s " +
+				"WHERE EXISTS (SELECT 1) GROUP BY a, b HAVING (SELECT true) ORDER BY a DESC NULLS LAST LIMIT 10 OFFSET 5))",
+		},
+		{
+			expr: &SubqueryExpr{
+				Select: &SelectStatement{
+					With: &WithStatement{
+						Recursive: true,
+						Queries: []CommonTableExpr{
+							{
+								Name:    "t",
+								Columns: []string{"n"},
+								Query: &SelectStatement{
+									Operator: token.UNION,
+									All:      true,
+									Left:     &SelectStatement{Result: []ResultStatement{{Expr: &ScalarExpr{Type: token.INT, Literal: "1"}}}},
+									Right:    &SelectStatement{Result: []ResultStatement{{Expr: &IdentExpr{Name: "n"}}}, From: &FromStatement{Table: "t"}},
+								},
+							},
+						},
+					},
+					Operator: token.UNION,
+					Left:     &SelectStatement{Result: []ResultStatement{{Expr: &IdentExpr{Name: "n"}}}, From: &FromStatement{Table: "t"}},
+					Right:    &SelectStatement{Result: []ResultStatement{{Expr: &ScalarExpr{Type: token.INT, Literal: "0"}}}},
+					Limit:    &LimitStatement{Value: &ScalarExpr{Type: token.INT, Literal: "1"}},
+				},
+			},
+			expected: "(WITH RECURSIVE t (n) AS (SELECT 1 UNION ALL SELECT n FROM t) SELECT n FROM t UNION SELECT 0 LIMIT 1)",
+		},
+		{
+			expr: &WindowExpr{
+				Call:        &CallExpr{Name: "lag", Args: []Expression{&IdentExpr{Name: "a"}}},
+				PartitionBy: []Expression{&IdentExpr{Name: "b"}},
+				OrderBy:     []SortKey{{Expr: &IdentExpr{Name: "c"}, Direction: token.DESC, Nulls: token.FIRST}},
+				Frame: &WindowFrame{
+					Mode:  token.ROWS,
+					Start: FrameBound{Type: token.PRECEDING},
+					End:   FrameBound{Type: token.FOLLOWING, Offset: &ScalarExpr{Type: token.INT, Literal: "1"}},
+				},
+			},
+			expected: "lag(a) OVER (PARTITION BY b ORDER BY c DESC NULLS FIRST ROWS BETWEEN UNBOUNDED PRECEDING AND 1 FOLLOWING)",
+		},
+		{
+			expr: &SubqueryExpr{
+				Select: &SelectStatement{
+					Operator: token.INTERSECT,
+					Left: &SelectStatement{
+						Operator: token.UNION,
+						Left:     &SelectStatement{Result: []ResultStatement{{Expr: &IdentExpr{Name: "a"}}}},
+						Right:    &SelectStatement{Result: []ResultStatement{{Expr: &IdentExpr{Name: "b"}}}},
+					},
+					Right: &SelectStatement{
+						Operator: token.EXCEPT,
+						All:      true,
+						Left:     &SelectStatement{Result: []ResultStatement{{Expr: &IdentExpr{Name: "c"}}}},
+						Right:    &SelectStatement{Result: []ResultStatement{{Expr: &IdentExpr{Name: "d"}}}, Limit: &LimitStatement{Value: &ScalarExpr{Type: token.INT, Literal: "1"}}},
+					},
+				},
+			},
+			expected: "((SELECT a UNION SELECT b) INTERSECT (SELECT c EXCEPT ALL (SELECT d LIMIT 1)))",
+		},
+		{
+			expr: &SubqueryExpr{
+				Select: &SelectStatement{
+					Distinct:   true,
+					DistinctOn: []Expression{&IdentExpr{Name: "a"}},
+					Result:     []ResultStatement{{Expr: &IdentExpr{Name: "a"}}, {Expr: &IdentExpr{Name: "b"}, Alias: "c"}},
+				},
+			},
+			expected: "(SELECT DISTINCT ON (a) a, b AS c)",
+		},
+		{
+			expr: &CaseExpr{
+				Operand: &IdentExpr{Name: "a"},
+				Whens: []WhenClause{
+					{Cond: &ScalarExpr{Type: token.INT, Literal: "1"}, Result: &ScalarExpr{Type: token.TEXT, Literal: "one"}},
+				},
+				Else: &CastExpr{Expr: &IdentExpr{Name: "a"}, Type: token.TEXT},
+			},
+			expected: "CASE a WHEN 1 THEN 'one' ELSE CAST(a AS TEXT) END",
+		},
+		{
+			expr: &ConditionExpr{
+				Left: &BetweenExpr{
+					Expr: &IdentExpr{Name: "a"},
+					Not:  true,
+					Low:  &ScalarExpr{Type: token.INT, Literal: "1"},
+					High: &ConditionExpr{Left: &IdentExpr{Name: "b"}, Operator: token.PLUS, Right: &ScalarExpr{Type: token.INT, Literal: "1"}},
+				},
+				Operator: token.OR,
+				Right: &LikeExpr{
+					Expr:     &IdentExpr{Name: "c"},
+					Operator: token.ILIKE,
+					Pattern:  &ScalarExpr{Type: token.TEXT, Literal: "a!%%"},
+					Escape:   &ScalarExpr{Type: token.TEXT, Literal: "!"},
+				},
+			},
+			expected: "(a NOT BETWEEN 1 AND (b + 1)) OR (c ILIKE 'a!%%' ESCAPE '!')",
+		},
+		{
+			expr:     &InExpr{Expr: &IdentExpr{Name: "a"}, List: []Expression{&ScalarExpr{Type: token.INT, Literal: "1"}, &IdentExpr{Name: "b"}}},
+			expected: "a IN (1, b)",
+		},
+		{
+			expr: &AtTimeZoneExpr{
+				Expr: &CastExpr{Expr: &ScalarExpr{Type: token.TEXT, Literal: "2024-03-01 04:05"}, Type: token.TIMESTAMPTZ},
+				Zone: &ScalarExpr{Type: token.TEXT, Literal: "Asia/Yakutsk"},
+			},
+			expected: "CAST('2024-03-01 04:05' AS TIMESTAMPTZ) AT TIME ZONE 'Asia/Yakutsk'",
+		},
+		{
+			expr:     &CastExpr{Expr: &IdentExpr{Name: "a"}, Type: token.NUMERIC, Precision: 10, Scale: 2},
+			expected: "CAST(a AS NUMERIC(10, 2))",
+		},
+		{
+			expr: &ConditionExpr{
+				Left: &ConditionExpr{
+					Left:     &IdentExpr{Name: "doc"},
+					Operator: token.ARROW,
+					Right:    &ScalarExpr{Type: token.TEXT, Literal: "tags"},
+				},
+				Operator: token.CONTAINS,
+				Right:    &CastExpr{Expr: &ScalarExpr{Type: token.TEXT, Literal: `["go"]`}, Type: token.JSONB},
+			},
+			expected: `(doc -> 'tags') @> CAST('["go"]' AS JSONB)`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.expected, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, test.expr.String())
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package lexer
 
 import (
+	"strings"
+
 	"github.com/okazaki-kk/miniDB/internal/parser/token"
 )
 
@@ -28,7 +30,30 @@ func (l *Lexer) NextToken() token.Token {
 	case '+':
 		tok = newToken(token.PLUS, l.ch)
 	case '-':
-		tok = newToken(token.MINUS, l.ch)
+		if l.peekChar() == '>' {
+			l.readChar()
+			tok = token.Token{Type: token.ARROW, Literal: "->"}
+			if l.peekChar() == '>' {
+				l.readChar()
+				tok = token.Token{Type: token.DOUBLE_ARROW, Literal: "->>"}
+			}
+		} else {
+			tok = newToken(token.MINUS, l.ch)
+		}
+	case '#':
+		if l.peekChar() == '>' {
+			l.readChar()
+			tok = token.Token{Type: token.HASH_ARROW, Literal: "#>"}
+		} else {
+			tok = newToken(token.ILLEGAL, l.ch)
+		}
+	case '@':
+		if l.peekChar() == '>' {
+			l.readChar()
+			tok = token.Token{Type: token.CONTAINS, Literal: "@>"}
+		} else {
+			tok = newToken(token.ILLEGAL, l.ch)
+		}
 	case '!':
 		if l.peekChar() == '=' {
 			ch := l.ch
@@ -38,6 +63,13 @@ func (l *Lexer) NextToken() token.Token {
 		} else {
 			tok = newToken(token.BANG, l.ch)
 		}
+	case ':':
+		if l.peekChar() == ':' {
+			l.readChar()
+			tok = token.Token{Type: token.DOUBLE_COLON, Literal: "::"}
+		} else {
+			tok = newToken(token.ILLEGAL, l.ch)
+		}
 	case '\'':
 		tok.Literal = l.readString()
 		tok.Type = token.TEXT
@@ -53,6 +85,8 @@ func (l *Lexer) NextToken() token.Token {
 		tok = newToken(token.SEMICOLON, l.ch)
 	case ',':
 		tok = newToken(token.COMMA, l.ch)
+	case '.':
+		tok = newToken(token.DOT, l.ch)
 	case '{':
 		tok = newToken(token.LBRACE, l.ch)
 	case '}':
@@ -70,8 +104,11 @@ func (l *Lexer) NextToken() token.Token {
 			tok.Type = token.LookupIdent(tok.Literal)
 			return tok
 		} else if isDigit(l.ch) {
-			tok.Type = token.INT
 			tok.Literal = l.readNumber()
+			tok.Type = token.INT
+			if strings.ContainsRune(tok.Literal, '.') {
+				tok.Type = token.FLOAT
+			}
 			return tok
 		} else {
 			tok = newToken(token.ILLEGAL, l.ch)
@@ -117,7 +154,7 @@ func (l *Lexer) readIdentifier() string {
 func (l *Lexer) readString() string {
 	l.readChar()
 	position := l.position
-	for l.ch != '\'' {
+	for l.ch != '\'' && l.ch != 0 {
 		l.readChar()
 	}
 	return l.input[position:l.position]
@@ -128,6 +165,12 @@ func (l *Lexer) readNumber() string {
 	for isDigit(l.ch) {
 		l.readChar()
 	}
+	if l.ch == '.' && isDigit(l.peekChar()) {
+		l.readChar()
+		for isDigit(l.ch) {
+			l.readChar()
+		}
+	}
 	return l.input[position:l.position]
 }
 
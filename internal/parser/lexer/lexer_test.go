@@ -40,6 +40,11 @@ func TestLexer_NextToken(t *testing.T) {
 			tokenType: token.COMMA,
 			literal:   ",",
 		},
+		{
+			input:     ".",
+			tokenType: token.DOT,
+			literal:   ".",
+		},
 		{
 			input:     ";",
 			tokenType: token.SEMICOLON,
@@ -115,11 +120,36 @@ func TestLexer_NextToken(t *testing.T) {
 			tokenType: token.SLASH,
 			literal:   "/",
 		},
+		{
+			input:     "->",
+			tokenType: token.ARROW,
+			literal:   "->",
+		},
+		{
+			input:     "->>",
+			tokenType: token.DOUBLE_ARROW,
+			literal:   "->>",
+		},
+		{
+			input:     "#>",
+			tokenType: token.HASH_ARROW,
+			literal:   "#>",
+		},
+		{
+			input:     "@>",
+			tokenType: token.CONTAINS,
+			literal:   "@>",
+		},
 		{
 			input:     "10",
 			tokenType: token.INT,
 			literal:   "10",
 		},
+		{
+			input:     "62.0932998657226562",
+			tokenType: token.FLOAT,
+			literal:   "62.0932998657226562",
+		},
 		{
 			input:     "'value'",
 			tokenType: token.TEXT,
@@ -240,6 +270,21 @@ func TestLexer_NextToken(t *testing.T) {
 			tokenType: token.NULL,
 			literal:   "NULL",
 		},
+		{
+			input:     "INTEGER",
+			tokenType: token.INT,
+			literal:   "INTEGER",
+		},
+		{
+			input:     "FLOAT",
+			tokenType: token.FLOAT,
+			literal:   "FLOAT",
+		},
+		{
+			input:     "ALTER",
+			tokenType: token.ALTER,
+			literal:   "ALTER",
+		},
 	}
 
 	for _, test := range tests {
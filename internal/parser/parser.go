@@ -3,6 +3,8 @@ package parser
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/okazaki-kk/miniDB/internal/parser/ast"
 	"github.com/okazaki-kk/miniDB/internal/parser/lexer"
@@ -23,8 +25,23 @@ func New(lx *lexer.Lexer) *Parser {
 	}
 }
 
+// Parse parses a single statement, which may be followed by a semicolon and
+// nothing else.
 func (p *Parser) Parse() (ast.Statement, error) {
-	return p.parseStatement()
+	stmt, err := p.parseStatement()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.token.Type == token.SEMICOLON {
+		p.nextToken()
+	}
+
+	if p.token.Type != token.EOF {
+		return nil, fmt.Errorf("syntax error at or near %q", p.token.Literal)
+	}
+
+	return stmt, nil
 }
 
 func (p *Parser) nextToken() {
@@ -35,7 +52,9 @@ func (p *Parser) nextToken() {
 func (p *Parser) parseStatement() (ast.Statement, error) {
 	switch p.token.Type {
 	// DML
-	case token.SELECT:
+	case token.WITH:
+		return p.parseWithStatement()
+	case token.SELECT, token.LPAREN:
 		return p.parseSelectStatement()
 	case token.INSERT:
 		return p.parseInsertStatement()
@@ -45,17 +64,143 @@ func (p *Parser) parseStatement() (ast.Statement, error) {
 		return p.parseDeleteStatement()
 	case token.DROP:
 		return p.parseDropStatement()
+	case token.TRUNCATE:
+		return p.parseTruncateStatement()
 	case token.CREATE:
 		p.nextToken()
 		return p.parseCreateStatement()
+	case token.ALTER:
+		return p.parseAlterStatement()
+	case token.USE:
+		return p.parseUseStatement()
+	case token.SHOW:
+		return p.parseShowStatement()
+	case token.DESCRIBE, token.DESC:
+		return p.parseDescribeStatement()
 	default:
 		return nil, fmt.Errorf("unexpected statement: %s(%q)", p.token.Type, p.token.Literal)
 	}
 }
 
+// setPrecedences are the binding powers of the set operations, INTERSECT
+// binds tighter than UNION and EXCEPT.
+var setPrecedences = map[token.TokenType]int{
+	token.UNION:     1,
+	token.EXCEPT:    1,
+	token.INTERSECT: 2,
+}
+
+// parseSelectStatement parses a SELECT statement, which may combine the
+// results of several queries with set operations.
 func (p *Parser) parseSelectStatement() (ast.Statement, error) {
+	return p.parseQuery()
+}
+
+// parseQuery parses the queries joined by set operations, followed by the
+// ORDER BY, LIMIT and OFFSET clauses of the combined result.
+func (p *Parser) parseQuery() (*ast.SelectStatement, error) {
+	stmt, err := p.parseSetOperation(0)
+	if err != nil {
+		return nil, err
+	}
+
+	orderBy, err := p.parseOrderByStatement()
+	if err != nil {
+		return nil, err
+	}
+	if orderBy != nil {
+		if stmt.OrderBy != nil {
+			return nil, fmt.Errorf("multiple ORDER BY clauses not allowed")
+		}
+		stmt.OrderBy = orderBy
+	}
+
+	limit, err := p.parseLimitStatement()
+	if err != nil {
+		return nil, err
+	}
+	if limit != nil {
+		if stmt.Limit != nil {
+			return nil, fmt.Errorf("multiple LIMIT clauses not allowed")
+		}
+		stmt.Limit = limit
+	}
+
+	offset, err := p.parseOffsetStatement()
+	if err != nil {
+		return nil, err
+	}
+	if offset != nil {
+		if stmt.Offset != nil {
+			return nil, fmt.Errorf("multiple OFFSET clauses not allowed")
+		}
+		stmt.Offset = offset
+	}
+
+	return stmt, nil
+}
+
+// parseSetOperation parses the queries joined by set operations binding
+// tighter than the precedence, combining them from left to right.
+func (p *Parser) parseSetOperation(precedence int) (*ast.SelectStatement, error) {
+	left, err := p.parseSetOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		operator := p.token.Type
+
+		next, ok := setPrecedences[operator]
+		if !ok || next <= precedence {
+			return left, nil
+		}
+
+		p.nextToken()
+
+		all := p.token.Type == token.ALL
+		if all || p.token.Type == token.DISTINCT {
+			p.nextToken()
+		}
+
+		right, err := p.parseSetOperation(next)
+		if err != nil {
+			return nil, err
+		}
+
+		left = &ast.SelectStatement{Operator: operator, All: all, Left: left, Right: right}
+	}
+}
+
+// parseSetOperand parses a query up to its HAVING clause or a parenthesized
+// query, leaving the token after it as the current token.
+func (p *Parser) parseSetOperand() (*ast.SelectStatement, error) {
+	switch p.token.Type {
+	case token.SELECT:
+		return p.parseSelectClauses()
+	case token.LPAREN:
+		query, err := p.parseSubquery()
+		if err != nil {
+			return nil, err
+		}
+
+		p.nextToken()
+		return query, nil
+	default:
+		return nil, fmt.Errorf("expected %q but found %q", token.SELECT, p.token.Type)
+	}
+}
+
+// parseSelectClauses parses SELECT up to the HAVING clause.
+func (p *Parser) parseSelectClauses() (*ast.SelectStatement, error) {
 	p.nextToken()
 
+	var selectStmt ast.SelectStatement
+
+	if err := p.parseDistinct(&selectStmt); err != nil {
+		return nil, err
+	}
+
 	result, err := p.parseResultStatement()
 	if err != nil {
 		return nil, err
@@ -71,31 +216,114 @@ func (p *Parser) parseSelectStatement() (ast.Statement, error) {
 		return nil, err
 	}
 
-	order, err := p.parseOrderByStatement()
+	groupBy, err := p.parseGroupByStatement()
 	if err != nil {
 		return nil, err
 	}
 
-	limit, err := p.parseLimitStatement()
+	having, err := p.parseHavingStatement()
 	if err != nil {
 		return nil, err
 	}
 
-	offset, err := p.parseOffsetStatement()
+	selectStmt.Result = result
+	selectStmt.From = from
+	selectStmt.Where = where
+	selectStmt.GroupBy = groupBy
+	selectStmt.Having = having
+
+	return &selectStmt, nil
+}
+
+// parseWithStatement parses a WITH clause and the SELECT, INSERT, UPDATE or
+// DELETE statement following it.
+func (p *Parser) parseWithStatement() (ast.Statement, error) {
+	with, err := p.parseWith()
 	if err != nil {
 		return nil, err
 	}
 
-	selectStmt := ast.SelectStatement{
-		Result:  result,
-		From:    from,
-		Where:   where,
-		OrderBy: order,
-		Limit:   limit,
-		Offset:  offset,
+	switch p.token.Type {
+	case token.SELECT, token.LPAREN:
+		stmt, err := p.parseSelectStatement()
+		if err != nil {
+			return nil, err
+		}
+		stmt.(*ast.SelectStatement).With = with
+		return stmt, nil
+	case token.INSERT:
+		stmt, err := p.parseInsertStatement()
+		if err != nil {
+			return nil, err
+		}
+		stmt.(*ast.InsertStatement).With = with
+		return stmt, nil
+	case token.UPDATE:
+		stmt, err := p.parseUpdateStatement()
+		if err != nil {
+			return nil, err
+		}
+		stmt.(*ast.UpdateStatement).With = with
+		return stmt, nil
+	case token.DELETE:
+		stmt, err := p.parseDeleteStatement()
+		if err != nil {
+			return nil, err
+		}
+		stmt.(*ast.DeleteStatement).With = with
+		return stmt, nil
+	default:
+		return nil, fmt.Errorf("expected SELECT, INSERT, UPDATE or DELETE but found %q", p.token.Type)
+	}
+}
+
+// parseWith parses the WITH clause, leaving the token after it as the
+// current token.
+func (p *Parser) parseWith() (*ast.WithStatement, error) {
+	p.nextToken()
+
+	with := ast.WithStatement{}
+
+	// RECURSIVE is a valid name of the first query too.
+	if p.token.Type == token.RECURSIVE && p.peekToken.Type != token.AS && p.peekToken.Type != token.LPAREN {
+		with.Recursive = true
+		p.nextToken()
 	}
 
-	return &selectStmt, nil
+	for {
+		name, err := p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+
+		query := ast.CommonTableExpr{Name: name.Name}
+
+		if p.token.Type == token.LPAREN {
+			if query.Columns, err = p.parseColumnsStatement(); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := p.expect(token.AS); err != nil {
+			return nil, err
+		}
+
+		if query.Query, err = p.parseSubquery(); err != nil {
+			return nil, err
+		}
+
+		p.nextToken()
+
+		with.Queries = append(with.Queries, query)
+
+		if p.token.Type != token.COMMA {
+			break
+		}
+
+		p.nextToken()
+	}
+
+	return &with, nil
 }
 
 func (p *Parser) parseInsertStatement() (ast.Statement, error) {
@@ -110,20 +338,31 @@ func (p *Parser) parseInsertStatement() (ast.Statement, error) {
 		return nil, err
 	}
 
-	columns, err := p.parseColumnsStatement()
-	if err != nil {
-		return nil, err
+	insert := ast.InsertStatement{
+		Table: table.Name,
 	}
 
-	values, err := p.parseValuesStatement()
-	if err != nil {
-		return nil, err
+	// INSERT INTO name DEFAULT VALUES
+	if p.token.Type == token.DEFAULT {
+		p.nextToken()
+
+		if err := p.expect(token.VALUES); err != nil {
+			return nil, err
+		}
+
+		insert.DefaultValues = true
+
+		return &insert, nil
 	}
 
-	insert := ast.InsertStatement{
-		Table:   table.Name,
-		Columns: columns,
-		Values:  values,
+	if p.token.Type == token.LPAREN {
+		if insert.Columns, err = p.parseColumnsStatement(); err != nil {
+			return nil, err
+		}
+	}
+
+	if insert.Values, err = p.parseValuesStatement(); err != nil {
+		return nil, err
 	}
 
 	return &insert, nil
@@ -176,6 +415,8 @@ func (p *Parser) parseCreateStatement() (ast.Statement, error) {
 		return p.parseCreateTableStatement()
 	case token.DATABASE:
 		return p.parseCreateDatabaseStatement()
+	case token.INDEX:
+		return p.parseCreateIndexStatement()
 	default:
 		return nil, fmt.Errorf("unexpected statement: %s(%q)", p.token.Type, p.token.Literal)
 	}
@@ -183,19 +424,27 @@ func (p *Parser) parseCreateStatement() (ast.Statement, error) {
 
 func (p *Parser) parseCreateTableStatement() (ast.Statement, error) {
 	p.nextToken()
+
+	ifNotExists, err := p.parseIfNotExists()
+	if err != nil {
+		return nil, err
+	}
+
 	table, err := p.parseIdent()
 	if err != nil {
 		return nil, err
 	}
 
-	columns, err := p.parseColumns()
+	columns, constraints, err := p.parseColumns()
 	if err != nil {
 		return nil, err
 	}
 
 	create := ast.CreateTableStatement{
-		Table:   table.Name,
-		Columns: columns,
+		Table:       table.Name,
+		Columns:     columns,
+		Constraints: constraints,
+		IfNotExists: ifNotExists,
 	}
 
 	return &create, nil
@@ -204,377 +453,2131 @@ func (p *Parser) parseCreateTableStatement() (ast.Statement, error) {
 func (p *Parser) parseCreateDatabaseStatement() (ast.Statement, error) {
 	p.nextToken()
 
+	ifNotExists, err := p.parseIfNotExists()
+	if err != nil {
+		return nil, err
+	}
+
 	database, err := p.parseIdent()
 	if err != nil {
 		return nil, err
 	}
 
 	create := ast.CreateDatabaseStatement{
-		Database: database.Name,
+		Database:    database.Name,
+		IfNotExists: ifNotExists,
 	}
 
 	return &create, nil
 }
 
-func (p *Parser) parseDropStatement() (ast.Statement, error) {
+// parseCreateIndexStatement parses CREATE INDEX [IF NOT EXISTS] name ON
+// table (expr), indexing a single column or expression.
+func (p *Parser) parseCreateIndexStatement() (ast.Statement, error) {
 	p.nextToken()
 
-	if err := p.expect(token.DATABASE); err != nil {
+	ifNotExists, err := p.parseIfNotExists()
+	if err != nil {
 		return nil, err
 	}
 
-	database, err := p.parseIdent()
+	index, err := p.parseIdent()
 	if err != nil {
 		return nil, err
 	}
 
-	return &ast.DropDatabaseStatement{Database: database.Name}, nil
-}
-
-func (p *Parser) parseColumns() ([]ast.Column, error) {
-	if p.token.Literal != "(" {
-		return nil, fmt.Errorf("expected (, got %q", p.token.Literal)
+	if err := p.expect(token.ON); err != nil {
+		return nil, err
 	}
 
-	p.nextToken()
+	table, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
 
-	columns := make([]ast.Column, 0)
+	if err := p.expect(token.LPAREN); err != nil {
+		return nil, err
+	}
 
-	for p.token.Type != token.EOF && p.token.Type != token.RPAREN {
-		if p.token.Type == token.COMMA {
-			p.nextToken()
-		}
+	expr, err := p.parseExpr(LOWEST)
+	if err != nil {
+		return nil, err
+	}
 
-		column, err := p.parseColumn()
-		if err != nil {
-			return nil, err
-		}
+	p.nextToken()
 
-		columns = append(columns, column)
+	if err := p.expect(token.RPAREN); err != nil {
+		return nil, err
 	}
 
-	if p.token.Literal != ")" {
-		return nil, fmt.Errorf("expected (, got %q", p.token.Literal)
+	create := ast.CreateIndexStatement{
+		Index:       index.Name,
+		Table:       table.Name,
+		Expr:        expr,
+		IfNotExists: ifNotExists,
 	}
+
+	return &create, nil
+}
+
+func (p *Parser) parseDropStatement() (ast.Statement, error) {
 	p.nextToken()
 
-	return columns, nil
+	switch p.token.Type {
+	case token.DATABASE:
+		return p.parseDropDatabaseStatement()
+	case token.TABLE:
+		return p.parseDropTableStatement()
+	case token.INDEX:
+		return p.parseDropIndexStatement()
+	default:
+		return nil, fmt.Errorf("unexpected statement: DROP %s(%q)", p.token.Type, p.token.Literal)
+	}
 }
 
-func (p *Parser) parseColumn() (ast.Column, error) {
-	columnName, err := p.parseIdent()
+func (p *Parser) parseDropDatabaseStatement() (ast.Statement, error) {
+	p.nextToken()
+
+	ifExists, err := p.parseIfExists()
 	if err != nil {
-		return ast.Column{}, err
+		return nil, err
 	}
 
-	columnType, err := p.parseColumnType()
+	database, err := p.parseIdent()
 	if err != nil {
-		return ast.Column{}, err
+		return nil, err
 	}
 
-	column := ast.Column{
-		Name: columnName.Name,
-		Type: columnType,
+	drop := ast.DropDatabaseStatement{
+		Database: database.Name,
+		IfExists: ifExists,
 	}
 
-	return column, nil
-}
-
-func (p *Parser) parseColumnType() (token.TokenType, error) {
-	fmt.Println(p.token.Type, p.token.Literal)
-	switch p.token.Type {
-	case token.INT, token.TEXT, token.TRUE, token.FALSE:
-		columnType := p.token.Type
+	// DROP DATABASE name [WITH] (FORCE)
+	if p.token.Type == token.WITH {
 		p.nextToken()
-
-		return columnType, nil
 	}
 
-	return "", fmt.Errorf("unexpected column type: %q", p.token.Type)
-}
-
-func (p *Parser) parseResultStatement() ([]ast.ResultStatement, error) {
-	var results []ast.ResultStatement
+	if p.token.Type == token.LPAREN {
+		p.nextToken()
 
-	for p.token.Type != token.EOF && p.token.Type != token.FROM {
-		result, err := p.parseResult()
-		if err != nil {
+		if err := p.expect(token.FORCE); err != nil {
 			return nil, err
 		}
 
-		results = append(results, result)
-
-		if p.token.Type == token.EOF || p.token.Type == token.FROM {
-			break
+		if err := p.expect(token.RPAREN); err != nil {
+			return nil, err
 		}
 
-		p.nextToken()
-	}
-
-	if len(results) == 0 {
-		return nil, fmt.Errorf("no columns specified")
+		drop.Force = true
 	}
 
-	return results, nil
+	return &drop, nil
 }
 
-func (p *Parser) parseResult() (ast.ResultStatement, error) {
-	var (
-		result ast.ResultStatement
-		err    error
-	)
+func (p *Parser) parseDropIndexStatement() (ast.Statement, error) {
+	p.nextToken()
 
-	result.Expr, err = p.parsePrimaryExpr()
+	ifExists, err := p.parseIfExists()
 	if err != nil {
-		return ast.ResultStatement{}, err
+		return nil, err
 	}
 
-	return result, nil
-}
+	index, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
 
-func (p *Parser) parseFromStatement() (*ast.FromStatement, error) {
-	if p.token.Type != token.FROM {
-		return nil, nil
+	drop := ast.DropIndexStatement{
+		Index:    index.Name,
+		IfExists: ifExists,
 	}
 
+	return &drop, nil
+}
+
+func (p *Parser) parseDropTableStatement() (ast.Statement, error) {
 	p.nextToken()
 
-	table, err := p.parseIdent()
+	ifExists, err := p.parseIfExists()
 	if err != nil {
 		return nil, err
 	}
 
-	from := ast.FromStatement{
-		Table: table.Name,
+	tables, err := p.parseTableNames()
+	if err != nil {
+		return nil, err
 	}
 
-	return &from, nil
+	drop := ast.DropTableStatement{
+		Tables:   tables,
+		IfExists: ifExists,
+	}
+
+	switch p.token.Type {
+	case token.CASCADE:
+		drop.Cascade = true
+		p.nextToken()
+	case token.RESTRICT:
+		p.nextToken()
+	}
+
+	return &drop, nil
+}
+
+func (p *Parser) parseTruncateStatement() (ast.Statement, error) {
+	p.nextToken()
+
+	if p.token.Type == token.TABLE {
+		p.nextToken()
+	}
+
+	tables, err := p.parseTableNames()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.TruncateStatement{Tables: tables}, nil
+}
+
+// parseTableNames parses a comma separated list of table names.
+func (p *Parser) parseTableNames() ([]string, error) {
+	var tables []string
+
+	for {
+		table, err := p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+
+		tables = append(tables, table.Name)
+
+		if p.token.Type != token.COMMA {
+			return tables, nil
+		}
+
+		p.nextToken()
+	}
+}
+
+// parseIfExists parses an optional IF EXISTS clause.
+func (p *Parser) parseIfExists() (bool, error) {
+	if p.token.Type != token.IF {
+		return false, nil
+	}
+
+	p.nextToken()
+
+	if err := p.expect(token.EXISTS); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// parseIfNotExists parses an optional IF NOT EXISTS clause.
+func (p *Parser) parseIfNotExists() (bool, error) {
+	if p.token.Type != token.IF {
+		return false, nil
+	}
+
+	p.nextToken()
+
+	if err := p.expect(token.NOT); err != nil {
+		return false, err
+	}
+
+	if err := p.expect(token.EXISTS); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (p *Parser) parseAlterStatement() (ast.Statement, error) {
+	p.nextToken()
+
+	if err := p.expect(token.TABLE); err != nil {
+		return nil, err
+	}
+
+	table, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	alter := ast.AlterTableStatement{Table: table.Name}
+
+	for {
+		action, err := p.parseAlterTableAction()
+		if err != nil {
+			return nil, err
+		}
+
+		alter.Actions = append(alter.Actions, action)
+
+		if p.token.Type != token.COMMA {
+			return &alter, nil
+		}
+
+		p.nextToken()
+	}
+}
+
+func (p *Parser) parseAlterTableAction() (ast.AlterTableAction, error) {
+	switch p.token.Type {
+	case token.ADD:
+		p.nextToken()
+
+		if isTableConstraint(p.token.Type) {
+			constraint, err := p.parseTableConstraint()
+			if err != nil {
+				return nil, err
+			}
+
+			return &ast.AddConstraintAction{Constraint: constraint}, nil
+		}
+
+		p.skip(token.COLUMN)
+
+		column, err := p.parseColumn()
+		if err != nil {
+			return nil, err
+		}
+
+		return &ast.AddColumnAction{Column: column}, nil
+	case token.DROP:
+		p.nextToken()
+
+		if p.token.Type == token.CONSTRAINT {
+			p.nextToken()
+
+			name, err := p.parseIdent()
+			if err != nil {
+				return nil, err
+			}
+
+			return &ast.DropConstraintAction{Name: name.Name}, nil
+		}
+
+		p.skip(token.COLUMN)
+
+		column, err := p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+
+		return &ast.DropColumnAction{Column: column.Name}, nil
+	case token.RENAME:
+		p.nextToken()
+
+		if p.token.Type == token.TO {
+			p.nextToken()
+
+			name, err := p.parseIdent()
+			if err != nil {
+				return nil, err
+			}
+
+			return &ast.RenameTableAction{NewName: name.Name}, nil
+		}
+
+		p.skip(token.COLUMN)
+
+		column, err := p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.expect(token.TO); err != nil {
+			return nil, err
+		}
+
+		name, err := p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+
+		return &ast.RenameColumnAction{Column: column.Name, NewName: name.Name}, nil
+	case token.ALTER:
+		p.nextToken()
+		p.skip(token.COLUMN)
+
+		column, err := p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+
+		return p.parseAlterColumnAction(column.Name)
+	default:
+		return nil, fmt.Errorf("unexpected statement: ALTER TABLE %s(%q)", p.token.Type, p.token.Literal)
+	}
+}
+
+// parseAlterColumnAction parses the change of ALTER TABLE ... ALTER COLUMN.
+func (p *Parser) parseAlterColumnAction(column string) (ast.AlterTableAction, error) {
+	switch p.token.Type {
+	case token.SET, token.DROP:
+		notNull := p.token.Type == token.SET
+		p.nextToken()
+
+		if err := p.expect(token.NOT); err != nil {
+			return nil, err
+		}
+
+		if err := p.expect(token.NULL); err != nil {
+			return nil, err
+		}
+
+		return &ast.SetNotNullAction{Column: column, NotNull: notNull}, nil
+	case token.TYPE:
+		p.nextToken()
+
+		columnType, precision, scale, err := p.parseColumnType()
+		if err != nil {
+			return nil, err
+		}
+
+		return &ast.AlterColumnTypeAction{Column: column, Type: columnType, Precision: precision, Scale: scale}, nil
+	default:
+		return nil, fmt.Errorf("unexpected statement: ALTER COLUMN %s(%q)", p.token.Type, p.token.Literal)
+	}
+}
+
+func (p *Parser) parseUseStatement() (ast.Statement, error) {
+	p.nextToken()
+
+	database, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.UseStatement{Database: database.Name}, nil
+}
+
+func (p *Parser) parseShowStatement() (ast.Statement, error) {
+	p.nextToken()
+
+	switch p.token.Type {
+	case token.DATABASES:
+		p.nextToken()
+		return &ast.ShowDatabasesStatement{}, nil
+	case token.TABLES:
+		p.nextToken()
+		return &ast.ShowTablesStatement{}, nil
+	case token.FUNCTIONS:
+		p.nextToken()
+		return &ast.ShowFunctionsStatement{}, nil
+	case token.COLUMNS:
+		p.nextToken()
+
+		if err := p.expect(token.FROM); err != nil {
+			return nil, err
+		}
+
+		table, err := p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+
+		return &ast.ShowColumnsStatement{Table: table.Name}, nil
+	case token.CREATE:
+		p.nextToken()
+
+		if err := p.expect(token.TABLE); err != nil {
+			return nil, err
+		}
+
+		table, err := p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+
+		return &ast.ShowCreateTableStatement{Table: table.Name}, nil
+	default:
+		return nil, fmt.Errorf("unexpected statement: SHOW %s(%q)", p.token.Type, p.token.Literal)
+	}
+}
+
+func (p *Parser) parseDescribeStatement() (ast.Statement, error) {
+	p.nextToken()
+
+	table, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.ShowColumnsStatement{Table: table.Name}, nil
+}
+
+// parseColumns parses the column definitions and table constraints of
+// CREATE TABLE.
+func (p *Parser) parseColumns() ([]ast.Column, []ast.Constraint, error) {
+	if p.token.Literal != "(" {
+		return nil, nil, fmt.Errorf("expected (, got %q", p.token.Literal)
+	}
+
+	p.nextToken()
+
+	columns := make([]ast.Column, 0)
+	var constraints []ast.Constraint
+
+	for p.token.Type != token.EOF && p.token.Type != token.RPAREN {
+		if p.token.Type == token.COMMA {
+			p.nextToken()
+		}
+
+		if isTableConstraint(p.token.Type) {
+			constraint, err := p.parseTableConstraint()
+			if err != nil {
+				return nil, nil, err
+			}
+
+			constraints = append(constraints, constraint)
+			continue
+		}
+
+		column, err := p.parseColumn()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		columns = append(columns, column)
+	}
+
+	if p.token.Literal != ")" {
+		return nil, nil, fmt.Errorf("expected (, got %q", p.token.Literal)
+	}
+	p.nextToken()
+
+	return columns, constraints, nil
+}
+
+func isTableConstraint(t token.TokenType) bool {
+	return t == token.CONSTRAINT || t == token.UNIQUE || t == token.CHECK || t == token.FOREIGN
+}
+
+// parseTableConstraint parses a constraint on columns of the table, like
+// UNIQUE (a, b), CHECK (expr) or FOREIGN KEY (a) REFERENCES t (b).
+func (p *Parser) parseTableConstraint() (ast.Constraint, error) {
+	var (
+		constraint ast.Constraint
+		err        error
+	)
+
+	if p.token.Type == token.CONSTRAINT {
+		p.nextToken()
+
+		name, err := p.parseIdent()
+		if err != nil {
+			return ast.Constraint{}, err
+		}
+
+		constraint.Name = name.Name
+	}
+
+	constraint.Type = p.token.Type
+
+	switch p.token.Type {
+	case token.UNIQUE:
+		p.nextToken()
+
+		if constraint.Columns, err = p.parseColumnsStatement(); err != nil {
+			return ast.Constraint{}, err
+		}
+	case token.CHECK:
+		if constraint.Check, err = p.parseCheck(); err != nil {
+			return ast.Constraint{}, err
+		}
+	case token.FOREIGN:
+		p.nextToken()
+
+		if err := p.expect(token.KEY); err != nil {
+			return ast.Constraint{}, err
+		}
+
+		if constraint.Columns, err = p.parseColumnsStatement(); err != nil {
+			return ast.Constraint{}, err
+		}
+
+		if constraint.References, err = p.parseReferences(); err != nil {
+			return ast.Constraint{}, err
+		}
+	default:
+		return ast.Constraint{}, fmt.Errorf("unexpected constraint: %s(%q)", p.token.Type, p.token.Literal)
+	}
+
+	return constraint, nil
+}
+
+// parseCheck parses CHECK (expr).
+func (p *Parser) parseCheck() (ast.Expression, error) {
+	p.nextToken()
+
+	if p.token.Type != token.LPAREN {
+		return nil, fmt.Errorf("expected %q but found %q", token.LPAREN, p.token.Type)
+	}
+
+	expr, err := p.parseExpr(LOWEST)
+	if err != nil {
+		return nil, err
+	}
+
+	p.nextToken()
+
+	return expr, nil
+}
+
+// parseReferences parses REFERENCES table [(columns)] followed by the
+// optional ON DELETE and ON UPDATE actions.
+func (p *Parser) parseReferences() (*ast.Reference, error) {
+	if err := p.expect(token.REFERENCES); err != nil {
+		return nil, err
+	}
+
+	table, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	reference := ast.Reference{
+		Table:    table.Name,
+		OnDelete: token.RESTRICT,
+		OnUpdate: token.RESTRICT,
+	}
+
+	if p.token.Type == token.LPAREN {
+		if reference.Columns, err = p.parseColumnsStatement(); err != nil {
+			return nil, err
+		}
+	}
+
+	for p.token.Type == token.ON {
+		p.nextToken()
+
+		event := p.token.Type
+		if event != token.DELETE && event != token.UPDATE {
+			return nil, fmt.Errorf("expected DELETE or UPDATE but found %q", p.token.Literal)
+		}
+
+		p.nextToken()
+
+		var action token.TokenType
+
+		switch p.token.Type {
+		case token.CASCADE, token.RESTRICT:
+			action = p.token.Type
+			p.nextToken()
+		case token.SET:
+			p.nextToken()
+
+			if err := p.expect(token.NULL); err != nil {
+				return nil, err
+			}
+
+			action = token.NULL
+		default:
+			return nil, fmt.Errorf("unexpected referential action %q", p.token.Literal)
+		}
+
+		if event == token.DELETE {
+			reference.OnDelete = action
+		} else {
+			reference.OnUpdate = action
+		}
+	}
+
+	return &reference, nil
+}
+
+func (p *Parser) parseColumn() (ast.Column, error) {
+	columnName, err := p.parseIdent()
+	if err != nil {
+		return ast.Column{}, err
+	}
+
+	columnType, precision, scale, err := p.parseColumnType()
+	if err != nil {
+		return ast.Column{}, err
+	}
+
+	column := ast.Column{
+		Name:      columnName.Name,
+		Type:      columnType,
+		Nullable:  true,
+		Precision: precision,
+		Scale:     scale,
+	}
+
+	if err := p.parseColumnConstraints(&column); err != nil {
+		return ast.Column{}, err
+	}
+
+	return column, nil
+}
+
+// parseColumnConstraints parses the NOT NULL, NULL, PRIMARY KEY, DEFAULT,
+// UNIQUE, CHECK and REFERENCES clauses following the column type.
+func (p *Parser) parseColumnConstraints(column *ast.Column) error {
+	var name string
+
+	for {
+		constraint := ast.Constraint{
+			Name:    name,
+			Type:    p.token.Type,
+			Columns: []string{column.Name},
+		}
+		name = ""
+
+		switch p.token.Type {
+		case token.CONSTRAINT:
+			p.nextToken()
+
+			ident, err := p.parseIdent()
+			if err != nil {
+				return err
+			}
+
+			name = ident.Name
+		case token.UNIQUE:
+			p.nextToken()
+			column.Constraints = append(column.Constraints, constraint)
+		case token.CHECK:
+			check, err := p.parseCheck()
+			if err != nil {
+				return err
+			}
+
+			constraint.Check = check
+			column.Constraints = append(column.Constraints, constraint)
+		case token.REFERENCES:
+			references, err := p.parseReferences()
+			if err != nil {
+				return err
+			}
+
+			constraint.Type = token.FOREIGN
+			constraint.References = references
+			column.Constraints = append(column.Constraints, constraint)
+		case token.NOT:
+			p.nextToken()
+
+			if err := p.expect(token.NULL); err != nil {
+				return err
+			}
+
+			column.Nullable = false
+		case token.NULL:
+			p.nextToken()
+			column.Nullable = true
+		case token.PRIMARY:
+			p.nextToken()
+
+			if err := p.expect(token.KEY); err != nil {
+				return err
+			}
+
+			column.PrimaryKey = true
+			column.Nullable = false
+		case token.DEFAULT:
+			p.nextToken()
+
+			// Like in PostgreSQL, the expression can't have NOT or IN, so
+			// that it can be followed by NOT NULL.
+			expr, err := p.parseExpr(precedences[token.NOT])
+			if err != nil {
+				return err
+			}
+
+			p.nextToken()
+			column.Default = expr
+		default:
+			return nil
+		}
+	}
+}
+
+// parseColumnType parses the type of a column with the precision and scale of
+// NUMERIC(p, s), leaving the token following it as the current token.
+func (p *Parser) parseColumnType() (token.TokenType, int, int, error) {
+	switch p.token.Type {
+	case token.INT, token.FLOAT, token.TEXT, token.BOOLEAN, token.TRUE, token.FALSE,
+		token.DATE, token.TIME, token.TIMESTAMP, token.TIMESTAMPTZ, token.INTERVAL,
+		token.DECIMAL, token.NUMERIC, token.JSON, token.JSONB:
+		columnType, err := p.parseTypeName()
+		if err != nil {
+			return "", 0, 0, err
+		}
+
+		precision, scale, err := p.parseNumericModifiers(columnType)
+		if err != nil {
+			return "", 0, 0, err
+		}
+
+		p.nextToken()
+
+		return columnType, precision, scale, nil
+	}
+
+	return "", 0, 0, fmt.Errorf("unexpected column type: %q", p.token.Type)
+}
+
+// parseTypeName parses the type named by the current token, leaving its last
+// token as the current token. TIMESTAMP WITH TIME ZONE is TIMESTAMPTZ, TIME
+// and TIMESTAMP WITHOUT TIME ZONE are themselves, DECIMAL is NUMERIC and
+// JSON is JSONB.
+func (p *Parser) parseTypeName() (token.TokenType, error) {
+	t := p.token.Type
+	switch t {
+	case token.DECIMAL:
+		return token.NUMERIC, nil
+	case token.JSON:
+		return token.JSONB, nil
+	}
+
+	if (t != token.TIME && t != token.TIMESTAMP) || (p.peekToken.Type != token.WITH && p.peekToken.Type != token.WITHOUT) {
+		return t, nil
+	}
+
+	p.nextToken()
+	with := p.token.Type == token.WITH
+
+	for _, expected := range []token.TokenType{token.TIME, token.ZONE} {
+		p.nextToken()
+
+		if p.token.Type != expected {
+			return "", fmt.Errorf("expected %q but found %q", expected, p.token.Type)
+		}
+	}
+
+	switch {
+	case with && t == token.TIME:
+		return "", fmt.Errorf("type TIME WITH TIME ZONE is not supported")
+	case with:
+		return token.TIMESTAMPTZ, nil
+	default:
+		return t, nil
+	}
+}
+
+// parseNumericModifiers parses the precision and scale following NUMERIC,
+// like (10, 2) or (10) for a scale of 0, leaving the closing parenthesis as
+// the current token. Without them the precision is 0 and the current token
+// stays the same.
+func (p *Parser) parseNumericModifiers(t token.TokenType) (int, int, error) {
+	if t != token.NUMERIC || p.peekToken.Type != token.LPAREN {
+		return 0, 0, nil
+	}
+
+	p.nextToken()
+
+	var modifiers []int
+	for len(modifiers) < 2 {
+		p.nextToken()
+
+		if p.token.Type != token.INT {
+			return 0, 0, fmt.Errorf("expected the precision and scale of NUMERIC but found %q", p.token.Literal)
+		}
+
+		n, err := strconv.Atoi(p.token.Literal)
+		if err != nil {
+			return 0, 0, fmt.Errorf("NUMERIC precision %s is out of range", p.token.Literal)
+		}
+		modifiers = append(modifiers, n)
+
+		if p.peekToken.Type != token.COMMA {
+			break
+		}
+		p.nextToken()
+	}
+
+	p.nextToken()
+
+	if p.token.Type != token.RPAREN {
+		return 0, 0, fmt.Errorf("expected %q but found %q", token.RPAREN, p.token.Type)
+	}
+
+	// A precision of 0 stands for none, so it can't be given.
+	if modifiers[0] == 0 {
+		return 0, 0, fmt.Errorf("NUMERIC precision 0 must be at least 1")
+	}
+
+	if len(modifiers) == 1 {
+		return modifiers[0], 0, nil
+	}
+	return modifiers[0], modifiers[1], nil
+}
+
+func (p *Parser) parseResultStatement() ([]ast.ResultStatement, error) {
+	if p.token.Type == token.EOF || p.token.Type == token.FROM {
+		return nil, fmt.Errorf("no columns specified")
+	}
+
+	var results []ast.ResultStatement
+
+	for {
+		result, err := p.parseResult()
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, result)
+
+		if p.token.Type != token.COMMA {
+			p.nextToken()
+			break
+		}
+
+		p.nextToken()
+	}
+
+	return results, nil
+}
+
+// parseResult parses a result expression, optionally followed by an alias
+// with or without AS.
+func (p *Parser) parseResult() (ast.ResultStatement, error) {
+	var (
+		result ast.ResultStatement
+		err    error
+	)
+
+	result.Expr, err = p.parseExpr(LOWEST)
+	if err != nil {
+		return ast.ResultStatement{}, err
+	}
+
+	switch p.peekToken.Type {
+	case token.AS:
+		p.nextToken()
+		p.nextToken()
+
+		if p.token.Type != token.IDENT && !token.IsNonReserved(p.token.Type) {
+			return ast.ResultStatement{}, fmt.Errorf("unexpected token %q", p.token.Type)
+		}
+
+		result.Alias = p.token.Literal
+	case token.IDENT:
+		p.nextToken()
+		result.Alias = p.token.Literal
+	}
+
+	if result.Alias != "" {
+		if _, ok := result.Expr.(*ast.AsteriskExpr); ok {
+			return ast.ResultStatement{}, fmt.Errorf("cannot alias %s", result.Expr)
+		}
+	}
+
+	if p.peekToken.Type == token.COMMA {
+		p.nextToken()
+	}
+
+	return result, nil
+}
+
+// parseDistinct parses DISTINCT or DISTINCT ON and its parenthesized
+// expressions, leaving the token after them as the current token. ALL is the
+// default of keeping duplicate rows.
+func (p *Parser) parseDistinct(stmt *ast.SelectStatement) error {
+	switch p.token.Type {
+	case token.ALL:
+		p.nextToken()
+		return nil
+	case token.DISTINCT:
+		p.nextToken()
+	default:
+		return nil
+	}
+
+	stmt.Distinct = true
+
+	if p.token.Type != token.ON {
+		return nil
+	}
+
+	p.nextToken()
+
+	if err := p.expect(token.LPAREN); err != nil {
+		return err
+	}
+
+	for {
+		expr, err := p.parsePrimaryExpr()
+		if err != nil {
+			return err
+		}
+
+		stmt.DistinctOn = append(stmt.DistinctOn, expr)
+
+		if p.token.Type != token.COMMA {
+			p.nextToken()
+			break
+		}
+
+		p.nextToken()
+	}
+
+	return p.expect(token.RPAREN)
+}
+
+func (p *Parser) parseFromStatement() (*ast.FromStatement, error) {
+	if p.token.Type != token.FROM {
+		return nil, nil
+	}
+
+	p.nextToken()
+
+	ref, err := p.parseTableRef()
+	if err != nil {
+		return nil, err
+	}
+
+	from := ast.FromStatement{Table: ref.table, Subquery: ref.subquery, Alias: ref.alias}
+
+	for {
+		join := ast.JoinStatement{Type: token.CROSS}
+
+		switch p.token.Type {
+		case token.COMMA:
+			p.nextToken()
+		case token.JOIN, token.INNER, token.LEFT, token.RIGHT, token.FULL, token.CROSS:
+			if join.Type, err = p.parseJoinType(); err != nil {
+				return nil, err
+			}
+		default:
+			return &from, nil
+		}
+
+		ref, err := p.parseTableRef()
+		if err != nil {
+			return nil, err
+		}
+
+		join.Table, join.Subquery, join.Alias = ref.table, ref.subquery, ref.alias
+
+		if join.Type != token.CROSS {
+			if err := p.parseJoinCondition(&join); err != nil {
+				return nil, err
+			}
+		}
+
+		from.Joins = append(from.Joins, join)
+	}
+}
+
+// tableRef is a table or a derived table of FROM.
+type tableRef struct {
+	table    string
+	subquery *ast.SelectStatement
+	alias    string
+}
+
+// parseTableRef parses a table name or a parenthesized subquery followed by
+// an alias, which is optional for a table.
+func (p *Parser) parseTableRef() (tableRef, error) {
+	var ref tableRef
+
+	if p.token.Type == token.LPAREN {
+		query, err := p.parseSubquery()
+		if err != nil {
+			return tableRef{}, err
+		}
+
+		p.nextToken()
+		ref.subquery = query
+	} else {
+		table, err := p.parseIdent()
+		if err != nil {
+			return tableRef{}, err
+		}
+
+		ref.table = table.Name
+	}
+
+	switch p.token.Type {
+	case token.AS:
+		p.nextToken()
+
+		alias, err := p.parseIdent()
+		if err != nil {
+			return tableRef{}, err
+		}
+
+		ref.alias = alias.Name
+	case token.IDENT:
+		ref.alias = p.token.Literal
+		p.nextToken()
+	}
+
+	if ref.subquery != nil && ref.alias == "" {
+		return tableRef{}, fmt.Errorf("subquery in FROM must have an alias")
+	}
+
+	return ref, nil
+}
+
+// parseJoinType parses the join type up to and including JOIN. A join is
+// INNER unless given otherwise.
+func (p *Parser) parseJoinType() (token.TokenType, error) {
+	joinType := token.TokenType(token.INNER)
+
+	switch p.token.Type {
+	case token.INNER, token.CROSS:
+		joinType = p.token.Type
+		p.nextToken()
+	case token.LEFT, token.RIGHT, token.FULL:
+		joinType = p.token.Type
+		p.nextToken()
+		p.skip(token.OUTER)
+	}
+
+	if err := p.expect(token.JOIN); err != nil {
+		return "", err
+	}
+
+	return joinType, nil
+}
+
+// parseJoinCondition parses ON condition or USING (columns).
+func (p *Parser) parseJoinCondition(join *ast.JoinStatement) error {
+	switch p.token.Type {
+	case token.ON:
+		p.nextToken()
+
+		on, err := p.parsePrimaryExpr()
+		if err != nil {
+			return err
+		}
+
+		// The expression is followed by a comma joining the next table or
+		// ends on its last token.
+		if p.token.Type != token.COMMA {
+			p.nextToken()
+		}
+
+		join.On = on
+	case token.USING:
+		p.nextToken()
+
+		using, err := p.parseColumnsStatement()
+		if err != nil {
+			return err
+		}
+
+		join.Using = using
+	default:
+		return fmt.Errorf("expected ON or USING but found %q", p.token.Type)
+	}
+
+	return nil
+}
+
+func (p *Parser) parseSetStatement() ([]ast.SetStatement, error) {
+	if err := p.expect(token.SET); err != nil {
+		return nil, err
+	}
+
+	columns := make([]ast.SetStatement, 0)
+
+	for {
+		column, err := p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+
+		if err = p.expect(token.EQ); err != nil {
+			return nil, err
+		}
+
+		value, err := p.parsePrimaryExpr()
+		if err != nil {
+			return nil, err
+		}
+
+		columns = append(columns, ast.SetStatement{
+			Column: column.Name,
+			Value:  value,
+		})
+
+		if p.token.Type != token.COMMA {
+			p.nextToken()
+			break
+		}
+
+		p.nextToken()
+	}
+
+	return columns, nil
+}
+
+func (p *Parser) parseWhereStatement() (*ast.WhereStatement, error) {
+	if p.token.Type != token.WHERE {
+		return nil, nil
+	}
+
+	p.nextToken()
+
+	expr, err := p.parsePrimaryExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	p.nextToken()
+
+	where := ast.WhereStatement{
+		Expr: expr,
+	}
+
+	return &where, nil
+}
+
+func (p *Parser) parseGroupByStatement() (*ast.GroupByStatement, error) {
+	if p.token.Type != token.GROUP {
+		return nil, nil
+	}
+
+	p.nextToken()
+
+	if err := p.expect(token.BY); err != nil {
+		return nil, err
+	}
+
+	var groupBy ast.GroupByStatement
+
+	for {
+		expr, err := p.parsePrimaryExpr()
+		if err != nil {
+			return nil, err
+		}
+
+		groupBy.Exprs = append(groupBy.Exprs, expr)
+
+		if p.token.Type != token.COMMA {
+			p.nextToken()
+			break
+		}
+
+		p.nextToken()
+	}
+
+	return &groupBy, nil
+}
+
+func (p *Parser) parseHavingStatement() (*ast.HavingStatement, error) {
+	if p.token.Type != token.HAVING {
+		return nil, nil
+	}
+
+	p.nextToken()
+
+	expr, err := p.parsePrimaryExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	p.nextToken()
+
+	return &ast.HavingStatement{Expr: expr}, nil
+}
+
+// parseOrderByStatement parses ORDER BY and its sort keys, each an
+// expression or the position of a result column.
+func (p *Parser) parseOrderByStatement() (*ast.OrderByStatement, error) {
+	if p.token.Type != token.ORDER {
+		return nil, nil
+	}
+
+	p.nextToken()
+
+	if err := p.expect(token.BY); err != nil {
+		return nil, err
+	}
+
+	keys, err := p.parseSortKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.OrderByStatement{Keys: keys}, nil
+}
+
+func (p *Parser) parseLimitStatement() (*ast.LimitStatement, error) {
+	if p.token.Type != token.LIMIT {
+		return nil, nil
+	}
+	p.nextToken()
+
+	value, err := p.parseScalar(token.INT)
+	if err != nil {
+		return nil, err
+	}
+
+	p.nextToken()
+
+	limit := ast.LimitStatement{
+		Value: value,
+	}
+	return &limit, nil
+}
+
+func (p *Parser) parseOffsetStatement() (*ast.OffsetStatement, error) {
+	if p.token.Type != token.OFFSET {
+		return nil, nil
+	}
+	p.nextToken()
+
+	value, err := p.parseScalar(token.INT)
+	if err != nil {
+		return nil, err
+	}
+
+	p.nextToken()
+
+	offset := ast.OffsetStatement{
+		Value: value,
+	}
+	return &offset, nil
+}
+
+func (p *Parser) parsePrimaryExpr() (ast.Expression, error) {
+	expr, err := p.parseExpr(LOWEST)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peekToken.Type == token.COMMA {
+		p.nextToken()
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) parseExpr(precedence int) (ast.Expression, error) {
+	expr, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peekToken.Type != token.COMMA && precedence < p.peekPrecedence() {
+		p.nextToken()
+
+		switch p.token.Type {
+		case token.IS:
+			expr, err = p.parseIsExpr(expr)
+		case token.NOT:
+			switch p.peekToken.Type {
+			case token.BETWEEN:
+				expr, err = p.parseBetweenExpr(expr)
+			case token.LIKE, token.ILIKE:
+				expr, err = p.parseLikeExpr(expr)
+			default:
+				expr, err = p.parseInExpr(expr)
+			}
+		case token.IN:
+			expr, err = p.parseInExpr(expr)
+		case token.BETWEEN:
+			expr, err = p.parseBetweenExpr(expr)
+		case token.LIKE, token.ILIKE:
+			expr, err = p.parseLikeExpr(expr)
+		case token.DOUBLE_COLON:
+			expr, err = p.parseCastSuffix(expr)
+		case token.AT:
+			expr, err = p.parseAtTimeZoneExpr(expr)
+		default:
+			expr, err = p.parseConditionExpr(expr)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) parseOperand() (ast.Expression, error) {
+	switch p.token.Type {
+	case token.IDENT:
+		if p.peekToken.Type == token.LPAREN {
+			return p.parseCallExpr()
+		}
+		return p.parseColumnRef()
+	case token.DEFAULT:
+		return &ast.DefaultExpr{}, nil
+	case token.ASTERISK:
+		return &ast.AsteriskExpr{}, nil
+	case token.INT, token.FLOAT, token.TEXT, token.TRUE, token.FALSE, token.NULL:
+		return p.parseScalar(p.token.Type)
+	case token.PLUS, token.MINUS, token.NOT:
+		return p.parseUnaryExpr()
+	case token.LPAREN:
+		if p.isSubquery() {
+			query, err := p.parseSubquery()
+			if err != nil {
+				return nil, err
+			}
+			return &ast.SubqueryExpr{Select: query}, nil
+		}
+		return p.parseGroupExpr()
+	case token.EXISTS:
+		p.nextToken()
+
+		query, err := p.parseSubquery()
+		if err != nil {
+			return nil, err
+		}
+		return &ast.ExistsExpr{Select: query}, nil
+	case token.CASE:
+		return p.parseCaseExpr()
+	case token.CAST:
+		return p.parseCastExpr()
+	default:
+		if p.isTypedLiteral() {
+			return p.parseTypedLiteral()
+		}
+		if token.IsNonReserved(p.token.Type) {
+			return p.parseColumnRef()
+		}
+		return nil, fmt.Errorf("unexpected operand %q", p.token.Type)
+	}
+}
+
+// parseColumnRef parses a column name, which may be qualified by a table
+// (like: a.code), or all columns of a table (like: a.*).
+func (p *Parser) parseColumnRef() (ast.Expression, error) {
+	name := p.token.Literal
+
+	if p.peekToken.Type != token.DOT {
+		return &ast.IdentExpr{Name: name}, nil
+	}
+
+	p.nextToken()
+	p.nextToken()
+
+	if p.token.Type == token.ASTERISK {
+		return &ast.AsteriskExpr{Table: name}, nil
+	}
+
+	if p.token.Type != token.IDENT && !token.IsNonReserved(p.token.Type) {
+		return nil, fmt.Errorf("unexpected token %q after %q", p.token.Type, name+".")
+	}
+
+	return &ast.IdentExpr{Table: name, Name: p.token.Literal}, nil
+}
+
+func (p *Parser) parseUnaryExpr() (ast.Expression, error) {
+	operator := p.token.Type
+
+	p.nextToken()
+
+	precedence := prefixPrecedence
+	if operator == token.NOT {
+		precedence = notPrecedence
+	}
+
+	operand, err := p.parseExpr(precedence)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.UnaryExpr{Operator: operator, Operand: operand}, nil
+}
+
+// parseCallExpr parses a function call, leaving the closing parenthesis as
+// the current token.
+func (p *Parser) parseCallExpr() (ast.Expression, error) {
+	call := ast.CallExpr{Name: p.token.Literal}
+
+	p.nextToken()
+
+	if p.peekToken.Type == token.DISTINCT {
+		call.Distinct = true
+		p.nextToken()
+	}
+
+	// position(substring IN text) is the SQL syntax of position(substring,
+	// text), whose first argument binds tighter than IN. extract(field FROM
+	// source) is the one of extract('field', source).
+	position := strings.EqualFold(call.Name, "position")
+	extract := strings.EqualFold(call.Name, "extract")
+
+	for p.peekToken.Type != token.RPAREN {
+		p.nextToken()
+
+		precedence := LOWEST
+		if position && len(call.Args) == 0 {
+			precedence = precedences[token.IN]
+		}
+
+		arg, err := p.parseExpr(precedence)
+		if err != nil {
+			return nil, err
+		}
+
+		call.Args = append(call.Args, arg)
+
+		if position && len(call.Args) == 1 && p.peekToken.Type == token.IN {
+			p.nextToken()
+			continue
+		}
+
+		if extract && len(call.Args) == 1 && p.peekToken.Type == token.FROM {
+			field, ok := arg.(*ast.IdentExpr)
+			if !ok || field.Table != "" {
+				return nil, fmt.Errorf("unexpected extract field %s", arg)
+			}
+
+			call.Args[0] = &ast.ScalarExpr{Type: token.TEXT, Literal: strings.ToLower(field.Name)}
+			p.nextToken()
+			continue
+		}
+
+		if p.peekToken.Type != token.COMMA {
+			break
+		}
+
+		p.nextToken()
+	}
+
+	p.nextToken()
+
+	if p.token.Type != token.RPAREN {
+		return nil, fmt.Errorf("expected %q but found %q", token.RPAREN, p.token.Type)
+	}
+
+	if p.peekToken.Type == token.OVER {
+		p.nextToken()
+		return p.parseWindowExpr(&call)
+	}
+
+	return &call, nil
+}
+
+// parseWindowExpr parses the OVER clause of the call, leaving its closing
+// parenthesis as the current token.
+func (p *Parser) parseWindowExpr(call *ast.CallExpr) (ast.Expression, error) {
+	p.nextToken()
+
+	if err := p.expect(token.LPAREN); err != nil {
+		return nil, err
+	}
+
+	window := ast.WindowExpr{Call: call}
+
+	if p.token.Type == token.PARTITION {
+		p.nextToken()
+
+		if err := p.expect(token.BY); err != nil {
+			return nil, err
+		}
+
+		for {
+			expr, err := p.parseExpr(LOWEST)
+			if err != nil {
+				return nil, err
+			}
+
+			window.PartitionBy = append(window.PartitionBy, expr)
+			p.nextToken()
+
+			if p.token.Type != token.COMMA {
+				break
+			}
+
+			p.nextToken()
+		}
+	}
+
+	if p.token.Type == token.ORDER {
+		p.nextToken()
+
+		if err := p.expect(token.BY); err != nil {
+			return nil, err
+		}
+
+		keys, err := p.parseSortKeys()
+		if err != nil {
+			return nil, err
+		}
+
+		window.OrderBy = keys
+	}
+
+	if p.token.Type == token.ROWS || p.token.Type == token.RANGE {
+		frame, err := p.parseWindowFrame()
+		if err != nil {
+			return nil, err
+		}
+
+		window.Frame = frame
+	}
+
+	if p.token.Type != token.RPAREN {
+		return nil, fmt.Errorf("expected %q but found %q", token.RPAREN, p.token.Type)
+	}
+
+	return &window, nil
+}
+
+// parseSortKeys parses the comma separated expressions of ORDER BY, each
+// optionally followed by ASC or DESC and NULLS FIRST or LAST.
+func (p *Parser) parseSortKeys() ([]ast.SortKey, error) {
+	var keys []ast.SortKey
+
+	for {
+		expr, err := p.parseExpr(LOWEST)
+		if err != nil {
+			return nil, err
+		}
+
+		p.nextToken()
+
+		key := ast.SortKey{Expr: expr, Direction: token.ASC}
+
+		switch p.token.Type {
+		case token.ASC, token.DESC:
+			key.Direction = p.token.Type
+			p.nextToken()
+		}
+
+		if p.token.Type == token.NULLS {
+			p.nextToken()
+
+			if p.token.Type != token.FIRST && p.token.Type != token.LAST {
+				return nil, fmt.Errorf("expected FIRST or LAST but found %q", p.token.Type)
+			}
+
+			key.Nulls = p.token.Type
+			p.nextToken()
+		}
+
+		keys = append(keys, key)
+
+		if p.token.Type != token.COMMA {
+			return keys, nil
+		}
+
+		p.nextToken()
+	}
+}
+
+// parseWindowFrame parses ROWS or RANGE with the bounds of the frame. A
+// single bound is the start of a frame ending with the current row.
+func (p *Parser) parseWindowFrame() (*ast.WindowFrame, error) {
+	frame := ast.WindowFrame{Mode: p.token.Type, End: ast.FrameBound{Type: token.CURRENT}}
+
+	p.nextToken()
+
+	between := p.token.Type == token.BETWEEN
+	if between {
+		p.nextToken()
+	}
+
+	start, err := p.parseFrameBound()
+	if err != nil {
+		return nil, err
+	}
+	frame.Start = start
+
+	if between {
+		if err := p.expect(token.AND); err != nil {
+			return nil, err
+		}
+
+		if frame.End, err = p.parseFrameBound(); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case frame.Start.Type == token.FOLLOWING && frame.Start.Offset == nil:
+		return nil, fmt.Errorf("frame start cannot be UNBOUNDED FOLLOWING")
+	case frame.End.Type == token.PRECEDING && frame.End.Offset == nil:
+		return nil, fmt.Errorf("frame end cannot be UNBOUNDED PRECEDING")
+	case frame.Start.Type == token.CURRENT && frame.End.Type == token.PRECEDING:
+		return nil, fmt.Errorf("frame starting from current row cannot have preceding rows")
+	case frame.Start.Type == token.FOLLOWING && frame.End.Type != token.FOLLOWING:
+		return nil, fmt.Errorf("frame starting from following row cannot have preceding rows")
+	}
+
+	return &frame, nil
+}
+
+// parseFrameBound parses UNBOUNDED PRECEDING or FOLLOWING, CURRENT ROW or an
+// offset followed by PRECEDING or FOLLOWING.
+func (p *Parser) parseFrameBound() (ast.FrameBound, error) {
+	var bound ast.FrameBound
+
+	switch p.token.Type {
+	case token.UNBOUNDED:
+		p.nextToken()
+	case token.CURRENT:
+		p.nextToken()
+
+		if err := p.expect(token.ROW); err != nil {
+			return ast.FrameBound{}, err
+		}
+
+		return ast.FrameBound{Type: token.CURRENT}, nil
+	default:
+		// The offset can't have AND, which ends the start of the frame.
+		offset, err := p.parseExpr(precedences[token.AND])
+		if err != nil {
+			return ast.FrameBound{}, err
+		}
+
+		bound.Offset = offset
+		p.nextToken()
+	}
+
+	if p.token.Type != token.PRECEDING && p.token.Type != token.FOLLOWING {
+		return ast.FrameBound{}, fmt.Errorf("expected PRECEDING or FOLLOWING but found %q", p.token.Type)
+	}
+
+	bound.Type = p.token.Type
+	p.nextToken()
+
+	return bound, nil
 }
 
-func (p *Parser) parseSetStatement() ([]ast.SetStatement, error) {
-	if err := p.expect(token.SET); err != nil {
+// skip advances past the token if it is of the given type.
+func (p *Parser) skip(tokenType token.TokenType) {
+	if p.token.Type == tokenType {
+		p.nextToken()
+	}
+}
+
+func (p *Parser) parseIdent() (*ast.IdentExpr, error) {
+	if p.token.Type != token.IDENT && !token.IsNonReserved(p.token.Type) {
+		return nil, fmt.Errorf("unexpected token %q", p.token.Type)
+	}
+
+	ident := ast.IdentExpr{
+		Name: p.token.Literal,
+	}
+
+	p.nextToken()
+
+	return &ident, nil
+}
+
+func (p *Parser) parseScalar(expected token.TokenType) (ast.Expression, error) {
+	if p.token.Type != expected {
+		return nil, fmt.Errorf("unexpected scalar type %q", p.token.Type)
+	}
+
+	scalar := ast.ScalarExpr{
+		Type:    p.token.Type,
+		Literal: p.token.Literal,
+	}
+
+	return &scalar, nil
+}
+
+func (p *Parser) parseConditionExpr(left ast.Expression) (ast.Expression, error) {
+	operator := p.token.Type
+	precedence := precedences[operator]
+
+	p.nextToken()
+
+	right, err := p.parseExpr(precedence)
+	if err != nil {
 		return nil, err
 	}
 
-	columns := make([]ast.SetStatement, 0)
+	expr := ast.ConditionExpr{
+		Left:     left,
+		Operator: operator,
+		Right:    right,
+	}
 
-	for {
-		column, err := p.parseIdent()
+	return &expr, nil
+}
+
+// parseIsExpr parses IS [NOT] NULL and IS [NOT] DISTINCT FROM following the
+// left operand.
+func (p *Parser) parseIsExpr(left ast.Expression) (ast.Expression, error) {
+	p.nextToken()
+
+	not := p.token.Type == token.NOT
+	if not {
+		p.nextToken()
+	}
+
+	switch p.token.Type {
+	case token.NULL:
+		return &ast.IsNullExpr{Expr: left, Not: not}, nil
+	case token.DISTINCT:
+		p.nextToken()
+
+		if p.token.Type != token.FROM {
+			return nil, fmt.Errorf("expected %q but found %q", token.FROM, p.token.Type)
+		}
+
+		p.nextToken()
+
+		right, err := p.parseExpr(precedences[token.IS])
 		if err != nil {
 			return nil, err
 		}
 
-		if err = p.expect(token.EQ); err != nil {
+		return &ast.IsDistinctExpr{Left: left, Right: right, Not: not}, nil
+	default:
+		return nil, fmt.Errorf("expected NULL or DISTINCT FROM after IS but found %q", p.token.Type)
+	}
+}
+
+// parseInExpr parses [NOT] IN with a subquery or a list of values following
+// the left operand, leaving the closing parenthesis as the current token.
+func (p *Parser) parseInExpr(left ast.Expression) (ast.Expression, error) {
+	not := p.token.Type == token.NOT
+	if not {
+		p.nextToken()
+
+		if p.token.Type != token.IN {
+			return nil, fmt.Errorf("expected %q but found %q", token.IN, p.token.Type)
+		}
+	}
+
+	p.nextToken()
+
+	if p.isSubquery() {
+		query, err := p.parseSubquery()
+		if err != nil {
 			return nil, err
 		}
 
-		value, err := p.parsePrimaryExpr()
+		return &ast.InExpr{Expr: left, Not: not, Select: query}, nil
+	}
+
+	if p.token.Type != token.LPAREN {
+		return nil, fmt.Errorf("expected %q after IN but found %q", token.LPAREN, p.token.Type)
+	}
+
+	in := ast.InExpr{Expr: left, Not: not}
+
+	for {
+		p.nextToken()
+
+		value, err := p.parseExpr(LOWEST)
 		if err != nil {
 			return nil, err
 		}
 
-		columns = append(columns, ast.SetStatement{
-			Column: column.Name,
-			Value:  value,
-		})
+		in.List = append(in.List, value)
 
-		if p.peekToken.Type == token.EOF || p.peekToken.Type == token.WHERE {
-			p.nextToken()
-			break
+		p.nextToken()
+
+		if p.token.Type == token.RPAREN {
+			return &in, nil
 		}
 
-		if err = p.expect(token.COMMA); err != nil {
-			return nil, err
+		if p.token.Type != token.COMMA {
+			return nil, fmt.Errorf("expected %q but found %q", token.RPAREN, p.token.Type)
 		}
 	}
-
-	return columns, nil
 }
 
-func (p *Parser) parseWhereStatement() (*ast.WhereStatement, error) {
-	if p.token.Type != token.WHERE {
-		return nil, nil
+// parseBetweenExpr parses [NOT] BETWEEN ... AND ... following the left
+// operand.
+func (p *Parser) parseBetweenExpr(left ast.Expression) (ast.Expression, error) {
+	between := ast.BetweenExpr{Expr: left, Not: p.token.Type == token.NOT}
+	if between.Not {
+		p.nextToken()
 	}
 
 	p.nextToken()
 
-	expr, err := p.parsePrimaryExpr()
+	low, err := p.parseExpr(precedences[token.BETWEEN])
 	if err != nil {
 		return nil, err
 	}
 
 	p.nextToken()
 
-	where := ast.WhereStatement{
-		Expr: expr,
+	if p.token.Type != token.AND {
+		return nil, fmt.Errorf("expected %q but found %q", token.AND, p.token.Type)
 	}
 
-	return &where, nil
+	p.nextToken()
+
+	high, err := p.parseExpr(precedences[token.BETWEEN])
+	if err != nil {
+		return nil, err
+	}
+
+	between.Low, between.High = low, high
+
+	return &between, nil
 }
 
-func (p *Parser) parseOrderByStatement() (*ast.OrderByStatement, error) {
-	if p.token.Type != token.ORDER {
-		return nil, nil
+// parseLikeExpr parses [NOT] LIKE or ILIKE with an optional ESCAPE clause
+// following the left operand.
+func (p *Parser) parseLikeExpr(left ast.Expression) (ast.Expression, error) {
+	like := ast.LikeExpr{Expr: left, Not: p.token.Type == token.NOT}
+	if like.Not {
+		p.nextToken()
 	}
 
+	like.Operator = p.token.Type
+
 	p.nextToken()
 
-	if err := p.expect(token.BY); err != nil {
+	pattern, err := p.parseExpr(precedences[token.LIKE])
+	if err != nil {
 		return nil, err
 	}
 
-	column, err := p.parseIdent()
-	if err != nil {
-		return nil, err
+	like.Pattern = pattern
+
+	if p.peekToken.Type == token.ESCAPE {
+		p.nextToken()
+		p.nextToken()
+
+		escape, err := p.parseExpr(precedences[token.LIKE])
+		if err != nil {
+			return nil, err
+		}
+
+		like.Escape = escape
 	}
 
-	var direction token.TokenType = token.ASC
+	return &like, nil
+}
+
+// parseCaseExpr parses a simple or searched CASE expression, leaving END as
+// the current token.
+func (p *Parser) parseCaseExpr() (ast.Expression, error) {
+	var expr ast.CaseExpr
+
+	p.nextToken()
+
+	if p.token.Type != token.WHEN {
+		operand, err := p.parseExpr(LOWEST)
+		if err != nil {
+			return nil, err
+		}
+
+		expr.Operand = operand
 
-	switch p.token.Type {
-	case token.ASC, token.DESC:
-		direction = p.token.Type
 		p.nextToken()
 	}
 
-	order := ast.OrderByStatement{
-		Column:    column.Name,
-		Direction: direction,
+	for p.token.Type == token.WHEN {
+		p.nextToken()
+
+		cond, err := p.parseExpr(LOWEST)
+		if err != nil {
+			return nil, err
+		}
+
+		p.nextToken()
+
+		if err := p.expect(token.THEN); err != nil {
+			return nil, err
+		}
+
+		result, err := p.parseExpr(LOWEST)
+		if err != nil {
+			return nil, err
+		}
+
+		expr.Whens = append(expr.Whens, ast.WhenClause{Cond: cond, Result: result})
+
+		p.nextToken()
 	}
 
-	return &order, nil
-}
+	if len(expr.Whens) == 0 {
+		return nil, fmt.Errorf("expected %q but found %q", token.WHEN, p.token.Type)
+	}
 
-func (p *Parser) parseLimitStatement() (*ast.LimitStatement, error) {
-	if p.token.Type != token.LIMIT {
-		return nil, nil
+	if p.token.Type == token.ELSE {
+		p.nextToken()
+
+		result, err := p.parseExpr(LOWEST)
+		if err != nil {
+			return nil, err
+		}
+
+		expr.Else = result
+
+		p.nextToken()
+	}
+
+	if p.token.Type != token.END {
+		return nil, fmt.Errorf("expected %q but found %q", token.END, p.token.Type)
 	}
+
+	return &expr, nil
+}
+
+// parseCastExpr parses CAST(expr AS type), leaving the closing parenthesis
+// as the current token.
+func (p *Parser) parseCastExpr() (ast.Expression, error) {
 	p.nextToken()
 
-	value, err := p.parseScalar(token.INT)
+	if err := p.expect(token.LPAREN); err != nil {
+		return nil, err
+	}
+
+	expr, err := p.parseExpr(LOWEST)
 	if err != nil {
 		return nil, err
 	}
 
 	p.nextToken()
 
-	limit := ast.LimitStatement{
-		Value: value,
+	if err := p.expect(token.AS); err != nil {
+		return nil, err
 	}
-	return &limit, nil
-}
 
-func (p *Parser) parseOffsetStatement() (*ast.OffsetStatement, error) {
-	if p.token.Type != token.OFFSET {
-		return nil, nil
+	if !isCastType(p.token.Type) {
+		return nil, fmt.Errorf("unexpected type %q", p.token.Literal)
 	}
-	p.nextToken()
 
-	value, err := p.parseScalar(token.INT)
+	t, err := p.parseTypeName()
 	if err != nil {
 		return nil, err
 	}
 
+	cast := ast.CastExpr{Expr: expr, Type: t}
+	if cast.Precision, cast.Scale, err = p.parseNumericModifiers(t); err != nil {
+		return nil, err
+	}
+
 	p.nextToken()
 
-	offset := ast.OffsetStatement{
-		Value: value,
+	if p.token.Type != token.RPAREN {
+		return nil, fmt.Errorf("expected %q but found %q", token.RPAREN, p.token.Type)
 	}
-	return &offset, nil
+
+	return &cast, nil
 }
 
-func (p *Parser) parsePrimaryExpr() (ast.Expression, error) {
-	expr, err := p.parseExpr(LOWEST)
+// parseCastSuffix parses the type of the :: operator following the left
+// operand, leaving the type as the current token.
+func (p *Parser) parseCastSuffix(left ast.Expression) (ast.Expression, error) {
+	p.nextToken()
+
+	if !isCastType(p.token.Type) {
+		return nil, fmt.Errorf("unexpected type %q", p.token.Literal)
+	}
+
+	t, err := p.parseTypeName()
 	if err != nil {
 		return nil, err
 	}
 
-	if p.peekToken.Type == token.COMMA {
-		p.nextToken()
+	cast := &ast.CastExpr{Expr: left, Type: t}
+	if cast.Precision, cast.Scale, err = p.parseNumericModifiers(t); err != nil {
+		return nil, err
 	}
 
-	return expr, nil
+	return cast, nil
 }
 
-func (p *Parser) parseExpr(precedence int) (ast.Expression, error) {
-	expr, err := p.parseOperand()
+// parseTypedLiteral parses a string preceded by its type (like: DATE
+// '2024-01-01'), which is cast to the type, leaving the string as the
+// current token.
+func (p *Parser) parseTypedLiteral() (ast.Expression, error) {
+	t, err := p.parseTypeName()
 	if err != nil {
 		return nil, err
 	}
 
-	for p.peekToken.Type != token.COMMA && precedence < p.peekPrecedence() {
-		p.nextToken()
+	p.nextToken()
 
-		expr, err = p.parseConditionExpr(expr)
-		if err != nil {
-			return nil, err
-		}
+	if p.token.Type != token.TEXT {
+		return nil, fmt.Errorf("expected a string after %s but found %q", t, p.token.Type)
 	}
 
-	return expr, nil
+	return &ast.CastExpr{Expr: &ast.ScalarExpr{Type: token.TEXT, Literal: p.token.Literal}, Type: t}, nil
 }
 
-func (p *Parser) parseOperand() (ast.Expression, error) {
+// isTypedLiteral reports whether the current token is the type of a typed
+// literal rather than a column named like the type.
+func (p *Parser) isTypedLiteral() bool {
 	switch p.token.Type {
-	case token.IDENT:
-		return &ast.IdentExpr{Name: p.token.Literal}, nil
-	case token.ASTERISK:
-		return &ast.AsteriskExpr{}, nil
-	case token.INT, token.TEXT, token.TRUE, token.FALSE, token.NULL:
-		return p.parseScalar(p.token.Type)
-	case token.PLUS, token.MINUS:
-		return nil, nil
-	case token.LPAREN:
-		return p.parseGroupExpr()
-	default:
-		return nil, fmt.Errorf("unexpected operand %q", p.token.Type)
+	case token.DATE, token.INTERVAL, token.TIMESTAMPTZ, token.DECIMAL, token.NUMERIC, token.JSON, token.JSONB:
+		return p.peekToken.Type == token.TEXT
+	case token.TIME, token.TIMESTAMP:
+		return p.peekToken.Type == token.TEXT || p.peekToken.Type == token.WITH || p.peekToken.Type == token.WITHOUT
 	}
+	return false
 }
 
-func (p *Parser) parseIdent() (*ast.IdentExpr, error) {
-	if p.token.Type != token.IDENT {
-		return nil, fmt.Errorf("unexpected token %q", p.token.Type)
-	}
+// parseAtTimeZoneExpr parses the time zone of the AT TIME ZONE operator
+// following the timestamp, leaving the last token of the zone as the current
+// token.
+func (p *Parser) parseAtTimeZoneExpr(left ast.Expression) (ast.Expression, error) {
+	for _, expected := range []token.TokenType{token.TIME, token.ZONE} {
+		p.nextToken()
 
-	ident := ast.IdentExpr{
-		Name: p.token.Literal,
+		if p.token.Type != expected {
+			return nil, fmt.Errorf("expected %q but found %q", expected, p.token.Type)
+		}
 	}
 
 	p.nextToken()
 
-	return &ident, nil
+	zone, err := p.parseExpr(precedences[token.AT])
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.AtTimeZoneExpr{Expr: left, Zone: zone}, nil
 }
 
-func (p *Parser) parseScalar(expected token.TokenType) (ast.Expression, error) {
-	if p.token.Type != expected {
-		return nil, fmt.Errorf("unexpected scalar type %q", p.token.Type)
+// isCastType reports whether the token names a type values can be cast to.
+func isCastType(t token.TokenType) bool {
+	switch t {
+	case token.INT, token.FLOAT, token.TEXT, token.BOOLEAN,
+		token.DATE, token.TIME, token.TIMESTAMP, token.TIMESTAMPTZ, token.INTERVAL,
+		token.DECIMAL, token.NUMERIC, token.JSON, token.JSONB:
+		return true
 	}
+	return false
+}
 
-	scalar := ast.ScalarExpr{
-		Type:    p.token.Type,
-		Literal: p.token.Literal,
+// parseSubquery parses a parenthesized SELECT statement, optionally preceded
+// by a WITH clause, leaving the closing parenthesis as the current token.
+func (p *Parser) parseSubquery() (*ast.SelectStatement, error) {
+	if p.token.Type != token.LPAREN {
+		return nil, fmt.Errorf("expected %q but found %q", token.LPAREN, p.token.Type)
 	}
 
-	return &scalar, nil
-}
+	p.nextToken()
 
-func (p *Parser) parseConditionExpr(left ast.Expression) (ast.Expression, error) {
-	operator := p.token.Type
-	precedence := precedences[operator]
+	var with *ast.WithStatement
+	if p.token.Type == token.WITH {
+		var err error
+		if with, err = p.parseWith(); err != nil {
+			return nil, err
+		}
+	}
 
-	p.nextToken()
+	if p.token.Type != token.SELECT && p.token.Type != token.LPAREN {
+		return nil, fmt.Errorf("expected %q but found %q", token.SELECT, p.token.Type)
+	}
 
-	right, err := p.parseExpr(precedence)
+	query, err := p.parseQuery()
 	if err != nil {
 		return nil, err
 	}
 
-	expr := ast.ConditionExpr{
-		Left:     left,
-		Operator: operator,
-		Right:    right,
+	if p.token.Type != token.RPAREN {
+		return nil, fmt.Errorf("expected %q but found %q", token.RPAREN, p.token.Type)
 	}
 
-	return &expr, nil
+	if with != nil {
+		query.With = with
+	}
+
+	return query, nil
+}
+
+// isSubquery reports whether the parenthesis opens a subquery.
+func (p *Parser) isSubquery() bool {
+	return p.token.Type == token.LPAREN && (p.peekToken.Type == token.SELECT || p.peekToken.Type == token.WITH)
 }
 
 func (p *Parser) parseGroupExpr() (ast.Expression, error) {
@@ -621,13 +2624,35 @@ func (p *Parser) parseColumnsStatement() ([]string, error) {
 	return columns, nil
 }
 
-func (p *Parser) parseValuesStatement() ([]ast.Expression, error) {
-	var values []ast.Expression
-
+// parseValuesStatement parses VALUES followed by one or more comma
+// separated rows of values.
+func (p *Parser) parseValuesStatement() ([][]ast.Expression, error) {
 	if err := p.expect(token.VALUES); err != nil {
 		return nil, err
 	}
 
+	var rows [][]ast.Expression
+
+	for {
+		row, err := p.parseValuesRow()
+		if err != nil {
+			return nil, err
+		}
+
+		rows = append(rows, row)
+
+		if p.token.Type != token.COMMA {
+			return rows, nil
+		}
+
+		p.nextToken()
+	}
+}
+
+// parseValuesRow parses a parenthesized row of values.
+func (p *Parser) parseValuesRow() ([]ast.Expression, error) {
+	var values []ast.Expression
+
 	if err := p.expect(token.LPAREN); err != nil {
 		return nil, err
 	}
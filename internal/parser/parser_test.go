@@ -39,6 +39,29 @@ func TestParser_Select(t *testing.T) {
 				},
 			},
 		},
+		{
+			input: "SELECT -1.5*2",
+			stmt: &ast.SelectStatement{
+				Result: []ast.ResultStatement{
+					{
+						Expr: &ast.ConditionExpr{
+							Left: &ast.UnaryExpr{
+								Operator: token.MINUS,
+								Operand: &ast.ScalarExpr{
+									Type:    token.FLOAT,
+									Literal: "1.5",
+								},
+							},
+							Operator: token.ASTERISK,
+							Right: &ast.ScalarExpr{
+								Type:    token.INT,
+								Literal: "2",
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			input: "SELECT 10+2*3",
 			stmt: &ast.SelectStatement{
@@ -222,8 +245,7 @@ func TestParser_Select(t *testing.T) {
 					},
 				},
 				OrderBy: &ast.OrderByStatement{
-					Column:    "id",
-					Direction: token.ASC,
+					Keys: []ast.SortKey{{Expr: &ast.IdentExpr{Name: "id"}, Direction: token.ASC}},
 				},
 			},
 		},
@@ -262,8 +284,7 @@ func TestParser_Select(t *testing.T) {
 					},
 				},
 				OrderBy: &ast.OrderByStatement{
-					Column:    "id",
-					Direction: token.ASC,
+					Keys: []ast.SortKey{{Expr: &ast.IdentExpr{Name: "id"}, Direction: token.ASC}},
 				},
 				Limit: &ast.LimitStatement{
 					Value: &ast.ScalarExpr{
@@ -308,8 +329,7 @@ func TestParser_Select(t *testing.T) {
 					},
 				},
 				OrderBy: &ast.OrderByStatement{
-					Column:    "id",
-					Direction: token.ASC,
+					Keys: []ast.SortKey{{Expr: &ast.IdentExpr{Name: "id"}, Direction: token.ASC}},
 				},
 				Limit: &ast.LimitStatement{
 					Value: &ast.ScalarExpr{
@@ -339,234 +359,1250 @@ func TestParser_Select(t *testing.T) {
 					Table: "customers",
 				},
 				OrderBy: &ast.OrderByStatement{
-					Column:    "id",
-					Direction: token.ASC,
+					Keys: []ast.SortKey{{Expr: &ast.IdentExpr{Name: "id"}, Direction: token.ASC}},
 				},
 			},
 		},
-	}
-
-	for _, test := range tests {
-		test := test
-
-		t.Run(test.input, func(t *testing.T) {
-			t.Parallel()
-
-			p := New(lexer.New(test.input))
-			stmts, err := p.Parse()
-			assert.NoError(t, err)
-			assert.Equal(t, test.stmt, stmts)
-		})
-	}
-}
-
-func TestParser_Insert(t *testing.T) {
-	t.Parallel()
-
-	tests := []struct {
-		input string
-		stmt  ast.Statement
-	}{
 		{
-			input: "INSERT INTO customers (id, name, salary) VALUES (10, 'Tom', 10*2+1)",
-			stmt: &ast.InsertStatement{
-				Table: "customers",
-				Columns: []string{
-					"id",
-					"name",
-					"salary",
+			input: "SELECT id FROM customers WHERE name IS NOT NULL AND age = 1 IS NULL ORDER BY age DESC NULLS LAST",
+			stmt: &ast.SelectStatement{
+				Result: []ast.ResultStatement{
+					{Expr: &ast.IdentExpr{Name: "id"}},
 				},
-				Values: []ast.Expression{
-					&ast.ScalarExpr{
-						Type:    token.INT,
-						Literal: "10",
-					},
-					&ast.ScalarExpr{
-						Type:    token.TEXT,
-						Literal: "Tom",
-					},
-					&ast.ConditionExpr{
-						Left: &ast.ConditionExpr{
-							Left: &ast.ScalarExpr{
-								Type:    token.INT,
-								Literal: "10",
-							},
-							Operator: token.ASTERISK,
-							Right: &ast.ScalarExpr{
-								Type:    token.INT,
-								Literal: "2",
+				From: &ast.FromStatement{Table: "customers"},
+				Where: &ast.WhereStatement{
+					Expr: &ast.ConditionExpr{
+						Left:     &ast.IsNullExpr{Expr: &ast.IdentExpr{Name: "name"}, Not: true},
+						Operator: token.AND,
+						Right: &ast.IsNullExpr{
+							Expr: &ast.ConditionExpr{
+								Left:     &ast.IdentExpr{Name: "age"},
+								Operator: token.EQ,
+								Right:    &ast.ScalarExpr{Type: token.INT, Literal: "1"},
 							},
 						},
-						Operator: token.PLUS,
-						Right: &ast.ScalarExpr{
-							Type:    token.INT,
-							Literal: "1",
-						},
 					},
 				},
+				OrderBy: &ast.OrderByStatement{
+					Keys: []ast.SortKey{{Expr: &ast.IdentExpr{Name: "age"}, Direction: token.DESC, Nulls: token.LAST}},
+				},
 			},
 		},
-	}
-
-	for _, test := range tests {
-		test := test
-
-		t.Run(test.input, func(t *testing.T) {
-			t.Parallel()
-
-			p := New(lexer.New(test.input))
-			stmts, err := p.Parse()
-			assert.NoError(t, err)
-			assert.Equal(t, test.stmt, stmts)
-		})
-	}
-}
-
-func TestParser_CreateDatabase(t *testing.T) {
-	t.Parallel()
-
-	tests := []struct {
-		input string
-		stmt  ast.Statement
-	}{
 		{
-			input: "CREATE DATABASE customers;",
-			stmt: &ast.CreateDatabaseStatement{
-				Database: "customers",
+			input: "SELECT a IS DISTINCT FROM b + 1, a IS NOT DISTINCT FROM NULL",
+			stmt: &ast.SelectStatement{
+				Result: []ast.ResultStatement{
+					{
+						Expr: &ast.IsDistinctExpr{
+							Left: &ast.IdentExpr{Name: "a"},
+							Right: &ast.ConditionExpr{
+								Left:     &ast.IdentExpr{Name: "b"},
+								Operator: token.PLUS,
+								Right:    &ast.ScalarExpr{Type: token.INT, Literal: "1"},
+							},
+						},
+					},
+					{
+						Expr: &ast.IsDistinctExpr{
+							Left:  &ast.IdentExpr{Name: "a"},
+							Right: &ast.ScalarExpr{Type: token.NULL, Literal: "NULL"},
+							Not:   true,
+						},
+					},
+				},
 			},
 		},
-	}
-
-	for _, test := range tests {
-		test := test
-
-		t.Run(test.input, func(t *testing.T) {
-			t.Parallel()
-
-			p := New(lexer.New(test.input))
-			stmts, err := p.Parse()
-			assert.NoError(t, err)
-			assert.Equal(t, test.stmt, stmts)
-		})
-	}
-}
-
-func TestParser_CreateTable(t *testing.T) {
-	t.Parallel()
-
-	tests := []struct {
-		input string
-		stmt  ast.Statement
-	}{
 		{
-			input: "CREATE TABLE users (id INT, name TEXT);",
-			stmt: &ast.CreateTableStatement{
-				Table: "users",
-				Columns: []ast.Column{
-					{
-						Name: "id",
-						Type: token.INT,
+			input: "SELECT timezone, COUNT(*), count(DISTINCT city) FROM airports WHERE city != 'Moscow' GROUP BY timezone, 2 HAVING COUNT(*) > 2 ORDER BY timezone",
+			stmt: &ast.SelectStatement{
+				Result: []ast.ResultStatement{
+					{Expr: &ast.IdentExpr{Name: "timezone"}},
+					{Expr: &ast.CallExpr{Name: "COUNT", Args: []ast.Expression{&ast.AsteriskExpr{}}}},
+					{Expr: &ast.CallExpr{Name: "count", Args: []ast.Expression{&ast.IdentExpr{Name: "city"}}, Distinct: true}},
+				},
+				From: &ast.FromStatement{Table: "airports"},
+				Where: &ast.WhereStatement{
+					Expr: &ast.ConditionExpr{
+						Left:     &ast.IdentExpr{Name: "city"},
+						Operator: token.NOT_EQ,
+						Right:    &ast.ScalarExpr{Type: token.TEXT, Literal: "Moscow"},
 					},
-					{
-						Name: "name",
-						Type: token.TEXT,
+				},
+				GroupBy: &ast.GroupByStatement{
+					Exprs: []ast.Expression{
+						&ast.IdentExpr{Name: "timezone"},
+						&ast.ScalarExpr{Type: token.INT, Literal: "2"},
 					},
 				},
+				Having: &ast.HavingStatement{
+					Expr: &ast.ConditionExpr{
+						Left:     &ast.CallExpr{Name: "COUNT", Args: []ast.Expression{&ast.AsteriskExpr{}}},
+						Operator: token.GT,
+						Right:    &ast.ScalarExpr{Type: token.INT, Literal: "2"},
+					},
+				},
+				OrderBy: &ast.OrderByStatement{Keys: []ast.SortKey{{Expr: &ast.IdentExpr{Name: "timezone"}, Direction: token.ASC}}},
 			},
 		},
-	}
-
-	for _, test := range tests {
-		test := test
-
-		t.Run(test.input, func(t *testing.T) {
-			t.Parallel()
-
-			p := New(lexer.New(test.input))
-			stmts, err := p.Parse()
-			assert.NoError(t, err)
-			assert.Equal(t, test.stmt, stmts)
-		})
-	}
-}
-
-func TestParser_Update(t *testing.T) {
-	t.Parallel()
-
-	tests := []struct {
-		input string
-		stmt  ast.Statement
-	}{
 		{
-			input: "UPDATE customers SET name = 'vlad', salary = 10*100 WHERE id = 1",
-			stmt: &ast.UpdateStatement{
-				Table: "customers",
-				Set: []ast.SetStatement{
-					{
-						Column: "name",
-						Value: &ast.ScalarExpr{
-							Type:    token.TEXT,
-							Literal: "vlad",
+			input: "SELECT a.*, f.code FROM airports AS a JOIN flights f ON a.code = f.departure LEFT OUTER JOIN aircrafts USING (model, range), seats CROSS JOIN planes p WHERE f.id > 1",
+			stmt: &ast.SelectStatement{
+				Result: []ast.ResultStatement{
+					{Expr: &ast.AsteriskExpr{Table: "a"}},
+					{Expr: &ast.IdentExpr{Table: "f", Name: "code"}},
+				},
+				From: &ast.FromStatement{
+					Table: "airports",
+					Alias: "a",
+					Joins: []ast.JoinStatement{
+						{
+							Type:  token.INNER,
+							Table: "flights",
+							Alias: "f",
+							On: &ast.ConditionExpr{
+								Left:     &ast.IdentExpr{Table: "a", Name: "code"},
+								Operator: token.EQ,
+								Right:    &ast.IdentExpr{Table: "f", Name: "departure"},
+							},
 						},
+						{Type: token.LEFT, Table: "aircrafts", Using: []string{"model", "range"}},
+						{Type: token.CROSS, Table: "seats"},
+						{Type: token.CROSS, Table: "planes", Alias: "p"},
 					},
+				},
+				Where: &ast.WhereStatement{
+					Expr: &ast.ConditionExpr{
+						Left:     &ast.IdentExpr{Table: "f", Name: "id"},
+						Operator: token.GT,
+						Right:    &ast.ScalarExpr{Type: token.INT, Literal: "1"},
+					},
+				},
+			},
+		},
+		{
+			input: "SELECT code, (SELECT max(id) FROM flights f WHERE f.departure = a.code) FROM airports a WHERE NOT EXISTS (SELECT 1 FROM runways) AND id NOT IN (SELECT id FROM closed) AND city IN (SELECT city FROM (SELECT city FROM cities) AS c)",
+			stmt: &ast.SelectStatement{
+				Result: []ast.ResultStatement{
+					{Expr: &ast.IdentExpr{Name: "code"}},
 					{
-						Column: "salary",
-						Value: &ast.ConditionExpr{
-							Left: &ast.ScalarExpr{
-								Type:    token.INT,
-								Literal: "10",
-							},
-							Operator: token.ASTERISK,
-							Right: &ast.ScalarExpr{
-								Type:    token.INT,
-								Literal: "100",
+						Expr: &ast.SubqueryExpr{
+							Select: &ast.SelectStatement{
+								Result: []ast.ResultStatement{
+									{Expr: &ast.CallExpr{Name: "max", Args: []ast.Expression{&ast.IdentExpr{Name: "id"}}}},
+								},
+								From: &ast.FromStatement{Table: "flights", Alias: "f"},
+								Where: &ast.WhereStatement{
+									Expr: &ast.ConditionExpr{
+										Left:     &ast.IdentExpr{Table: "f", Name: "departure"},
+										Operator: token.EQ,
+										Right:    &ast.IdentExpr{Table: "a", Name: "code"},
+									},
+								},
 							},
 						},
 					},
 				},
+				From: &ast.FromStatement{Table: "airports", Alias: "a"},
 				Where: &ast.WhereStatement{
 					Expr: &ast.ConditionExpr{
-						Left: &ast.IdentExpr{
-							Name: "id",
+						Left: &ast.ConditionExpr{
+							Left: &ast.UnaryExpr{
+								Operator: token.NOT,
+								Operand: &ast.ExistsExpr{
+									Select: &ast.SelectStatement{
+										Result: []ast.ResultStatement{{Expr: &ast.ScalarExpr{Type: token.INT, Literal: "1"}}},
+										From:   &ast.FromStatement{Table: "runways"},
+									},
+								},
+							},
+							Operator: token.AND,
+							Right: &ast.InExpr{
+								Expr: &ast.IdentExpr{Name: "id"},
+								Not:  true,
+								Select: &ast.SelectStatement{
+									Result: []ast.ResultStatement{{Expr: &ast.IdentExpr{Name: "id"}}},
+									From:   &ast.FromStatement{Table: "closed"},
+								},
+							},
 						},
-						Operator: token.EQ,
-						Right: &ast.ScalarExpr{
-							Type:    token.INT,
-							Literal: "1",
+						Operator: token.AND,
+						Right: &ast.InExpr{
+							Expr: &ast.IdentExpr{Name: "city"},
+							Select: &ast.SelectStatement{
+								Result: []ast.ResultStatement{{Expr: &ast.IdentExpr{Name: "city"}}},
+								From: &ast.FromStatement{
+									Subquery: &ast.SelectStatement{
+										Result: []ast.ResultStatement{{Expr: &ast.IdentExpr{Name: "city"}}},
+										From:   &ast.FromStatement{Table: "cities"},
+									},
+									Alias: "c",
+								},
+							},
 						},
 					},
 				},
 			},
 		},
-	}
-
-	for _, test := range tests {
-		test := test
-
-		t.Run(test.input, func(t *testing.T) {
-			t.Parallel()
-
-			p := New(lexer.New(test.input))
-			stmts, err := p.Parse()
-			assert.NoError(t, err)
-			assert.Equal(t, test.stmt, stmts)
-		})
-	}
-}
-
-func TestParser_Delete(t *testing.T) {
-	t.Parallel()
-
-	tests := []struct {
-		input string
-		stmt  ast.Statement
-	}{
 		{
-			input: "DELETE FROM customers WHERE id = 10",
-			stmt: &ast.DeleteStatement{
-				Table: "customers",
-				Where: &ast.WhereStatement{
-					Expr: &ast.ConditionExpr{
+			input: "SELECT (SELECT 1), NOT a = b",
+			stmt: &ast.SelectStatement{
+				Result: []ast.ResultStatement{
+					{
+						Expr: &ast.SubqueryExpr{
+							Select: &ast.SelectStatement{
+								Result: []ast.ResultStatement{{Expr: &ast.ScalarExpr{Type: token.INT, Literal: "1"}}},
+							},
+						},
+					},
+					{
+						Expr: &ast.UnaryExpr{
+							Operator: token.NOT,
+							Operand: &ast.ConditionExpr{
+								Left:     &ast.IdentExpr{Name: "a"},
+								Operator: token.EQ,
+								Right:    &ast.IdentExpr{Name: "b"},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			input: "SELECT * FROM a FULL JOIN b ON a.id = b.id, c RIGHT JOIN d ON true",
+			stmt: &ast.SelectStatement{
+				Result: []ast.ResultStatement{{Expr: &ast.AsteriskExpr{}}},
+				From: &ast.FromStatement{
+					Table: "a",
+					Joins: []ast.JoinStatement{
+						{
+							Type:  token.FULL,
+							Table: "b",
+							On: &ast.ConditionExpr{
+								Left:     &ast.IdentExpr{Table: "a", Name: "id"},
+								Operator: token.EQ,
+								Right:    &ast.IdentExpr{Table: "b", Name: "id"},
+							},
+						},
+						{Type: token.CROSS, Table: "c"},
+						{Type: token.RIGHT, Table: "d", On: &ast.ScalarExpr{Type: token.TRUE, Literal: "true"}},
+					},
+				},
+			},
+		},
+		{
+			input: "WITH RECURSIVE t (n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM t WHERE n < 5), recursive AS (SELECT n FROM t) SELECT n FROM recursive UNION SELECT 0 ORDER BY n LIMIT 3",
+			stmt: &ast.SelectStatement{
+				With: &ast.WithStatement{
+					Recursive: true,
+					Queries: []ast.CommonTableExpr{
+						{
+							Name:    "t",
+							Columns: []string{"n"},
+							Query: &ast.SelectStatement{
+								Operator: token.UNION,
+								All:      true,
+								Left: &ast.SelectStatement{
+									Result: []ast.ResultStatement{{Expr: &ast.ScalarExpr{Type: token.INT, Literal: "1"}}},
+								},
+								Right: &ast.SelectStatement{
+									Result: []ast.ResultStatement{
+										{
+											Expr: &ast.ConditionExpr{
+												Left:     &ast.IdentExpr{Name: "n"},
+												Operator: token.PLUS,
+												Right:    &ast.ScalarExpr{Type: token.INT, Literal: "1"},
+											},
+										},
+									},
+									From: &ast.FromStatement{Table: "t"},
+									Where: &ast.WhereStatement{
+										Expr: &ast.ConditionExpr{
+											Left:     &ast.IdentExpr{Name: "n"},
+											Operator: token.LT,
+											Right:    &ast.ScalarExpr{Type: token.INT, Literal: "5"},
+										},
+									},
+								},
+							},
+						},
+						{
+							Name: "recursive",
+							Query: &ast.SelectStatement{
+								Result: []ast.ResultStatement{{Expr: &ast.IdentExpr{Name: "n"}}},
+								From:   &ast.FromStatement{Table: "t"},
+							},
+						},
+					},
+				},
+				Operator: token.UNION,
+				Left: &ast.SelectStatement{
+					Result: []ast.ResultStatement{{Expr: &ast.IdentExpr{Name: "n"}}},
+					From:   &ast.FromStatement{Table: "recursive"},
+				},
+				Right: &ast.SelectStatement{
+					Result: []ast.ResultStatement{{Expr: &ast.ScalarExpr{Type: token.INT, Literal: "0"}}},
+				},
+				OrderBy: &ast.OrderByStatement{Keys: []ast.SortKey{{Expr: &ast.IdentExpr{Name: "n"}, Direction: token.ASC}}},
+				Limit:   &ast.LimitStatement{Value: &ast.ScalarExpr{Type: token.INT, Literal: "3"}},
+			},
+		},
+		{
+			input: "SELECT rank() OVER (PARTITION BY a, b ORDER BY c DESC NULLS FIRST), sum(c) OVER (ORDER BY c ROWS BETWEEN 1 PRECEDING AND UNBOUNDED FOLLOWING), count(*) OVER (RANGE CURRENT ROW) FROM t",
+			stmt: &ast.SelectStatement{
+				Result: []ast.ResultStatement{
+					{
+						Expr: &ast.WindowExpr{
+							Call:        &ast.CallExpr{Name: "rank"},
+							PartitionBy: []ast.Expression{&ast.IdentExpr{Name: "a"}, &ast.IdentExpr{Name: "b"}},
+							OrderBy:     []ast.SortKey{{Expr: &ast.IdentExpr{Name: "c"}, Direction: token.DESC, Nulls: token.FIRST}},
+						},
+					},
+					{
+						Expr: &ast.WindowExpr{
+							Call:    &ast.CallExpr{Name: "sum", Args: []ast.Expression{&ast.IdentExpr{Name: "c"}}},
+							OrderBy: []ast.SortKey{{Expr: &ast.IdentExpr{Name: "c"}, Direction: token.ASC}},
+							Frame: &ast.WindowFrame{
+								Mode:  token.ROWS,
+								Start: ast.FrameBound{Type: token.PRECEDING, Offset: &ast.ScalarExpr{Type: token.INT, Literal: "1"}},
+								End:   ast.FrameBound{Type: token.FOLLOWING},
+							},
+						},
+					},
+					{
+						Expr: &ast.WindowExpr{
+							Call: &ast.CallExpr{Name: "count", Args: []ast.Expression{&ast.AsteriskExpr{}}},
+							Frame: &ast.WindowFrame{
+								Mode:  token.RANGE,
+								Start: ast.FrameBound{Type: token.CURRENT},
+								End:   ast.FrameBound{Type: token.CURRENT},
+							},
+						},
+					},
+				},
+				From: &ast.FromStatement{Table: "t"},
+			},
+		},
+		{
+			input: "SELECT a FROM t EXCEPT ALL SELECT b FROM u INTERSECT (SELECT c FROM v LIMIT 1) ORDER BY a",
+			stmt: &ast.SelectStatement{
+				Operator: token.EXCEPT,
+				All:      true,
+				Left: &ast.SelectStatement{
+					Result: []ast.ResultStatement{{Expr: &ast.IdentExpr{Name: "a"}}},
+					From:   &ast.FromStatement{Table: "t"},
+				},
+				Right: &ast.SelectStatement{
+					Operator: token.INTERSECT,
+					Left: &ast.SelectStatement{
+						Result: []ast.ResultStatement{{Expr: &ast.IdentExpr{Name: "b"}}},
+						From:   &ast.FromStatement{Table: "u"},
+					},
+					Right: &ast.SelectStatement{
+						Result: []ast.ResultStatement{{Expr: &ast.IdentExpr{Name: "c"}}},
+						From:   &ast.FromStatement{Table: "v"},
+						Limit:  &ast.LimitStatement{Value: &ast.ScalarExpr{Type: token.INT, Literal: "1"}},
+					},
+				},
+				OrderBy: &ast.OrderByStatement{Keys: []ast.SortKey{{Expr: &ast.IdentExpr{Name: "a"}, Direction: token.ASC}}},
+			},
+		},
+		{
+			input: "SELECT DISTINCT ON (city, 2) city AS c, id + 1 n, range AS rows FROM airports",
+			stmt: &ast.SelectStatement{
+				Distinct:   true,
+				DistinctOn: []ast.Expression{&ast.IdentExpr{Name: "city"}, &ast.ScalarExpr{Type: token.INT, Literal: "2"}},
+				Result: []ast.ResultStatement{
+					{Expr: &ast.IdentExpr{Name: "city"}, Alias: "c"},
+					{
+						Expr: &ast.ConditionExpr{
+							Left:     &ast.IdentExpr{Name: "id"},
+							Operator: token.PLUS,
+							Right:    &ast.ScalarExpr{Type: token.INT, Literal: "1"},
+						},
+						Alias: "n",
+					},
+					{Expr: &ast.IdentExpr{Name: "range"}, Alias: "rows"},
+				},
+				From: &ast.FromStatement{Table: "airports"},
+			},
+		},
+		{
+			input: "SELECT DISTINCT city FROM airports",
+			stmt: &ast.SelectStatement{
+				Distinct: true,
+				Result:   []ast.ResultStatement{{Expr: &ast.IdentExpr{Name: "city"}}},
+				From:     &ast.FromStatement{Table: "airports"},
+			},
+		},
+		{
+			input: "SELECT a FROM t ORDER BY a DESC NULLS LAST, 2, b + 1 NULLS FIRST LIMIT 1",
+			stmt: &ast.SelectStatement{
+				Result: []ast.ResultStatement{{Expr: &ast.IdentExpr{Name: "a"}}},
+				From:   &ast.FromStatement{Table: "t"},
+				OrderBy: &ast.OrderByStatement{
+					Keys: []ast.SortKey{
+						{Expr: &ast.IdentExpr{Name: "a"}, Direction: token.DESC, Nulls: token.LAST},
+						{Expr: &ast.ScalarExpr{Type: token.INT, Literal: "2"}, Direction: token.ASC},
+						{
+							Expr: &ast.ConditionExpr{
+								Left:     &ast.IdentExpr{Name: "b"},
+								Operator: token.PLUS,
+								Right:    &ast.ScalarExpr{Type: token.INT, Literal: "1"},
+							},
+							Direction: token.ASC,
+							Nulls:     token.FIRST,
+						},
+					},
+				},
+				Limit: &ast.LimitStatement{Value: &ast.ScalarExpr{Type: token.INT, Literal: "1"}},
+			},
+		},
+		{
+			input: "SELECT * FROM (WITH recursive AS (SELECT 1) SELECT * FROM recursive) AS r",
+			stmt: &ast.SelectStatement{
+				Result: []ast.ResultStatement{{Expr: &ast.AsteriskExpr{}}},
+				From: &ast.FromStatement{
+					Subquery: &ast.SelectStatement{
+						With: &ast.WithStatement{
+							Queries: []ast.CommonTableExpr{
+								{
+									Name: "recursive",
+									Query: &ast.SelectStatement{
+										Result: []ast.ResultStatement{{Expr: &ast.ScalarExpr{Type: token.INT, Literal: "1"}}},
+									},
+								},
+							},
+						},
+						Result: []ast.ResultStatement{{Expr: &ast.AsteriskExpr{}}},
+						From:   &ast.FromStatement{Table: "recursive"},
+					},
+					Alias: "r",
+				},
+			},
+		},
+		{
+			input: "SELECT CASE WHEN a BETWEEN 1 AND 2 THEN 'x' ELSE NULL END, CASE b WHEN 1 THEN 2 END FROM t WHERE c NOT LIKE 'a%' ESCAPE '!' AND d IN (1, 2) AND e::TEXT ILIKE f",
+			stmt: &ast.SelectStatement{
+				Result: []ast.ResultStatement{
+					{
+						Expr: &ast.CaseExpr{
+							Whens: []ast.WhenClause{
+								{
+									Cond: &ast.BetweenExpr{
+										Expr: &ast.IdentExpr{Name: "a"},
+										Low:  &ast.ScalarExpr{Type: token.INT, Literal: "1"},
+										High: &ast.ScalarExpr{Type: token.INT, Literal: "2"},
+									},
+									Result: &ast.ScalarExpr{Type: token.TEXT, Literal: "x"},
+								},
+							},
+							Else: &ast.ScalarExpr{Type: token.NULL, Literal: "NULL"},
+						},
+					},
+					{
+						Expr: &ast.CaseExpr{
+							Operand: &ast.IdentExpr{Name: "b"},
+							Whens: []ast.WhenClause{
+								{Cond: &ast.ScalarExpr{Type: token.INT, Literal: "1"}, Result: &ast.ScalarExpr{Type: token.INT, Literal: "2"}},
+							},
+						},
+					},
+				},
+				From: &ast.FromStatement{Table: "t"},
+				Where: &ast.WhereStatement{
+					Expr: &ast.ConditionExpr{
+						Left: &ast.ConditionExpr{
+							Left: &ast.LikeExpr{
+								Expr:     &ast.IdentExpr{Name: "c"},
+								Operator: token.LIKE,
+								Not:      true,
+								Pattern:  &ast.ScalarExpr{Type: token.TEXT, Literal: "a%"},
+								Escape:   &ast.ScalarExpr{Type: token.TEXT, Literal: "!"},
+							},
+							Operator: token.AND,
+							Right: &ast.InExpr{
+								Expr: &ast.IdentExpr{Name: "d"},
+								List: []ast.Expression{
+									&ast.ScalarExpr{Type: token.INT, Literal: "1"},
+									&ast.ScalarExpr{Type: token.INT, Literal: "2"},
+								},
+							},
+						},
+						Operator: token.AND,
+						Right: &ast.LikeExpr{
+							Expr:     &ast.CastExpr{Expr: &ast.IdentExpr{Name: "e"}, Type: token.TEXT},
+							Operator: token.ILIKE,
+							Pattern:  &ast.IdentExpr{Name: "f"},
+						},
+					},
+				},
+			},
+		},
+		{
+			input: "SELECT CAST(a + 1 AS FLOAT), -b::INT, c NOT BETWEEN d AND e + 1",
+			stmt: &ast.SelectStatement{
+				Result: []ast.ResultStatement{
+					{
+						Expr: &ast.CastExpr{
+							Expr: &ast.ConditionExpr{
+								Left:     &ast.IdentExpr{Name: "a"},
+								Operator: token.PLUS,
+								Right:    &ast.ScalarExpr{Type: token.INT, Literal: "1"},
+							},
+							Type: token.FLOAT,
+						},
+					},
+					{
+						Expr: &ast.UnaryExpr{
+							Operator: token.MINUS,
+							Operand:  &ast.CastExpr{Expr: &ast.IdentExpr{Name: "b"}, Type: token.INT},
+						},
+					},
+					{
+						Expr: &ast.BetweenExpr{
+							Expr: &ast.IdentExpr{Name: "c"},
+							Not:  true,
+							Low:  &ast.IdentExpr{Name: "d"},
+							High: &ast.ConditionExpr{
+								Left:     &ast.IdentExpr{Name: "e"},
+								Operator: token.PLUS,
+								Right:    &ast.ScalarExpr{Type: token.INT, Literal: "1"},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			input: "SELECT position('a' IN lower(c)), position(d, e)",
+			stmt: &ast.SelectStatement{
+				Result: []ast.ResultStatement{
+					{
+						Expr: &ast.CallExpr{
+							Name: "position",
+							Args: []ast.Expression{
+								&ast.ScalarExpr{Type: token.TEXT, Literal: "a"},
+								&ast.CallExpr{Name: "lower", Args: []ast.Expression{&ast.IdentExpr{Name: "c"}}},
+							},
+						},
+					},
+					{Expr: &ast.CallExpr{Name: "position", Args: []ast.Expression{&ast.IdentExpr{Name: "d"}, &ast.IdentExpr{Name: "e"}}}},
+				},
+			},
+		},
+		{
+			input: "SELECT CAST(a AS NUMERIC(4, 2)), b::DECIMAL, NUMERIC '1.50'",
+			stmt: &ast.SelectStatement{
+				Result: []ast.ResultStatement{
+					{Expr: &ast.CastExpr{Expr: &ast.IdentExpr{Name: "a"}, Type: token.NUMERIC, Precision: 4, Scale: 2}},
+					{Expr: &ast.CastExpr{Expr: &ast.IdentExpr{Name: "b"}, Type: token.NUMERIC}},
+					{Expr: &ast.CastExpr{Expr: &ast.ScalarExpr{Type: token.TEXT, Literal: "1.50"}, Type: token.NUMERIC}},
+				},
+			},
+		},
+		{
+			input: "SELECT DATE '2024-03-01' + 1, ts AT TIME ZONE 'Asia/Yakutsk', extract(YEAR FROM ts), a::TIMESTAMP WITH TIME ZONE",
+			stmt: &ast.SelectStatement{
+				Result: []ast.ResultStatement{
+					{
+						Expr: &ast.ConditionExpr{
+							Left:     &ast.CastExpr{Expr: &ast.ScalarExpr{Type: token.TEXT, Literal: "2024-03-01"}, Type: token.DATE},
+							Operator: token.PLUS,
+							Right:    &ast.ScalarExpr{Type: token.INT, Literal: "1"},
+						},
+					},
+					{
+						Expr: &ast.AtTimeZoneExpr{
+							Expr: &ast.IdentExpr{Name: "ts"},
+							Zone: &ast.ScalarExpr{Type: token.TEXT, Literal: "Asia/Yakutsk"},
+						},
+					},
+					{
+						Expr: &ast.CallExpr{
+							Name: "extract",
+							Args: []ast.Expression{
+								&ast.ScalarExpr{Type: token.TEXT, Literal: "year"},
+								&ast.IdentExpr{Name: "ts"},
+							},
+						},
+					},
+					{Expr: &ast.CastExpr{Expr: &ast.IdentExpr{Name: "a"}, Type: token.TIMESTAMPTZ}},
+				},
+			},
+		},
+		{
+			input: "SELECT doc->'tags'->>0 = 'x', doc #> '{a,b}', doc @> '{\"a\": 1}' AND true",
+			stmt: &ast.SelectStatement{
+				Result: []ast.ResultStatement{
+					{
+						Expr: &ast.ConditionExpr{
+							Left: &ast.ConditionExpr{
+								Left: &ast.ConditionExpr{
+									Left:     &ast.IdentExpr{Name: "doc"},
+									Operator: token.ARROW,
+									Right:    &ast.ScalarExpr{Type: token.TEXT, Literal: "tags"},
+								},
+								Operator: token.DOUBLE_ARROW,
+								Right:    &ast.ScalarExpr{Type: token.INT, Literal: "0"},
+							},
+							Operator: token.EQ,
+							Right:    &ast.ScalarExpr{Type: token.TEXT, Literal: "x"},
+						},
+					},
+					{
+						Expr: &ast.ConditionExpr{
+							Left:     &ast.IdentExpr{Name: "doc"},
+							Operator: token.HASH_ARROW,
+							Right:    &ast.ScalarExpr{Type: token.TEXT, Literal: "{a,b}"},
+						},
+					},
+					{
+						Expr: &ast.ConditionExpr{
+							Left: &ast.ConditionExpr{
+								Left:     &ast.IdentExpr{Name: "doc"},
+								Operator: token.CONTAINS,
+								Right:    &ast.ScalarExpr{Type: token.TEXT, Literal: `{"a": 1}`},
+							},
+							Operator: token.AND,
+							Right:    &ast.ScalarExpr{Type: token.TRUE, Literal: "true"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.input, func(t *testing.T) {
+			t.Parallel()
+
+			p := New(lexer.New(test.input))
+			stmts, err := p.Parse()
+			assert.NoError(t, err)
+			assert.Equal(t, test.stmt, stmts)
+		})
+	}
+}
+
+func TestParser_Insert(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		stmt  ast.Statement
+	}{
+		{
+			input: "INSERT INTO customers (id, name, salary) VALUES (10, 'Tom', 10*2+1)",
+			stmt: &ast.InsertStatement{
+				Table: "customers",
+				Columns: []string{
+					"id",
+					"name",
+					"salary",
+				},
+				Values: [][]ast.Expression{{
+					&ast.ScalarExpr{
+						Type:    token.INT,
+						Literal: "10",
+					},
+					&ast.ScalarExpr{
+						Type:    token.TEXT,
+						Literal: "Tom",
+					},
+					&ast.ConditionExpr{
+						Left: &ast.ConditionExpr{
+							Left: &ast.ScalarExpr{
+								Type:    token.INT,
+								Literal: "10",
+							},
+							Operator: token.ASTERISK,
+							Right: &ast.ScalarExpr{
+								Type:    token.INT,
+								Literal: "2",
+							},
+						},
+						Operator: token.PLUS,
+						Right: &ast.ScalarExpr{
+							Type:    token.INT,
+							Literal: "1",
+						},
+					},
+				}},
+			},
+		},
+		{
+			input: "INSERT INTO users VALUES (DEFAULT, now())",
+			stmt: &ast.InsertStatement{
+				Table: "users",
+				Values: [][]ast.Expression{{
+					&ast.DefaultExpr{},
+					&ast.CallExpr{Name: "now"},
+				}},
+			},
+		},
+		{
+			input: "INSERT INTO users (id, name) VALUES (1, 'a'), (2, 'b');",
+			stmt: &ast.InsertStatement{
+				Table:   "users",
+				Columns: []string{"id", "name"},
+				Values: [][]ast.Expression{
+					{&ast.ScalarExpr{Type: token.INT, Literal: "1"}, &ast.ScalarExpr{Type: token.TEXT, Literal: "a"}},
+					{&ast.ScalarExpr{Type: token.INT, Literal: "2"}, &ast.ScalarExpr{Type: token.TEXT, Literal: "b"}},
+				},
+			},
+		},
+		{
+			input: "INSERT INTO users DEFAULT VALUES",
+			stmt: &ast.InsertStatement{
+				Table:         "users",
+				DefaultValues: true,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.input, func(t *testing.T) {
+			t.Parallel()
+
+			p := New(lexer.New(test.input))
+			stmts, err := p.Parse()
+			assert.NoError(t, err)
+			assert.Equal(t, test.stmt, stmts)
+		})
+	}
+}
+
+func TestParser_CreateDatabase(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		stmt  ast.Statement
+	}{
+		{
+			input: "CREATE DATABASE customers;",
+			stmt: &ast.CreateDatabaseStatement{
+				Database: "customers",
+			},
+		},
+		{
+			input: "CREATE DATABASE IF NOT EXISTS customers;",
+			stmt: &ast.CreateDatabaseStatement{
+				Database:    "customers",
+				IfNotExists: true,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.input, func(t *testing.T) {
+			t.Parallel()
+
+			p := New(lexer.New(test.input))
+			stmts, err := p.Parse()
+			assert.NoError(t, err)
+			assert.Equal(t, test.stmt, stmts)
+		})
+	}
+}
+
+func TestParser_CreateIndex(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		stmt  ast.Statement
+	}{
+		{
+			input: "CREATE INDEX users_email ON users (email);",
+			stmt: &ast.CreateIndexStatement{
+				Index: "users_email",
+				Table: "users",
+				Expr:  &ast.IdentExpr{Name: "email"},
+			},
+		},
+		{
+			input: "CREATE INDEX IF NOT EXISTS docs_kind ON docs ((body->>'kind'))",
+			stmt: &ast.CreateIndexStatement{
+				Index: "docs_kind",
+				Table: "docs",
+				Expr: &ast.ConditionExpr{
+					Left:     &ast.IdentExpr{Name: "body"},
+					Operator: token.DOUBLE_ARROW,
+					Right:    &ast.ScalarExpr{Type: token.TEXT, Literal: "kind"},
+				},
+				IfNotExists: true,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.input, func(t *testing.T) {
+			t.Parallel()
+
+			p := New(lexer.New(test.input))
+			stmts, err := p.Parse()
+			assert.NoError(t, err)
+			assert.Equal(t, test.stmt, stmts)
+		})
+	}
+}
+
+func TestParser_CreateTable(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		stmt  ast.Statement
+	}{
+		{
+			input: "CREATE TABLE users (id INT, name TEXT);",
+			stmt: &ast.CreateTableStatement{
+				Table: "users",
+				Columns: []ast.Column{
+					{
+						Name:     "id",
+						Type:     token.INT,
+						Nullable: true,
+					},
+					{
+						Name:     "name",
+						Type:     token.TEXT,
+						Nullable: true,
+					},
+				},
+			},
+		},
+		{
+			input: "CREATE TABLE IF NOT EXISTS users (id INT);",
+			stmt: &ast.CreateTableStatement{
+				Table: "users",
+				Columns: []ast.Column{
+					{
+						Name:     "id",
+						Type:     token.INT,
+						Nullable: true,
+					},
+				},
+				IfNotExists: true,
+			},
+		},
+		{
+			input: "CREATE TABLE airports (id INTEGER PRIMARY KEY, code TEXT NOT NULL, lat FLOAT NULL, active BOOLEAN DEFAULT true, range INT DEFAULT -1);",
+			stmt: &ast.CreateTableStatement{
+				Table: "airports",
+				Columns: []ast.Column{
+					{
+						Name:       "id",
+						Type:       token.INT,
+						PrimaryKey: true,
+					},
+					{
+						Name: "code",
+						Type: token.TEXT,
+					},
+					{
+						Name:     "lat",
+						Type:     token.FLOAT,
+						Nullable: true,
+					},
+					{
+						Name:     "active",
+						Type:     token.BOOLEAN,
+						Default:  &ast.ScalarExpr{Type: token.TRUE, Literal: "true"},
+						Nullable: true,
+					},
+					{
+						Name: "range",
+						Type: token.INT,
+						Default: &ast.UnaryExpr{
+							Operator: token.MINUS,
+							Operand:  &ast.ScalarExpr{Type: token.INT, Literal: "1"},
+						},
+						Nullable: true,
+					},
+				},
+			},
+		},
+		{
+			input: "CREATE TABLE flights (id INT PRIMARY KEY, code TEXT UNIQUE, seats INT CONSTRAINT positive CHECK (seats > 0), aircraft TEXT REFERENCES aircrafts (code) ON DELETE CASCADE ON UPDATE SET NULL);",
+			stmt: &ast.CreateTableStatement{
+				Table: "flights",
+				Columns: []ast.Column{
+					{
+						Name:       "id",
+						Type:       token.INT,
+						PrimaryKey: true,
+					},
+					{
+						Name:     "code",
+						Type:     token.TEXT,
+						Nullable: true,
+						Constraints: []ast.Constraint{
+							{Type: token.UNIQUE, Columns: []string{"code"}},
+						},
+					},
+					{
+						Name:     "seats",
+						Type:     token.INT,
+						Nullable: true,
+						Constraints: []ast.Constraint{
+							{
+								Name:    "positive",
+								Type:    token.CHECK,
+								Columns: []string{"seats"},
+								Check: &ast.ConditionExpr{
+									Left:     &ast.IdentExpr{Name: "seats"},
+									Operator: token.GT,
+									Right:    &ast.ScalarExpr{Type: token.INT, Literal: "0"},
+								},
+							},
+						},
+					},
+					{
+						Name:     "aircraft",
+						Type:     token.TEXT,
+						Nullable: true,
+						Constraints: []ast.Constraint{
+							{
+								Type:    token.FOREIGN,
+								Columns: []string{"aircraft"},
+								References: &ast.Reference{
+									Table:    "aircrafts",
+									Columns:  []string{"code"},
+									OnDelete: token.CASCADE,
+									OnUpdate: token.NULL,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			input: "CREATE TABLE payments (amount NUMERIC(10, 2) NOT NULL, rate DECIMAL(5), total NUMERIC);",
+			stmt: &ast.CreateTableStatement{
+				Table: "payments",
+				Columns: []ast.Column{
+					{Name: "amount", Type: token.NUMERIC, Precision: 10, Scale: 2},
+					{Name: "rate", Type: token.NUMERIC, Nullable: true, Precision: 5},
+					{Name: "total", Type: token.NUMERIC, Nullable: true},
+				},
+			},
+		},
+		{
+			input: "CREATE TABLE documents (body JSONB NOT NULL, meta JSON);",
+			stmt: &ast.CreateTableStatement{
+				Table: "documents",
+				Columns: []ast.Column{
+					{Name: "body", Type: token.JSONB},
+					{Name: "meta", Type: token.JSONB, Nullable: true},
+				},
+			},
+		},
+		{
+			input: "CREATE TABLE events (day DATE, clock TIME WITHOUT TIME ZONE, starts TIMESTAMP, ends TIMESTAMP WITH TIME ZONE, length INTERVAL);",
+			stmt: &ast.CreateTableStatement{
+				Table: "events",
+				Columns: []ast.Column{
+					{Name: "day", Type: token.DATE, Nullable: true},
+					{Name: "clock", Type: token.TIME, Nullable: true},
+					{Name: "starts", Type: token.TIMESTAMP, Nullable: true},
+					{Name: "ends", Type: token.TIMESTAMPTZ, Nullable: true},
+					{Name: "length", Type: token.INTERVAL, Nullable: true},
+				},
+			},
+		},
+		{
+			input: "CREATE TABLE seats (code TEXT, no TEXT, UNIQUE (code, no), CONSTRAINT seats_check CHECK (no != ''), FOREIGN KEY (code) REFERENCES aircrafts);",
+			stmt: &ast.CreateTableStatement{
+				Table: "seats",
+				Columns: []ast.Column{
+					{Name: "code", Type: token.TEXT, Nullable: true},
+					{Name: "no", Type: token.TEXT, Nullable: true},
+				},
+				Constraints: []ast.Constraint{
+					{Type: token.UNIQUE, Columns: []string{"code", "no"}},
+					{
+						Name: "seats_check",
+						Type: token.CHECK,
+						Check: &ast.ConditionExpr{
+							Left:     &ast.IdentExpr{Name: "no"},
+							Operator: token.NOT_EQ,
+							Right:    &ast.ScalarExpr{Type: token.TEXT, Literal: ""},
+						},
+					},
+					{
+						Type:    token.FOREIGN,
+						Columns: []string{"code"},
+						References: &ast.Reference{
+							Table:    "aircrafts",
+							OnDelete: token.RESTRICT,
+							OnUpdate: token.RESTRICT,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.input, func(t *testing.T) {
+			t.Parallel()
+
+			p := New(lexer.New(test.input))
+			stmts, err := p.Parse()
+			assert.NoError(t, err)
+			assert.Equal(t, test.stmt, stmts)
+		})
+	}
+}
+
+func TestParser_AlterTable(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		stmt  ast.Statement
+	}{
+		{
+			input: "ALTER TABLE users ADD COLUMN age INT NOT NULL DEFAULT 0",
+			stmt: &ast.AlterTableStatement{
+				Table: "users",
+				Actions: []ast.AlterTableAction{
+					&ast.AddColumnAction{
+						Column: ast.Column{
+							Name:    "age",
+							Type:    token.INT,
+							Default: &ast.ScalarExpr{Type: token.INT, Literal: "0"},
+						},
+					},
+				},
+			},
+		},
+		{
+			input: "ALTER TABLE users ADD email TEXT, DROP COLUMN age, DROP name",
+			stmt: &ast.AlterTableStatement{
+				Table: "users",
+				Actions: []ast.AlterTableAction{
+					&ast.AddColumnAction{
+						Column: ast.Column{Name: "email", Type: token.TEXT, Nullable: true},
+					},
+					&ast.DropColumnAction{Column: "age"},
+					&ast.DropColumnAction{Column: "name"},
+				},
+			},
+		},
+		{
+			input: "ALTER TABLE users RENAME COLUMN name TO full_name",
+			stmt: &ast.AlterTableStatement{
+				Table: "users",
+				Actions: []ast.AlterTableAction{
+					&ast.RenameColumnAction{Column: "name", NewName: "full_name"},
+				},
+			},
+		},
+		{
+			input: "ALTER TABLE users RENAME name TO full_name",
+			stmt: &ast.AlterTableStatement{
+				Table: "users",
+				Actions: []ast.AlterTableAction{
+					&ast.RenameColumnAction{Column: "name", NewName: "full_name"},
+				},
+			},
+		},
+		{
+			input: "ALTER TABLE users RENAME TO customers",
+			stmt: &ast.AlterTableStatement{
+				Table: "users",
+				Actions: []ast.AlterTableAction{
+					&ast.RenameTableAction{NewName: "customers"},
+				},
+			},
+		},
+		{
+			input: "ALTER TABLE users ALTER COLUMN name SET NOT NULL, ALTER age DROP NOT NULL",
+			stmt: &ast.AlterTableStatement{
+				Table: "users",
+				Actions: []ast.AlterTableAction{
+					&ast.SetNotNullAction{Column: "name", NotNull: true},
+					&ast.SetNotNullAction{Column: "age", NotNull: false},
+				},
+			},
+		},
+		{
+			input: "ALTER TABLE users ALTER COLUMN age TYPE FLOAT",
+			stmt: &ast.AlterTableStatement{
+				Table: "users",
+				Actions: []ast.AlterTableAction{
+					&ast.AlterColumnTypeAction{Column: "age", Type: token.FLOAT},
+				},
+			},
+		},
+		{
+			input: "ALTER TABLE users ALTER COLUMN balance TYPE DECIMAL(12, 4)",
+			stmt: &ast.AlterTableStatement{
+				Table: "users",
+				Actions: []ast.AlterTableAction{
+					&ast.AlterColumnTypeAction{Column: "balance", Type: token.NUMERIC, Precision: 12, Scale: 4},
+				},
+			},
+		},
+		{
+			input: "ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email), DROP CONSTRAINT users_age_check",
+			stmt: &ast.AlterTableStatement{
+				Table: "users",
+				Actions: []ast.AlterTableAction{
+					&ast.AddConstraintAction{
+						Constraint: ast.Constraint{Name: "users_email_key", Type: token.UNIQUE, Columns: []string{"email"}},
+					},
+					&ast.DropConstraintAction{Name: "users_age_check"},
+				},
+			},
+		},
+		{
+			input: "ALTER TABLE users ADD CHECK (age > 17)",
+			stmt: &ast.AlterTableStatement{
+				Table: "users",
+				Actions: []ast.AlterTableAction{
+					&ast.AddConstraintAction{
+						Constraint: ast.Constraint{
+							Type: token.CHECK,
+							Check: &ast.ConditionExpr{
+								Left:     &ast.IdentExpr{Name: "age"},
+								Operator: token.GT,
+								Right:    &ast.ScalarExpr{Type: token.INT, Literal: "17"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.input, func(t *testing.T) {
+			t.Parallel()
+
+			p := New(lexer.New(test.input))
+			stmts, err := p.Parse()
+			assert.NoError(t, err)
+			assert.Equal(t, test.stmt, stmts)
+		})
+	}
+}
+
+func TestParser_Update(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		stmt  ast.Statement
+	}{
+		{
+			input: "UPDATE customers SET name = 'vlad', salary = 10*100 WHERE id = 1",
+			stmt: &ast.UpdateStatement{
+				Table: "customers",
+				Set: []ast.SetStatement{
+					{
+						Column: "name",
+						Value: &ast.ScalarExpr{
+							Type:    token.TEXT,
+							Literal: "vlad",
+						},
+					},
+					{
+						Column: "salary",
+						Value: &ast.ConditionExpr{
+							Left: &ast.ScalarExpr{
+								Type:    token.INT,
+								Literal: "10",
+							},
+							Operator: token.ASTERISK,
+							Right: &ast.ScalarExpr{
+								Type:    token.INT,
+								Literal: "100",
+							},
+						},
+					},
+				},
+				Where: &ast.WhereStatement{
+					Expr: &ast.ConditionExpr{
+						Left: &ast.IdentExpr{
+							Name: "id",
+						},
+						Operator: token.EQ,
+						Right: &ast.ScalarExpr{
+							Type:    token.INT,
+							Literal: "1",
+						},
+					},
+				},
+			},
+		},
+		{
+			input: "UPDATE users SET name = DEFAULT, code = upper(name, 1);",
+			stmt: &ast.UpdateStatement{
+				Table: "users",
+				Set: []ast.SetStatement{
+					{
+						Column: "name",
+						Value:  &ast.DefaultExpr{},
+					},
+					{
+						Column: "code",
+						Value: &ast.CallExpr{
+							Name: "upper",
+							Args: []ast.Expression{
+								&ast.IdentExpr{Name: "name"},
+								&ast.ScalarExpr{Type: token.INT, Literal: "1"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.input, func(t *testing.T) {
+			t.Parallel()
+
+			p := New(lexer.New(test.input))
+			stmts, err := p.Parse()
+			assert.NoError(t, err)
+			assert.Equal(t, test.stmt, stmts)
+		})
+	}
+}
+
+func TestParser_Delete(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		stmt  ast.Statement
+	}{
+		{
+			input: "DELETE FROM customers WHERE id = 10",
+			stmt: &ast.DeleteStatement{
+				Table: "customers",
+				Where: &ast.WhereStatement{
+					Expr: &ast.ConditionExpr{
 						Left: &ast.IdentExpr{
 							Name: "id",
 						},
@@ -579,6 +1615,61 @@ func TestParser_Delete(t *testing.T) {
 				},
 			},
 		},
+		{
+			input: "DELETE FROM customers WHERE id = 10 OR age > 18 AND name != 'Tom'",
+			stmt: &ast.DeleteStatement{
+				Table: "customers",
+				Where: &ast.WhereStatement{
+					Expr: &ast.ConditionExpr{
+						Left: &ast.ConditionExpr{
+							Left:     &ast.IdentExpr{Name: "id"},
+							Operator: token.EQ,
+							Right:    &ast.ScalarExpr{Type: token.INT, Literal: "10"},
+						},
+						Operator: token.OR,
+						Right: &ast.ConditionExpr{
+							Left: &ast.ConditionExpr{
+								Left:     &ast.IdentExpr{Name: "age"},
+								Operator: token.GT,
+								Right:    &ast.ScalarExpr{Type: token.INT, Literal: "18"},
+							},
+							Operator: token.AND,
+							Right: &ast.ConditionExpr{
+								Left:     &ast.IdentExpr{Name: "name"},
+								Operator: token.NOT_EQ,
+								Right:    &ast.ScalarExpr{Type: token.TEXT, Literal: "Tom"},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			input: "WITH old AS (SELECT id FROM orders) DELETE FROM customers WHERE id IN (SELECT id FROM old)",
+			stmt: &ast.DeleteStatement{
+				With: &ast.WithStatement{
+					Queries: []ast.CommonTableExpr{
+						{
+							Name: "old",
+							Query: &ast.SelectStatement{
+								Result: []ast.ResultStatement{{Expr: &ast.IdentExpr{Name: "id"}}},
+								From:   &ast.FromStatement{Table: "orders"},
+							},
+						},
+					},
+				},
+				Table: "customers",
+				Where: &ast.WhereStatement{
+					Expr: &ast.InExpr{
+						Expr: &ast.IdentExpr{Name: "id"},
+						Select: &ast.SelectStatement{
+							Result: []ast.ResultStatement{{Expr: &ast.IdentExpr{Name: "id"}}},
+							From:   &ast.FromStatement{Table: "old"},
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -608,6 +1699,193 @@ func TestParser_Drop(t *testing.T) {
 				Database: "customers",
 			},
 		},
+		{
+			input: "DROP DATABASE IF EXISTS customers",
+			stmt: &ast.DropDatabaseStatement{
+				Database: "customers",
+				IfExists: true,
+			},
+		},
+		{
+			input: "DROP DATABASE customers WITH (FORCE);",
+			stmt: &ast.DropDatabaseStatement{
+				Database: "customers",
+				Force:    true,
+			},
+		},
+		{
+			input: "DROP DATABASE IF EXISTS customers (FORCE)",
+			stmt: &ast.DropDatabaseStatement{
+				Database: "customers",
+				IfExists: true,
+				Force:    true,
+			},
+		},
+		{
+			input: "DROP TABLE users",
+			stmt: &ast.DropTableStatement{
+				Tables: []string{"users"},
+			},
+		},
+		{
+			input: "DROP TABLE IF EXISTS users, orders CASCADE;",
+			stmt: &ast.DropTableStatement{
+				Tables:   []string{"users", "orders"},
+				IfExists: true,
+				Cascade:  true,
+			},
+		},
+		{
+			input: "DROP INDEX IF EXISTS users_email;",
+			stmt: &ast.DropIndexStatement{
+				Index:    "users_email",
+				IfExists: true,
+			},
+		},
+		{
+			input: "DROP TABLE users RESTRICT",
+			stmt: &ast.DropTableStatement{
+				Tables: []string{"users"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.input, func(t *testing.T) {
+			t.Parallel()
+
+			p := New(lexer.New(test.input))
+			stmts, err := p.Parse()
+			assert.NoError(t, err)
+			assert.Equal(t, test.stmt, stmts)
+		})
+	}
+}
+
+func TestParser_Truncate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		stmt  ast.Statement
+	}{
+		{
+			input: "TRUNCATE TABLE users",
+			stmt: &ast.TruncateStatement{
+				Tables: []string{"users"},
+			},
+		},
+		{
+			input: "TRUNCATE users, orders;",
+			stmt: &ast.TruncateStatement{
+				Tables: []string{"users", "orders"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.input, func(t *testing.T) {
+			t.Parallel()
+
+			p := New(lexer.New(test.input))
+			stmts, err := p.Parse()
+			assert.NoError(t, err)
+			assert.Equal(t, test.stmt, stmts)
+		})
+	}
+}
+
+func TestParser_Use(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		stmt  ast.Statement
+	}{
+		{
+			input: "USE demo;",
+			stmt: &ast.UseStatement{
+				Database: "demo",
+			},
+		},
+		{
+			input: "use demo",
+			stmt: &ast.UseStatement{
+				Database: "demo",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.input, func(t *testing.T) {
+			t.Parallel()
+
+			p := New(lexer.New(test.input))
+			stmts, err := p.Parse()
+			assert.NoError(t, err)
+			assert.Equal(t, test.stmt, stmts)
+		})
+	}
+}
+
+func TestParser_Show(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		stmt  ast.Statement
+		err   string
+	}{
+		{
+			input: "SHOW DATABASES;",
+			stmt:  &ast.ShowDatabasesStatement{},
+		},
+		{
+			input: "SHOW TABLES",
+			stmt:  &ast.ShowTablesStatement{},
+		},
+		{
+			input: "SHOW FUNCTIONS",
+			stmt:  &ast.ShowFunctionsStatement{},
+		},
+		{
+			input: "SHOW COLUMNS FROM users",
+			stmt: &ast.ShowColumnsStatement{
+				Table: "users",
+			},
+		},
+		{
+			input: "DESCRIBE users;",
+			stmt: &ast.ShowColumnsStatement{
+				Table: "users",
+			},
+		},
+		{
+			input: "DESC users",
+			stmt: &ast.ShowColumnsStatement{
+				Table: "users",
+			},
+		},
+		{
+			input: "SHOW CREATE TABLE users",
+			stmt: &ast.ShowCreateTableStatement{
+				Table: "users",
+			},
+		},
+		{
+			input: "SHOW COLUMNS users",
+			err:   `expected "FROM" but found "users" (IDENT)`,
+		},
+		{
+			input: "SHOW users",
+			err:   `unexpected statement: SHOW IDENT("users")`,
+		},
 	}
 
 	for _, test := range tests {
@@ -618,8 +1896,41 @@ func TestParser_Drop(t *testing.T) {
 
 			p := New(lexer.New(test.input))
 			stmts, err := p.Parse()
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+
 			assert.NoError(t, err)
 			assert.Equal(t, test.stmt, stmts)
 		})
 	}
 }
+
+func TestParser_TrailingTokens(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{input: "DELETE FROM t WHRE id = 1", err: `syntax error at or near "WHRE"`},
+		{input: "UPDATE t SET n = 0 WEHRE id = 1", err: `syntax error at or near "WEHRE"`},
+		{input: "SELECT 7 % 0", err: `syntax error at or near "%"`},
+		{input: "SELECT 1; SELECT 2", err: `syntax error at or near "SELECT"`},
+		{input: "INSERT INTO t VALUES (1) (2)", err: `syntax error at or near "("`},
+		{input: "DROP TABLE t;;", err: `syntax error at or near ";"`},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.input, func(t *testing.T) {
+			t.Parallel()
+
+			p := New(lexer.New(test.input))
+			_, err := p.Parse()
+			assert.EqualError(t, err, test.err)
+		})
+	}
+}
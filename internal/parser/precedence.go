@@ -68,15 +68,42 @@ const (
 	Key
 )
 
+// prefixPrecedence binds unary operators tighter than any binary operator.
+const prefixPrecedence = 11
+
+// notPrecedence binds the NOT operator looser than IS and tighter than AND.
+const notPrecedence = 4
+
+// castPrecedence binds the :: operator tighter than unary operators.
+const castPrecedence = 12
+
+// precedences of the binary operators, all of them binding tighter than LOWEST.
+// Like in PostgreSQL, IS binds looser than the comparisons and IN, BETWEEN
+// and LIKE tighter. NOT stands for NOT IN, NOT BETWEEN and NOT LIKE. The
+// JSON operators bind tighter than those and looser than arithmetic.
 var precedences = map[token.TokenType]int{
-	token.OR:       1,
-	token.AND:      2,
-	token.EQ:       3,
-	token.NOT_EQ:   3,
-	token.LT:       3,
-	token.GT:       3,
-	token.PLUS:     4,
-	token.MINUS:    4,
-	token.ASTERISK: 5,
-	token.SLASH:    5,
+	token.OR:           2,
+	token.AND:          3,
+	token.IS:           5,
+	token.EQ:           6,
+	token.NOT_EQ:       6,
+	token.LT:           6,
+	token.GT:           6,
+	token.IN:           7,
+	token.NOT:          7,
+	token.BETWEEN:      7,
+	token.LIKE:         7,
+	token.ILIKE:        7,
+	token.ARROW:        8,
+	token.DOUBLE_ARROW: 8,
+	token.HASH_ARROW:   8,
+	token.CONTAINS:     8,
+	token.PLUS:         9,
+	token.MINUS:        9,
+	token.ASTERISK:     10,
+	token.SLASH:        10,
+	// AT TIME ZONE binds looser than unary operators.
+	token.AT: prefixPrecedence,
+
+	token.DOUBLE_COLON: castPrecedence,
 }
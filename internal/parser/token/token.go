@@ -1,6 +1,9 @@
 package token
 
-import "strings"
+import (
+	"sort"
+	"strings"
+)
 
 type TokenType string
 
@@ -26,9 +29,18 @@ const (
 	EQ     = "="
 	NOT_EQ = "!="
 
+	DOUBLE_COLON = "::"
+
+	// JSON operators
+	ARROW        = "->"
+	DOUBLE_ARROW = "->>"
+	HASH_ARROW   = "#>"
+	CONTAINS     = "@>"
+
 	// Delimiters
 	COMMA     = ","
 	SEMICOLON = ";"
+	DOT       = "."
 
 	LPAREN = "("
 	RPAREN = ")"
@@ -69,6 +81,101 @@ const (
 	INTO     = "INTO"
 	DEFAULT  = "DEFAULT"
 	NULL     = "NULL"
+
+	USE       = "USE"
+	SHOW      = "SHOW"
+	DATABASES = "DATABASES"
+	TABLES    = "TABLES"
+	COLUMNS   = "COLUMNS"
+	FUNCTIONS = "FUNCTIONS"
+	DESCRIBE  = "DESCRIBE"
+
+	EXISTS   = "EXISTS"
+	CASCADE  = "CASCADE"
+	RESTRICT = "RESTRICT"
+	TRUNCATE = "TRUNCATE"
+	WITH     = "WITH"
+	FORCE    = "FORCE"
+
+	FLOAT   = "FLOAT"
+	BOOLEAN = "BOOLEAN"
+	PRIMARY = "PRIMARY"
+	KEY     = "KEY"
+	ALTER   = "ALTER"
+	ADD     = "ADD"
+	COLUMN  = "COLUMN"
+	RENAME  = "RENAME"
+	TO      = "TO"
+	TYPE    = "TYPE"
+
+	UNIQUE     = "UNIQUE"
+	CHECK      = "CHECK"
+	FOREIGN    = "FOREIGN"
+	REFERENCES = "REFERENCES"
+	CONSTRAINT = "CONSTRAINT"
+	ON         = "ON"
+
+	IS       = "IS"
+	DISTINCT = "DISTINCT"
+	NULLS    = "NULLS"
+	FIRST    = "FIRST"
+	LAST     = "LAST"
+
+	GROUP  = "GROUP"
+	HAVING = "HAVING"
+
+	AS    = "AS"
+	JOIN  = "JOIN"
+	INNER = "INNER"
+	LEFT  = "LEFT"
+	RIGHT = "RIGHT"
+	FULL  = "FULL"
+	OUTER = "OUTER"
+	CROSS = "CROSS"
+	USING = "USING"
+
+	IN = "IN"
+
+	UNION     = "UNION"
+	INTERSECT = "INTERSECT"
+	EXCEPT    = "EXCEPT"
+	ALL       = "ALL"
+	RECURSIVE = "RECURSIVE"
+
+	OVER      = "OVER"
+	PARTITION = "PARTITION"
+	ROWS      = "ROWS"
+	RANGE     = "RANGE"
+	BETWEEN   = "BETWEEN"
+	UNBOUNDED = "UNBOUNDED"
+	PRECEDING = "PRECEDING"
+	FOLLOWING = "FOLLOWING"
+	CURRENT   = "CURRENT"
+	ROW       = "ROW"
+
+	CASE   = "CASE"
+	WHEN   = "WHEN"
+	THEN   = "THEN"
+	END    = "END"
+	CAST   = "CAST"
+	LIKE   = "LIKE"
+	ILIKE  = "ILIKE"
+	ESCAPE = "ESCAPE"
+
+	DATE        = "DATE"
+	TIME        = "TIME"
+	TIMESTAMP   = "TIMESTAMP"
+	TIMESTAMPTZ = "TIMESTAMPTZ"
+	INTERVAL    = "INTERVAL"
+	DECIMAL     = "DECIMAL"
+	NUMERIC     = "NUMERIC"
+	WITHOUT     = "WITHOUT"
+	ZONE        = "ZONE"
+	AT          = "AT"
+
+	JSON  = "JSON"
+	JSONB = "JSONB"
+	INDEX = "INDEX"
 )
 
 type Token struct {
@@ -105,6 +212,157 @@ var keywords = map[string]TokenType{
 	"INTO":     INTO,
 	"DEFAULT":  DEFAULT,
 	"NULL":     NULL,
+
+	"USE":       USE,
+	"SHOW":      SHOW,
+	"DATABASES": DATABASES,
+	"TABLES":    TABLES,
+	"COLUMNS":   COLUMNS,
+	"FUNCTIONS": FUNCTIONS,
+	"DESCRIBE":  DESCRIBE,
+
+	"IF":       IF,
+	"EXISTS":   EXISTS,
+	"CASCADE":  CASCADE,
+	"RESTRICT": RESTRICT,
+	"TRUNCATE": TRUNCATE,
+	"WITH":     WITH,
+	"FORCE":    FORCE,
+
+	"INTEGER": INT,
+	"FLOAT":   FLOAT,
+	"BOOLEAN": BOOLEAN,
+	"PRIMARY": PRIMARY,
+	"KEY":     KEY,
+	"ALTER":   ALTER,
+	"ADD":     ADD,
+	"COLUMN":  COLUMN,
+	"RENAME":  RENAME,
+	"TO":      TO,
+	"TYPE":    TYPE,
+
+	"UNIQUE":     UNIQUE,
+	"CHECK":      CHECK,
+	"FOREIGN":    FOREIGN,
+	"REFERENCES": REFERENCES,
+	"CONSTRAINT": CONSTRAINT,
+	"ON":         ON,
+
+	"IS":       IS,
+	"DISTINCT": DISTINCT,
+	"NULLS":    NULLS,
+	"FIRST":    FIRST,
+	"LAST":     LAST,
+
+	"GROUP":  GROUP,
+	"HAVING": HAVING,
+
+	"AS":    AS,
+	"JOIN":  JOIN,
+	"INNER": INNER,
+	"LEFT":  LEFT,
+	"RIGHT": RIGHT,
+	"FULL":  FULL,
+	"OUTER": OUTER,
+	"CROSS": CROSS,
+	"USING": USING,
+
+	"IN": IN,
+
+	"UNION":     UNION,
+	"INTERSECT": INTERSECT,
+	"EXCEPT":    EXCEPT,
+	"ALL":       ALL,
+	"RECURSIVE": RECURSIVE,
+
+	"OVER":      OVER,
+	"PARTITION": PARTITION,
+	"ROWS":      ROWS,
+	"RANGE":     RANGE,
+	"BETWEEN":   BETWEEN,
+	"UNBOUNDED": UNBOUNDED,
+	"PRECEDING": PRECEDING,
+	"FOLLOWING": FOLLOWING,
+	"CURRENT":   CURRENT,
+	"ROW":       ROW,
+
+	"CASE":   CASE,
+	"WHEN":   WHEN,
+	"THEN":   THEN,
+	"ELSE":   ELSE,
+	"END":    END,
+	"CAST":   CAST,
+	"LIKE":   LIKE,
+	"ILIKE":  ILIKE,
+	"ESCAPE": ESCAPE,
+
+	"DATE":        DATE,
+	"TIME":        TIME,
+	"TIMESTAMP":   TIMESTAMP,
+	"TIMESTAMPTZ": TIMESTAMPTZ,
+	"INTERVAL":    INTERVAL,
+	"DECIMAL":     DECIMAL,
+	"NUMERIC":     NUMERIC,
+	"WITHOUT":     WITHOUT,
+	"ZONE":        ZONE,
+	"AT":          AT,
+
+	"JSON":  JSON,
+	"JSONB": JSONB,
+	"INDEX": INDEX,
+}
+
+// nonReserved lists the keywords which are still valid identifiers, so that
+// columns can be named like them.
+var nonReserved = map[TokenType]bool{
+	DATABASES:   true,
+	TABLES:      true,
+	COLUMNS:     true,
+	FUNCTIONS:   true,
+	FORCE:       true,
+	KEY:         true,
+	TYPE:        true,
+	NULLS:       true,
+	FIRST:       true,
+	LAST:        true,
+	RECURSIVE:   true,
+	PARTITION:   true,
+	ROWS:        true,
+	RANGE:       true,
+	UNBOUNDED:   true,
+	PRECEDING:   true,
+	FOLLOWING:   true,
+	CURRENT:     true,
+	ROW:         true,
+	ESCAPE:      true,
+	DATE:        true,
+	TIME:        true,
+	TIMESTAMP:   true,
+	TIMESTAMPTZ: true,
+	INTERVAL:    true,
+	DECIMAL:     true,
+	NUMERIC:     true,
+	WITHOUT:     true,
+	ZONE:        true,
+	JSON:        true,
+	JSONB:       true,
+	INDEX:       true,
+}
+
+// IsNonReserved reports whether the keyword can be used as an identifier.
+func IsNonReserved(t TokenType) bool {
+	return nonReserved[t]
+}
+
+// Keywords returns the reserved words of the SQL dialect in alphabetical order.
+func Keywords() []string {
+	words := make([]string, 0, len(keywords))
+	for word := range keywords {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+
+	return words
 }
 
 func LookupIdent(ident string) TokenType {
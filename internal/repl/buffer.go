@@ -0,0 +1,76 @@
+package repl
+
+import "strings"
+
+// statementBuffer accumulates input lines until they form complete statements
+// terminated by a semicolon. Semicolons inside quoted strings, quoted
+// identifiers and comments don't terminate a statement. Comments are stripped
+// from the buffered text because the lexer doesn't understand them.
+type statementBuffer struct {
+	text    strings.Builder
+	quote   byte // active quote character, or 0 outside of quotes
+	comment bool // inside a /* ... */ block comment
+}
+
+// Write appends a line of input and returns the statements it completed.
+func (b *statementBuffer) Write(line string) []string {
+	var statements []string
+
+	if b.text.Len() > 0 {
+		b.text.WriteByte('\n')
+	}
+
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+
+		switch {
+		case b.comment:
+			if ch == '*' && i+1 < len(line) && line[i+1] == '/' {
+				b.comment = false
+				i++
+			}
+		case b.quote != 0:
+			if ch == b.quote {
+				b.quote = 0
+			}
+			b.text.WriteByte(ch)
+		case ch == '\'' || ch == '"':
+			b.quote = ch
+			b.text.WriteByte(ch)
+		case ch == '-' && i+1 < len(line) && line[i+1] == '-':
+			i = len(line)
+		case ch == '/' && i+1 < len(line) && line[i+1] == '*':
+			b.comment = true
+			i++
+		case ch == ';':
+			if stmt := strings.TrimSpace(b.text.String()); stmt != "" {
+				statements = append(statements, stmt)
+			}
+			b.text.Reset()
+		default:
+			b.text.WriteByte(ch)
+		}
+	}
+
+	return statements
+}
+
+// Flush returns the unterminated statement left in the buffer, if any, and
+// resets the buffer.
+func (b *statementBuffer) Flush() string {
+	stmt := strings.TrimSpace(b.text.String())
+	b.Reset()
+	return stmt
+}
+
+// Reset discards the buffered input.
+func (b *statementBuffer) Reset() {
+	b.text.Reset()
+	b.quote = 0
+	b.comment = false
+}
+
+// Empty reports whether there is no pending input in the buffer.
+func (b *statementBuffer) Empty() bool {
+	return b.quote == 0 && !b.comment && strings.TrimSpace(b.text.String()) == ""
+}
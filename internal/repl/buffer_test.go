@@ -0,0 +1,126 @@
+package repl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatementBuffer_Write(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		lines      []string
+		statements []string
+		empty      bool
+	}{
+		{
+			name:       "single line",
+			lines:      []string{"SELECT id FROM users;"},
+			statements: []string{"SELECT id FROM users"},
+			empty:      true,
+		},
+		{
+			name:       "multiple lines",
+			lines:      []string{"SELECT id", "FROM users", "WHERE id = 1;"},
+			statements: []string{"SELECT id\nFROM users\nWHERE id = 1"},
+			empty:      true,
+		},
+		{
+			name:       "multiple statements on one line",
+			lines:      []string{"CREATE DATABASE demo; DROP DATABASE demo;"},
+			statements: []string{"CREATE DATABASE demo", "DROP DATABASE demo"},
+			empty:      true,
+		},
+		{
+			name:       "unterminated statement",
+			lines:      []string{"SELECT id", "FROM users"},
+			statements: nil,
+			empty:      false,
+		},
+		{
+			name:       "blank lines",
+			lines:      []string{"", "   ", ";"},
+			statements: nil,
+			empty:      true,
+		},
+		{
+			name:       "semicolon inside string",
+			lines:      []string{"INSERT INTO t (name) VALUES ('a;", "b');"},
+			statements: []string{"INSERT INTO t (name) VALUES ('a;\nb')"},
+			empty:      true,
+		},
+		{
+			name:       "open string",
+			lines:      []string{"SELECT 'it''s;"},
+			statements: nil,
+			empty:      false,
+		},
+		{
+			name:       "line comment",
+			lines:      []string{"SELECT id -- the id; really", "FROM users;"},
+			statements: []string{"SELECT id \nFROM users"},
+			empty:      true,
+		},
+		{
+			name:       "block comment",
+			lines:      []string{"SELECT /* a;", "b */ id FROM users;"},
+			statements: []string{"SELECT \n id FROM users"},
+			empty:      true,
+		},
+		{
+			name:       "open block comment",
+			lines:      []string{"/* comment"},
+			statements: nil,
+			empty:      false,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				buffer     statementBuffer
+				statements []string
+			)
+
+			for _, line := range test.lines {
+				statements = append(statements, buffer.Write(line)...)
+			}
+
+			assert.Equal(t, test.statements, statements)
+			assert.Equal(t, test.empty, buffer.Empty())
+		})
+	}
+}
+
+func TestStatementBuffer_Flush(t *testing.T) {
+	t.Parallel()
+
+	var buffer statementBuffer
+
+	assert.Empty(t, buffer.Write("SELECT id"))
+	assert.Empty(t, buffer.Write("FROM users"))
+	assert.False(t, buffer.Empty())
+
+	assert.Equal(t, "SELECT id\nFROM users", buffer.Flush())
+	assert.True(t, buffer.Empty())
+	assert.Equal(t, "", buffer.Flush())
+}
+
+func TestStatementBuffer_Reset(t *testing.T) {
+	t.Parallel()
+
+	var buffer statementBuffer
+
+	assert.Empty(t, buffer.Write("SELECT 'abc"))
+	assert.False(t, buffer.Empty())
+
+	buffer.Reset()
+	assert.True(t, buffer.Empty())
+	assert.Equal(t, []string{"SELECT 1"}, buffer.Write("SELECT 1;"))
+}
@@ -0,0 +1,333 @@
+package repl
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/okazaki-kk/miniDB/internal/format"
+	"github.com/okazaki-kk/miniDB/internal/sql"
+	"github.com/okazaki-kk/miniDB/internal/sql/datatype"
+	"github.com/okazaki-kk/miniDB/storage"
+)
+
+var errQuit = errors.New("quit")
+
+const help = `General
+  \q                    quit miniDB
+  \?                    show this help
+
+Formatting
+  \pset [NAME [VALUE]]  set output option (format, expanded, null)
+  \x [on|off]           toggle expanded output
+
+Informational
+  \l                    list databases
+  \dn                   list schemas
+  \dt                   list tables of the current database
+  \df [PATTERN]         list functions
+  \d NAME               describe table
+
+Connection
+  \use DBNAME           connect to database
+`
+
+func (r *Repl) listDatabases() (string, error) {
+	databases, err := r.catalog.ListDatabases()
+	if err != nil {
+		return "", err
+	}
+
+	sort.Slice(databases, func(i, j int) bool {
+		return databases[i].Name() < databases[j].Name()
+	})
+
+	rows := make([]sql.Row, 0, len(databases))
+	for i := range databases {
+		rows = append(rows, sql.Row{datatype.NewText(databases[i].Name())})
+	}
+
+	return r.render(format.Result{
+		Title:   "List of databases",
+		Columns: []string{"Name"},
+		Rows:    sql.NewSliceRowsIter(rows...),
+	})
+}
+
+// listSchemas lists the namespaces of the current database. miniDB keeps all
+// tables of a database in a single "public" schema.
+func (r *Repl) listSchemas() (string, error) {
+	if err := r.requireDatabase(); err != nil {
+		return "", err
+	}
+
+	return r.render(format.Result{
+		Title:   "List of schemas",
+		Columns: []string{"Name"},
+		Rows:    sql.NewSliceRowsIter(sql.Row{datatype.NewText("public")}),
+	})
+}
+
+func (r *Repl) listTables() (string, error) {
+	if err := r.requireDatabase(); err != nil {
+		return "", err
+	}
+
+	tables := r.database.ListTables()
+	if len(tables) == 0 {
+		return "Did not find any relations.\n", nil
+	}
+
+	sort.Slice(tables, func(i, j int) bool {
+		return tables[i].Name() < tables[j].Name()
+	})
+
+	rows := make([]sql.Row, 0, len(tables))
+	for i := range tables {
+		rows = append(rows, sql.Row{
+			datatype.NewText("public"),
+			datatype.NewText(tables[i].Name()),
+			datatype.NewText("table"),
+		})
+	}
+
+	return r.render(format.Result{
+		Title:   "List of relations",
+		Columns: []string{"Schema", "Name", "Type"},
+		Rows:    sql.NewSliceRowsIter(rows...),
+	})
+}
+
+// listFunctions lists the functions, with one row for every signature. The
+// pattern matches the names like psql's, with * and ? as wildcards.
+func (r *Repl) listFunctions(params []string) (string, error) {
+	pattern := "*"
+	if len(params) > 1 {
+		pattern = params[1]
+	}
+
+	var rows []sql.Row
+	for _, fn := range r.engine.Functions() {
+		ok, err := path.Match(pattern, fn.Name)
+		if err != nil {
+			return "", fmt.Errorf("invalid pattern %q", pattern)
+		}
+		if !ok {
+			continue
+		}
+
+		schema, kind := "pg_catalog", "func"
+		if fn.User {
+			schema = "public"
+		}
+		if fn.Aggregate {
+			kind = "agg"
+		}
+
+		for _, signature := range fn.Signatures {
+			rows = append(rows, sql.Row{
+				datatype.NewText(schema),
+				datatype.NewText(fn.Name),
+				datatype.NewText(signature.ResultType()),
+				datatype.NewText(signature.ArgumentTypes()),
+				datatype.NewText(kind),
+			})
+		}
+	}
+
+	return r.render(format.Result{
+		Title:   "List of functions",
+		Columns: []string{"Schema", "Name", "Result data type", "Argument data types", "Type"},
+		Rows:    sql.NewSliceRowsIter(rows...),
+	})
+}
+
+func (r *Repl) describeTable(params []string) (string, error) {
+	if len(params) < 2 {
+		return r.listTables()
+	}
+
+	if err := r.requireDatabase(); err != nil {
+		return "", err
+	}
+
+	table, err := r.database.GetTable(params[1])
+	if err != nil {
+		return "", err
+	}
+
+	columns := table.Scheme().Columns()
+
+	rows := make([]sql.Row, 0, len(columns))
+	for _, column := range columns {
+		nullable := ""
+		if !column.Nullable {
+			nullable = "not null"
+		}
+
+		def := ""
+		if column.Default != nil {
+			def = column.Default.String()
+		}
+
+		rows = append(rows, sql.Row{
+			datatype.NewText(column.Name),
+			datatype.NewText(column.TypeName()),
+			datatype.NewText(nullable),
+			datatype.NewText(def),
+		})
+	}
+
+	message, err := r.render(format.Result{
+		Title:   fmt.Sprintf("Table \"%s\"", table.Name()),
+		Columns: []string{"Column", "Type", "Nullable", "Default"},
+		Rows:    sql.NewSliceRowsIter(rows...),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if r.format.Format == format.Aligned && !r.format.Expanded {
+		message += r.describeConstraints(table)
+	}
+
+	return message, nil
+}
+
+// describeConstraints lists the indexes and constraints of the table and the
+// foreign keys referencing it.
+func (r *Repl) describeConstraints(table *storage.Table) string {
+	var indexes, checks, foreignKeys, referencedBy []string
+
+	if pk := table.PrimaryKey(); pk.Name != "" {
+		indexes = append(indexes, fmt.Sprintf("\"%s_pkey\" PRIMARY KEY (%s)", table.Name(), pk.Name))
+	}
+
+	for _, c := range table.Constraints() {
+		switch c.Type {
+		case storage.Unique:
+			indexes = append(indexes, fmt.Sprintf("\"%s\" UNIQUE (%s)", c.Name, strings.Join(c.Columns, ", ")))
+		case storage.Check:
+			checks = append(checks, fmt.Sprintf("\"%s\" CHECK (%s)", c.Name, c.Check))
+		case storage.ForeignKey:
+			foreignKeys = append(foreignKeys, fmt.Sprintf("\"%s\" %s", c.Name, strings.TrimPrefix(c.String(), "CONSTRAINT "+c.Name+" ")))
+		}
+	}
+
+	for _, index := range table.Indexes() {
+		indexes = append(indexes, index.String(table.Name()))
+	}
+
+	tables := r.database.ListTables()
+	sort.Slice(tables, func(i, j int) bool {
+		return tables[i].Name() < tables[j].Name()
+	})
+
+	for _, t := range tables {
+		for _, c := range t.Constraints() {
+			if c.Type == storage.ForeignKey && c.Reference.Table == table.Name() {
+				referencedBy = append(referencedBy, fmt.Sprintf("TABLE \"%s\" CONSTRAINT \"%s\"", t.Name(), c.Name))
+			}
+		}
+	}
+
+	var b strings.Builder
+
+	for _, section := range []struct {
+		title string
+		lines []string
+	}{
+		{"Indexes", indexes},
+		{"Check constraints", checks},
+		{"Foreign-key constraints", foreignKeys},
+		{"Referenced by", referencedBy},
+	} {
+		if len(section.lines) == 0 {
+			continue
+		}
+
+		b.WriteString(section.title + ":\n")
+		for _, line := range section.lines {
+			b.WriteString("    " + line + "\n")
+		}
+	}
+
+	return b.String()
+}
+
+// setOption changes an output option, like psql's \pset.
+func (r *Repl) setOption(params []string) (string, error) {
+	if len(params) < 2 {
+		return fmt.Sprintf(
+			"format %s\nexpanded %s\nnull %q\n",
+			r.format.Format, onOff(r.format.Expanded), r.format.Null,
+		), nil
+	}
+
+	switch params[1] {
+	case "format":
+		if len(params) < 3 {
+			return fmt.Sprintf("Output format is %s.\n", r.format.Format), nil
+		}
+
+		f, err := format.Parse(params[2])
+		if err != nil {
+			return "", err
+		}
+
+		r.format.Format = f
+		return fmt.Sprintf("Output format is %s.\n", f), nil
+	case "expanded", "x":
+		return r.toggleExpanded(params[1:])
+	case "null":
+		r.format.Null = ""
+		if len(params) > 2 {
+			r.format.Null = strings.Trim(strings.Join(params[2:], " "), "'")
+		}
+		return fmt.Sprintf("Null display is %q.\n", r.format.Null), nil
+	default:
+		return "", fmt.Errorf("\\pset: unknown option: %s", params[1])
+	}
+}
+
+// toggleExpanded switches the expanded output, like psql's \x.
+func (r *Repl) toggleExpanded(params []string) (string, error) {
+	if len(params) < 2 {
+		r.format.Expanded = !r.format.Expanded
+	} else {
+		switch strings.ToLower(params[1]) {
+		case "on":
+			r.format.Expanded = true
+		case "off":
+			r.format.Expanded = false
+		default:
+			return "", fmt.Errorf("unrecognized value %q for expanded: on or off expected", params[1])
+		}
+	}
+
+	return fmt.Sprintf("Expanded display is %s.\n", onOff(r.format.Expanded)), nil
+}
+
+func (r *Repl) render(result format.Result) (string, error) {
+	var b strings.Builder
+	if err := format.Write(&b, result, r.format); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func (r *Repl) requireDatabase() error {
+	if r.database.Name() == "" {
+		return fmt.Errorf("no database selected, use \\use DBNAME")
+	}
+	return nil
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
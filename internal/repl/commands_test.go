@@ -0,0 +1,246 @@
+package repl
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/okazaki-kk/miniDB/internal/engine"
+	"github.com/okazaki-kk/miniDB/internal/sql"
+	"github.com/okazaki-kk/miniDB/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRepl(t *testing.T) *Repl {
+	t.Helper()
+
+	catalog := storage.NewCatalog()
+	db, err := catalog.CreateDatabase("demo")
+	assert.NoError(t, err)
+
+	_, err = catalog.CreateDatabase("abc")
+	assert.NoError(t, err)
+
+	_, err = db.CreateTable("users", storage.Scheme{
+		"id": storage.Column{
+			Position:   0,
+			Name:       "id",
+			DataType:   sql.Integer,
+			PrimaryKey: true,
+			Nullable:   false,
+		},
+		"name": storage.Column{
+			Position:   1,
+			Name:       "name",
+			DataType:   sql.Text,
+			PrimaryKey: false,
+			Nullable:   true,
+		},
+	})
+	assert.NoError(t, err)
+
+	return New(&bytes.Buffer{}, &bytes.Buffer{}, *catalog, *engine.New(*catalog))
+}
+
+func TestRepl_Commands(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		commands []string
+		expected string
+		err      string
+	}{
+		{
+			name:     "list databases",
+			commands: []string{`\l`},
+			expected: "List of databases\n" +
+				" Name\n" +
+				"------\n" +
+				" abc\n" +
+				" demo\n" +
+				"(2 rows)\n",
+		},
+		{
+			name:     "list tables",
+			commands: []string{`\use demo`, `\dt`},
+			expected: "   List of relations\n" +
+				" Schema | Name  | Type\n" +
+				"--------+-------+-------\n" +
+				" public | users | table\n" +
+				"(1 row)\n",
+		},
+		{
+			name:     "list tables of empty database",
+			commands: []string{`\use abc`, `\dt`},
+			expected: "Did not find any relations.\n",
+		},
+		{
+			name:     "list tables without database",
+			commands: []string{`\dt`},
+			err:      `no database selected, use \use DBNAME`,
+		},
+		{
+			name:     "describe table",
+			commands: []string{`\use demo`, `\d users`},
+			expected: "             Table \"users\"\n" +
+				" Column |  Type   | Nullable | Default\n" +
+				"--------+---------+----------+---------\n" +
+				" id     | integer | not null | \n" +
+				" name   | text    |          | \n" +
+				"(2 rows)\n" +
+				"Indexes:\n" +
+				"    \"users_pkey\" PRIMARY KEY (id)\n",
+		},
+		{
+			name: "describe table with constraints",
+			commands: []string{
+				`\use demo`,
+				"CREATE TABLE orders (id INT PRIMARY KEY, code TEXT UNIQUE, user_id INT REFERENCES users ON DELETE CASCADE, CHECK (id > 0))",
+				`\d orders`,
+			},
+			expected: "             Table \"orders\"\n" +
+				" Column  |  Type   | Nullable | Default\n" +
+				"---------+---------+----------+---------\n" +
+				" id      | integer | not null | \n" +
+				" code    | text    |          | \n" +
+				" user_id | integer |          | \n" +
+				"(3 rows)\n" +
+				"Indexes:\n" +
+				"    \"orders_pkey\" PRIMARY KEY (id)\n" +
+				"    \"orders_code_key\" UNIQUE (code)\n" +
+				"Check constraints:\n" +
+				"    \"orders_check\" CHECK (id > 0)\n" +
+				"Foreign-key constraints:\n" +
+				"    \"orders_user_id_fkey\" FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE\n",
+		},
+		{
+			name: "describe table with index",
+			commands: []string{
+				`\use demo`,
+				"CREATE INDEX users_name_idx ON users (lower(name))",
+				`\d users`,
+			},
+			expected: "             Table \"users\"\n" +
+				" Column |  Type   | Nullable | Default\n" +
+				"--------+---------+----------+---------\n" +
+				" id     | integer | not null | \n" +
+				" name   | text    |          | \n" +
+				"(2 rows)\n" +
+				"Indexes:\n" +
+				"    \"users_pkey\" PRIMARY KEY (id)\n" +
+				"    CREATE INDEX users_name_idx ON users (lower(name))\n",
+		},
+		{
+			name:     "describe unknown table",
+			commands: []string{`\use demo`, `\d orders`},
+			err:      `table "orders" not found`,
+		},
+		{
+			name:     "list schemas",
+			commands: []string{`\use demo`, `\dn`},
+			expected: "List of schemas\n" +
+				"  Name\n" +
+				"--------\n" +
+				" public\n" +
+				"(1 row)\n",
+		},
+		{
+			name:     "list functions",
+			commands: []string{`\df l*`},
+			expected: "                          List of functions\n" +
+				"   Schema   |  Name  | Result data type | Argument data types | Type\n" +
+				"------------+--------+------------------+---------------------+------\n" +
+				" pg_catalog | least  | anyelement       | VARIADIC anyelement | func\n" +
+				" pg_catalog | length | integer          | text                | func\n" +
+				" pg_catalog | lower  | text             | text                | func\n" +
+				"(3 rows)\n",
+		},
+		{
+			name:     "list databases as csv",
+			commands: []string{`\pset format csv`, `\l`},
+			expected: "Name\n" +
+				"abc\n" +
+				"demo\n",
+		},
+		{
+			name:     "describe table in expanded mode",
+			commands: []string{`\use demo`, `\x`, `\d users`},
+			expected: "-[ RECORD 1 ]+---------\n" +
+				"Column   | id\n" +
+				"Type     | integer\n" +
+				"Nullable | not null\n" +
+				"Default  | \n" +
+				"-[ RECORD 2 ]+---------\n" +
+				"Column   | name\n" +
+				"Type     | text\n" +
+				"Nullable | \n" +
+				"Default  | \n",
+		},
+		{
+			name:     "set format",
+			commands: []string{`\pset format json`},
+			expected: "Output format is json.\n",
+		},
+		{
+			name:     "set unknown format",
+			commands: []string{`\pset format xml`},
+			err:      `unknown format "xml"`,
+		},
+		{
+			name:     "set null display",
+			commands: []string{`\pset null (null)`, `\pset`},
+			expected: "format aligned\nexpanded off\nnull \"(null)\"\n",
+		},
+		{
+			name:     "toggle expanded",
+			commands: []string{`\x`, `\x`},
+			expected: "Expanded display is off.\n",
+		},
+		{
+			name:     "set expanded",
+			commands: []string{`\pset expanded on`},
+			expected: "Expanded display is on.\n",
+		},
+		{
+			name:     "help",
+			commands: []string{`\?`},
+			expected: help,
+		},
+		{
+			name:     "quit",
+			commands: []string{`\q`},
+			err:      errQuit.Error(),
+		},
+		{
+			name:     "unknown command",
+			commands: []string{`\foo`},
+			err:      `unknown command: \foo`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := newTestRepl(t)
+
+			var (
+				message string
+				err     error
+			)
+			for _, command := range test.commands {
+				message, err = r.exec(command)
+			}
+
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, message)
+		})
+	}
+}
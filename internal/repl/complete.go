@@ -0,0 +1,81 @@
+package repl
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/okazaki-kk/miniDB/internal/parser/token"
+)
+
+var commands = []string{`\?`, `\d`, `\df`, `\dn`, `\dt`, `\l`, `\pset`, `\q`, `\use`, `\x`}
+
+// complete returns the SQL keywords, meta-commands, and database, table and
+// column names starting with the prefix. Keywords follow the case of the
+// prefix.
+func (r *Repl) complete(prefix string) []string {
+	if prefix == "" {
+		return nil
+	}
+
+	if strings.HasPrefix(prefix, `\`) {
+		return matching(commands, prefix)
+	}
+
+	keywords := token.Keywords()
+	if strings.ToLower(prefix) == prefix {
+		for i := range keywords {
+			keywords[i] = strings.ToLower(keywords[i])
+		}
+	}
+
+	words := matching(keywords, prefix)
+	words = append(words, matching(r.names(), prefix)...)
+
+	sort.Strings(words)
+
+	unique := words[:0]
+	for i := range words {
+		if i == 0 || words[i] != words[i-1] {
+			unique = append(unique, words[i])
+		}
+	}
+
+	return unique
+}
+
+// names returns the database names and the table and column names of the
+// current database.
+func (r *Repl) names() []string {
+	var names []string
+
+	databases, err := r.catalog.ListDatabases()
+	if err == nil {
+		for i := range databases {
+			names = append(names, databases[i].Name())
+		}
+	}
+
+	if r.database.Name() == "" {
+		return names
+	}
+
+	for _, table := range r.database.ListTables() {
+		names = append(names, table.Name())
+
+		for column := range table.Scheme() {
+			names = append(names, column)
+		}
+	}
+
+	return names
+}
+
+func matching(words []string, prefix string) []string {
+	var matches []string
+	for _, word := range words {
+		if len(word) >= len(prefix) && strings.EqualFold(word[:len(prefix)], prefix) {
+			matches = append(matches, word)
+		}
+	}
+	return matches
+}
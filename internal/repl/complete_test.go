@@ -0,0 +1,28 @@
+package repl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepl_Complete(t *testing.T) {
+	t.Parallel()
+
+	r := newTestRepl(t)
+
+	assert.Equal(t, []string{"SELECT", "SET"}, r.complete("SE"))
+	assert.Equal(t, []string{"select", "set"}, r.complete("se"))
+	assert.Contains(t, r.complete("DE"), "DELETE")
+	assert.Contains(t, r.complete("DE"), "demo")
+	assert.Equal(t, []string{`\d`, `\df`, `\dn`, `\dt`}, r.complete(`\d`))
+	assert.NotContains(t, r.complete("us"), "users")
+	assert.Empty(t, r.complete(""))
+
+	_, err := r.exec(`\use demo`)
+	assert.NoError(t, err)
+
+	assert.Contains(t, r.complete("us"), "users")
+	assert.Equal(t, []string{"name"}, r.complete("na"))
+	assert.Contains(t, r.complete("i"), "id")
+}
@@ -2,17 +2,25 @@ package repl
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/user"
+	"path/filepath"
 	"strings"
 
 	"github.com/okazaki-kk/miniDB/internal/engine"
+	"github.com/okazaki-kk/miniDB/internal/format"
+	"github.com/okazaki-kk/miniDB/internal/lineedit"
 	"github.com/okazaki-kk/miniDB/storage"
 )
 
-const PROMPT = "miniDB >> "
+const (
+	PROMPT              = "miniDB >> "
+	CONTINUATION_PROMPT = "       -> "
+	HISTORY_FILE        = ".minidb_history"
+)
 
 type Repl struct {
 	input    io.Reader
@@ -20,6 +28,7 @@ type Repl struct {
 	catalog  storage.Catalog
 	database storage.Database
 	engine   engine.Engine
+	format   format.Options
 }
 
 func New(input io.Reader, output io.Writer, catalog storage.Catalog, engine engine.Engine) *Repl {
@@ -28,9 +37,15 @@ func New(input io.Reader, output io.Writer, catalog storage.Catalog, engine engi
 		output:  output,
 		catalog: catalog,
 		engine:  engine,
+		format:  format.Options{Format: format.Aligned},
 	}
 }
 
+// SetFormat changes the output format of query results.
+func (r *Repl) SetFormat(f format.Format) {
+	r.format.Format = f
+}
+
 func (r Repl) Start() {
 	user, err := user.Current()
 	if err != nil {
@@ -40,25 +55,123 @@ func (r Repl) Start() {
 	io.WriteString(r.output, "This is the miniDB!\n")
 	io.WriteString(r.output, "Feel free to type in commands\n")
 
-	scanner := bufio.NewScanner(os.Stdin)
+	editor := lineedit.New(r.input, r.output)
+	editor.Completer = r.complete
+	defer editor.Close()
+
+	if editor.IsTerminal() {
+		if home, err := os.UserHomeDir(); err == nil {
+			if err := editor.LoadHistory(filepath.Join(home, HISTORY_FILE)); err != nil {
+				io.WriteString(r.output, fmt.Sprintf("could not load history: %s\n", err))
+			}
+		}
+	}
+
+	var buffer statementBuffer
 
 	for {
-		io.WriteString(r.output, PROMPT)
-		scanned := scanner.Scan()
-		if !scanned {
+		prompt := PROMPT
+		if !buffer.Empty() {
+			prompt = CONTINUATION_PROMPT
+		}
+
+		line, err := editor.ReadLine(prompt)
+		if errors.Is(err, lineedit.ErrInterrupted) {
+			buffer.Reset()
+			continue
+		}
+		if err != nil {
+			if stmt := buffer.Flush(); stmt != "" {
+				r.run(stmt)
+			}
 			return
 		}
 
+		editor.AddHistory(line)
+
+		if buffer.Empty() && strings.HasPrefix(strings.TrimSpace(line), `\`) {
+			buffer.Reset()
+			if !r.run(line) {
+				return
+			}
+			continue
+		}
+
+		for _, stmt := range buffer.Write(line) {
+			if !r.run(stmt) {
+				return
+			}
+		}
+	}
+}
+
+// Run executes the statements read from the input without prompting, as in
+// script mode. It stops at the first failing statement.
+func (r *Repl) Run() error {
+	scanner := bufio.NewScanner(r.input)
+
+	var buffer statementBuffer
+
+	for scanner.Scan() {
 		line := scanner.Text()
-		if message, err := r.exec(line); err != nil {
-			io.WriteString(r.output, fmt.Sprintf("%s\n", err.Error()))
+
+		var statements []string
+		if buffer.Empty() && strings.HasPrefix(strings.TrimSpace(line), `\`) {
+			statements = []string{line}
 		} else {
+			statements = buffer.Write(line)
+		}
+
+		for _, stmt := range statements {
+			message, err := r.exec(stmt)
+			if errors.Is(err, errQuit) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
 			io.WriteString(r.output, message)
 		}
 	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if stmt := buffer.Flush(); stmt != "" {
+		message, err := r.exec(stmt)
+		if err != nil && !errors.Is(err, errQuit) {
+			return err
+		}
+
+		io.WriteString(r.output, message)
+	}
+
+	return nil
+}
+
+// run executes the input and writes its outcome. It returns false once the
+// user asked to quit.
+func (r *Repl) run(input string) bool {
+	message, err := r.exec(input)
+	switch {
+	case errors.Is(err, errQuit):
+		return false
+	case err != nil:
+		io.WriteString(r.output, fmt.Sprintf("%s\n", err.Error()))
+	default:
+		io.WriteString(r.output, message)
+	}
+	return true
 }
 
 func (r *Repl) exec(input string) (string, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return "", nil
+	}
+
 	switch input[0] {
 	case '\\':
 		return r.execCommand(input)
@@ -74,6 +187,24 @@ func (r *Repl) execCommand(input string) (string, error) {
 	switch params[0] {
 	case `\use`:
 		return r.useDatabase(params)
+	case `\l`:
+		return r.listDatabases()
+	case `\dt`:
+		return r.listTables()
+	case `\d`:
+		return r.describeTable(params)
+	case `\dn`:
+		return r.listSchemas()
+	case `\df`:
+		return r.listFunctions(params)
+	case `\?`:
+		return help, nil
+	case `\q`:
+		return "", errQuit
+	case `\pset`:
+		return r.setOption(params)
+	case `\x`:
+		return r.toggleExpanded(params)
 	default:
 		return "", fmt.Errorf("unknown command: %v", params[0])
 	}
@@ -84,25 +215,49 @@ func (r *Repl) useDatabase(params []string) (string, error) {
 		return "", fmt.Errorf("database name not specified")
 	}
 
-	db, err := r.catalog.GetDatabase(params[1])
+	message, err := r.engine.Use(params[1])
 	if err != nil {
 		return "", err
 	}
 
-	r.database = db
-	io.WriteString(r.output, fmt.Sprintf("database %s using", db.Name()))
+	return message, r.syncDatabase()
+}
+
+// syncDatabase follows the current database of the engine session, which may
+// be switched by a USE statement.
+func (r *Repl) syncDatabase() error {
+	name := r.engine.Database()
+	if name == r.database.Name() {
+		return nil
+	}
+
+	if name == "" {
+		r.database = storage.Database{}
+		return nil
+	}
 
-	return "database changed\n", nil
+	db, err := r.catalog.GetDatabase(name)
+	if err != nil {
+		return err
+	}
+
+	r.database = db
+	return nil
 }
 
 func (r *Repl) execQuery(input string) (string, error) {
-	var database string
+	result, err := r.engine.Exec(input)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute query: %w", err)
+	}
 
-	database = r.database.Name()
+	if err := r.syncDatabase(); err != nil {
+		return "", err
+	}
 
-	message, err := r.engine.Exec(database, input)
-	if err != nil {
-		return message, fmt.Errorf("failed to execute query: %w", err)
+	if result.Rows == nil {
+		return result.Message, nil
 	}
-	return message, nil
+
+	return r.render(format.Result{Columns: result.Columns, Rows: result.Rows})
 }
@@ -13,6 +13,13 @@ const (
 	Float
 	Text
 	Boolean
+	Date
+	Time
+	Timestamp
+	TimestampTZ
+	Interval
+	Decimal
+	JSON
 )
 
 func (t DataType) String() string {
@@ -25,6 +32,20 @@ func (t DataType) String() string {
 		return "text"
 	case Boolean:
 		return "boolean"
+	case Date:
+		return "date"
+	case Time:
+		return "time"
+	case Timestamp:
+		return "timestamp"
+	case TimestampTZ:
+		return "timestamptz"
+	case Interval:
+		return "interval"
+	case Decimal:
+		return "numeric"
+	case JSON:
+		return "jsonb"
 	case Null:
 		return "null"
 	default:
@@ -25,3 +25,32 @@ func (b Boolean) String() string {
 func (b Boolean) DataType() sql.DataType {
 	return sql.Boolean
 }
+
+func (b Boolean) Compare(other sql.Value) (int, error) {
+	return compare(b, other)
+}
+
+func (b Boolean) Equal(other sql.Value) bool {
+	return equal(b, other)
+}
+
+func (b Boolean) Hash() uint64 {
+	return hash(b)
+}
+
+// MarshalBinary encodes false as 0 and true as 1.
+func (b Boolean) MarshalBinary() ([]byte, error) {
+	if b.value {
+		return []byte{1}, nil
+	}
+	return []byte{0}, nil
+}
+
+func (b *Boolean) UnmarshalBinary(data []byte) error {
+	if len(data) != 1 || data[0] > 1 {
+		return errDecode(sql.Boolean, data)
+	}
+
+	b.value = data[0] == 1
+	return nil
+}
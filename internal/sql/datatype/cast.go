@@ -0,0 +1,260 @@
+package datatype
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/okazaki-kk/miniDB/internal/sql"
+)
+
+type conversion struct {
+	from, to sql.DataType
+}
+
+// casts are the explicit conversions between the data types. Every type can
+// be cast to itself and to text, and NULL to every type. A missing pair can't
+// be cast:
+//
+//	from \ to  integer  float  numeric  text  boolean  jsonb
+//	integer    yes      yes    yes      yes   yes      -
+//	float      yes      yes    yes      yes   -        -
+//	numeric    yes      yes    yes      yes   -        -
+//	text       yes      yes    yes      yes   yes      yes
+//	boolean    yes      -      -        yes   yes      -
+//	jsonb      yes      yes    yes      yes   yes      yes
+//
+// Only JSON numbers cast to numbers and JSON booleans to booleans.
+//
+// Text can also be cast to the date and time types. Dates, timestamps and
+// timestamps with time zone, in UTC, convert among each other, timestamps to
+// times, and times and intervals to each other.
+var casts = map[conversion]func(sql.Value) (sql.Value, error){
+	{sql.Float, sql.Integer}:   floatToInteger,
+	{sql.Boolean, sql.Integer}: booleanToInteger,
+	{sql.Text, sql.Integer}:    textToInteger,
+	{sql.Integer, sql.Float}:   integerToFloat,
+	{sql.Text, sql.Float}:      textToFloat,
+	{sql.Integer, sql.Boolean}: integerToBoolean,
+	{sql.Text, sql.Boolean}:    textToBoolean,
+	{sql.Integer, sql.Decimal}: integerToDecimal,
+	{sql.Float, sql.Decimal}:   floatToDecimal,
+	{sql.Text, sql.Decimal}:    textToDecimal,
+	{sql.Decimal, sql.Integer}: decimalToInteger,
+	{sql.Decimal, sql.Float}:   decimalToFloat,
+	{sql.Text, sql.JSON}:       textToJSON,
+	{sql.JSON, sql.Integer}:    jsonToInteger,
+	{sql.JSON, sql.Float}:      jsonToFloat,
+	{sql.JSON, sql.Decimal}:    jsonToDecimal,
+	{sql.JSON, sql.Boolean}:    jsonToBoolean,
+
+	{sql.Text, sql.Date}:        textToDate,
+	{sql.Text, sql.Time}:        textToTime,
+	{sql.Text, sql.Timestamp}:   textToTimestamp,
+	{sql.Text, sql.TimestampTZ}: textToTimestampTZ,
+	{sql.Text, sql.Interval}:    textToInterval,
+
+	{sql.Timestamp, sql.Date}:        timeToDate,
+	{sql.TimestampTZ, sql.Date}:      timeToDate,
+	{sql.Date, sql.Timestamp}:        timeToTimestamp,
+	{sql.TimestampTZ, sql.Timestamp}: timeToTimestamp,
+	{sql.Date, sql.TimestampTZ}:      timeToTimestampTZ,
+	{sql.Timestamp, sql.TimestampTZ}: timeToTimestampTZ,
+	{sql.Timestamp, sql.Time}:        timeToTime,
+	{sql.TimestampTZ, sql.Time}:      timeToTime,
+	{sql.Time, sql.Interval}:         timeToInterval,
+	{sql.Interval, sql.Time}:         intervalToTime,
+}
+
+// CanCast reports whether values of a type can be cast to another type.
+func CanCast(from, to sql.DataType) bool {
+	if from == to || from == sql.Null || to == sql.Text {
+		return true
+	}
+
+	_, ok := casts[conversion{from, to}]
+	return ok
+}
+
+// Cast converts the value to the data type. NULL stays NULL.
+func Cast(value sql.Value, to sql.DataType) (sql.Value, error) {
+	if sql.IsNull(value) {
+		return NewNull(), nil
+	}
+
+	switch {
+	case value.DataType() == to:
+		return value, nil
+	case to == sql.Text:
+		return NewText(value.String()), nil
+	}
+
+	cast, ok := casts[conversion{value.DataType(), to}]
+	if !ok {
+		return nil, fmt.Errorf("cannot cast type %s to %s", value.DataType(), to)
+	}
+
+	return cast(value)
+}
+
+func floatToInteger(value sql.Value) (sql.Value, error) {
+	v := value.Raw().(float64)
+	if math.IsNaN(v) || v >= math.MaxInt64 || v < math.MinInt64 {
+		return nil, fmt.Errorf("integer out of range: %s", value)
+	}
+	return NewInteger(int64(math.Round(v))), nil
+}
+
+func booleanToInteger(value sql.Value) (sql.Value, error) {
+	if value.Raw().(bool) {
+		return NewInteger(1), nil
+	}
+	return NewInteger(0), nil
+}
+
+func textToInteger(value sql.Value) (sql.Value, error) {
+	v := value.Raw().(string)
+
+	i, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid input syntax for type integer: %q", v)
+	}
+	return NewInteger(i), nil
+}
+
+func integerToFloat(value sql.Value) (sql.Value, error) {
+	return NewFloat(float64(value.Raw().(int64))), nil
+}
+
+func textToFloat(value sql.Value) (sql.Value, error) {
+	v := value.Raw().(string)
+
+	f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid input syntax for type float: %q", v)
+	}
+	return NewFloat(f), nil
+}
+
+func integerToBoolean(value sql.Value) (sql.Value, error) {
+	return NewBoolean(value.Raw().(int64) != 0), nil
+}
+
+func textToBoolean(value sql.Value) (sql.Value, error) {
+	v := value.Raw().(string)
+
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "t", "true", "y", "yes", "on", "1":
+		return NewBoolean(true), nil
+	case "f", "false", "n", "no", "off", "0":
+		return NewBoolean(false), nil
+	}
+	return nil, fmt.Errorf("invalid input syntax for type boolean: %q", v)
+}
+
+func integerToDecimal(value sql.Value) (sql.Value, error) {
+	return DecimalFromInt(value.Raw().(int64)), nil
+}
+
+func floatToDecimal(value sql.Value) (sql.Value, error) {
+	return DecimalFromFloat(value.Raw().(float64))
+}
+
+func textToDecimal(value sql.Value) (sql.Value, error) {
+	return ParseDecimal(value.Raw().(string))
+}
+
+// decimalToInteger rounds half away from zero.
+func decimalToInteger(value sql.Value) (sql.Value, error) {
+	i, ok := value.(Decimal).Int64()
+	if !ok {
+		return nil, fmt.Errorf("integer out of range: %s", value)
+	}
+	return NewInteger(i), nil
+}
+
+func decimalToFloat(value sql.Value) (sql.Value, error) {
+	return NewFloat(value.(Decimal).Float64()), nil
+}
+
+func textToJSON(value sql.Value) (sql.Value, error) {
+	return ParseJSON(value.Raw().(string))
+}
+
+func jsonToInteger(value sql.Value) (sql.Value, error) {
+	d, err := jsonToNumber(value.(JSON), sql.Integer)
+	if err != nil {
+		return nil, err
+	}
+	return decimalToInteger(d)
+}
+
+func jsonToFloat(value sql.Value) (sql.Value, error) {
+	d, err := jsonToNumber(value.(JSON), sql.Float)
+	if err != nil {
+		return nil, err
+	}
+	return decimalToFloat(d)
+}
+
+func jsonToDecimal(value sql.Value) (sql.Value, error) {
+	return jsonToNumber(value.(JSON), sql.Decimal)
+}
+
+func jsonToBoolean(value sql.Value) (sql.Value, error) {
+	b, ok := value.(JSON).value.(bool)
+	if !ok {
+		return nil, fmt.Errorf("cannot cast jsonb %s to type %s", value.(JSON).Kind(), sql.Boolean)
+	}
+	return NewBoolean(b), nil
+}
+
+func textToDate(value sql.Value) (sql.Value, error) {
+	return ParseDate(value.Raw().(string))
+}
+
+func textToTime(value sql.Value) (sql.Value, error) {
+	return ParseTime(value.Raw().(string))
+}
+
+func textToTimestamp(value sql.Value) (sql.Value, error) {
+	return ParseTimestamp(value.Raw().(string))
+}
+
+func textToTimestampTZ(value sql.Value) (sql.Value, error) {
+	return ParseTimestampTZ(value.Raw().(string))
+}
+
+func textToInterval(value sql.Value) (sql.Value, error) {
+	return ParseInterval(value.Raw().(string))
+}
+
+func timeToDate(value sql.Value) (sql.Value, error) {
+	return NewDate(value.Raw().(time.Time)), nil
+}
+
+func timeToTimestamp(value sql.Value) (sql.Value, error) {
+	return NewTimestamp(value.Raw().(time.Time)), nil
+}
+
+func timeToTimestampTZ(value sql.Value) (sql.Value, error) {
+	return NewTimestampTZ(value.Raw().(time.Time)), nil
+}
+
+// timeToTime returns the time of day of the timestamp.
+func timeToTime(value sql.Value) (sql.Value, error) {
+	t := value.Raw().(time.Time)
+	return NewTime(t.Sub(t.Truncate(24 * time.Hour))), nil
+}
+
+func timeToInterval(value sql.Value) (sql.Value, error) {
+	return NewInterval(0, 0, int64(value.Raw().(time.Duration)/time.Microsecond)), nil
+}
+
+// intervalToTime returns the time of day the time of the interval is after
+// midnight, ignoring its months and days.
+func intervalToTime(value sql.Value) (sql.Value, error) {
+	return NewTime(time.Duration(value.(Interval).micros) * time.Microsecond), nil
+}
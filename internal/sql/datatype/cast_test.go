@@ -0,0 +1,73 @@
+package datatype
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/okazaki-kk/miniDB/internal/sql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCast(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		value    sql.Value
+		to       sql.DataType
+		expected sql.Value
+		err      string
+	}{
+		{name: "null", value: NewNull(), to: sql.Integer, expected: NewNull()},
+		{name: "same type", value: NewText("a"), to: sql.Text, expected: NewText("a")},
+		{name: "integer to float", value: NewInteger(2), to: sql.Float, expected: NewFloat(2)},
+		{name: "float to integer rounds", value: NewFloat(2.5), to: sql.Integer, expected: NewInteger(3)},
+		{name: "integer to text", value: NewInteger(-7), to: sql.Text, expected: NewText("-7")},
+		{name: "boolean to text", value: NewBoolean(true), to: sql.Text, expected: NewText("true")},
+		{name: "text to integer", value: NewText(" 42 "), to: sql.Integer, expected: NewInteger(42)},
+		{name: "text to float", value: NewText("1.5"), to: sql.Float, expected: NewFloat(1.5)},
+		{name: "text to boolean", value: NewText("yes"), to: sql.Boolean, expected: NewBoolean(true)},
+		{name: "integer to boolean", value: NewInteger(0), to: sql.Boolean, expected: NewBoolean(false)},
+		{name: "boolean to integer", value: NewBoolean(true), to: sql.Integer, expected: NewInteger(1)},
+		{name: "invalid integer", value: NewText("abc"), to: sql.Integer, err: `invalid input syntax for type integer: "abc"`},
+		{name: "invalid boolean", value: NewText("maybe"), to: sql.Boolean, err: `invalid input syntax for type boolean: "maybe"`},
+		{name: "float to boolean", value: NewFloat(1), to: sql.Boolean, err: "cannot cast type float to boolean"},
+		{name: "float out of range", value: NewFloat(1e300), to: sql.Integer, err: "integer out of range: 1E+300"},
+		{name: "integer to numeric", value: NewInteger(-7), to: sql.Decimal, expected: DecimalFromInt(-7)},
+		{name: "float to numeric", value: NewFloat(19.99), to: sql.Decimal, expected: NewDecimal(big.NewInt(1999), 2)},
+		{name: "text to numeric", value: NewText(" 1.50 "), to: sql.Decimal, expected: NewDecimal(big.NewInt(150), 2)},
+		{name: "numeric to integer rounds", value: NewDecimal(big.NewInt(-25), 1), to: sql.Integer, expected: NewInteger(-3)},
+		{name: "numeric to float", value: NewDecimal(big.NewInt(1), 1), to: sql.Float, expected: NewFloat(0.1)},
+		{name: "numeric to text", value: NewDecimal(big.NewInt(5), 3), to: sql.Text, expected: NewText("0.005")},
+		{name: "invalid numeric", value: NewText("1,5"), to: sql.Decimal, err: `invalid input syntax for type numeric: "1,5"`},
+		{name: "infinity to numeric", value: NewFloat(math.Inf(-1)), to: sql.Decimal, err: "cannot convert -Inf to numeric"},
+		{name: "numeric out of range", value: NewDecimal(big.NewInt(1e18), -2), to: sql.Integer, err: "integer out of range: 100000000000000000000"},
+		{name: "numeric to boolean", value: DecimalFromInt(1), to: sql.Boolean, err: "cannot cast type numeric to boolean"},
+		{name: "text to jsonb", value: NewText(`{"a": [1]}`), to: sql.JSON, expected: JSON{value: map[string]any{"a": []any{DecimalFromInt(1)}}}},
+		{name: "jsonb to text", value: JSON{value: []any{"a", nil}}, to: sql.Text, expected: NewText(`["a", null]`)},
+		{name: "jsonb to integer", value: JSON{value: NewDecimal(big.NewInt(25), 1)}, to: sql.Integer, expected: NewInteger(3)},
+		{name: "jsonb to numeric", value: JSON{value: NewDecimal(big.NewInt(25), 1)}, to: sql.Decimal, expected: NewDecimal(big.NewInt(25), 1)},
+		{name: "jsonb to boolean", value: JSON{value: true}, to: sql.Boolean, expected: NewBoolean(true)},
+		{name: "invalid jsonb", value: NewText("{"), to: sql.JSON, err: `invalid input syntax for type jsonb: "{"`},
+		{name: "jsonb string to integer", value: JSON{value: "1"}, to: sql.Integer, err: "cannot cast jsonb string to type integer"},
+		{name: "integer to jsonb", value: NewInteger(1), to: sql.JSON, err: "cannot cast type integer to jsonb"},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			value, err := Cast(test.value, test.to)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, value)
+		})
+	}
+}
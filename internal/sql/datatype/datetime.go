@@ -0,0 +1,422 @@
+package datatype
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/okazaki-kk/miniDB/internal/sql"
+)
+
+const (
+	microsPerSecond = int64(time.Second / time.Microsecond)
+	microsPerDay    = 24 * 60 * 60 * microsPerSecond
+)
+
+// Date is a calendar date, stored as the days since 1970-01-01.
+type Date struct {
+	days int64
+}
+
+// NewDate returns the date of the time in its location.
+func NewDate(t time.Time) Date {
+	y, m, d := t.Date()
+	return Date{days: time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / (microsPerDay / microsPerSecond)}
+}
+
+// Raw returns the midnight of the date in UTC.
+func (d Date) Raw() any {
+	return time.Unix(d.days*(microsPerDay/microsPerSecond), 0).UTC()
+}
+
+func (d Date) String() string {
+	return d.Raw().(time.Time).Format("2006-01-02")
+}
+
+func (d Date) DataType() sql.DataType {
+	return sql.Date
+}
+
+func (d Date) Compare(other sql.Value) (int, error) {
+	return compare(d, other)
+}
+
+func (d Date) Equal(other sql.Value) bool {
+	return equal(d, other)
+}
+
+func (d Date) Hash() uint64 {
+	return hash(d)
+}
+
+// MarshalBinary encodes the days like an integer.
+func (d Date) MarshalBinary() ([]byte, error) {
+	return NewInteger(d.days).MarshalBinary()
+}
+
+func (d *Date) UnmarshalBinary(data []byte) error {
+	var i Integer
+	if err := i.UnmarshalBinary(data); err != nil {
+		return errDecode(sql.Date, data)
+	}
+
+	d.days = i.value
+	return nil
+}
+
+// Time is a time of day without a date, stored as the microseconds since
+// midnight.
+type Time struct {
+	micros int64
+}
+
+// NewTime returns the time of day the duration after midnight is, rounded
+// to microseconds.
+func NewTime(d time.Duration) Time {
+	micros := int64(d.Round(time.Microsecond) / time.Microsecond)
+
+	micros %= microsPerDay
+	if micros < 0 {
+		micros += microsPerDay
+	}
+
+	return Time{micros: micros}
+}
+
+// Raw returns the duration since midnight.
+func (t Time) Raw() any {
+	return time.Duration(t.micros) * time.Microsecond
+}
+
+func (t Time) String() string {
+	return formatClock(t.micros)
+}
+
+func (t Time) DataType() sql.DataType {
+	return sql.Time
+}
+
+func (t Time) Compare(other sql.Value) (int, error) {
+	return compare(t, other)
+}
+
+func (t Time) Equal(other sql.Value) bool {
+	return equal(t, other)
+}
+
+func (t Time) Hash() uint64 {
+	return hash(t)
+}
+
+// MarshalBinary encodes the microseconds like an integer.
+func (t Time) MarshalBinary() ([]byte, error) {
+	return NewInteger(t.micros).MarshalBinary()
+}
+
+func (t *Time) UnmarshalBinary(data []byte) error {
+	var i Integer
+	if err := i.UnmarshalBinary(data); err != nil {
+		return errDecode(sql.Time, data)
+	}
+
+	t.micros = i.value
+	return nil
+}
+
+// Timestamp is a date and time of day without a time zone, stored as the
+// microseconds since 1970-01-01 00:00:00.
+type Timestamp struct {
+	micros int64
+}
+
+// NewTimestamp returns the timestamp of the date and time of day of the time
+// in its location, rounded to microseconds.
+func NewTimestamp(t time.Time) Timestamp {
+	y, m, d := t.Date()
+	wall := time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+
+	return Timestamp{micros: wall.Round(time.Microsecond).UnixMicro()}
+}
+
+// Raw returns the time with the date and time of day of the timestamp in
+// UTC.
+func (t Timestamp) Raw() any {
+	return time.UnixMicro(t.micros).UTC()
+}
+
+func (t Timestamp) String() string {
+	return t.Raw().(time.Time).Format("2006-01-02 15:04:05.999999")
+}
+
+func (t Timestamp) DataType() sql.DataType {
+	return sql.Timestamp
+}
+
+func (t Timestamp) Compare(other sql.Value) (int, error) {
+	return compare(t, other)
+}
+
+func (t Timestamp) Equal(other sql.Value) bool {
+	return equal(t, other)
+}
+
+func (t Timestamp) Hash() uint64 {
+	return hash(t)
+}
+
+// MarshalBinary encodes the microseconds like an integer.
+func (t Timestamp) MarshalBinary() ([]byte, error) {
+	return NewInteger(t.micros).MarshalBinary()
+}
+
+func (t *Timestamp) UnmarshalBinary(data []byte) error {
+	var i Integer
+	if err := i.UnmarshalBinary(data); err != nil {
+		return errDecode(sql.Timestamp, data)
+	}
+
+	t.micros = i.value
+	return nil
+}
+
+// TimestampTZ is an instant, stored as the microseconds since 1970-01-01
+// 00:00:00 UTC. It is shown in UTC.
+type TimestampTZ struct {
+	micros int64
+}
+
+// NewTimestampTZ returns the instant of the time rounded to microseconds.
+func NewTimestampTZ(t time.Time) TimestampTZ {
+	return TimestampTZ{micros: t.Round(time.Microsecond).UnixMicro()}
+}
+
+// Raw returns the instant in UTC.
+func (t TimestampTZ) Raw() any {
+	return time.UnixMicro(t.micros).UTC()
+}
+
+func (t TimestampTZ) String() string {
+	return t.Raw().(time.Time).Format("2006-01-02 15:04:05.999999-07")
+}
+
+func (t TimestampTZ) DataType() sql.DataType {
+	return sql.TimestampTZ
+}
+
+func (t TimestampTZ) Compare(other sql.Value) (int, error) {
+	return compare(t, other)
+}
+
+func (t TimestampTZ) Equal(other sql.Value) bool {
+	return equal(t, other)
+}
+
+func (t TimestampTZ) Hash() uint64 {
+	return hash(t)
+}
+
+// MarshalBinary encodes the microseconds like an integer.
+func (t TimestampTZ) MarshalBinary() ([]byte, error) {
+	return NewInteger(t.micros).MarshalBinary()
+}
+
+func (t *TimestampTZ) UnmarshalBinary(data []byte) error {
+	var i Integer
+	if err := i.UnmarshalBinary(data); err != nil {
+		return errDecode(sql.TimestampTZ, data)
+	}
+
+	t.micros = i.value
+	return nil
+}
+
+// ParseDate parses a date like 2006-01-02. A time of day and a time zone
+// following the date are ignored.
+func ParseDate(s string) (Date, error) {
+	t, _, err := parseTimestamp(s, sql.Date)
+	if err != nil {
+		return Date{}, err
+	}
+	return NewDate(t), nil
+}
+
+// ParseTime parses a time of day like 15:04:05.999999.
+func ParseTime(s string) (Time, error) {
+	micros, ok := parseClock(strings.TrimSpace(s))
+	if !ok {
+		return Time{}, invalidSyntax(sql.Time, s)
+	}
+	return Time{micros: micros}, nil
+}
+
+// ParseTimestamp parses a date optionally followed by a time of day, like
+// 2006-01-02 15:04:05.999999. A time zone following them is ignored.
+func ParseTimestamp(s string) (Timestamp, error) {
+	t, _, err := parseTimestamp(s, sql.Timestamp)
+	if err != nil {
+		return Timestamp{}, err
+	}
+	return NewTimestamp(t), nil
+}
+
+// ParseTimestampTZ parses a date optionally followed by a time of day and a
+// time zone, like 2006-01-02 15:04:05.999999+07 or 2006-01-02 15:04
+// Asia/Yakutsk. Without a time zone the time is in UTC.
+func ParseTimestampTZ(s string) (TimestampTZ, error) {
+	t, loc, err := parseTimestamp(s, sql.TimestampTZ)
+	if err != nil {
+		return TimestampTZ{}, err
+	}
+
+	if loc != nil {
+		y, m, d := t.Date()
+		t = time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+	}
+
+	return NewTimestampTZ(t), nil
+}
+
+// parseTimestamp returns the date and time of day of the text in UTC and
+// the time zone following them, nil if there is none. Errors name the data
+// type.
+func parseTimestamp(s string, dataType sql.DataType) (time.Time, *time.Location, error) {
+	text := strings.TrimSpace(s)
+	if len(text) < len("2006-01-02") {
+		return time.Time{}, nil, invalidSyntax(dataType, s)
+	}
+
+	t, err := time.Parse("2006-01-02", text[:10])
+	if err != nil {
+		return time.Time{}, nil, invalidSyntax(dataType, s)
+	}
+
+	rest := text[10:]
+	if rest != "" && (rest[0] == 'T' || rest[0] == ' ') {
+		rest = strings.TrimLeft(rest[1:], " ")
+	}
+
+	n := strings.IndexFunc(rest, func(r rune) bool {
+		return !(r >= '0' && r <= '9' || r == ':' || r == '.')
+	})
+	if n < 0 {
+		n = len(rest)
+	}
+
+	if n > 0 {
+		micros, ok := parseClock(rest[:n])
+		if !ok {
+			return time.Time{}, nil, invalidSyntax(dataType, s)
+		}
+		t = t.Add(time.Duration(micros) * time.Microsecond)
+	}
+
+	zone := strings.TrimSpace(rest[n:])
+	if zone == "" {
+		return t, nil, nil
+	}
+
+	loc, err := LoadZone(zone)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+
+	return t, loc, nil
+}
+
+// parseClock returns the microseconds since midnight of a time of day like
+// 15:04 or 15:04:05.999999, rounding fractions of microseconds.
+func parseClock(s string) (int64, bool) {
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, false
+	}
+
+	hours, err := strconv.ParseUint(parts[0], 10, 8)
+	if err != nil || hours > 23 || len(parts[0]) > 2 {
+		return 0, false
+	}
+
+	minutes, err := strconv.ParseUint(parts[1], 10, 8)
+	if err != nil || minutes > 59 || len(parts[1]) != 2 {
+		return 0, false
+	}
+
+	var seconds float64
+	if len(parts) == 3 {
+		if len(parts[2]) < 2 || parts[2][0] == '.' || parts[2][0] == '+' || parts[2][0] == '-' {
+			return 0, false
+		}
+
+		if seconds, err = strconv.ParseFloat(parts[2], 64); err != nil || seconds >= 60 {
+			return 0, false
+		}
+	}
+
+	micros := (int64(hours)*60+int64(minutes))*60*microsPerSecond + int64(seconds*float64(microsPerSecond)+0.5)
+
+	return micros, micros < microsPerDay
+}
+
+// formatClock formats the microseconds as a time of day like
+// 15:04:05.999999, omitting a fraction of zero.
+func formatClock(micros int64) string {
+	seconds := micros / microsPerSecond
+	clock := fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+
+	if fraction := micros % microsPerSecond; fraction != 0 {
+		clock += strings.TrimRight(fmt.Sprintf(".%06d", fraction), "0")
+	}
+
+	return clock
+}
+
+// LoadZone returns the time zone of the name: UTC, a name of the tz
+// database like Asia/Yakutsk, or an offset east of UTC like +07 or -03:30.
+func LoadZone(name string) (*time.Location, error) {
+	switch {
+	case strings.EqualFold(name, "UTC") || strings.EqualFold(name, "Z"):
+		return time.UTC, nil
+	case strings.HasPrefix(name, "+") || strings.HasPrefix(name, "-"):
+		if offset, ok := parseOffset(name[1:]); ok {
+			if name[0] == '-' {
+				offset = -offset
+			}
+			return time.FixedZone(name, offset), nil
+		}
+	default:
+		if loc, err := time.LoadLocation(name); err == nil && name != "" && name != "Local" {
+			return loc, nil
+		}
+	}
+
+	return nil, fmt.Errorf("time zone %q not recognized", name)
+}
+
+// parseOffset returns the seconds of an offset like 07, 0730 or 07:30.
+func parseOffset(s string) (int, bool) {
+	s = strings.Replace(s, ":", "", 1)
+	if len(s) != 2 && len(s) != 4 {
+		return 0, false
+	}
+
+	n, err := strconv.ParseUint(s, 10, 16)
+	if err != nil {
+		return 0, false
+	}
+
+	hours, minutes := int(n), 0
+	if len(s) == 4 {
+		hours, minutes = int(n/100), int(n%100)
+	}
+
+	if hours > 15 || minutes > 59 {
+		return 0, false
+	}
+
+	return (hours*60 + minutes) * 60, true
+}
+
+func invalidSyntax(dataType sql.DataType, s string) error {
+	return fmt.Errorf("invalid input syntax for type %s: %q", dataType, s)
+}
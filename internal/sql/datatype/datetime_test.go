@@ -0,0 +1,138 @@
+package datatype
+
+import (
+	"testing"
+	"time"
+
+	"github.com/okazaki-kk/miniDB/internal/sql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDate_Raw(t *testing.T) {
+	d := NewDate(time.Date(2024, 2, 29, 23, 30, 0, 0, time.FixedZone("", 9*60*60)))
+	assert.Equal(t, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), d.Raw())
+	assert.Equal(t, "2024-02-29", d.String())
+	assert.Equal(t, sql.Date, d.DataType())
+}
+
+func TestTime_Raw(t *testing.T) {
+	tm := NewTime(25*time.Hour + 1500*time.Microsecond)
+	assert.Equal(t, time.Hour+1500*time.Microsecond, tm.Raw())
+	assert.Equal(t, "01:00:00.0015", tm.String())
+	assert.Equal(t, sql.Time, tm.DataType())
+
+	assert.Equal(t, "23:00:00", NewTime(-time.Hour).String())
+}
+
+func TestTimestamp_Raw(t *testing.T) {
+	loc := time.FixedZone("", -3*60*60)
+	ts := NewTimestamp(time.Date(2024, 1, 2, 3, 4, 5, 600000000, loc))
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 600000000, time.UTC), ts.Raw())
+	assert.Equal(t, "2024-01-02 03:04:05.6", ts.String())
+	assert.Equal(t, sql.Timestamp, ts.DataType())
+
+	tz := NewTimestampTZ(time.Date(2024, 1, 2, 3, 4, 5, 0, loc))
+	assert.Equal(t, time.Date(2024, 1, 2, 6, 4, 5, 0, time.UTC), tz.Raw())
+	assert.Equal(t, "2024-01-02 06:04:05+00", tz.String())
+	assert.Equal(t, sql.TimestampTZ, tz.DataType())
+}
+
+func TestParseDatetime(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		input    string
+		to       sql.DataType
+		expected string
+		err      string
+	}{
+		{name: "date", input: "2024-03-01", to: sql.Date, expected: "2024-03-01"},
+		{name: "date ignores time", input: " 2024-03-01 10:00 ", to: sql.Date, expected: "2024-03-01"},
+		{name: "time", input: "04:05", to: sql.Time, expected: "04:05:00"},
+		{name: "time with fraction", input: "04:05:06.123456", to: sql.Time, expected: "04:05:06.123456"},
+		{name: "timestamp", input: "2024-03-01 04:05:06", to: sql.Timestamp, expected: "2024-03-01 04:05:06"},
+		{name: "timestamp with T", input: "2024-03-01T04:05", to: sql.Timestamp, expected: "2024-03-01 04:05:00"},
+		{name: "timestamp ignores zone", input: "2024-03-01 04:05+09", to: sql.Timestamp, expected: "2024-03-01 04:05:00"},
+		{name: "timestamptz utc", input: "2024-03-01 04:05", to: sql.TimestampTZ, expected: "2024-03-01 04:05:00+00"},
+		{name: "timestamptz offset", input: "2024-03-01 04:05:00-03:30", to: sql.TimestampTZ, expected: "2024-03-01 07:35:00+00"},
+		{name: "timestamptz zone name", input: "2024-07-01 12:00 Europe/Berlin", to: sql.TimestampTZ, expected: "2024-07-01 10:00:00+00"},
+		{name: "invalid date", input: "2024-02-30", to: sql.Date, err: `invalid input syntax for type date: "2024-02-30"`},
+		{name: "invalid time", input: "24:00", to: sql.Time, err: `invalid input syntax for type time: "24:00"`},
+		{name: "invalid timestamp", input: "2024-03-01 4:5:6:7", to: sql.Timestamp, err: `invalid input syntax for type timestamp: "2024-03-01 4:5:6:7"`},
+		{name: "unknown zone", input: "2024-03-01 04:05 Mars/Olympus", to: sql.TimestampTZ, err: `time zone "Mars/Olympus" not recognized`},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			value, err := Cast(NewText(test.input), test.to)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.to, value.DataType())
+			assert.Equal(t, test.expected, value.String())
+		})
+	}
+}
+
+func TestDatetime_Compare(t *testing.T) {
+	t.Parallel()
+
+	earlier, _ := ParseTimestamp("2024-03-01 04:05")
+	later, _ := ParseTimestamp("2024-03-01 04:06")
+
+	c, err := earlier.Compare(later)
+	assert.NoError(t, err)
+	assert.Equal(t, -1, c)
+	assert.True(t, earlier.Equal(earlier))
+	assert.Equal(t, earlier.Hash(), NewTimestamp(earlier.Raw().(time.Time)).Hash())
+
+	c, err = NewTime(time.Hour).Compare(NewTime(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, c)
+}
+
+func TestDatetime_MarshalBinary(t *testing.T) {
+	t.Parallel()
+
+	tests := []sql.Value{
+		NewDate(time.Date(1969, 12, 31, 0, 0, 0, 0, time.UTC)),
+		NewTime(13*time.Hour + time.Microsecond),
+		NewTimestamp(time.Date(2024, 3, 1, 4, 5, 6, 7000, time.UTC)),
+		NewTimestampTZ(time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)),
+	}
+
+	for _, value := range tests {
+		b, err := value.MarshalBinary()
+		assert.NoError(t, err)
+
+		decoded, err := Unmarshal(value.DataType(), b)
+		assert.NoError(t, err)
+		assert.Equal(t, value, decoded)
+	}
+
+	earlier, _ := NewDate(time.Date(1969, 12, 31, 0, 0, 0, 0, time.UTC)).MarshalBinary()
+	later, _ := NewDate(time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)).MarshalBinary()
+	assert.Less(t, string(earlier), string(later))
+}
+
+func TestLoadZone(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"UTC", "z", "+07", "-0330", "+05:45", "Asia/Yakutsk"} {
+		_, err := LoadZone(name)
+		assert.NoError(t, err, name)
+	}
+
+	for _, name := range []string{"", "Local", "+16", "+07:60", "Nowhere"} {
+		_, err := LoadZone(name)
+		assert.EqualError(t, err, `time zone "`+name+`" not recognized`)
+	}
+}
@@ -0,0 +1,411 @@
+package datatype
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/okazaki-kk/miniDB/internal/sql"
+)
+
+const (
+	// MaxDecimalPrecision is the largest precision of NUMERIC(p, s) and the
+	// largest scale of a decimal.
+	MaxDecimalPrecision = 1000
+	// minDivisionDigits are the significant digits a quotient has at least.
+	minDivisionDigits = 16
+)
+
+// Decimal is an exact decimal number, the unscaled integer divided by 10 to
+// the power of the scale. The scale is the number of digits after the
+// decimal point, so 1.50 has the unscaled integer 150 and the scale 2. The
+// unscaled integer is never modified.
+type Decimal struct {
+	value *big.Int
+	scale int32
+}
+
+// NewDecimal returns the decimal of the unscaled integer and the scale. A
+// negative scale multiplies the integer by its power of 10.
+func NewDecimal(unscaled *big.Int, scale int32) Decimal {
+	value := new(big.Int).Set(unscaled)
+	if scale < 0 {
+		return Decimal{value: value.Mul(value, pow10(int64(-scale)))}
+	}
+	return Decimal{value: value, scale: scale}
+}
+
+// DecimalFromInt returns the decimal of the integer with the scale 0.
+func DecimalFromInt(i int64) Decimal {
+	return Decimal{value: big.NewInt(i)}
+}
+
+// DecimalFromFloat returns the decimal of the shortest text that parses to
+// the float, like 0.1 for 0.1.
+func DecimalFromFloat(f float64) (Decimal, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Decimal{}, fmt.Errorf("cannot convert %s to numeric", strconv.FormatFloat(f, 'g', -1, 64))
+	}
+	return ParseDecimal(strconv.FormatFloat(f, 'e', -1, 64))
+}
+
+// ParseDecimal parses a decimal like 12, -0.50 or 1.5e3. The scale is the
+// number of digits after the point less the exponent, at least 0.
+func ParseDecimal(s string) (Decimal, error) {
+	text := strings.TrimSpace(s)
+
+	mantissa, exponent := text, int64(0)
+	if i := strings.IndexAny(text, "eE"); i >= 0 {
+		var err error
+		if exponent, err = strconv.ParseInt(text[i+1:], 10, 32); err != nil {
+			return Decimal{}, invalidSyntax(sql.Decimal, s)
+		}
+		mantissa = text[:i]
+	}
+
+	sign := ""
+	if mantissa != "" && (mantissa[0] == '-' || mantissa[0] == '+') {
+		sign, mantissa = mantissa[:1], mantissa[1:]
+	}
+
+	whole, fraction, _ := strings.Cut(mantissa, ".")
+	digits := whole + fraction
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return Decimal{}, invalidSyntax(sql.Decimal, s)
+	}
+
+	scale := int64(len(fraction)) - exponent
+	if scale > MaxDecimalPrecision || int64(len(digits))-scale > MaxDecimalPrecision {
+		return Decimal{}, fmt.Errorf("value overflows numeric format: %q", s)
+	}
+
+	value, _ := new(big.Int).SetString(sign+digits, 10)
+	if scale < 0 {
+		value.Mul(value, pow10(-scale))
+		scale = 0
+	}
+
+	return Decimal{value: value, scale: int32(scale)}, nil
+}
+
+// Raw returns the decimal itself.
+func (d Decimal) Raw() any {
+	return d
+}
+
+// String formats the decimal with all digits of its scale, like 1.50.
+func (d Decimal) String() string {
+	digits := new(big.Int).Abs(d.value).String()
+
+	sign := ""
+	if d.value.Sign() < 0 {
+		sign = "-"
+	}
+
+	if d.scale == 0 {
+		return sign + digits
+	}
+
+	if pad := int(d.scale) + 1 - len(digits); pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+
+	point := len(digits) - int(d.scale)
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+func (d Decimal) DataType() sql.DataType {
+	return sql.Decimal
+}
+
+func (d Decimal) Compare(other sql.Value) (int, error) {
+	return compare(d, other)
+}
+
+func (d Decimal) Equal(other sql.Value) bool {
+	return equal(d, other)
+}
+
+func (d Decimal) Hash() uint64 {
+	return hash(d)
+}
+
+// MarshalBinary encodes the scale, the sign and the big-endian absolute
+// unscaled integer.
+func (d Decimal) MarshalBinary() ([]byte, error) {
+	b := binary.BigEndian.AppendUint32(nil, uint32(d.scale))
+
+	sign := byte(0)
+	if d.value.Sign() < 0 {
+		sign = 1
+	}
+	b = append(b, sign)
+
+	return append(b, new(big.Int).Abs(d.value).Bytes()...), nil
+}
+
+func (d *Decimal) UnmarshalBinary(data []byte) error {
+	if len(data) < 5 || data[4] > 1 {
+		return errDecode(sql.Decimal, data)
+	}
+
+	d.scale = int32(binary.BigEndian.Uint32(data))
+	d.value = new(big.Int).SetBytes(data[5:])
+	if data[4] == 1 {
+		d.value.Neg(d.value)
+	}
+
+	return nil
+}
+
+// Scale returns the number of digits after the decimal point.
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+// Sign returns -1, 0 or 1 for a negative, zero or positive decimal.
+func (d Decimal) Sign() int {
+	return d.value.Sign()
+}
+
+// Float64 returns the float nearest to the decimal.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// Int64 returns the decimal rounded half away from zero to an integer and
+// whether the integer is in the range of int64.
+func (d Decimal) Int64() (int64, bool) {
+	i := d.Round(0).value
+	return i.Int64(), i.IsInt64()
+}
+
+// Cmp compares the decimals by their value, so 1.5 equals 1.50.
+func (d Decimal) Cmp(other Decimal) int {
+	x, y := align(d, other)
+	return x.Cmp(y)
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{value: new(big.Int).Neg(d.value), scale: d.scale}
+}
+
+func (d Decimal) Abs() Decimal {
+	return Decimal{value: new(big.Int).Abs(d.value), scale: d.scale}
+}
+
+// Add returns the sum with the larger scale of both decimals.
+func (d Decimal) Add(other Decimal) Decimal {
+	x, y := align(d, other)
+	return Decimal{value: x.Add(x, y), scale: maxScale(d, other)}
+}
+
+// Sub returns the difference with the larger scale of both decimals.
+func (d Decimal) Sub(other Decimal) Decimal {
+	x, y := align(d, other)
+	return Decimal{value: x.Sub(x, y), scale: maxScale(d, other)}
+}
+
+// Mul returns the exact product, whose scale is the sum of both scales.
+func (d Decimal) Mul(other Decimal) Decimal {
+	return Decimal{value: new(big.Int).Mul(d.value, other.value), scale: d.scale + other.scale}.limit()
+}
+
+// Quo returns the quotient rounded half away from zero to at least 16
+// significant digits and at least the larger scale of both decimals.
+func (d Decimal) Quo(other Decimal) (Decimal, error) {
+	if other.value.Sign() == 0 {
+		return Decimal{}, fmt.Errorf("division by zero")
+	}
+
+	scale := int64(minDivisionDigits) - (d.exponent() - other.exponent())
+	if s := int64(maxScale(d, other)); scale < s {
+		scale = s
+	}
+	if scale < 0 {
+		scale = 0
+	}
+	if scale > MaxDecimalPrecision {
+		scale = MaxDecimalPrecision
+	}
+
+	// d / other = (d.value * 10^(scale - d.scale + other.scale) / other.value) / 10^scale
+	numerator, denominator := new(big.Int).Set(d.value), new(big.Int).Set(other.value)
+	if shift := scale - int64(d.scale) + int64(other.scale); shift >= 0 {
+		numerator.Mul(numerator, pow10(shift))
+	} else {
+		denominator.Mul(denominator, pow10(-shift))
+	}
+
+	return Decimal{value: divide(numerator, denominator, roundHalfAwayFromZero), scale: int32(scale)}, nil
+}
+
+// Rem returns the remainder of the division truncated toward zero, which has
+// the sign of the dividend.
+func (d Decimal) Rem(other Decimal) (Decimal, error) {
+	if other.value.Sign() == 0 {
+		return Decimal{}, fmt.Errorf("division by zero")
+	}
+
+	x, y := align(d, other)
+	return Decimal{value: x.Rem(x, y), scale: maxScale(d, other)}, nil
+}
+
+// Round rounds half away from zero to the number of decimal places, negative
+// places rounding to tens, hundreds and so on.
+func (d Decimal) Round(places int32) Decimal {
+	return d.rescale(places, roundHalfAwayFromZero)
+}
+
+// Truncate rounds toward zero to the number of decimal places.
+func (d Decimal) Truncate(places int32) Decimal {
+	return d.rescale(places, roundTowardZero)
+}
+
+// Floor returns the largest integer not greater than the decimal.
+func (d Decimal) Floor() Decimal {
+	return d.rescale(0, roundDown)
+}
+
+// Ceil returns the smallest integer not less than the decimal.
+func (d Decimal) Ceil() Decimal {
+	return d.rescale(0, roundUp)
+}
+
+// Fit rounds the decimal to the scale of NUMERIC(precision, scale) and fails
+// when it has more than precision - scale digits before the point. A
+// precision of 0 leaves the decimal as it is.
+func (d Decimal) Fit(precision, scale int) (Decimal, error) {
+	if precision == 0 {
+		return d, nil
+	}
+
+	rounded := d.Round(int32(scale))
+	if rounded.exponent() >= int64(precision-scale) {
+		return Decimal{}, fmt.Errorf(
+			"numeric field overflow: a field with precision %d, scale %d must round to an absolute value less than 10^%d",
+			precision, scale, precision-scale,
+		)
+	}
+
+	return rounded, nil
+}
+
+// CheckDecimalType verifies the precision and scale of NUMERIC(precision,
+// scale).
+func CheckDecimalType(precision, scale int) error {
+	if precision < 1 || precision > MaxDecimalPrecision {
+		return fmt.Errorf("NUMERIC precision %d must be between 1 and %d", precision, MaxDecimalPrecision)
+	}
+
+	if scale < 0 || scale > precision {
+		return fmt.Errorf("NUMERIC scale %d must be between 0 and precision %d", scale, precision)
+	}
+
+	return nil
+}
+
+type roundingMode int
+
+const (
+	roundHalfAwayFromZero roundingMode = iota
+	roundTowardZero
+	roundDown
+	roundUp
+)
+
+// rescale returns the decimal with the scale of the places, rounding away
+// digits with the mode. Negative places round to tens, hundreds and so on
+// and give the scale 0.
+func (d Decimal) rescale(places int32, mode roundingMode) Decimal {
+	if places > MaxDecimalPrecision {
+		places = MaxDecimalPrecision
+	}
+
+	if places >= d.scale {
+		value := new(big.Int).Mul(d.value, pow10(int64(places-d.scale)))
+		return Decimal{value: value, scale: places}
+	}
+
+	unit := pow10(int64(d.scale - places))
+	value := divide(d.value, unit, mode)
+
+	if places < 0 {
+		return Decimal{value: value.Mul(value, pow10(int64(-places)))}
+	}
+	return Decimal{value: value, scale: places}
+}
+
+// limit rounds a decimal whose scale exceeds the largest one.
+func (d Decimal) limit() Decimal {
+	if d.scale > MaxDecimalPrecision {
+		return d.Round(MaxDecimalPrecision)
+	}
+	return d
+}
+
+// exponent returns the power of ten of the leading digit, like 1 for 12.5
+// and -2 for 0.05, and the scale less one for zero.
+func (d Decimal) exponent() int64 {
+	if d.value.Sign() == 0 {
+		return -int64(d.scale) - 1
+	}
+	return int64(len(new(big.Int).Abs(d.value).String())) - 1 - int64(d.scale)
+}
+
+// divide returns the quotient of the integers rounded with the mode.
+func divide(x, y *big.Int, mode roundingMode) *big.Int {
+	q, r := new(big.Int).QuoRem(x, y, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+
+	// The sign of the exact quotient, q is rounded toward zero.
+	sign := x.Sign() * y.Sign()
+
+	switch mode {
+	case roundHalfAwayFromZero:
+		if new(big.Int).Abs(r).Lsh(new(big.Int).Abs(r), 1).CmpAbs(y) >= 0 {
+			q.Add(q, big.NewInt(int64(sign)))
+		}
+	case roundDown:
+		if sign < 0 {
+			q.Sub(q, big.NewInt(1))
+		}
+	case roundUp:
+		if sign > 0 {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+
+	return q
+}
+
+// align returns the unscaled integers of both decimals at the larger scale.
+func align(a, b Decimal) (*big.Int, *big.Int) {
+	x, y := new(big.Int).Set(a.value), new(big.Int).Set(b.value)
+
+	switch {
+	case a.scale < b.scale:
+		x.Mul(x, pow10(int64(b.scale-a.scale)))
+	case a.scale > b.scale:
+		y.Mul(y, pow10(int64(a.scale-b.scale)))
+	}
+
+	return x, y
+}
+
+func maxScale(a, b Decimal) int32 {
+	if a.scale > b.scale {
+		return a.scale
+	}
+	return b.scale
+}
+
+func pow10(n int64) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(n), nil)
+}
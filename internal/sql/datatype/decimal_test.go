@@ -0,0 +1,202 @@
+package datatype
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/okazaki-kk/miniDB/internal/sql"
+	"github.com/stretchr/testify/assert"
+)
+
+func decimalOf(t *testing.T, s string) Decimal {
+	t.Helper()
+
+	d, err := ParseDecimal(s)
+	assert.NoError(t, err)
+	return d
+}
+
+func TestDecimal_Raw(t *testing.T) {
+	d := NewDecimal(big.NewInt(-150), 2)
+	assert.Equal(t, d, d.Raw())
+	assert.Equal(t, "-1.50", d.String())
+	assert.Equal(t, sql.Decimal, d.DataType())
+	assert.Equal(t, int32(2), d.Scale())
+	assert.Equal(t, -1.5, d.Float64())
+}
+
+func TestParseDecimal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input    string
+		expected string
+		err      string
+	}{
+		{input: "12", expected: "12"},
+		{input: " -0.50 ", expected: "-0.50"},
+		{input: "+.5", expected: "0.5"},
+		{input: "5.", expected: "5"},
+		{input: "1.5e3", expected: "1500"},
+		{input: "15E-4", expected: "0.0015"},
+		{input: "0.000", expected: "0.000"},
+		{input: "", err: `invalid input syntax for type numeric: ""`},
+		{input: "1.2.3", err: `invalid input syntax for type numeric: "1.2.3"`},
+		{input: "NaN", err: `invalid input syntax for type numeric: "NaN"`},
+		{input: "1e", err: `invalid input syntax for type numeric: "1e"`},
+		{input: "1e-1001", err: `value overflows numeric format: "1e-1001"`},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.input, func(t *testing.T) {
+			t.Parallel()
+
+			d, err := ParseDecimal(test.input)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, d.String())
+		})
+	}
+}
+
+func TestDecimal_Arithmetic(t *testing.T) {
+	t.Parallel()
+
+	a, b := decimalOf(t, "19.99"), decimalOf(t, "0.1")
+
+	assert.Equal(t, "20.09", a.Add(b).String())
+	assert.Equal(t, "19.89", a.Sub(b).String())
+	assert.Equal(t, "1.999", a.Mul(b).String())
+	assert.Equal(t, "-19.99", a.Neg().String())
+	assert.Equal(t, "19.99", a.Neg().Abs().String())
+	assert.Equal(t, "0.3", decimalOf(t, "0.1").Add(decimalOf(t, "0.2")).String())
+
+	quotient, err := DecimalFromInt(1).Quo(DecimalFromInt(3))
+	assert.NoError(t, err)
+	assert.Equal(t, "0.3333333333333333", quotient.String())
+
+	quotient, err = decimalOf(t, "2").Quo(decimalOf(t, "3.000000000000000000"))
+	assert.NoError(t, err)
+	assert.Equal(t, "0.666666666666666667", quotient.String())
+
+	quotient, err = decimalOf(t, "1000000").Quo(decimalOf(t, "0.5"))
+	assert.NoError(t, err)
+	assert.Equal(t, "2000000.000000000", quotient.String())
+
+	_, err = a.Quo(decimalOf(t, "0.00"))
+	assert.EqualError(t, err, "division by zero")
+
+	remainder, err := decimalOf(t, "-7.5").Rem(DecimalFromInt(2))
+	assert.NoError(t, err)
+	assert.Equal(t, "-1.5", remainder.String())
+}
+
+func TestDecimal_Round(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input  string
+		places int32
+		round  string
+		trunc  string
+	}{
+		{input: "2.5", places: 0, round: "3", trunc: "2"},
+		{input: "-2.5", places: 0, round: "-3", trunc: "-2"},
+		{input: "1.005", places: 2, round: "1.01", trunc: "1.00"},
+		{input: "-0.004", places: 2, round: "0.00", trunc: "0.00"},
+		{input: "1.5", places: 3, round: "1.500", trunc: "1.500"},
+		{input: "1250.5", places: -2, round: "1300", trunc: "1200"},
+		{input: "49.9", places: -2, round: "0", trunc: "0"},
+	}
+
+	for _, test := range tests {
+		d := decimalOf(t, test.input)
+		assert.Equal(t, test.round, d.Round(test.places).String(), "round(%s, %d)", test.input, test.places)
+		assert.Equal(t, test.trunc, d.Truncate(test.places).String(), "trunc(%s, %d)", test.input, test.places)
+	}
+
+	assert.Equal(t, "-2", decimalOf(t, "-1.1").Floor().String())
+	assert.Equal(t, "-1", decimalOf(t, "-1.1").Ceil().String())
+	assert.Equal(t, "2", decimalOf(t, "1.1").Ceil().String())
+	assert.Equal(t, "1", decimalOf(t, "1.000").Floor().String())
+}
+
+func TestDecimal_Fit(t *testing.T) {
+	t.Parallel()
+
+	d, err := decimalOf(t, "99999999.994").Fit(10, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "99999999.99", d.String())
+
+	d, err = decimalOf(t, "-0.5").Fit(1, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "-1", d.String())
+
+	d, err = decimalOf(t, "123.456").Fit(0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "123.456", d.String())
+
+	_, err = decimalOf(t, "99999999.995").Fit(10, 2)
+	assert.EqualError(t, err, "numeric field overflow: a field with precision 10, scale 2 must round to an absolute value less than 10^8")
+
+	_, err = decimalOf(t, "1").Fit(2, 2)
+	assert.EqualError(t, err, "numeric field overflow: a field with precision 2, scale 2 must round to an absolute value less than 10^0")
+
+	assert.NoError(t, CheckDecimalType(1000, 1000))
+	assert.EqualError(t, CheckDecimalType(1001, 0), "NUMERIC precision 1001 must be between 1 and 1000")
+	assert.EqualError(t, CheckDecimalType(3, 4), "NUMERIC scale 4 must be between 0 and precision 3")
+}
+
+func TestDecimal_Compare(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		a, b     sql.Value
+		expected int
+	}{
+		{a: decimalOf(t, "1.5"), b: decimalOf(t, "1.50"), expected: 0},
+		{a: decimalOf(t, "-1.5"), b: decimalOf(t, "1"), expected: -1},
+		{a: decimalOf(t, "2.00"), b: NewInteger(2), expected: 0},
+		{a: NewInteger(3), b: decimalOf(t, "2.99"), expected: 1},
+		{a: decimalOf(t, "0.1"), b: NewFloat(0.1), expected: 0},
+		{a: NewFloat(0.25), b: decimalOf(t, "0.2"), expected: 1},
+	}
+
+	for _, test := range tests {
+		c, err := test.a.Compare(test.b)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, c, "%s <=> %s", test.a, test.b)
+
+		if test.expected == 0 {
+			assert.Equal(t, test.a.Hash(), test.b.Hash(), "%s = %s", test.a, test.b)
+		}
+	}
+
+	_, err := decimalOf(t, "1").Compare(NewText("1"))
+	assert.EqualError(t, err, "cannot compare numeric with text")
+}
+
+func TestDecimal_MarshalBinary(t *testing.T) {
+	t.Parallel()
+
+	for _, s := range []string{"0", "0.00", "-1.50", "123456789012345678901234567890.123456789"} {
+		d := decimalOf(t, s)
+
+		b, err := d.MarshalBinary()
+		assert.NoError(t, err)
+
+		decoded, err := Unmarshal(sql.Decimal, b)
+		assert.NoError(t, err)
+		assert.Equal(t, s, decoded.String())
+		assert.True(t, d.Equal(decoded))
+	}
+
+	_, err := Unmarshal(sql.Decimal, []byte{0, 0})
+	assert.EqualError(t, err, "invalid numeric encoding of 2 bytes")
+}
@@ -1,6 +1,8 @@
 package datatype
 
 import (
+	"encoding/binary"
+	"math"
 	"strconv"
 
 	"github.com/okazaki-kk/miniDB/internal/sql"
@@ -25,3 +27,45 @@ func (f Float) String() string {
 func (f Float) DataType() sql.DataType {
 	return sql.Float
 }
+
+func (f Float) Compare(other sql.Value) (int, error) {
+	return compare(f, other)
+}
+
+func (f Float) Equal(other sql.Value) bool {
+	return equal(f, other)
+}
+
+func (f Float) Hash() uint64 {
+	return hash(f)
+}
+
+// MarshalBinary encodes the bits of the float big-endian, with the sign bit
+// flipped for positive floats and all bits flipped for negative ones, so that
+// the encodings sort like the floats.
+func (f Float) MarshalBinary() ([]byte, error) {
+	bits := math.Float64bits(f.value)
+	if bits&(1<<63) != 0 {
+		bits = ^bits
+	} else {
+		bits |= 1 << 63
+	}
+
+	return binary.BigEndian.AppendUint64(nil, bits), nil
+}
+
+func (f *Float) UnmarshalBinary(data []byte) error {
+	if len(data) != 8 {
+		return errDecode(sql.Float, data)
+	}
+
+	bits := binary.BigEndian.Uint64(data)
+	if bits&(1<<63) != 0 {
+		bits &^= 1 << 63
+	} else {
+		bits = ^bits
+	}
+
+	f.value = math.Float64frombits(bits)
+	return nil
+}
@@ -1,6 +1,7 @@
 package datatype
 
 import (
+	"encoding/binary"
 	"strconv"
 
 	"github.com/okazaki-kk/miniDB/internal/sql"
@@ -25,3 +26,30 @@ func (i Integer) String() string {
 func (i Integer) DataType() sql.DataType {
 	return sql.Integer
 }
+
+func (i Integer) Compare(other sql.Value) (int, error) {
+	return compare(i, other)
+}
+
+func (i Integer) Equal(other sql.Value) bool {
+	return equal(i, other)
+}
+
+func (i Integer) Hash() uint64 {
+	return hash(i)
+}
+
+// MarshalBinary encodes the integer big-endian with the sign bit flipped, so
+// that negative integers sort first.
+func (i Integer) MarshalBinary() ([]byte, error) {
+	return binary.BigEndian.AppendUint64(nil, uint64(i.value)^(1<<63)), nil
+}
+
+func (i *Integer) UnmarshalBinary(data []byte) error {
+	if len(data) != 8 {
+		return errDecode(sql.Integer, data)
+	}
+
+	i.value = int64(binary.BigEndian.Uint64(data) ^ (1 << 63))
+	return nil
+}
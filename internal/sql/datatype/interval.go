@@ -0,0 +1,272 @@
+package datatype
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/okazaki-kk/miniDB/internal/sql"
+)
+
+// Interval is a span of time in months, days and microseconds, which are
+// kept apart as months and days vary in length. For comparisons a month has
+// 30 days and a day 24 hours.
+type Interval struct {
+	months int64
+	days   int64
+	micros int64
+}
+
+func NewInterval(months, days, micros int64) Interval {
+	return Interval{months: months, days: days, micros: micros}
+}
+
+// Raw returns the interval itself.
+func (i Interval) Raw() any {
+	return i
+}
+
+func (i Interval) Months() int64 {
+	return i.months
+}
+
+func (i Interval) Days() int64 {
+	return i.days
+}
+
+func (i Interval) Micros() int64 {
+	return i.micros
+}
+
+// span returns the microseconds of the interval with months of 30 days.
+func (i Interval) span() int64 {
+	return (i.months*30+i.days)*microsPerDay + i.micros
+}
+
+// String formats the interval like 1 year 2 mons 3 days 04:05:06.
+func (i Interval) String() string {
+	var parts []string
+
+	units := []struct {
+		n    int64
+		unit string
+	}{
+		{i.months / 12, "year"},
+		{i.months % 12, "mon"},
+		{i.days, "day"},
+	}
+	for _, u := range units {
+		switch u.n {
+		case 0:
+		case 1:
+			parts = append(parts, "1 "+u.unit)
+		default:
+			parts = append(parts, fmt.Sprintf("%d %ss", u.n, u.unit))
+		}
+	}
+
+	switch {
+	case i.micros < 0:
+		parts = append(parts, "-"+formatClock(-i.micros))
+	case i.micros > 0 || len(parts) == 0:
+		parts = append(parts, formatClock(i.micros))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func (i Interval) DataType() sql.DataType {
+	return sql.Interval
+}
+
+func (i Interval) Compare(other sql.Value) (int, error) {
+	return compare(i, other)
+}
+
+func (i Interval) Equal(other sql.Value) bool {
+	return equal(i, other)
+}
+
+func (i Interval) Hash() uint64 {
+	return hash(i)
+}
+
+// MarshalBinary encodes the span of the interval like an integer, so that
+// the encodings sort like the intervals, followed by the months and days.
+func (i Interval) MarshalBinary() ([]byte, error) {
+	b, _ := NewInteger(i.span()).MarshalBinary()
+	b = binary.BigEndian.AppendUint64(b, uint64(i.months))
+	return binary.BigEndian.AppendUint64(b, uint64(i.days)), nil
+}
+
+func (i *Interval) UnmarshalBinary(data []byte) error {
+	if len(data) != 24 {
+		return errDecode(sql.Interval, data)
+	}
+
+	var span Integer
+	if err := span.UnmarshalBinary(data[:8]); err != nil {
+		return err
+	}
+
+	i.months = int64(binary.BigEndian.Uint64(data[8:]))
+	i.days = int64(binary.BigEndian.Uint64(data[16:]))
+	i.micros = span.value - (i.months*30+i.days)*microsPerDay
+
+	return nil
+}
+
+// intervalUnits are the units of intervals in months, days or microseconds.
+var intervalUnits = map[string]struct{ months, days, micros int64 }{
+	"microsecond": {micros: 1},
+	"millisecond": {micros: 1000},
+	"second":      {micros: microsPerSecond},
+	"minute":      {micros: 60 * microsPerSecond},
+	"hour":        {micros: 60 * 60 * microsPerSecond},
+	"day":         {days: 1},
+	"week":        {days: 7},
+	"month":       {months: 1},
+	"year":        {months: 12},
+	"decade":      {months: 10 * 12},
+	"century":     {months: 100 * 12},
+	"millennium":  {months: 1000 * 12},
+}
+
+// intervalAbbreviations are the other names of the units.
+var intervalAbbreviations = map[string]string{
+	"us": "microsecond", "usec": "microsecond", "usecs": "microsecond", "microseconds": "microsecond",
+	"ms": "millisecond", "msec": "millisecond", "msecs": "millisecond", "milliseconds": "millisecond",
+	"s": "second", "sec": "second", "secs": "second", "seconds": "second",
+	"m": "minute", "min": "minute", "mins": "minute", "minutes": "minute",
+	"h": "hour", "hr": "hour", "hrs": "hour", "hours": "hour",
+	"d": "day", "days": "day",
+	"w": "week", "weeks": "week",
+	"mon": "month", "mons": "month", "months": "month",
+	"y": "year", "yr": "year", "yrs": "year", "years": "year",
+	"decades": "decade", "centuries": "century", "millennia": "millennium", "millenniums": "millennium",
+}
+
+// IntervalUnit returns the unit of the name, like hour for hours, and
+// whether there is one.
+func IntervalUnit(name string) (string, bool) {
+	name = strings.ToLower(name)
+	if unit, ok := intervalAbbreviations[name]; ok {
+		name = unit
+	}
+
+	_, ok := intervalUnits[name]
+	return name, ok
+}
+
+// ParseInterval parses an interval of quantities with units, optionally
+// followed by a time like 04:05:06 and by ago negating the interval, like
+// 1 year 2 months 3 days 04:05:06 or 90 minutes ago. A quantity without a
+// unit counts seconds, fractions spill into the smaller units.
+func ParseInterval(s string) (Interval, error) {
+	fields := strings.Fields(strings.ToLower(s))
+
+	ago := len(fields) > 0 && fields[len(fields)-1] == "ago"
+	if ago {
+		fields = fields[:len(fields)-1]
+	}
+
+	if len(fields) == 0 {
+		return Interval{}, invalidSyntax(sql.Interval, s)
+	}
+
+	var i Interval
+
+	for n := 0; n < len(fields); n++ {
+		field := fields[n]
+
+		if strings.Contains(field, ":") {
+			micros, ok := parseIntervalClock(field)
+			if !ok {
+				return Interval{}, invalidSyntax(sql.Interval, s)
+			}
+			i.micros += micros
+			continue
+		}
+
+		end := strings.IndexFunc(field, func(r rune) bool {
+			return !(r >= '0' && r <= '9' || r == '.' || r == '-' || r == '+')
+		})
+		if end < 0 {
+			end = len(field)
+		}
+
+		quantity, err := strconv.ParseFloat(field[:end], 64)
+		if err != nil {
+			return Interval{}, invalidSyntax(sql.Interval, s)
+		}
+
+		name := field[end:]
+		if name == "" && n+1 < len(fields) && !strings.Contains(fields[n+1], ":") {
+			if _, err := strconv.ParseFloat(fields[n+1], 64); err != nil {
+				n++
+				name = fields[n]
+			}
+		}
+		if name == "" {
+			name = "second"
+		}
+
+		unit, ok := IntervalUnit(name)
+		if !ok {
+			return Interval{}, invalidSyntax(sql.Interval, s)
+		}
+
+		i = i.add(intervalOf(quantity, intervalUnits[unit]))
+	}
+
+	if ago {
+		i = Interval{months: -i.months, days: -i.days, micros: -i.micros}
+	}
+
+	return i, nil
+}
+
+// intervalOf returns the quantity of the unit, spilling fractions of months
+// into days and fractions of days into microseconds.
+func intervalOf(quantity float64, unit struct{ months, days, micros int64 }) Interval {
+	months := quantity * float64(unit.months)
+	whole := math.Trunc(months)
+
+	days := quantity*float64(unit.days) + (months-whole)*30
+	wholeDays := math.Trunc(days)
+
+	micros := quantity*float64(unit.micros) + (days-wholeDays)*float64(microsPerDay)
+
+	return Interval{months: int64(whole), days: int64(wholeDays), micros: int64(math.Round(micros))}
+}
+
+func (i Interval) add(other Interval) Interval {
+	return Interval{months: i.months + other.months, days: i.days + other.days, micros: i.micros + other.micros}
+}
+
+// parseIntervalClock returns the microseconds of a signed time like
+// -25:30 or 04:05:06.5, whose hours may exceed a day.
+func parseIntervalClock(s string) (int64, bool) {
+	sign := int64(1)
+	switch {
+	case strings.HasPrefix(s, "-"):
+		sign, s = -1, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+
+	end := strings.Index(s, ":")
+	hours, err := strconv.ParseUint(s[:end], 10, 32)
+	if err != nil {
+		return 0, false
+	}
+
+	micros, ok := parseClock("00" + s[end:])
+	if !ok {
+		return 0, false
+	}
+
+	return sign * (int64(hours)*60*60*microsPerSecond + micros), true
+}
@@ -0,0 +1,88 @@
+package datatype
+
+import (
+	"testing"
+
+	"github.com/okazaki-kk/miniDB/internal/sql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterval_String(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		interval Interval
+		expected string
+	}{
+		{interval: NewInterval(0, 0, 0), expected: "00:00:00"},
+		{interval: NewInterval(14, 3, 4*3600*microsPerSecond+5*60*microsPerSecond+6*microsPerSecond), expected: "1 year 2 mons 3 days 04:05:06"},
+		{interval: NewInterval(1, 1, 0), expected: "1 mon 1 day"},
+		{interval: NewInterval(0, 0, -3600*microsPerSecond), expected: "-01:00:00"},
+		{interval: NewInterval(0, -2, 1500), expected: "-2 days 00:00:00.0015"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, test.interval.String())
+	}
+}
+
+func TestParseInterval(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input    string
+		expected Interval
+		err      string
+	}{
+		{input: "1 day", expected: NewInterval(0, 1, 0)},
+		{input: "1 year 2 months 3 days 04:05:06", expected: NewInterval(14, 3, (4*3600+5*60+6)*microsPerSecond)},
+		{input: "90 minutes ago", expected: NewInterval(0, 0, -90*60*microsPerSecond)},
+		{input: "1.5 days", expected: NewInterval(0, 1, 12*3600*microsPerSecond)},
+		{input: "0.5 mon", expected: NewInterval(0, 15, 0)},
+		{input: "2h 30min", expected: NewInterval(0, 0, 150*60*microsPerSecond)},
+		{input: "2 weeks", expected: NewInterval(0, 14, 0)},
+		{input: "-25:30", expected: NewInterval(0, 0, -(25*3600+30*60)*microsPerSecond)},
+		{input: "10", expected: NewInterval(0, 0, 10*microsPerSecond)},
+		{input: "", err: `invalid input syntax for type interval: ""`},
+		{input: "1 fortnight", err: `invalid input syntax for type interval: "1 fortnight"`},
+		{input: "ago", err: `invalid input syntax for type interval: "ago"`},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.input, func(t *testing.T) {
+			t.Parallel()
+
+			i, err := ParseInterval(test.input)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, i)
+		})
+	}
+}
+
+func TestInterval_Compare(t *testing.T) {
+	t.Parallel()
+
+	month := NewInterval(1, 0, 0)
+
+	c, err := month.Compare(NewInterval(0, 30, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, c)
+
+	c, err = month.Compare(NewInterval(0, 30, 1))
+	assert.NoError(t, err)
+	assert.Equal(t, -1, c)
+
+	b, err := NewInterval(-1, 2, 3).MarshalBinary()
+	assert.NoError(t, err)
+
+	decoded, err := Unmarshal(sql.Interval, b)
+	assert.NoError(t, err)
+	assert.Equal(t, NewInterval(-1, 2, 3), decoded)
+}
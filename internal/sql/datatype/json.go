@@ -0,0 +1,600 @@
+package datatype
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/okazaki-kk/miniDB/internal/sql"
+)
+
+// JSON is a JSON document stored decoded, like PostgreSQL's jsonb: numbers
+// are decimals, insignificant whitespace is dropped and objects keep the
+// last value of a key and their keys sorted. The decoded value is nil,
+// bool, Decimal, string, []any or map[string]any.
+type JSON struct {
+	value any
+}
+
+// The tags of the values in the binary encoding of a document.
+const (
+	jsonNull byte = iota
+	jsonFalse
+	jsonTrue
+	jsonNumber
+	jsonString
+	jsonArray
+	jsonObject
+)
+
+// ParseJSON parses the text of a JSON document.
+func ParseJSON(s string) (JSON, error) {
+	decoder := json.NewDecoder(strings.NewReader(s))
+	decoder.UseNumber()
+
+	var v any
+	if err := decoder.Decode(&v); err != nil {
+		return JSON{}, invalidSyntax(sql.JSON, s)
+	}
+
+	if _, err := decoder.Token(); err != io.EOF {
+		return JSON{}, invalidSyntax(sql.JSON, s)
+	}
+
+	value, err := decodedJSON(v)
+	if err != nil {
+		return JSON{}, err
+	}
+
+	return JSON{value: value}, nil
+}
+
+// decodedJSON replaces the numbers decoded by encoding/json with decimals.
+func decodedJSON(v any) (any, error) {
+	switch v := v.(type) {
+	case json.Number:
+		return ParseDecimal(v.String())
+	case []any:
+		for i := range v {
+			var err error
+			if v[i], err = decodedJSON(v[i]); err != nil {
+				return nil, err
+			}
+		}
+	case map[string]any:
+		for key := range v {
+			var err error
+			if v[key], err = decodedJSON(v[key]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return v, nil
+}
+
+// ToJSON returns the JSON value of the value: NULL is null, numbers are
+// numbers, booleans booleans and other values strings of their text. Floats
+// which are not finite are strings too.
+func ToJSON(value sql.Value) JSON {
+	switch v := value.Raw().(type) {
+	case JSON:
+		return v
+	case nil:
+		return JSON{}
+	case bool:
+		return JSON{value: v}
+	case int64:
+		return JSON{value: DecimalFromInt(v)}
+	case float64:
+		if d, err := DecimalFromFloat(v); err == nil {
+			return JSON{value: d}
+		}
+	case Decimal:
+		return JSON{value: v}
+	}
+
+	return JSON{value: value.String()}
+}
+
+// NewJSONArray returns the array of the elements.
+func NewJSONArray(elements []JSON) JSON {
+	array := make([]any, len(elements))
+	for i, element := range elements {
+		array[i] = element.value
+	}
+	return JSON{value: array}
+}
+
+// NewJSONObject returns the object of the keys with the values at the same
+// positions. A key given twice keeps its last value.
+func NewJSONObject(keys []string, values []JSON) JSON {
+	object := make(map[string]any, len(keys))
+	for i, key := range keys {
+		object[key] = values[i].value
+	}
+	return JSON{value: object}
+}
+
+// Raw returns the document itself.
+func (j JSON) Raw() any {
+	return j
+}
+
+// String formats the document like PostgreSQL formats jsonb, with a space
+// after the colons and commas, like {"a": [1, 2]}.
+func (j JSON) String() string {
+	var b strings.Builder
+	writeJSON(&b, j.value)
+	return b.String()
+}
+
+func writeJSON(b *strings.Builder, v any) {
+	switch v := v.(type) {
+	case nil:
+		b.WriteString("null")
+	case bool:
+		b.WriteString(strconv.FormatBool(v))
+	case Decimal:
+		b.WriteString(v.String())
+	case string:
+		writeJSONString(b, v)
+	case []any:
+		b.WriteByte('[')
+		for i, element := range v {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			writeJSON(b, element)
+		}
+		b.WriteByte(']')
+	case map[string]any:
+		b.WriteByte('{')
+		for i, key := range sortedKeys(v) {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			writeJSONString(b, key)
+			b.WriteString(": ")
+			writeJSON(b, v[key])
+		}
+		b.WriteByte('}')
+	}
+}
+
+// writeJSONString writes the string quoted, escaping quotes, backslashes and
+// control characters.
+func writeJSONString(b *strings.Builder, s string) {
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(b, `\u%04x`, r)
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+}
+
+func (j JSON) DataType() sql.DataType {
+	return sql.JSON
+}
+
+func (j JSON) Compare(other sql.Value) (int, error) {
+	return compare(j, other)
+}
+
+func (j JSON) Equal(other sql.Value) bool {
+	return equal(j, other)
+}
+
+func (j JSON) Hash() uint64 {
+	return hash(j)
+}
+
+// MarshalBinary encodes the document as a tree of tagged values: numbers
+// and strings are prefixed with the length of their encoding, arrays with
+// their number of elements and objects with their number of keys, which
+// follow in order, each before its value.
+func (j JSON) MarshalBinary() ([]byte, error) {
+	return appendJSON(nil, j.value, func(b []byte, d Decimal) []byte {
+		number, _ := d.MarshalBinary()
+		b = binary.AppendUvarint(b, uint64(len(number)))
+		return append(b, number...)
+	}), nil
+}
+
+func (j *JSON) UnmarshalBinary(data []byte) error {
+	value, rest, ok := decodeJSON(data)
+	if !ok || len(rest) > 0 {
+		return errDecode(sql.JSON, data)
+	}
+
+	j.value = value
+
+	return nil
+}
+
+// appendJSON appends the binary encoding of the value, with numbers
+// appended by the function.
+func appendJSON(b []byte, v any, number func([]byte, Decimal) []byte) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(b, jsonNull)
+	case bool:
+		if v {
+			return append(b, jsonTrue)
+		}
+		return append(b, jsonFalse)
+	case Decimal:
+		return number(append(b, jsonNumber), v)
+	case string:
+		b = binary.AppendUvarint(append(b, jsonString), uint64(len(v)))
+		return append(b, v...)
+	case []any:
+		b = binary.AppendUvarint(append(b, jsonArray), uint64(len(v)))
+		for _, element := range v {
+			b = appendJSON(b, element, number)
+		}
+		return b
+	default:
+		object := v.(map[string]any)
+
+		b = binary.AppendUvarint(append(b, jsonObject), uint64(len(object)))
+		for _, key := range sortedKeys(object) {
+			b = binary.AppendUvarint(b, uint64(len(key)))
+			b = appendJSON(append(b, key...), object[key], number)
+		}
+		return b
+	}
+}
+
+// decodeJSON decodes the value at the start of the data and returns the
+// data following it.
+func decodeJSON(data []byte) (any, []byte, bool) {
+	if len(data) == 0 {
+		return nil, nil, false
+	}
+
+	tag, data := data[0], data[1:]
+
+	switch tag {
+	case jsonNull:
+		return nil, data, true
+	case jsonFalse, jsonTrue:
+		return tag == jsonTrue, data, true
+	case jsonNumber, jsonString:
+		b, rest, ok := decodeBytes(data)
+		if !ok {
+			return nil, nil, false
+		}
+
+		if tag == jsonString {
+			return string(b), rest, true
+		}
+
+		var d Decimal
+		if err := d.UnmarshalBinary(b); err != nil {
+			return nil, nil, false
+		}
+		return d, rest, true
+	case jsonArray:
+		n, size := binary.Uvarint(data)
+		if size <= 0 || n > uint64(len(data)) {
+			return nil, nil, false
+		}
+		data = data[size:]
+
+		array := make([]any, n)
+		for i := range array {
+			var ok bool
+			if array[i], data, ok = decodeJSON(data); !ok {
+				return nil, nil, false
+			}
+		}
+		return array, data, true
+	case jsonObject:
+		n, size := binary.Uvarint(data)
+		if size <= 0 || n > uint64(len(data)) {
+			return nil, nil, false
+		}
+		data = data[size:]
+
+		object := make(map[string]any, n)
+		for i := uint64(0); i < n; i++ {
+			key, rest, ok := decodeBytes(data)
+			if !ok {
+				return nil, nil, false
+			}
+
+			if object[string(key)], data, ok = decodeJSON(rest); !ok {
+				return nil, nil, false
+			}
+		}
+		return object, data, true
+	default:
+		return nil, nil, false
+	}
+}
+
+// decodeBytes returns the bytes prefixed with their length and the data
+// following them.
+func decodeBytes(data []byte) ([]byte, []byte, bool) {
+	n, size := binary.Uvarint(data)
+	if size <= 0 || n > uint64(len(data)-size) {
+		return nil, nil, false
+	}
+
+	data = data[size:]
+	return data[:n], data[n:], true
+}
+
+// Kind returns the kind of the value: object, array, string, number,
+// boolean or null.
+func (j JSON) Kind() string {
+	switch j.value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case Decimal:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// Field returns the value of the key of an object.
+func (j JSON) Field(key string) (JSON, bool) {
+	object, ok := j.value.(map[string]any)
+	if !ok {
+		return JSON{}, false
+	}
+
+	v, ok := object[key]
+	return JSON{value: v}, ok
+}
+
+// Element returns the element of an array at the position counted from 0,
+// or from its end for a negative one.
+func (j JSON) Element(i int) (JSON, bool) {
+	array, ok := j.value.([]any)
+	if !ok {
+		return JSON{}, false
+	}
+
+	if i < 0 {
+		i += len(array)
+	}
+	if i < 0 || i >= len(array) {
+		return JSON{}, false
+	}
+
+	return JSON{value: array[i]}, true
+}
+
+// Path returns the value at the path, whose steps are keys of objects and
+// positions in arrays.
+func (j JSON) Path(path []string) (JSON, bool) {
+	for _, step := range path {
+		var ok bool
+		switch j.value.(type) {
+		case []any:
+			i, err := strconv.Atoi(step)
+			if err != nil {
+				return JSON{}, false
+			}
+			j, ok = j.Element(i)
+		default:
+			j, ok = j.Field(step)
+		}
+
+		if !ok {
+			return JSON{}, false
+		}
+	}
+
+	return j, true
+}
+
+// Len returns the number of elements of an array.
+func (j JSON) Len() (int, bool) {
+	array, ok := j.value.([]any)
+	return len(array), ok
+}
+
+// Text returns a string without its quotes and any other value formatted
+// like String, except for null, for which it returns false.
+func (j JSON) Text() (string, bool) {
+	switch v := j.value.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	default:
+		return j.String(), true
+	}
+}
+
+// Contains reports whether the document contains the other one, like
+// PostgreSQL's @>: an object contains the objects with a subset of its keys
+// whose values it contains, an array the arrays whose elements all are
+// contained in one of its elements and the scalars it has as an element,
+// and a scalar the equal scalar.
+func (j JSON) Contains(other JSON) bool {
+	if array, ok := j.value.([]any); ok && isJSONScalar(other.value) {
+		for _, element := range array {
+			if compareJSON(element, other.value) == 0 {
+				return true
+			}
+		}
+		return false
+	}
+
+	return containsJSON(j.value, other.value)
+}
+
+func containsJSON(a, b any) bool {
+	switch y := b.(type) {
+	case map[string]any:
+		x, ok := a.(map[string]any)
+		if !ok {
+			return false
+		}
+
+		for key, v := range y {
+			if w, ok := x[key]; !ok || !containsJSON(w, v) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		x, ok := a.([]any)
+		if !ok {
+			return false
+		}
+
+	next:
+		for _, v := range y {
+			for _, w := range x {
+				if containsJSON(w, v) {
+					continue next
+				}
+			}
+			return false
+		}
+		return true
+	default:
+		return isJSONScalar(a) && compareJSON(a, b) == 0
+	}
+}
+
+func isJSONScalar(v any) bool {
+	switch v.(type) {
+	case []any, map[string]any:
+		return false
+	}
+	return true
+}
+
+// jsonRank orders the kinds of values like PostgreSQL orders jsonb: null,
+// strings, numbers, booleans, arrays and objects.
+func jsonRank(v any) int {
+	switch v.(type) {
+	case nil:
+		return 0
+	case string:
+		return 1
+	case Decimal:
+		return 2
+	case bool:
+		return 3
+	case []any:
+		return 4
+	default:
+		return 5
+	}
+}
+
+// compareJSON compares values of different kinds by their rank, arrays and
+// objects by their length first and then element by element, objects by
+// their sorted keys and the values of the keys.
+func compareJSON(a, b any) int {
+	if ra, rb := jsonRank(a), jsonRank(b); ra != rb {
+		return compareOrdered(ra, rb)
+	}
+
+	switch x := a.(type) {
+	case string:
+		return strings.Compare(x, b.(string))
+	case Decimal:
+		return x.Cmp(b.(Decimal))
+	case bool:
+		if y := b.(bool); x != y {
+			if x {
+				return 1
+			}
+			return -1
+		}
+	case []any:
+		y := b.([]any)
+		if len(x) != len(y) {
+			return compareOrdered(len(x), len(y))
+		}
+
+		for i := range x {
+			if c := compareJSON(x[i], y[i]); c != 0 {
+				return c
+			}
+		}
+	case map[string]any:
+		y := b.(map[string]any)
+		if len(x) != len(y) {
+			return compareOrdered(len(x), len(y))
+		}
+
+		xkeys, ykeys := sortedKeys(x), sortedKeys(y)
+		for i := range xkeys {
+			if c := strings.Compare(xkeys[i], ykeys[i]); c != 0 {
+				return c
+			}
+			if c := compareJSON(x[xkeys[i]], y[ykeys[i]]); c != 0 {
+				return c
+			}
+		}
+	}
+
+	return 0
+}
+
+// hashJSON hashes the encoding of the document with its numbers encoded by
+// their float value, so that equal numbers like 1 and 1.0 hash the same.
+func hashJSON(j JSON) uint64 {
+	b := appendJSON([]byte{byte(sql.JSON)}, j.value, func(b []byte, d Decimal) []byte {
+		return binary.BigEndian.AppendUint64(b, floatBits(d.Float64()))
+	})
+
+	h := fnv.New64a()
+	h.Write(b)
+	return h.Sum64()
+}
+
+func sortedKeys(object map[string]any) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// jsonToNumber returns the decimal of a number.
+func jsonToNumber(j JSON, to sql.DataType) (Decimal, error) {
+	d, ok := j.value.(Decimal)
+	if !ok {
+		return Decimal{}, fmt.Errorf("cannot cast jsonb %s to type %s", j.Kind(), to)
+	}
+	return d, nil
+}
@@ -0,0 +1,202 @@
+package datatype
+
+import (
+	"testing"
+
+	"github.com/okazaki-kk/miniDB/internal/sql"
+	"github.com/stretchr/testify/assert"
+)
+
+func jsonOf(t *testing.T, s string) JSON {
+	t.Helper()
+
+	j, err := ParseJSON(s)
+	assert.NoError(t, err)
+	return j
+}
+
+func TestParseJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input    string
+		expected string
+		kind     string
+		err      string
+	}{
+		{input: `{"b": [1, 2.50, "x"], "a" :null}`, expected: `{"a": null, "b": [1, 2.50, "x"]}`, kind: "object"},
+		{input: `{"a": 1, "a": 2}`, expected: `{"a": 2}`, kind: "object"},
+		{input: ` [ ] `, expected: `[]`, kind: "array"},
+		{input: `"tab\there é"`, expected: `"tab\there é"`, kind: "string"},
+		{input: `1e3`, expected: `1000`, kind: "number"},
+		{input: `true`, expected: `true`, kind: "boolean"},
+		{input: `null`, expected: `null`, kind: "null"},
+		{input: `{"a": }`, err: `invalid input syntax for type jsonb: "{\"a\": }"`},
+		{input: `[1] [2]`, err: `invalid input syntax for type jsonb: "[1] [2]"`},
+		{input: ``, err: `invalid input syntax for type jsonb: ""`},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.input, func(t *testing.T) {
+			t.Parallel()
+
+			j, err := ParseJSON(test.input)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, j.String())
+			assert.Equal(t, test.kind, j.Kind())
+			assert.Equal(t, sql.JSON, j.DataType())
+		})
+	}
+}
+
+func TestJSON_MarshalBinary(t *testing.T) {
+	t.Parallel()
+
+	for _, s := range []string{`null`, `false`, `-1.50`, `"é"`, `[1, [true], {}]`, `{"a": {"b": [null, 0.1]}, "c": ""}`} {
+		j := jsonOf(t, s)
+
+		b, err := j.MarshalBinary()
+		assert.NoError(t, err)
+
+		decoded, err := Unmarshal(sql.JSON, b)
+		assert.NoError(t, err)
+		assert.Equal(t, s, decoded.String())
+		assert.True(t, j.Equal(decoded))
+	}
+
+	_, err := Unmarshal(sql.JSON, []byte{jsonArray, 2, jsonNull})
+	assert.EqualError(t, err, "invalid jsonb encoding of 3 bytes")
+}
+
+func TestJSON_Compare(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{a: `1.0`, b: `1`, expected: 0},
+		{a: `{"b": 1, "a": [2]}`, b: `{"a": [2.0], "b": 1}`, expected: 0},
+		{a: `null`, b: `""`, expected: -1},
+		{a: `"z"`, b: `1`, expected: -1},
+		{a: `10`, b: `true`, expected: -1},
+		{a: `true`, b: `false`, expected: 1},
+		{a: `[9]`, b: `[1, 2]`, expected: -1},
+		{a: `[1, 3]`, b: `[1, 2]`, expected: 1},
+		{a: `{"a": 1}`, b: `[1, 2, 3]`, expected: 1},
+		{a: `{"a": 1}`, b: `{"b": 1}`, expected: -1},
+	}
+
+	for _, test := range tests {
+		a, b := jsonOf(t, test.a), jsonOf(t, test.b)
+
+		c, err := a.Compare(b)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, c, "%s <=> %s", a, b)
+
+		if test.expected == 0 {
+			assert.Equal(t, a.Hash(), b.Hash(), "%s = %s", a, b)
+		}
+	}
+
+	_, err := jsonOf(t, `1`).Compare(NewInteger(1))
+	assert.EqualError(t, err, "cannot compare jsonb with integer")
+}
+
+func TestJSON_Path(t *testing.T) {
+	t.Parallel()
+
+	doc := jsonOf(t, `{"a": {"b": [10, {"c": "x"}]}, "n": null}`)
+
+	tests := []struct {
+		path     []string
+		expected string
+		text     string
+	}{
+		{path: nil, expected: doc.String(), text: doc.String()},
+		{path: []string{"a", "b", "0"}, expected: `10`, text: "10"},
+		{path: []string{"a", "b", "-1", "c"}, expected: `"x"`, text: "x"},
+		{path: []string{"a", "b", "2"}},
+		{path: []string{"a", "b", "c"}},
+		{path: []string{"missing"}},
+		{path: []string{"n"}, expected: `null`},
+	}
+
+	for _, test := range tests {
+		j, ok := doc.Path(test.path)
+		assert.Equal(t, test.expected != "", ok, "%v", test.path)
+		if !ok {
+			continue
+		}
+
+		assert.Equal(t, test.expected, j.String(), "%v", test.path)
+
+		text, ok := j.Text()
+		assert.Equal(t, test.text != "", ok, "%v", test.path)
+		assert.Equal(t, test.text, text, "%v", test.path)
+	}
+
+	n, ok := jsonOf(t, `[1, 2, 3]`).Len()
+	assert.True(t, ok)
+	assert.Equal(t, 3, n)
+
+	_, ok = jsonOf(t, `{"a": 1}`).Len()
+	assert.False(t, ok)
+}
+
+func TestJSON_Contains(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{a: `{"a": 1, "b": {"c": [1, 2]}}`, b: `{"b": {"c": [2]}}`, expected: true},
+		{a: `{"a": 1}`, b: `{"a": 1, "b": 2}`, expected: false},
+		{a: `{"a": 1}`, b: `{}`, expected: true},
+		{a: `[1, [2, 3]]`, b: `[[3], 1, 1]`, expected: true},
+		{a: `[1, 2]`, b: `[3]`, expected: false},
+		{a: `["a", "b"]`, b: `"a"`, expected: true},
+		{a: `"a"`, b: `["a"]`, expected: false},
+		{a: `1.0`, b: `1`, expected: true},
+		{a: `{"a": [1]}`, b: `{"a": 1}`, expected: false},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, jsonOf(t, test.a).Contains(jsonOf(t, test.b)), "%s @> %s", test.a, test.b)
+	}
+}
+
+func TestToJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value    sql.Value
+		expected string
+	}{
+		{value: NewNull(), expected: `null`},
+		{value: NewInteger(-3), expected: `-3`},
+		{value: NewFloat(0.5), expected: `0.5`},
+		{value: decimalOf(t, "1.50"), expected: `1.50`},
+		{value: NewBoolean(true), expected: `true`},
+		{value: NewText(`say "hi"`), expected: `"say \"hi\""`},
+		{value: jsonOf(t, `[1]`), expected: `[1]`},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, ToJSON(test.value).String())
+	}
+
+	array := NewJSONArray([]JSON{ToJSON(NewInteger(1)), ToJSON(NewNull())})
+	assert.Equal(t, `[1, null]`, array.String())
+
+	object := NewJSONObject([]string{"k", "a", "k"}, []JSON{ToJSON(NewInteger(1)), array, ToJSON(NewText("v"))})
+	assert.Equal(t, `{"a": [1, null], "k": "v"}`, object.String())
+}
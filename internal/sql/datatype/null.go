@@ -0,0 +1,49 @@
+package datatype
+
+import (
+	"github.com/okazaki-kk/miniDB/internal/sql"
+)
+
+// Null is the SQL NULL. It is a value of every data type, unknown in
+// comparisons and stored in a row like any other value.
+type Null struct{}
+
+func NewNull() Null {
+	return Null{}
+}
+
+func (n Null) Raw() any {
+	return nil
+}
+
+func (n Null) String() string {
+	return "NULL"
+}
+
+func (n Null) DataType() sql.DataType {
+	return sql.Null
+}
+
+func (n Null) Compare(other sql.Value) (int, error) {
+	return compare(n, other)
+}
+
+func (n Null) Equal(other sql.Value) bool {
+	return equal(n, other)
+}
+
+func (n Null) Hash() uint64 {
+	return hash(n)
+}
+
+// MarshalBinary encodes NULL as no bytes.
+func (n Null) MarshalBinary() ([]byte, error) {
+	return []byte{}, nil
+}
+
+func (n *Null) UnmarshalBinary(data []byte) error {
+	if len(data) != 0 {
+		return errDecode(sql.Null, data)
+	}
+	return nil
+}
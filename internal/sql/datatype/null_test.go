@@ -0,0 +1,27 @@
+package datatype
+
+import (
+	"testing"
+
+	"github.com/okazaki-kk/miniDB/internal/sql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNull_Raw(t *testing.T) {
+	assert.Nil(t, NewNull().Raw())
+}
+
+func TestNull_String(t *testing.T) {
+	assert.Equal(t, "NULL", NewNull().String())
+}
+
+func TestNull_DataType(t *testing.T) {
+	assert.Equal(t, sql.Null, NewNull().DataType())
+}
+
+func TestIsNull(t *testing.T) {
+	assert.True(t, sql.IsNull(nil))
+	assert.True(t, sql.IsNull(NewNull()))
+	assert.False(t, sql.IsNull(NewInteger(0)))
+	assert.False(t, sql.IsNull(NewText("")))
+}
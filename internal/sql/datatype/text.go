@@ -21,3 +21,25 @@ func (t Text) String() string {
 func (t Text) DataType() sql.DataType {
 	return sql.Text
 }
+
+func (t Text) Compare(other sql.Value) (int, error) {
+	return compare(t, other)
+}
+
+func (t Text) Equal(other sql.Value) bool {
+	return equal(t, other)
+}
+
+func (t Text) Hash() uint64 {
+	return hash(t)
+}
+
+// MarshalBinary encodes the text as its UTF-8 bytes.
+func (t Text) MarshalBinary() ([]byte, error) {
+	return []byte(t.value), nil
+}
+
+func (t *Text) UnmarshalBinary(data []byte) error {
+	t.value = string(data)
+	return nil
+}
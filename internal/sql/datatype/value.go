@@ -0,0 +1,233 @@
+package datatype
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/okazaki-kk/miniDB/internal/sql"
+)
+
+// Unmarshal decodes a value of the data type encoded by MarshalBinary.
+func Unmarshal(dataType sql.DataType, data []byte) (sql.Value, error) {
+	switch dataType {
+	case sql.Null:
+		var n Null
+		return n, n.UnmarshalBinary(data)
+	case sql.Integer:
+		var i Integer
+		return i, i.UnmarshalBinary(data)
+	case sql.Float:
+		var f Float
+		return f, f.UnmarshalBinary(data)
+	case sql.Text:
+		var t Text
+		return t, t.UnmarshalBinary(data)
+	case sql.Boolean:
+		var b Boolean
+		return b, b.UnmarshalBinary(data)
+	case sql.Date:
+		var d Date
+		return d, d.UnmarshalBinary(data)
+	case sql.Time:
+		var t Time
+		return t, t.UnmarshalBinary(data)
+	case sql.Timestamp:
+		var t Timestamp
+		return t, t.UnmarshalBinary(data)
+	case sql.TimestampTZ:
+		var t TimestampTZ
+		return t, t.UnmarshalBinary(data)
+	case sql.Interval:
+		var i Interval
+		return i, i.UnmarshalBinary(data)
+	case sql.Decimal:
+		var d Decimal
+		return d, d.UnmarshalBinary(data)
+	case sql.JSON:
+		var j JSON
+		return j, j.UnmarshalBinary(data)
+	default:
+		return nil, fmt.Errorf("cannot decode a value of type %s", dataType)
+	}
+}
+
+// compare implements sql.Value.Compare for all data types. Integers and
+// decimals compare exactly, a float with an integer or a decimal as floats. NaN is larger than any other
+// number and equal to itself, like in PostgreSQL. Dates and timestamps of
+// both kinds compare as the times their Raw values are. JSON documents
+// compare like PostgreSQL's jsonb.
+func compare(a, b sql.Value) (int, error) {
+	switch {
+	case sql.IsNull(a) && sql.IsNull(b):
+		return 0, nil
+	case sql.IsNull(a):
+		return 1, nil
+	case sql.IsNull(b):
+		return -1, nil
+	}
+
+	switch x := a.Raw().(type) {
+	case int64:
+		switch y := b.Raw().(type) {
+		case int64:
+			return compareOrdered(x, y), nil
+		case float64:
+			return compareFloats(float64(x), y), nil
+		case Decimal:
+			return DecimalFromInt(x).Cmp(y), nil
+		}
+	case float64:
+		switch y := b.Raw().(type) {
+		case int64:
+			return compareFloats(x, float64(y)), nil
+		case float64:
+			return compareFloats(x, y), nil
+		case Decimal:
+			return compareFloats(x, y.Float64()), nil
+		}
+	case Decimal:
+		switch y := b.Raw().(type) {
+		case int64:
+			return x.Cmp(DecimalFromInt(y)), nil
+		case float64:
+			return compareFloats(x.Float64(), y), nil
+		case Decimal:
+			return x.Cmp(y), nil
+		}
+	case string:
+		if y, ok := b.Raw().(string); ok {
+			return strings.Compare(x, y), nil
+		}
+	case bool:
+		if y, ok := b.Raw().(bool); ok {
+			var i, j int
+			if x {
+				i = 1
+			}
+			if y {
+				j = 1
+			}
+			return compareOrdered(i, j), nil
+		}
+	case time.Time:
+		if y, ok := b.Raw().(time.Time); ok {
+			return x.Compare(y), nil
+		}
+	case time.Duration:
+		if y, ok := b.Raw().(time.Duration); ok {
+			return compareOrdered(int64(x), int64(y)), nil
+		}
+	case Interval:
+		if y, ok := b.Raw().(Interval); ok {
+			return compareOrdered(x.span(), y.span()), nil
+		}
+	case JSON:
+		if y, ok := b.Raw().(JSON); ok {
+			return compareJSON(x.value, y.value), nil
+		}
+	}
+
+	return 0, fmt.Errorf("cannot compare %s with %s", a.DataType(), b.DataType())
+}
+
+func compareOrdered[T int | int64 | float64](x, y T) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareFloats(x, y float64) int {
+	switch {
+	case math.IsNaN(x) && math.IsNaN(y):
+		return 0
+	case math.IsNaN(x):
+		return 1
+	case math.IsNaN(y):
+		return -1
+	default:
+		return compareOrdered(x, y)
+	}
+}
+
+func equal(a, b sql.Value) bool {
+	c, err := compare(a, b)
+	return err == nil && c == 0
+}
+
+// hash implements sql.Value.Hash. Numbers hash by their float value, so that
+// equal integers, floats and decimals get the same hash, and dates and
+// timestamps by their time. JSON documents hash their numbers likewise.
+func hash(v sql.Value) uint64 {
+	h := fnv.New64a()
+
+	var b [9]byte
+
+	switch x := v.Raw().(type) {
+	case int64:
+		b[0] = byte(sql.Float)
+		binary.BigEndian.PutUint64(b[1:], floatBits(float64(x)))
+		h.Write(b[:])
+	case float64:
+		b[0] = byte(sql.Float)
+		binary.BigEndian.PutUint64(b[1:], floatBits(x))
+		h.Write(b[:])
+	case Decimal:
+		b[0] = byte(sql.Float)
+		binary.BigEndian.PutUint64(b[1:], floatBits(x.Float64()))
+		h.Write(b[:])
+	case string:
+		b[0] = byte(sql.Text)
+		h.Write(b[:1])
+		h.Write([]byte(x))
+	case bool:
+		b[0] = byte(sql.Boolean)
+		if x {
+			b[1] = 1
+		}
+		h.Write(b[:2])
+	case time.Time:
+		b[0] = byte(sql.Timestamp)
+		binary.BigEndian.PutUint64(b[1:], uint64(x.UnixMicro()))
+		h.Write(b[:])
+	case time.Duration:
+		b[0] = byte(sql.Time)
+		binary.BigEndian.PutUint64(b[1:], uint64(x))
+		h.Write(b[:])
+	case Interval:
+		b[0] = byte(sql.Interval)
+		binary.BigEndian.PutUint64(b[1:], uint64(x.span()))
+		h.Write(b[:])
+	case JSON:
+		return hashJSON(x)
+	default:
+		b[0] = byte(sql.Null)
+		h.Write(b[:1])
+	}
+
+	return h.Sum64()
+}
+
+// floatBits returns the bits of the float with all zeros and NaNs folded.
+func floatBits(f float64) uint64 {
+	switch {
+	case f == 0:
+		return 0
+	case math.IsNaN(f):
+		return math.Float64bits(math.NaN())
+	default:
+		return math.Float64bits(f)
+	}
+}
+
+func errDecode(dataType sql.DataType, data []byte) error {
+	return fmt.Errorf("invalid %s encoding of %d bytes", dataType, len(data))
+}
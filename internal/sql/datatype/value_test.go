@@ -0,0 +1,239 @@
+package datatype
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/okazaki-kk/miniDB/internal/sql"
+	"github.com/stretchr/testify/assert"
+)
+
+// samples has a value of every data type.
+var samples = map[sql.DataType]sql.Value{
+	sql.Null:    NewNull(),
+	sql.Integer: NewInteger(1),
+	sql.Float:   NewFloat(1),
+	sql.Text:    NewText("1"),
+	sql.Boolean: NewBoolean(true),
+}
+
+func TestValue_Compare(t *testing.T) {
+	t.Parallel()
+
+	comparable := map[sql.DataType]map[sql.DataType]bool{
+		sql.Integer: {sql.Integer: true, sql.Float: true},
+		sql.Float:   {sql.Integer: true, sql.Float: true},
+		sql.Text:    {sql.Text: true},
+		sql.Boolean: {sql.Boolean: true},
+	}
+
+	for from, a := range samples {
+		for to, b := range samples {
+			c, err := a.Compare(b)
+
+			switch {
+			case from == sql.Null && to == sql.Null:
+				assert.NoError(t, err)
+				assert.Equal(t, 0, c)
+			case from == sql.Null:
+				assert.NoError(t, err)
+				assert.Equal(t, 1, c, "NULL sorts after %s", to)
+			case to == sql.Null:
+				assert.NoError(t, err)
+				assert.Equal(t, -1, c, "%s sorts before NULL", from)
+			case comparable[from][to]:
+				assert.NoError(t, err)
+				assert.Equal(t, 0, c, "%s and %s", from, to)
+				assert.True(t, a.Equal(b), "%s and %s", from, to)
+			default:
+				assert.EqualError(t, err, "cannot compare "+from.String()+" with "+to.String())
+				assert.False(t, a.Equal(b), "%s and %s", from, to)
+			}
+		}
+	}
+}
+
+func TestValue_Order(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		values []sql.Value
+	}{
+		{
+			name: "integer",
+			values: []sql.Value{
+				NewInteger(math.MinInt64), NewInteger(-300), NewInteger(-1), NewInteger(0),
+				NewInteger(1), NewInteger(256), NewInteger(math.MaxInt64),
+			},
+		},
+		{
+			name: "float",
+			values: []sql.Value{
+				NewFloat(math.Inf(-1)), NewFloat(-1e300), NewFloat(-1.5), NewFloat(-math.SmallestNonzeroFloat64),
+				NewFloat(0), NewFloat(math.SmallestNonzeroFloat64), NewFloat(0.5), NewFloat(1e300),
+				NewFloat(math.Inf(1)), NewFloat(math.NaN()),
+			},
+		},
+		{
+			name:   "text",
+			values: []sql.Value{NewText(""), NewText("A"), NewText("Z"), NewText("a"), NewText("ab"), NewText("b")},
+		},
+		{
+			name:   "boolean",
+			values: []sql.Value{NewBoolean(false), NewBoolean(true)},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			for i := 1; i < len(test.values); i++ {
+				a, b := test.values[i-1], test.values[i]
+
+				c, err := a.Compare(b)
+				assert.NoError(t, err)
+				assert.Equal(t, -1, c, "%s < %s", a, b)
+
+				c, err = b.Compare(a)
+				assert.NoError(t, err)
+				assert.Equal(t, 1, c, "%s > %s", b, a)
+
+				x, err := a.MarshalBinary()
+				assert.NoError(t, err)
+				y, err := b.MarshalBinary()
+				assert.NoError(t, err)
+				assert.Equal(t, -1, bytes.Compare(x, y), "encoding of %s < %s", a, b)
+			}
+
+			last := test.values[len(test.values)-1]
+			c, err := last.Compare(NewNull())
+			assert.NoError(t, err)
+			assert.Equal(t, -1, c)
+		})
+	}
+}
+
+func TestValue_Hash(t *testing.T) {
+	t.Parallel()
+
+	equal := [][2]sql.Value{
+		{NewInteger(1), NewFloat(1)},
+		{NewInteger(-7), NewFloat(-7)},
+		{NewFloat(0), NewFloat(math.Copysign(0, -1))},
+		{NewFloat(math.NaN()), NewFloat(math.NaN())},
+		{NewText("Moscow"), NewText("Moscow")},
+		{NewBoolean(false), NewBoolean(false)},
+		{NewNull(), NewNull()},
+	}
+
+	for _, pair := range equal {
+		assert.True(t, pair[0].Equal(pair[1]), "%s = %s", pair[0], pair[1])
+		assert.Equal(t, pair[0].Hash(), pair[1].Hash(), "%s = %s", pair[0], pair[1])
+	}
+
+	distinct := [][2]sql.Value{
+		{NewInteger(1), NewInteger(2)},
+		{NewInteger(1), NewText("1")},
+		{NewInteger(1), NewBoolean(true)},
+		{NewInteger(0), NewNull()},
+		{NewText(""), NewNull()},
+		{NewFloat(0.1), NewFloat(0.2)},
+	}
+
+	for _, pair := range distinct {
+		assert.False(t, pair[0].Equal(pair[1]), "%s != %s", pair[0], pair[1])
+		assert.NotEqual(t, pair[0].Hash(), pair[1].Hash(), "%s != %s", pair[0], pair[1])
+	}
+}
+
+func TestValue_MarshalBinary(t *testing.T) {
+	t.Parallel()
+
+	values := []sql.Value{
+		NewNull(),
+		NewInteger(0), NewInteger(math.MinInt64), NewInteger(math.MaxInt64), NewInteger(-42),
+		NewFloat(0), NewFloat(math.Copysign(0, -1)), NewFloat(math.Pi), NewFloat(-1e-300), NewFloat(math.Inf(-1)),
+		NewText(""), NewText("Шереметьево"),
+		NewBoolean(false), NewBoolean(true),
+	}
+
+	for _, value := range values {
+		data, err := value.MarshalBinary()
+		assert.NoError(t, err)
+
+		decoded, err := Unmarshal(value.DataType(), data)
+		assert.NoError(t, err)
+		assert.Equal(t, value, decoded)
+	}
+
+	data, err := NewFloat(math.NaN()).MarshalBinary()
+	assert.NoError(t, err)
+
+	decoded, err := Unmarshal(sql.Float, data)
+	assert.NoError(t, err)
+	assert.True(t, math.IsNaN(decoded.Raw().(float64)))
+}
+
+func TestUnmarshal_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		dataType sql.DataType
+		data     []byte
+		err      string
+	}{
+		{dataType: sql.Null, data: []byte{0}, err: "invalid null encoding of 1 bytes"},
+		{dataType: sql.Integer, data: []byte{1, 2}, err: "invalid integer encoding of 2 bytes"},
+		{dataType: sql.Float, data: nil, err: "invalid float encoding of 0 bytes"},
+		{dataType: sql.Boolean, data: []byte{2}, err: "invalid boolean encoding of 1 bytes"},
+		{dataType: sql.DataType(99), data: nil, err: "cannot decode a value of type DataType<99>"},
+	}
+
+	for _, test := range tests {
+		_, err := Unmarshal(test.dataType, test.data)
+		assert.EqualError(t, err, test.err)
+	}
+}
+
+func TestCanCast(t *testing.T) {
+	t.Parallel()
+
+	allowed := map[sql.DataType][]sql.DataType{
+		sql.Null:    {sql.Null, sql.Integer, sql.Float, sql.Text, sql.Boolean},
+		sql.Integer: {sql.Integer, sql.Float, sql.Text, sql.Boolean},
+		sql.Float:   {sql.Integer, sql.Float, sql.Text},
+		sql.Text:    {sql.Integer, sql.Float, sql.Text, sql.Boolean},
+		sql.Boolean: {sql.Integer, sql.Text, sql.Boolean},
+	}
+
+	for from, value := range samples {
+		for to := range samples {
+			if to == sql.Null {
+				continue
+			}
+
+			expected := false
+			for _, t := range allowed[from] {
+				expected = expected || t == to
+			}
+
+			assert.Equal(t, expected, CanCast(from, to), "%s to %s", from, to)
+
+			cast, err := Cast(value, to)
+			if !expected {
+				assert.EqualError(t, err, "cannot cast type "+from.String()+" to "+to.String())
+				continue
+			}
+
+			assert.NoError(t, err, "%s to %s", from, to)
+			if from != sql.Null {
+				assert.Equal(t, to, cast.DataType(), "%s to %s", from, to)
+			}
+		}
+	}
+}
@@ -2,10 +2,26 @@ package sql
 
 import "io"
 
+// Value is a value of a column. Values of the same type, and numbers of any
+// type, are comparable and hashable, so that sorting, grouping, joining and
+// storage share one notion of equality.
 type Value interface {
 	Raw() any
 	String() string
 	DataType() DataType
+
+	// Compare returns -1, 0 or +1 when the value sorts before, like or after
+	// the other one. NULL sorts after every other value and like NULL. An
+	// error is returned for values of incomparable types.
+	Compare(other Value) (int, error)
+	// Equal reports whether the values compare alike. Unlike the = operator
+	// it holds for two NULLs and doesn't fail for incomparable types.
+	Equal(other Value) bool
+	// Hash returns the same hash for equal values.
+	Hash() uint64
+	// MarshalBinary encodes the value so that the encodings of two values of
+	// the same type sort like the values.
+	MarshalBinary() ([]byte, error)
 }
 
 type Row []Value
@@ -20,6 +36,10 @@ type SliceRowsIter struct {
 	index int
 }
 
+func NewSliceRowsIter(rows ...Row) *SliceRowsIter {
+	return &SliceRowsIter{rows: rows}
+}
+
 func (i *SliceRowsIter) Next() (Row, error) {
 	if i.index > len(i.rows)-1 {
 		return nil, io.EOF
@@ -35,3 +55,45 @@ func (i *SliceRowsIter) Close() error {
 	i.rows = nil
 	return nil
 }
+
+// IsNull reports whether the value is NULL. A nil value is NULL too.
+func IsNull(v Value) bool {
+	return v == nil || v.DataType() == Null
+}
+
+// Hash returns the same hash for equal rows.
+func (r Row) Hash() uint64 {
+	// FNV-1a over the hashes of the values.
+	h := uint64(14695981039346656037)
+
+	for _, v := range r {
+		var vh uint64
+		if !IsNull(v) {
+			vh = v.Hash()
+		}
+
+		h = (h ^ vh) * 1099511628211
+	}
+
+	return h
+}
+
+// Equal reports whether the rows have equal values, NULL being equal to NULL.
+func (r Row) Equal(other Row) bool {
+	if len(r) != len(other) {
+		return false
+	}
+
+	for i, v := range r {
+		switch {
+		case IsNull(v) || IsNull(other[i]):
+			if IsNull(v) != IsNull(other[i]) {
+				return false
+			}
+		case !v.Equal(other[i]):
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,47 @@
+package sql_test
+
+import (
+	"testing"
+
+	"github.com/okazaki-kk/miniDB/internal/sql"
+	"github.com/okazaki-kk/miniDB/internal/sql/datatype"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRow_Equal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		a, b  sql.Row
+		equal bool
+	}{
+		{name: "empty", a: sql.Row{}, b: sql.Row{}, equal: true},
+		{
+			name:  "numbers",
+			a:     sql.Row{datatype.NewInteger(1), datatype.NewText("SVO")},
+			b:     sql.Row{datatype.NewFloat(1), datatype.NewText("SVO")},
+			equal: true,
+		},
+		{name: "nulls", a: sql.Row{datatype.NewNull()}, b: sql.Row{nil}, equal: true},
+		{name: "null and value", a: sql.Row{datatype.NewNull()}, b: sql.Row{datatype.NewInteger(0)}},
+		{name: "different values", a: sql.Row{datatype.NewText("SVO")}, b: sql.Row{datatype.NewText("VKO")}},
+		{name: "different types", a: sql.Row{datatype.NewText("1")}, b: sql.Row{datatype.NewInteger(1)}},
+		{name: "different lengths", a: sql.Row{datatype.NewNull()}, b: sql.Row{}},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.equal, test.a.Equal(test.b))
+			assert.Equal(t, test.equal, test.b.Equal(test.a))
+
+			if test.equal {
+				assert.Equal(t, test.a.Hash(), test.b.Hash())
+			}
+		})
+	}
+}
@@ -2,10 +2,12 @@ package storage
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/okazaki-kk/miniDB/internal/parser/ast"
 	"github.com/okazaki-kk/miniDB/internal/parser/token"
 	"github.com/okazaki-kk/miniDB/internal/sql"
+	"github.com/okazaki-kk/miniDB/internal/sql/datatype"
 )
 
 type Scheme map[string]Column
@@ -16,6 +18,39 @@ type Column struct {
 	DataType   sql.DataType
 	PrimaryKey bool
 	Nullable   bool
+	Default    ast.Expression
+	// Precision and Scale of a NUMERIC(p, s) column, Precision is 0 for a
+	// NUMERIC column without them.
+	Precision int
+	Scale     int
+}
+
+// Convert casts the value to the type of the column, rounding a number to
+// the scale of a NUMERIC(p, s) column.
+func (c Column) Convert(value sql.Value) (sql.Value, error) {
+	return ConvertValue(value, c.DataType, c.Precision, c.Scale)
+}
+
+// TypeName returns the name of the type of the column, like numeric(10,2).
+func (c Column) TypeName() string {
+	if c.DataType == sql.Decimal && c.Precision > 0 {
+		return fmt.Sprintf("%s(%d,%d)", c.DataType, c.Precision, c.Scale)
+	}
+	return c.DataType.String()
+}
+
+// Columns returns the columns of the scheme ordered by their position.
+func (s Scheme) Columns() []Column {
+	columns := make([]Column, 0, len(s))
+	for _, column := range s {
+		columns = append(columns, column)
+	}
+
+	sort.Slice(columns, func(i, j int) bool {
+		return columns[i].Position < columns[j].Position
+	})
+
+	return columns
 }
 
 func CreateTableScheme(columns []ast.Column) (Scheme, error) {
@@ -23,7 +58,7 @@ func CreateTableScheme(columns []ast.Column) (Scheme, error) {
 	scheme := make(Scheme, len(columns))
 
 	for i := range columns {
-		column, err := createSchemeColumn(uint8(i), columns[i])
+		column, err := NewColumn(uint8(i), columns[i])
 		if err != nil {
 			return nil, err
 		}
@@ -46,18 +81,17 @@ func CreateTableScheme(columns []ast.Column) (Scheme, error) {
 	return scheme, nil
 }
 
-func createSchemeColumn(position uint8, column ast.Column) (Column, error) {
-	var dataType sql.DataType
+// NewColumn creates the scheme column of the column definition.
+func NewColumn(position uint8, column ast.Column) (Column, error) {
+	dataType, err := ColumnType(column.Type)
+	if err != nil {
+		return Column{}, err
+	}
 
-	switch column.Type {
-	case token.INT:
-		dataType = sql.Integer
-	case token.TEXT:
-		dataType = sql.Text
-	case token.TRUE, token.FALSE:
-		dataType = sql.Boolean
-	default:
-		return Column{}, fmt.Errorf("unexpected column type: %q", column.Type)
+	if column.Precision > 0 {
+		if err := datatype.CheckDecimalType(column.Precision, column.Scale); err != nil {
+			return Column{}, err
+		}
 	}
 
 	return Column{
@@ -66,5 +100,49 @@ func createSchemeColumn(position uint8, column ast.Column) (Column, error) {
 		DataType:   dataType,
 		PrimaryKey: column.PrimaryKey,
 		Nullable:   column.Nullable,
+		Default:    column.Default,
+		Precision:  column.Precision,
+		Scale:      column.Scale,
 	}, nil
 }
+
+// ConvertValue casts the value to the data type and rounds a decimal to the
+// scale of NUMERIC(precision, scale) for a precision other than 0.
+func ConvertValue(value sql.Value, dataType sql.DataType, precision, scale int) (sql.Value, error) {
+	value, err := datatype.Cast(value, dataType)
+	if err != nil || precision == 0 || sql.IsNull(value) {
+		return value, err
+	}
+
+	return value.(datatype.Decimal).Fit(precision, scale)
+}
+
+// ColumnType returns the data type of the column type token.
+func ColumnType(t token.TokenType) (sql.DataType, error) {
+	switch t {
+	case token.INT:
+		return sql.Integer, nil
+	case token.FLOAT:
+		return sql.Float, nil
+	case token.TEXT:
+		return sql.Text, nil
+	case token.BOOLEAN, token.TRUE, token.FALSE:
+		return sql.Boolean, nil
+	case token.DATE:
+		return sql.Date, nil
+	case token.TIME:
+		return sql.Time, nil
+	case token.TIMESTAMP:
+		return sql.Timestamp, nil
+	case token.TIMESTAMPTZ:
+		return sql.TimestampTZ, nil
+	case token.INTERVAL:
+		return sql.Interval, nil
+	case token.DECIMAL, token.NUMERIC:
+		return sql.Decimal, nil
+	case token.JSON, token.JSONB:
+		return sql.JSON, nil
+	default:
+		return sql.Null, fmt.Errorf("unexpected column type: %q", t)
+	}
+}
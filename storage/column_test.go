@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/okazaki-kk/miniDB/internal/sql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheme_Columns(t *testing.T) {
+	t.Parallel()
+
+	scheme := Scheme{
+		"name": Column{
+			Position: 1,
+			Name:     "name",
+			DataType: sql.Text,
+		},
+		"id": Column{
+			Position:   0,
+			Name:       "id",
+			DataType:   sql.Integer,
+			PrimaryKey: true,
+		},
+		"age": Column{
+			Position: 2,
+			Name:     "age",
+			DataType: sql.Integer,
+		},
+	}
+
+	assert.Equal(t, []Column{scheme["id"], scheme["name"], scheme["age"]}, scheme.Columns())
+}
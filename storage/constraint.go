@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/okazaki-kk/miniDB/internal/parser/ast"
+	"github.com/okazaki-kk/miniDB/internal/parser/token"
+)
+
+type ConstraintType uint8
+
+const (
+	Unique ConstraintType = iota + 1
+	Check
+	ForeignKey
+)
+
+func (t ConstraintType) String() string {
+	switch t {
+	case Unique:
+		return "UNIQUE"
+	case Check:
+		return "CHECK"
+	case ForeignKey:
+		return "FOREIGN KEY"
+	default:
+		return fmt.Sprintf("ConstraintType<%d>", t)
+	}
+}
+
+// ReferentialAction is what happens to the referencing rows when the
+// referenced row is deleted or its key updated.
+type ReferentialAction uint8
+
+const (
+	Restrict ReferentialAction = iota
+	Cascade
+	SetNull
+)
+
+func (a ReferentialAction) String() string {
+	switch a {
+	case Cascade:
+		return "CASCADE"
+	case SetNull:
+		return "SET NULL"
+	default:
+		return "RESTRICT"
+	}
+}
+
+// Constraint is an integrity constraint of a table.
+type Constraint struct {
+	Name    string
+	Type    ConstraintType
+	Columns []string
+	// Check is the condition of a CHECK constraint.
+	Check ast.Expression
+	// Reference is the referenced table of a FOREIGN KEY constraint.
+	Reference *Reference
+}
+
+// Reference describes the rows referenced by a foreign key.
+type Reference struct {
+	Table    string
+	Columns  []string
+	OnDelete ReferentialAction
+	OnUpdate ReferentialAction
+}
+
+// NewConstraint creates the constraint of the table from its definition.
+// Unnamed constraints get a name derived from the table and columns.
+func NewConstraint(table string, constraint ast.Constraint) (Constraint, error) {
+	c := Constraint{
+		Name:    constraint.Name,
+		Columns: constraint.Columns,
+		Check:   constraint.Check,
+	}
+
+	suffix := ""
+
+	switch constraint.Type {
+	case token.UNIQUE:
+		c.Type, suffix = Unique, "key"
+	case token.CHECK:
+		c.Type, suffix = Check, "check"
+	case token.FOREIGN:
+		c.Type, suffix = ForeignKey, "fkey"
+
+		if constraint.References == nil {
+			return Constraint{}, fmt.Errorf("foreign key without referenced table")
+		}
+
+		c.Reference = &Reference{
+			Table:    constraint.References.Table,
+			Columns:  constraint.References.Columns,
+			OnDelete: referentialAction(constraint.References.OnDelete),
+			OnUpdate: referentialAction(constraint.References.OnUpdate),
+		}
+	default:
+		return Constraint{}, fmt.Errorf("unexpected constraint type: %q", constraint.Type)
+	}
+
+	if c.Name == "" {
+		c.Name = strings.Join(append([]string{table}, append(c.Columns, suffix)...), "_")
+	}
+
+	return c, nil
+}
+
+func referentialAction(t token.TokenType) ReferentialAction {
+	switch t {
+	case token.CASCADE:
+		return Cascade
+	case token.NULL:
+		return SetNull
+	default:
+		return Restrict
+	}
+}
+
+// String renders the constraint as in a CREATE TABLE statement.
+func (c Constraint) String() string {
+	switch c.Type {
+	case Check:
+		return fmt.Sprintf("CONSTRAINT %s CHECK (%s)", c.Name, c.Check)
+	case ForeignKey:
+		definition := fmt.Sprintf(
+			"CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)",
+			c.Name, strings.Join(c.Columns, ", "), c.Reference.Table, strings.Join(c.Reference.Columns, ", "),
+		)
+
+		if c.Reference.OnDelete != Restrict {
+			definition += " ON DELETE " + c.Reference.OnDelete.String()
+		}
+
+		if c.Reference.OnUpdate != Restrict {
+			definition += " ON UPDATE " + c.Reference.OnUpdate.String()
+		}
+
+		return definition
+	default:
+		return fmt.Sprintf("CONSTRAINT %s %s (%s)", c.Name, c.Type, strings.Join(c.Columns, ", "))
+	}
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/okazaki-kk/miniDB/internal/parser/ast"
+	"github.com/okazaki-kk/miniDB/internal/parser/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewConstraint(t *testing.T) {
+	t.Parallel()
+
+	check := &ast.ConditionExpr{
+		Left:     &ast.IdentExpr{Name: "seats"},
+		Operator: token.GT,
+		Right:    &ast.ScalarExpr{Type: token.INT, Literal: "0"},
+	}
+
+	tests := []struct {
+		name       string
+		definition ast.Constraint
+		expected   Constraint
+		definedAs  string
+	}{
+		{
+			name:       "unique",
+			definition: ast.Constraint{Type: token.UNIQUE, Columns: []string{"code", "no"}},
+			expected:   Constraint{Name: "flights_code_no_key", Type: Unique, Columns: []string{"code", "no"}},
+			definedAs:  "CONSTRAINT flights_code_no_key UNIQUE (code, no)",
+		},
+		{
+			name:       "named check",
+			definition: ast.Constraint{Name: "positive", Type: token.CHECK, Check: check},
+			expected:   Constraint{Name: "positive", Type: Check, Check: check},
+			definedAs:  "CONSTRAINT positive CHECK (seats > 0)",
+		},
+		{
+			name: "foreign key",
+			definition: ast.Constraint{
+				Type:    token.FOREIGN,
+				Columns: []string{"aircraft"},
+				References: &ast.Reference{
+					Table:    "aircrafts",
+					Columns:  []string{"code"},
+					OnDelete: token.CASCADE,
+					OnUpdate: token.NULL,
+				},
+			},
+			expected: Constraint{
+				Name:    "flights_aircraft_fkey",
+				Type:    ForeignKey,
+				Columns: []string{"aircraft"},
+				Reference: &Reference{
+					Table:    "aircrafts",
+					Columns:  []string{"code"},
+					OnDelete: Cascade,
+					OnUpdate: SetNull,
+				},
+			},
+			definedAs: "CONSTRAINT flights_aircraft_fkey FOREIGN KEY (aircraft) REFERENCES aircrafts (code) ON DELETE CASCADE ON UPDATE SET NULL",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			c, err := NewConstraint("flights", test.definition)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, c)
+			assert.Equal(t, test.definedAs, c.String())
+		})
+	}
+}
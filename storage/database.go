@@ -1,22 +1,33 @@
 package storage
 
-import "fmt"
+import (
+	"fmt"
+	"sync"
 
+	"github.com/okazaki-kk/miniDB/internal/sql"
+)
+
+// Database is a set of tables. Copies of it share the tables and are safe
+// for concurrent use.
 type Database struct {
 	name   string
-	tables map[string]Table
+	mu     *sync.RWMutex
+	tables map[string]*Table
 }
 
 func NewDatabase(name string) *Database {
-	return &Database{name: name, tables: make(map[string]Table)}
+	return &Database{name: name, mu: new(sync.RWMutex), tables: make(map[string]*Table)}
 }
 
 func (d *Database) Name() string {
 	return d.name
 }
 
-func (d *Database) ListTables() []Table {
-	tables := make([]Table, 0, len(d.tables))
+func (d *Database) ListTables() []*Table {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	tables := make([]*Table, 0, len(d.tables))
 
 	for _, t := range d.tables {
 		tables = append(tables, t)
@@ -25,26 +36,35 @@ func (d *Database) ListTables() []Table {
 	return tables
 }
 
-func (d Database) GetTable(name string) (Table, error) {
+func (d Database) GetTable(name string) (*Table, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
 	if table, ok := d.tables[name]; ok {
 		return table, nil
 	}
 
-	return Table{}, fmt.Errorf("table %q not found", name)
+	return nil, fmt.Errorf("table %q not found", name)
 }
 
-func (d *Database) CreateTable(name string, scheme Scheme) (Table, error) {
+func (d *Database) CreateTable(name string, scheme Scheme, constraints ...Constraint) (*Table, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
 	if _, ok := d.tables[name]; ok {
-		return Table{}, fmt.Errorf("table %q already exist", name)
+		return nil, fmt.Errorf("table %q already exist", name)
 	}
 
-	table := NewTable(name, scheme)
-	d.tables[name] = *table
+	table := NewTable(name, scheme, constraints...)
+	d.tables[name] = table
 
-	return *table, nil
+	return table, nil
 }
 
 func (d *Database) DropTable(name string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
 	if _, ok := d.tables[name]; !ok {
 		return fmt.Errorf("table %s not found", name)
 	}
@@ -53,3 +73,94 @@ func (d *Database) DropTable(name string) error {
 
 	return nil
 }
+
+// RenameTable gives the table a new name.
+func (d *Database) RenameTable(name, newName string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	table, err := d.renameTable(name, newName)
+	if err != nil {
+		return err
+	}
+
+	table.rename(newName)
+
+	return nil
+}
+
+// Alteration is a change of a table applied by AlterTable.
+type Alteration struct {
+	// Name is the new name of the table, empty to keep it.
+	Name string
+	// Scheme replaces the scheme, Rewrite the rows and Indexes the indexes
+	// like with Table.Alter.
+	Scheme  Scheme
+	Rewrite func(sql.Row) (sql.Row, error)
+	Indexes []*Index
+	// Constraints replaces the constraints of the table.
+	Constraints []Constraint
+	// References replaces the constraints of the tables referencing the
+	// table, which follow its new names.
+	References map[*Table][]Constraint
+}
+
+// AlterTable applies the alteration to the table and the tables referencing
+// it while holding the lock of the database and of each table. Readers see
+// each table before or after the change, never its new scheme with its old
+// name or constraints. Either the whole alteration applies or, when rewriting
+// a row fails, none of it.
+func (d *Database) AlterTable(name string, a Alteration) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	table, ok := d.tables[name]
+	if !ok {
+		return fmt.Errorf("table %q not found", name)
+	}
+
+	newName := name
+	if a.Name != "" && a.Name != name {
+		if _, ok := d.tables[a.Name]; ok {
+			return fmt.Errorf("table %q already exist", a.Name)
+		}
+		newName = a.Name
+	}
+
+	table.mu.Lock()
+	err := table.alter(newName, a.Scheme, a.Rewrite, a.Indexes, a.Constraints)
+	table.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if newName != name {
+		if _, err := d.renameTable(name, newName); err != nil {
+			return err
+		}
+	}
+
+	for referencing, constraints := range a.References {
+		referencing.SetConstraints(constraints)
+	}
+
+	return nil
+}
+
+// renameTable moves the table to the new name in the map of tables, the
+// caller holding the lock of the database.
+func (d *Database) renameTable(name, newName string) (*Table, error) {
+	table, ok := d.tables[name]
+	if !ok {
+		return nil, fmt.Errorf("table %q not found", name)
+	}
+
+	if _, ok := d.tables[newName]; ok {
+		return nil, fmt.Errorf("table %q already exist", newName)
+	}
+
+	delete(d.tables, name)
+	d.tables[newName] = table
+
+	return table, nil
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/okazaki-kk/miniDB/internal/sql"
+	"github.com/okazaki-kk/miniDB/internal/sql/datatype"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -35,7 +36,7 @@ func TestDatabase(t *testing.T) {
 		tickets, err := database.CreateTable("tickets", scheme)
 		assert.NoError(t, err)
 
-		expected := []Table{
+		expected := []*Table{
 			users,
 			tickets,
 		}
@@ -103,4 +104,111 @@ func TestDatabase(t *testing.T) {
 		tables := database.ListTables()
 		assert.Empty(t, tables)
 	})
+
+	t.Run("rename table", func(t *testing.T) {
+		scheme := Scheme{
+			"id": Column{
+				Position:   0,
+				Name:       "id",
+				DataType:   sql.Integer,
+				PrimaryKey: true,
+				Nullable:   false,
+			},
+		}
+
+		database := NewDatabase("playground")
+		users, err := database.CreateTable("users", scheme)
+		assert.NoError(t, err)
+
+		_, err = database.CreateTable("tickets", scheme)
+		assert.NoError(t, err)
+
+		err = database.RenameTable("users", "tickets")
+		assert.EqualError(t, err, `table "tickets" already exist`)
+
+		err = database.RenameTable("users", "customers")
+		assert.NoError(t, err)
+		assert.Equal(t, "customers", users.Name())
+
+		_, err = database.GetTable("users")
+		assert.Error(t, err)
+
+		table, err := database.GetTable("customers")
+		assert.NoError(t, err)
+		assert.Same(t, users, table)
+	})
+}
+
+func TestDatabase_AlterTable(t *testing.T) {
+	scheme := Scheme{
+		"id":   Column{Position: 0, Name: "id", DataType: sql.Integer, PrimaryKey: true},
+		"code": Column{Position: 1, Name: "code", DataType: sql.Text, Nullable: true},
+	}
+
+	database := NewDatabase("playground")
+	aircrafts, err := database.CreateTable("aircrafts", scheme, Constraint{Name: "aircrafts_code_key", Type: Unique, Columns: []string{"code"}})
+	assert.NoError(t, err)
+	assert.NoError(t, aircrafts.Insert(1, sql.Row{datatype.NewInteger(1), datatype.NewText("773")}))
+
+	reference := Constraint{
+		Name:      "flights_aircraft_fkey",
+		Type:      ForeignKey,
+		Columns:   []string{"aircraft"},
+		Reference: &Reference{Table: "aircrafts", Columns: []string{"code"}},
+	}
+	flights, err := database.CreateTable("flights", Scheme{
+		"id":       Column{Position: 0, Name: "id", DataType: sql.Integer, PrimaryKey: true},
+		"aircraft": Column{Position: 1, Name: "aircraft", DataType: sql.Text, Nullable: true},
+	}, reference)
+	assert.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		// Readers see the table under its old or new name, with the scheme
+		// and constraints that go with it.
+		for i := 0; i < 100; i++ {
+			for _, table := range database.ListTables() {
+				name, constraints := table.Name(), table.Constraints()
+				if name == "planes" {
+					assert.Contains(t, table.Scheme(), "aircraft_code")
+					assert.Equal(t, []string{"aircraft_code"}, constraints[0].Columns)
+				}
+			}
+			_, _ = database.GetTable("planes")
+		}
+	}()
+
+	renamed := Scheme{
+		"id":            scheme["id"],
+		"aircraft_code": Column{Position: 1, Name: "aircraft_code", DataType: sql.Text, Nullable: true},
+	}
+	followed := reference
+	followed.Reference = &Reference{Table: "planes", Columns: []string{"aircraft_code"}}
+
+	err = database.AlterTable("aircrafts", Alteration{
+		Name:        "planes",
+		Scheme:      renamed,
+		Rewrite:     func(row sql.Row) (sql.Row, error) { return row, nil },
+		Constraints: []Constraint{{Name: "aircrafts_code_key", Type: Unique, Columns: []string{"aircraft_code"}}},
+		References:  map[*Table][]Constraint{flights: {followed}},
+	})
+	assert.NoError(t, err)
+	<-done
+
+	_, err = database.GetTable("aircrafts")
+	assert.EqualError(t, err, `table "aircrafts" not found`)
+
+	planes, err := database.GetTable("planes")
+	assert.NoError(t, err)
+	assert.Equal(t, "planes", planes.Name())
+	assert.Equal(t, []Constraint{followed}, flights.Constraints())
+
+	keys, _, err := planes.LookupKey([]string{"aircraft_code"}, []sql.Value{datatype.NewText("773")})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1}, keys)
+
+	err = database.AlterTable("planes", Alteration{Name: "flights", Scheme: renamed})
+	assert.EqualError(t, err, `table "flights" already exist`)
 }
@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/okazaki-kk/miniDB/internal/parser/ast"
+	"github.com/okazaki-kk/miniDB/internal/sql"
+)
+
+// Index maps the value of an expression of the columns of a table to the
+// keys of the rows having it. NULL values are not indexed.
+type Index struct {
+	Name string
+	Expr ast.Expression
+
+	value   func(sql.Row) (sql.Value, error)
+	entries map[uint64][]indexEntry
+}
+
+type indexEntry struct {
+	value sql.Value
+	key   int64
+}
+
+// NewIndex creates an empty index computing the indexed value of a row
+// with the function.
+func NewIndex(name string, expr ast.Expression, value func(sql.Row) (sql.Value, error)) *Index {
+	return &Index{
+		Name:    name,
+		Expr:    expr,
+		value:   value,
+		entries: make(map[uint64][]indexEntry),
+	}
+}
+
+// String renders the index as a CREATE INDEX statement of the table.
+func (i *Index) String(table string) string {
+	return fmt.Sprintf("CREATE INDEX %s ON %s (%s)", i.Name, table, i.Expr)
+}
+
+func (i *Index) add(key int64, row sql.Row) error {
+	value, err := i.value(row)
+	if err != nil {
+		return err
+	}
+
+	if sql.IsNull(value) {
+		return nil
+	}
+
+	hash := value.Hash()
+	i.entries[hash] = append(i.entries[hash], indexEntry{value: value, key: key})
+
+	return nil
+}
+
+// remove removes the entry of the row, which was added with add.
+func (i *Index) remove(key int64, row sql.Row) {
+	value, err := i.value(row)
+	if err != nil || sql.IsNull(value) {
+		return
+	}
+
+	hash := value.Hash()
+	entries := i.entries[hash]
+
+	for n := range entries {
+		if entries[n].key == key {
+			entries = append(entries[:n:n], entries[n+1:]...)
+			break
+		}
+	}
+
+	if len(entries) == 0 {
+		delete(i.entries, hash)
+	} else {
+		i.entries[hash] = entries
+	}
+}
+
+// lookup returns the keys of the rows whose indexed value equals the value.
+func (i *Index) lookup(value sql.Value) []int64 {
+	var keys []int64
+
+	for _, entry := range i.entries[value.Hash()] {
+		if entry.value.Equal(value) {
+			keys = append(keys, entry.key)
+		}
+	}
+
+	return keys
+}
+
+func (i *Index) clear() {
+	i.entries = make(map[uint64][]indexEntry)
+}
@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/okazaki-kk/miniDB/internal/sql"
+)
+
+// EncodeKey encodes the values of the columns of a key for comparison. It
+// reports false when one of the values is NULL, which equals no key.
+func EncodeKey(values ...sql.Value) (string, bool, error) {
+	var b strings.Builder
+
+	for _, value := range values {
+		if sql.IsNull(value) {
+			return "", false, nil
+		}
+
+		data, err := value.MarshalBinary()
+		if err != nil {
+			return "", false, err
+		}
+
+		fmt.Fprintf(&b, "%d:%d:%s", value.DataType(), len(data), data)
+	}
+
+	return b.String(), true, nil
+}
+
+// keyIndex maps the values of the columns of a primary key, UNIQUE or
+// FOREIGN KEY constraint to the keys of the rows having them. The columns
+// are sorted by name, so that a key matches whatever the order its columns
+// are listed in. Rows with a NULL in one of the columns are not indexed.
+type keyIndex struct {
+	columns   []string
+	positions []int
+	entries   map[string][]int64
+}
+
+// keyIndexes creates the empty key indexes of the primary key and the
+// constraints. Constraints on columns missing from the scheme, which are
+// renamed while the table is altered, are skipped.
+func keyIndexes(scheme Scheme, constraints []Constraint) []*keyIndex {
+	sets := make([][]string, 0, len(constraints)+1)
+
+	if pk := primaryKey(scheme); pk.PrimaryKey {
+		sets = append(sets, []string{pk.Name})
+	}
+
+	for _, c := range constraints {
+		if c.Type == Unique || c.Type == ForeignKey {
+			sets = append(sets, c.Columns)
+		}
+	}
+
+	positions := make(map[string]int, len(scheme))
+	for i, column := range scheme.Columns() {
+		positions[column.Name] = i
+	}
+
+	var indexes []*keyIndex
+
+next:
+	for _, set := range sets {
+		columns := sortedColumns(set)
+
+		for _, index := range indexes {
+			if equalColumns(index.columns, columns) {
+				continue next
+			}
+		}
+
+		index := &keyIndex{columns: columns, positions: make([]int, len(columns))}
+		for i, name := range columns {
+			position, ok := positions[name]
+			if !ok {
+				continue next
+			}
+
+			index.positions[i] = position
+		}
+
+		index.clear()
+		indexes = append(indexes, index)
+	}
+
+	return indexes
+}
+
+func sortedColumns(columns []string) []string {
+	sorted := make([]string, len(columns))
+	copy(sorted, columns)
+	sort.Strings(sorted)
+
+	return sorted
+}
+
+func equalColumns(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// encode encodes the values of the indexed columns of the row.
+func (i *keyIndex) encode(row sql.Row) (string, bool, error) {
+	values := make([]sql.Value, len(i.positions))
+	for n, position := range i.positions {
+		values[n] = row[position]
+	}
+
+	return EncodeKey(values...)
+}
+
+func (i *keyIndex) add(key int64, row sql.Row) error {
+	value, ok, err := i.encode(row)
+	if err != nil || !ok {
+		return err
+	}
+
+	i.entries[value] = append(i.entries[value], key)
+
+	return nil
+}
+
+// remove removes the entry of the row, which was added with add.
+func (i *keyIndex) remove(key int64, row sql.Row) {
+	value, ok, err := i.encode(row)
+	if err != nil || !ok {
+		return
+	}
+
+	keys := i.entries[value]
+
+	for n := range keys {
+		if keys[n] == key {
+			keys = append(keys[:n:n], keys[n+1:]...)
+			break
+		}
+	}
+
+	if len(keys) == 0 {
+		delete(i.entries, value)
+	} else {
+		i.entries[value] = keys
+	}
+}
+
+func (i *keyIndex) clear() {
+	i.entries = make(map[string][]int64)
+}
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/okazaki-kk/miniDB/internal/sql"
+	"github.com/okazaki-kk/miniDB/internal/sql/datatype"
+)
+
+// A record is the encoding of a row stored in a table. It starts with the
+// number of values and a bitmap with a bit set for every NULL, followed by
+// the non-NULL values, each tagged with its data type and prefixed with the
+// length of its binary encoding:
+//
+//	uvarint(n) | bitmap[(n+7)/8] | type uvarint(len) value | ...
+
+var errCorruptRecord = errors.New("corrupt record")
+
+// EncodeRow encodes the row as a record.
+func EncodeRow(row sql.Row) ([]byte, error) {
+	bitmap := make([]byte, (len(row)+7)/8)
+	for i, value := range row {
+		if sql.IsNull(value) {
+			bitmap[i/8] |= 1 << (i % 8)
+		}
+	}
+
+	b := binary.AppendUvarint(nil, uint64(len(row)))
+	b = append(b, bitmap...)
+
+	for _, value := range row {
+		if sql.IsNull(value) {
+			continue
+		}
+
+		data, err := value.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+
+		b = append(b, byte(value.DataType()))
+		b = binary.AppendUvarint(b, uint64(len(data)))
+		b = append(b, data...)
+	}
+
+	return b, nil
+}
+
+// DecodeRow decodes a record encoded by EncodeRow.
+func DecodeRow(b []byte) (sql.Row, error) {
+	n, size := binary.Uvarint(b)
+	if size <= 0 || uint64(len(b)-size) < (n+7)/8 {
+		return nil, errCorruptRecord
+	}
+	b = b[size:]
+
+	bitmap := b[:(n+7)/8]
+	b = b[len(bitmap):]
+
+	row := make(sql.Row, n)
+
+	for i := range row {
+		if bitmap[i/8]&(1<<(i%8)) != 0 {
+			row[i] = datatype.NewNull()
+			continue
+		}
+
+		if len(b) == 0 {
+			return nil, errCorruptRecord
+		}
+
+		dataType := sql.DataType(b[0])
+
+		length, size := binary.Uvarint(b[1:])
+		if size <= 0 || uint64(len(b)-1-size) < length {
+			return nil, errCorruptRecord
+		}
+		b = b[1+size:]
+
+		value, err := datatype.Unmarshal(dataType, b[:length])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errCorruptRecord, err)
+		}
+
+		row[i], b = value, b[length:]
+	}
+
+	if len(b) != 0 {
+		return nil, errCorruptRecord
+	}
+
+	return row, nil
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"math"
+	"testing"
+
+	"github.com/okazaki-kk/miniDB/internal/sql"
+	"github.com/okazaki-kk/miniDB/internal/sql/datatype"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecord(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		row  sql.Row
+		size int
+	}{
+		{name: "empty", row: sql.Row{}, size: 1},
+		{
+			name: "all types",
+			row: sql.Row{
+				datatype.NewInteger(-42),
+				datatype.NewFloat(math.Pi),
+				datatype.NewText("Sheremetyevo"),
+				datatype.NewBoolean(true),
+			},
+			size: 1 + 1 + (1 + 1 + 8) + (1 + 1 + 8) + (1 + 1 + 12) + (1 + 1 + 1),
+		},
+		{
+			name: "nulls take a bit",
+			row: sql.Row{
+				datatype.NewNull(), datatype.NewNull(), datatype.NewNull(),
+				datatype.NewNull(), datatype.NewNull(), datatype.NewNull(),
+				datatype.NewNull(), datatype.NewNull(), datatype.NewInteger(1),
+			},
+			size: 1 + 2 + (1 + 1 + 8),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			record, err := EncodeRow(test.row)
+			assert.NoError(t, err)
+			assert.Len(t, record, test.size)
+
+			row, err := DecodeRow(record)
+			assert.NoError(t, err)
+			assert.Equal(t, test.row, row)
+		})
+	}
+}
+
+func TestRecord_NilIsNull(t *testing.T) {
+	t.Parallel()
+
+	record, err := EncodeRow(sql.Row{nil, datatype.NewText("")})
+	assert.NoError(t, err)
+
+	row, err := DecodeRow(record)
+	assert.NoError(t, err)
+	assert.Equal(t, sql.Row{datatype.NewNull(), datatype.NewText("")}, row)
+}
+
+func TestRecord_Corrupt(t *testing.T) {
+	t.Parallel()
+
+	record, err := EncodeRow(sql.Row{datatype.NewText("Vnukovo"), datatype.NewInteger(7)})
+	assert.NoError(t, err)
+
+	for _, corrupt := range [][]byte{
+		nil,
+		record[:len(record)-1],
+		append(record, 0),
+		{1, 0, 99},
+	} {
+		_, err := DecodeRow(corrupt)
+		assert.ErrorIs(t, err, errCorruptRecord, "%v", corrupt)
+	}
+}
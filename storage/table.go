@@ -1,21 +1,49 @@
 package storage
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"sort"
+	"sync"
 
 	"github.com/okazaki-kk/miniDB/internal/sql"
 )
 
+// ErrDuplicateKey is returned when a row is inserted with a key already in use.
+var ErrDuplicateKey = errors.New("duplicate primary key")
+
+// Table stores the rows of a table encoded as records. It is safe for
+// concurrent use; readers never observe a half applied change.
 type Table struct {
+	mu sync.RWMutex
+
 	name       string
-	rows       map[int64]sql.Row
+	rows       map[int64][]byte
 	scheme     Scheme
 	keys       []int64
+	lastKey    int64
 	primaryKey Column
+
+	constraints []Constraint
+	indexes     []*Index
+	// keyIndexes index the columns of the primary key and the UNIQUE and
+	// FOREIGN KEY constraints for LookupKey.
+	keyIndexes []*keyIndex
+}
+
+func NewTable(name string, scheme Scheme, constraints ...Constraint) *Table {
+	return &Table{
+		name:        name,
+		scheme:      scheme,
+		primaryKey:  primaryKey(scheme),
+		rows:        make(map[int64][]byte),
+		constraints: constraints,
+		keyIndexes:  keyIndexes(scheme, constraints),
+	}
 }
 
-func NewTable(name string, scheme Scheme) *Table {
+func primaryKey(scheme Scheme) Column {
 	var pk Column
 	for col := range scheme {
 		if scheme[col].PrimaryKey {
@@ -23,94 +51,589 @@ func NewTable(name string, scheme Scheme) *Table {
 			break
 		}
 	}
-
-	return &Table{
-		name:       name,
-		scheme:     scheme,
-		primaryKey: pk,
-		rows:       make(map[int64]sql.Row),
-	}
+	return pk
 }
 
 func (t *Table) Name() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
 	return t.name
 }
 
 func (t *Table) PrimaryKey() Column {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
 	return t.primaryKey
 }
 
 func (t *Table) Scheme() Scheme {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
 	return t.scheme
 }
 
+// Constraints returns the UNIQUE, CHECK and FOREIGN KEY constraints of the table.
+func (t *Table) Constraints() []Constraint {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	constraints := make([]Constraint, len(t.constraints))
+	copy(constraints, t.constraints)
+
+	return constraints
+}
+
+// SetConstraints replaces the constraints of the table. The caller is
+// responsible for the rows satisfying them.
+func (t *Table) SetConstraints(constraints []Constraint) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.constraints = constraints
+	t.keyIndexes = keyIndexes(t.scheme, constraints)
+
+	for _, key := range t.keys {
+		row := t.row(key)
+
+		for _, index := range t.keyIndexes {
+			// The records decode, so their values encode.
+			if err := index.add(key, row); err != nil {
+				panic(fmt.Sprintf("table %s, key %d: %v", t.name, key, err))
+			}
+		}
+	}
+}
+
+// Indexes returns the indexes of the table.
+func (t *Table) Indexes() []*Index {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	indexes := make([]*Index, len(t.indexes))
+	copy(indexes, t.indexes)
+
+	return indexes
+}
+
+// CreateIndex adds the index to the table, indexing the rows it holds.
+func (t *Table) CreateIndex(index *Index) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, i := range t.indexes {
+		if i.Name == index.Name {
+			return fmt.Errorf("index %q already exists", index.Name)
+		}
+	}
+
+	index.clear()
+
+	for _, key := range t.keys {
+		if err := index.add(key, t.row(key)); err != nil {
+			index.clear()
+			return err
+		}
+	}
+
+	t.indexes = append(t.indexes, index)
+
+	return nil
+}
+
+// DropIndex removes the index from the table.
+func (t *Table) DropIndex(name string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for n, index := range t.indexes {
+		if index.Name == name {
+			t.indexes = append(t.indexes[:n:n], t.indexes[n+1:]...)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("index %q does not exist", name)
+}
+
+// Lookup returns the keys and the rows whose value of the index equals the
+// value, ordered by key.
+func (t *Table) Lookup(name string, value sql.Value) ([]int64, []sql.Row, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	for _, index := range t.indexes {
+		if index.Name != name {
+			continue
+		}
+
+		keys := index.lookup(value)
+		sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+		rows := make([]sql.Row, 0, len(keys))
+		for _, key := range keys {
+			rows = append(rows, t.row(key))
+		}
+
+		return keys, rows, nil
+	}
+
+	return nil, nil, fmt.Errorf("index %q does not exist", name)
+}
+
+// LookupKey returns the keys and the rows whose values of the columns equal
+// the values, ordered by key. No row matches when one of the values is NULL.
+// The columns of the primary key and of the UNIQUE and FOREIGN KEY
+// constraints of the table, in any order, are looked up in their key index,
+// other columns are compared on every row.
+func (t *Table) LookupKey(columns []string, values []sql.Value) ([]int64, []sql.Row, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	lookup := &keyIndex{columns: sortedColumns(columns)}
+
+	for _, index := range t.keyIndexes {
+		if equalColumns(index.columns, lookup.columns) {
+			lookup = index
+			break
+		}
+	}
+
+	ordered := make([]sql.Value, 0, len(values))
+	for _, name := range lookup.columns {
+		for i := range columns {
+			if columns[i] == name {
+				ordered = append(ordered, values[i])
+				break
+			}
+		}
+	}
+
+	value, ok, err := EncodeKey(ordered...)
+	if err != nil || !ok {
+		return nil, nil, err
+	}
+
+	var keys []int64
+
+	if lookup.entries != nil {
+		keys = make([]int64, len(lookup.entries[value]))
+		copy(keys, lookup.entries[value])
+	} else {
+		if lookup.positions, err = t.positions(lookup.columns); err != nil {
+			return nil, nil, err
+		}
+
+		for _, key := range t.keys {
+			v, ok, err := lookup.encode(t.row(key))
+			if err != nil {
+				return nil, nil, err
+			}
+			if ok && v == value {
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	rows := make([]sql.Row, 0, len(keys))
+	for _, key := range keys {
+		rows = append(rows, t.row(key))
+	}
+
+	return keys, rows, nil
+}
+
+// positions returns the positions of the columns in the rows.
+func (t *Table) positions(columns []string) ([]int, error) {
+	all := t.scheme.Columns()
+	positions := make([]int, len(columns))
+
+next:
+	for i, name := range columns {
+		for position, column := range all {
+			if column.Name == name {
+				positions[i] = position
+				continue next
+			}
+		}
+
+		return nil, fmt.Errorf("column %q of table %s does not exist", name, t.name)
+	}
+
+	return positions, nil
+}
+
+// Scan returns an iterator over a snapshot of the rows, which decodes the
+// rows as they are read. Records are replaced rather than changed, so the
+// snapshot only holds on to them.
 func (t *Table) Scan() (sql.RowIter, error) {
-	rows := make([]sql.Row, 0, len(t.keys))
+	t.mu.RLock()
+	defer t.mu.RUnlock()
 
+	keys := make([]int64, len(t.keys))
+	copy(keys, t.keys)
+
+	records := make([][]byte, 0, len(t.keys))
 	for _, key := range t.keys {
-		rows = append(rows, t.rows[key])
+		records = append(records, t.rows[key])
 	}
 
-	i := &iter{rows: rows}
+	return &iter{table: t.name, keys: keys, records: records}, nil
+}
+
+// Snapshot returns the keys and the rows of the table in insertion order.
+func (t *Table) Snapshot() ([]int64, []sql.Row) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
 
-	return i, nil
+	keys := make([]int64, len(t.keys))
+	copy(keys, t.keys)
+
+	rows := make([]sql.Row, 0, len(t.keys))
+	for _, key := range t.keys {
+		rows = append(rows, t.row(key))
+	}
+
+	return keys, rows
+}
+
+// Get returns the row stored with the key.
+func (t *Table) Get(key int64) (sql.Row, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if _, ok := t.rows[key]; !ok {
+		return nil, false
+	}
+
+	return t.row(key), true
+}
+
+// Restore replaces the rows of the table with a snapshot taken by Snapshot.
+func (t *Table) Restore(keys []int64, rows []sql.Row) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.keys = make([]int64, len(keys))
+	copy(t.keys, keys)
+
+	t.rows = make(map[int64][]byte, len(keys))
+	for i, key := range keys {
+		// The rows of a snapshot were decoded from records, so they encode.
+		record, err := EncodeRow(rows[i])
+		if err != nil {
+			panic(fmt.Sprintf("table %s, key %d: %v", t.name, key, err))
+		}
+
+		t.rows[key] = record
+	}
+
+	for _, index := range t.indexes {
+		index.clear()
+
+		for i, key := range keys {
+			if err := index.add(key, rows[i]); err != nil {
+				panic(fmt.Sprintf("table %s, index %s, key %d: %v", t.name, index.Name, key, err))
+			}
+		}
+	}
+
+	for _, index := range t.keyIndexes {
+		index.clear()
+
+		for i, key := range keys {
+			if err := index.add(key, rows[i]); err != nil {
+				panic(fmt.Sprintf("table %s, key %d: %v", t.name, key, err))
+			}
+		}
+	}
+}
+
+// NextKey returns a key greater than every key inserted so far.
+func (t *Table) NextKey() int64 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.lastKey + 1
 }
 
 func (t *Table) Insert(key int64, row sql.Row) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
 	if _, ok := t.rows[key]; ok {
-		return fmt.Errorf("duplicate primary key %d", key)
+		return fmt.Errorf("%w %d", ErrDuplicateKey, key)
+	}
+
+	record, err := EncodeRow(row)
+	if err != nil {
+		return err
 	}
 
-	t.rows[key] = row
+	if err := t.index(key, row); err != nil {
+		return err
+	}
+
+	t.rows[key] = record
 	t.keys = append(t.keys, key)
 
+	if key > t.lastKey {
+		t.lastKey = key
+	}
+
 	return nil
 }
 
 func (t *Table) Delete(key int64) error {
+	_, _, err := t.Remove(key)
+	return err
+}
+
+// Remove deletes the row stored with the key and returns it along with its
+// position in insertion order, with which InsertAt puts it back.
+func (t *Table) Remove(key int64) (int, sql.Row, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
 	if _, ok := t.rows[key]; !ok {
-		return fmt.Errorf("key %d not found", key)
+		return 0, nil, fmt.Errorf("key %d not found", key)
 	}
 
-	for index := range t.keys {
-		if t.keys[index] == key {
-			t.keys = append(t.keys[:index], t.keys[index+1:]...)
-			break
-		}
+	position := 0
+	for position < len(t.keys) && t.keys[position] != key {
+		position++
 	}
+	t.keys = append(t.keys[:position], t.keys[position+1:]...)
+
+	row := t.row(key)
+	t.unindex(key, row)
 
 	delete(t.rows, key)
 
+	return position, row, nil
+}
+
+// InsertAt inserts the row at the position in insertion order, undoing its
+// removal by Remove.
+func (t *Table) InsertAt(position int, key int64, row sql.Row) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.rows[key]; ok {
+		return fmt.Errorf("%w %d", ErrDuplicateKey, key)
+	}
+
+	if position < 0 || position > len(t.keys) {
+		return fmt.Errorf("position %d out of range", position)
+	}
+
+	record, err := EncodeRow(row)
+	if err != nil {
+		return err
+	}
+
+	if err := t.index(key, row); err != nil {
+		return err
+	}
+
+	t.rows[key] = record
+	t.keys = append(t.keys, 0)
+	copy(t.keys[position+1:], t.keys[position:])
+	t.keys[position] = key
+
+	if key > t.lastKey {
+		t.lastKey = key
+	}
+
 	return nil
 }
 
+// Truncate removes all rows of the table.
+func (t *Table) Truncate() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rows = make(map[int64][]byte)
+	t.keys = nil
+
+	for _, index := range t.indexes {
+		index.clear()
+	}
+
+	for _, index := range t.keyIndexes {
+		index.clear()
+	}
+}
+
 func (t *Table) Update(key int64, row sql.Row) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
 	if _, ok := t.rows[key]; !ok {
 		return fmt.Errorf("key %d not found", key)
 	}
 
-	t.rows[key] = row
+	record, err := EncodeRow(row)
+	if err != nil {
+		return err
+	}
+
+	old := t.row(key)
+	t.unindex(key, old)
+
+	if err := t.index(key, row); err != nil {
+		// The old row was indexed before, so it indexes again.
+		_ = t.index(key, old)
+		return err
+	}
+
+	t.rows[key] = record
 
 	return nil
 }
 
+// Alter replaces the scheme of the table and rewrites every row with the
+// function, replacing the indexes with the ones given, which are built from
+// the rewritten rows. Either all rows are rewritten or, when rewrite fails,
+// the table is left unchanged. Concurrent readers see the table before or
+// after the change.
+func (t *Table) Alter(scheme Scheme, rewrite func(sql.Row) (sql.Row, error), indexes []*Index) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.alter(t.name, scheme, rewrite, indexes, t.constraints)
+}
+
+// alter renames the table and replaces its constraints along with the
+// changes of Alter. The caller holds the lock of the table.
+func (t *Table) alter(name string, scheme Scheme, rewrite func(sql.Row) (sql.Row, error), indexes []*Index, constraints []Constraint) error {
+	for _, index := range indexes {
+		index.clear()
+	}
+
+	keyIndexes := keyIndexes(scheme, constraints)
+
+	rows := make(map[int64][]byte, len(t.rows))
+	for _, key := range t.keys {
+		row, err := rewrite(t.row(key))
+		if err != nil {
+			return err
+		}
+
+		rows[key], err = EncodeRow(row)
+		if err != nil {
+			return err
+		}
+
+		for _, index := range indexes {
+			if err := index.add(key, row); err != nil {
+				return err
+			}
+		}
+
+		for _, index := range keyIndexes {
+			if err := index.add(key, row); err != nil {
+				return err
+			}
+		}
+	}
+
+	t.name = name
+	t.scheme = scheme
+	t.primaryKey = primaryKey(scheme)
+	t.constraints = constraints
+	t.rows = rows
+	t.indexes = indexes
+	t.keyIndexes = keyIndexes
+
+	return nil
+}
+
+// index adds the row to the indexes and the key indexes. When one of them
+// fails the row is left in none of them.
+func (t *Table) index(key int64, row sql.Row) error {
+	for n, index := range t.indexes {
+		if err := index.add(key, row); err != nil {
+			for _, added := range t.indexes[:n] {
+				added.remove(key, row)
+			}
+			return err
+		}
+	}
+
+	for n, index := range t.keyIndexes {
+		if err := index.add(key, row); err != nil {
+			for _, added := range t.keyIndexes[:n] {
+				added.remove(key, row)
+			}
+			for _, added := range t.indexes {
+				added.remove(key, row)
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// unindex removes the row, added with index, from the indexes and the key
+// indexes.
+func (t *Table) unindex(key int64, row sql.Row) {
+	for _, index := range t.indexes {
+		index.remove(key, row)
+	}
+
+	for _, index := range t.keyIndexes {
+		index.remove(key, row)
+	}
+}
+
+// row decodes the record stored with the key. Records are only written by
+// EncodeRow, a record failing to decode is a bug.
+func (t *Table) row(key int64) sql.Row {
+	row, err := DecodeRow(t.rows[key])
+	if err != nil {
+		panic(fmt.Sprintf("table %s, key %d: %v", t.name, key, err))
+	}
+
+	return row
+}
+
+func (t *Table) rename(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.name = name
+}
+
 type iter struct {
-	index int
-	rows  []sql.Row
+	table   string
+	index   int
+	keys    []int64
+	records [][]byte
 }
 
 func (i *iter) Next() (sql.Row, error) {
-	if i.index > len(i.rows)-1 {
+	if i.index > len(i.records)-1 {
 		return nil, io.EOF
 	}
 
-	row := i.rows[i.index]
+	row, err := DecodeRow(i.records[i.index])
+	if err != nil {
+		return nil, fmt.Errorf("table %s, key %d: %w", i.table, i.keys[i.index], err)
+	}
+
+	i.records[i.index] = nil
 	i.index++
 
 	return row, nil
 }
 
 func (i *iter) Close() error {
-	i.rows = nil
+	i.keys, i.records = nil, nil
 	return nil
 }
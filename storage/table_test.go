@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"fmt"
 	"io"
 	"testing"
 
@@ -239,3 +240,217 @@ func TestTable_Delete(t *testing.T) {
 		assert.Nil(t, row)
 	})
 }
+
+func TestTable_Truncate(t *testing.T) {
+	t.Parallel()
+
+	scheme := Scheme{
+		"id": Column{
+			Position:   0,
+			Name:       "id",
+			DataType:   sql.Integer,
+			PrimaryKey: true,
+			Nullable:   false,
+		},
+	}
+
+	database := NewDatabase("playground")
+	table, err := database.CreateTable("users", scheme)
+	assert.NoError(t, err)
+
+	for key := int64(1); key <= 3; key++ {
+		err = table.Insert(key, sql.Row{datatype.NewInteger(key)})
+		assert.NoError(t, err)
+	}
+
+	table.Truncate()
+
+	iter, err := table.Scan()
+	assert.NoError(t, err)
+
+	row, err := iter.Next()
+	assert.ErrorIs(t, io.EOF, err)
+	assert.Nil(t, row)
+
+	err = table.Insert(1, sql.Row{datatype.NewInteger(1)})
+	assert.NoError(t, err)
+}
+
+func TestTable_Alter(t *testing.T) {
+	t.Parallel()
+
+	scheme := Scheme{
+		"id": Column{
+			Position:   0,
+			Name:       "id",
+			DataType:   sql.Integer,
+			PrimaryKey: true,
+		},
+		"name": Column{
+			Position: 1,
+			Name:     "name",
+			DataType: sql.Text,
+			Nullable: true,
+		},
+	}
+
+	table := NewTable("users", scheme)
+	assert.NoError(t, table.Insert(1, sql.Row{datatype.NewInteger(1), datatype.NewText("Max")}))
+	assert.NoError(t, table.Insert(2, sql.Row{datatype.NewInteger(2), nil}))
+
+	t.Run("failed rewrite leaves the table unchanged", func(t *testing.T) {
+		err := table.Alter(Scheme{}, func(row sql.Row) (sql.Row, error) {
+			if sql.IsNull(row[1]) {
+				return nil, fmt.Errorf("null value")
+			}
+			return row[:1], nil
+		}, nil)
+		assert.EqualError(t, err, "null value")
+		assert.Equal(t, scheme, table.Scheme())
+
+		iter, err := table.Scan()
+		assert.NoError(t, err)
+
+		row, err := iter.Next()
+		assert.NoError(t, err)
+		assert.Equal(t, sql.Row{datatype.NewInteger(1), datatype.NewText("Max")}, row)
+	})
+
+	t.Run("rewrites all rows", func(t *testing.T) {
+		altered := Scheme{
+			"key": Column{
+				Position:   0,
+				Name:       "key",
+				DataType:   sql.Integer,
+				PrimaryKey: true,
+			},
+		}
+
+		err := table.Alter(altered, func(row sql.Row) (sql.Row, error) {
+			return row[:1], nil
+		}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, altered, table.Scheme())
+		assert.Equal(t, "key", table.PrimaryKey().Name)
+
+		iter, err := table.Scan()
+		assert.NoError(t, err)
+
+		for _, expected := range []sql.Row{{datatype.NewInteger(1)}, {datatype.NewInteger(2)}} {
+			row, err := iter.Next()
+			assert.NoError(t, err)
+			assert.Equal(t, expected, row)
+		}
+	})
+}
+
+func TestTable_Index(t *testing.T) {
+	scheme := Scheme{
+		"id":   Column{Position: 0, Name: "id", DataType: sql.Integer, PrimaryKey: true},
+		"name": Column{Position: 1, Name: "name", DataType: sql.Text, Nullable: true},
+	}
+
+	table := NewTable("users", scheme)
+	assert.NoError(t, table.Insert(1, sql.Row{datatype.NewInteger(1), datatype.NewText("Max")}))
+	assert.NoError(t, table.Insert(2, sql.Row{datatype.NewInteger(2), nil}))
+
+	index := NewIndex("users_name", nil, func(row sql.Row) (sql.Value, error) {
+		if text, ok := row[1].(datatype.Text); ok && text.String() == "fail" {
+			return nil, fmt.Errorf("cannot index")
+		}
+		return row[1], nil
+	})
+	assert.NoError(t, table.CreateIndex(index))
+	assert.EqualError(t, table.CreateIndex(index), `index "users_name" already exists`)
+
+	lookup := func(name string) []int64 {
+		keys, rows, err := table.Lookup("users_name", datatype.NewText(name))
+		assert.NoError(t, err)
+		assert.Len(t, rows, len(keys))
+		return keys
+	}
+
+	assert.NoError(t, table.Insert(5, sql.Row{datatype.NewInteger(5), datatype.NewText("Max")}))
+	assert.NoError(t, table.Insert(3, sql.Row{datatype.NewInteger(3), datatype.NewText("Jane")}))
+	assert.Equal(t, []int64{1, 5}, lookup("Max"))
+
+	assert.EqualError(t, table.Insert(4, sql.Row{datatype.NewInteger(4), datatype.NewText("fail")}), "cannot index")
+	_, ok := table.Get(4)
+	assert.False(t, ok)
+
+	assert.NoError(t, table.Update(2, sql.Row{datatype.NewInteger(2), datatype.NewText("Jane")}))
+	assert.NoError(t, table.Update(3, sql.Row{datatype.NewInteger(3), datatype.NewText("Max")}))
+	assert.Equal(t, []int64{1, 3, 5}, lookup("Max"))
+	assert.Equal(t, []int64{2}, lookup("Jane"))
+
+	assert.EqualError(t, table.Update(2, sql.Row{datatype.NewInteger(2), datatype.NewText("fail")}), "cannot index")
+	assert.Equal(t, []int64{2}, lookup("Jane"))
+
+	keys, rows := table.Snapshot()
+
+	assert.NoError(t, table.Delete(1))
+	assert.Equal(t, []int64{3, 5}, lookup("Max"))
+
+	table.Truncate()
+	assert.Empty(t, lookup("Max"))
+
+	table.Restore(keys, rows)
+	assert.Equal(t, []int64{1, 3, 5}, lookup("Max"))
+
+	assert.NoError(t, table.DropIndex("users_name"))
+	assert.EqualError(t, table.DropIndex("users_name"), `index "users_name" does not exist`)
+
+	_, _, err := table.Lookup("users_name", datatype.NewText("Max"))
+	assert.EqualError(t, err, `index "users_name" does not exist`)
+}
+
+func TestTable_LookupKey(t *testing.T) {
+	scheme := Scheme{
+		"id":     Column{Position: 0, Name: "id", DataType: sql.Integer, PrimaryKey: true},
+		"origin": Column{Position: 1, Name: "origin", DataType: sql.Text, Nullable: true},
+		"dest":   Column{Position: 2, Name: "dest", DataType: sql.Text, Nullable: true},
+	}
+
+	table := NewTable("routes", scheme, Constraint{Name: "routes_key", Type: Unique, Columns: []string{"origin", "dest"}})
+	assert.NoError(t, table.Insert(1, sql.Row{datatype.NewInteger(1), datatype.NewText("OVB"), datatype.NewText("DME")}))
+	assert.NoError(t, table.Insert(2, sql.Row{datatype.NewInteger(2), datatype.NewText("DME"), datatype.NewText("OVB")}))
+	assert.NoError(t, table.Insert(3, sql.Row{datatype.NewInteger(3), datatype.NewText("OVB"), nil}))
+
+	lookup := func(columns []string, values ...sql.Value) []int64 {
+		keys, rows, err := table.LookupKey(columns, values)
+		assert.NoError(t, err)
+		assert.Len(t, rows, len(keys))
+		return keys
+	}
+
+	assert.Equal(t, []int64{2}, lookup([]string{"id"}, datatype.NewInteger(2)))
+	assert.Equal(t, []int64{1}, lookup([]string{"origin", "dest"}, datatype.NewText("OVB"), datatype.NewText("DME")))
+	assert.Equal(t, []int64{1}, lookup([]string{"dest", "origin"}, datatype.NewText("DME"), datatype.NewText("OVB")))
+	assert.Empty(t, lookup([]string{"origin", "dest"}, datatype.NewText("OVB"), datatype.NewNull()))
+
+	// Columns without a key are compared on every row.
+	assert.Equal(t, []int64{1, 3}, lookup([]string{"origin"}, datatype.NewText("OVB")))
+
+	assert.NoError(t, table.Update(2, sql.Row{datatype.NewInteger(2), datatype.NewText("OVB"), datatype.NewText("DME")}))
+	assert.Equal(t, []int64{1, 2}, lookup([]string{"origin", "dest"}, datatype.NewText("OVB"), datatype.NewText("DME")))
+
+	position, row, err := table.Remove(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, position)
+	assert.Equal(t, []int64{2}, lookup([]string{"origin", "dest"}, datatype.NewText("OVB"), datatype.NewText("DME")))
+
+	assert.NoError(t, table.InsertAt(position, 1, row))
+	assert.Equal(t, []int64{1, 2}, lookup([]string{"origin", "dest"}, datatype.NewText("OVB"), datatype.NewText("DME")))
+
+	keys, _ := table.Snapshot()
+	assert.Equal(t, []int64{1, 2, 3}, keys)
+
+	table.SetConstraints(nil)
+	assert.Equal(t, []int64{1, 2}, lookup([]string{"dest", "origin"}, datatype.NewText("DME"), datatype.NewText("OVB")))
+
+	table.Truncate()
+	assert.Empty(t, lookup([]string{"id"}, datatype.NewInteger(2)))
+
+	_, _, err = table.LookupKey([]string{"name"}, []sql.Value{datatype.NewText("OVB")})
+	assert.EqualError(t, err, `column "name" of table routes does not exist`)
+}